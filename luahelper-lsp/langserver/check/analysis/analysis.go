@@ -3,9 +3,12 @@ package analysis
 import (
 	"fmt"
 	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/lexer"
 	"luahelper-lsp/langserver/check/projects"
 	"luahelper-lsp/langserver/check/results"
 	"luahelper-lsp/langserver/log"
+	"sort"
+	"strings"
 )
 
 // IgnoreAssignOR 忽略a = a or 0 其中a没有定义的告警
@@ -124,6 +127,15 @@ func (a *Analysis) isNeedCheck() bool {
 	return false
 }
 
+// getFileConfig 获取当前分析文件所使用的配置，多根工作区时每个工作区文件夹可以有自己的配置
+func (a *Analysis) getFileConfig() *common.GlobalConfig {
+	if a.curResult == nil {
+		return common.GConfig
+	}
+
+	return common.GConfig.GetFileConfig(a.curResult.Name)
+}
+
 // 进入一个scope
 func (a *Analysis) enterScope() {
 	a.curFunc.EnterScope()
@@ -138,8 +150,10 @@ func (a *Analysis) exitScope() {
 		return
 	}
 
-	// 判断是否开启了局部变量定义了是否未使用的告警
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorLocalNoUse) {
+	// 判断是否开启了局部变量、函数参数定义了是否未使用的告警
+	localFlag := !a.getFileConfig().IsGlobalIgnoreErrType(common.CheckErrorLocalNoUse)
+	paramFlag := !a.getFileConfig().IsGlobalIgnoreErrType(common.CheckErrorUnusedParam)
+	if !localFlag && !paramFlag {
 		return
 	}
 
@@ -157,13 +171,22 @@ func (a *Analysis) exitScope() {
 			continue
 		}
 
-		if common.GConfig.IsIgnoreLocNotUseVar(varName) {
-			// 如果为系统忽略的局部变量定义了，未使用的，忽略掉
-			continue
-		}
+		// 如果为系统忽略的局部变量定义了，未使用的，忽略掉
+		ignoreLocal := !localFlag || a.getFileConfig().IsIgnoreLocNotUseVar(varName)
+
+		// 函数的参数，忽略self与_开头的参数
+		ignoreParam := !paramFlag || varName == "self" || strings.HasPrefix(varName, "_")
 
 		for _, oneVar := range varInfoList.VarVec {
-			if oneVar.IsUse || oneVar.IsClose {
+			if oneVar.IsParam {
+				if !ignoreParam && !oneVar.IsUse {
+					errorStr := fmt.Sprintf("unused parameter '%s'", varName)
+					fileResult.InsertError(common.CheckErrorUnusedParam, errorStr, oneVar.Loc)
+				}
+				continue
+			}
+
+			if ignoreLocal || oneVar.IsUse || oneVar.IsClose {
 				continue
 			}
 
@@ -256,6 +279,8 @@ func (a *Analysis) HandleFirstTraverseAST(fileResult *results.FileResult) {
 	a.curScope = fileResult.MainFunc.MainScope
 	a.cgBlock(fileResult.Block)
 	a.exitScope()
+	a.checkFuncFlow(fileResult.Block, nil)
+	a.checkAccidentalGlobal()
 }
 
 // HandleSecondProjectTraverseAST 第二轮深度遍历AST的处理（带工程的方式）或是第三轮遍历单个文件
@@ -266,6 +291,12 @@ func (a *Analysis) HandleSecondProjectTraverseAST(initialResult *results.FileRes
 	dirManager := common.GConfig.GetDirManager()
 	entryFile := dirManager.RemovePathDirPre(a.entryFile)
 
+	// 增量分析时，不需要重新遍历的文件由第一阶段的结果还原
+	if !a.SingleProjectResult.NeedTraverseFile(strFile) {
+		a.replaySecondProjectFile(initialResult)
+		return
+	}
+
 	fileResult := results.CreateFileResult(strFile, mainAst, results.CheckTermSecond, entryFile)
 
 	// 插入主函数
@@ -280,6 +311,52 @@ func (a *Analysis) HandleSecondProjectTraverseAST(initialResult *results.FileRes
 	a.exitScope()
 }
 
+// replayItem 还原文件时，按照在文件中的先后顺序处理的全局变量定义或是引用
+type replayItem struct {
+	strName   string
+	varInfo   *common.VarInfo
+	referInfo *common.ReferInfo
+	loc       lexer.Location
+}
+
+// replaySecondProjectFile 第二轮增量分析中，不需要重新遍历AST的文件，由第一阶段的结果还原出对工程的影响
+// 按照在文件中的先后顺序，插入_G的全局变量以及协议前缀符号，并跟进引用的其他文件
+func (a *Analysis) replaySecondProjectFile(firstResult *results.FileResult) {
+	a.insertSecondProjectAnalysis(firstResult.Name, firstResult)
+
+	itemVec := []replayItem{}
+	for _, globalMaps := range []map[string]*common.VarInfo{firstResult.GlobalMaps, firstResult.ProtocolMaps} {
+		for strName, varInfo := range globalMaps {
+			for oneVar := varInfo; oneVar != nil; oneVar = oneVar.ExtraGlobal.Prev {
+				if oneVar.ExtraGlobal.GFlag || oneVar.ExtraGlobal.StrProPre != "" {
+					itemVec = append(itemVec, replayItem{strName: strName, varInfo: oneVar, loc: oneVar.Loc})
+				}
+			}
+		}
+	}
+
+	for _, referInfo := range firstResult.ReferVec {
+		itemVec = append(itemVec, replayItem{referInfo: referInfo, loc: referInfo.Loc})
+	}
+
+	sort.SliceStable(itemVec, func(i, j int) bool {
+		if itemVec[i].loc.StartLine != itemVec[j].loc.StartLine {
+			return itemVec[i].loc.StartLine < itemVec[j].loc.StartLine
+		}
+		return itemVec[i].loc.StartColumn < itemVec[j].loc.StartColumn
+	})
+
+	for _, oneItem := range itemVec {
+		if oneItem.referInfo == nil {
+			a.SingleProjectResult.InsertGlobalGMaps(oneItem.strName, oneItem.varInfo, results.CheckTermSecond)
+			continue
+		}
+
+		a.deepHanleReferFile(oneItem.referInfo)
+		a.InsertRequireInfoGlobalVars(oneItem.referInfo, results.CheckTermSecond)
+	}
+}
+
 // 第二轮深度遍历过程中，其他地方又引入了其他的lua文件，这里统一处理
 // 例如，lua文件中 第三行 local a = import("two.lua")
 // 因此，执行到底三行的时候，需要跟入进去，执行two.lua文件
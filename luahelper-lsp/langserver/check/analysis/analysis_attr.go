@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+)
+
+// setLocalAttr 设置局部变量lua5.4的属性，第六轮中对只读的局部变量定义进行着色
+func (a *Analysis) setLocalAttr(varInfo *common.VarInfo, attr ast.LocalAttr) {
+	switch attr {
+	case ast.RDKTOCLOSE:
+		varInfo.IsClose = true
+	case ast.RDKCONST:
+		varInfo.IsConst = true
+	default:
+		return
+	}
+
+	if a.isSixTerm() {
+		a.ColorResult.InsertOneColorElem(common.CTReadonlyVar, &varInfo.Loc)
+	}
+}
+
+// getNonClosableType 判断表达式的值是否一定不能被close，返回值的类型描述
+// close属性的变量只能为nil、false或是有__close元方法的值，表构造没有元表，也不能被close
+func getNonClosableType(exp ast.Exp) string {
+	switch exp.(type) {
+	case *ast.IntegerExp, *ast.FloatExp:
+		return "number"
+	case *ast.StringExp:
+		return "string"
+	case *ast.TrueExp:
+		return "boolean"
+	case *ast.FuncDefExp:
+		return "function"
+	case *ast.TableConstructorExp:
+		return "table without metatable"
+	}
+
+	return ""
+}
+
+// checkLocalAttr 检查局部变量定义语句中close属性的使用，只在第一轮非实时检查时判断
+// 一条语句只能定义一个close属性的变量，且赋值的表达式必须能够被close
+func (a *Analysis) checkLocalAttr(node *ast.LocalVarDeclStat) {
+	if !a.isFirstTerm() || a.realTimeFlag {
+		return
+	}
+
+	if a.getFileConfig().IsGlobalIgnoreErrType(common.CheckErrorCloseVar) {
+		return
+	}
+
+	fileResult := a.curResult
+	firstClose := -1
+	for i, oneAttr := range node.AttrList {
+		if oneAttr != ast.RDKTOCLOSE {
+			continue
+		}
+
+		strName := node.NameList[i]
+		if firstClose >= 0 {
+			relateVec := []common.RelateCheckInfo{
+				{
+					LuaFile: fileResult.Name,
+					ErrStr:  fmt.Sprintf("first to-be-closed variable '%s'", node.NameList[firstClose]),
+					Loc:     node.VarLocList[firstClose],
+				},
+			}
+			fileResult.InsertRelateError(common.CheckErrorCloseVar, "multiple to-be-closed variables in local list",
+				node.VarLocList[i], relateVec)
+		} else {
+			firstClose = i
+		}
+
+		if i >= len(node.ExpList) {
+			continue
+		}
+
+		if strType := getNonClosableType(node.ExpList[i]); strType != "" {
+			errStr := fmt.Sprintf("variable '%s' got a non-closable value(%s)", strName, strType)
+			fileResult.InsertError(common.CheckErrorCloseVar, errStr, node.VarLocList[i])
+		}
+	}
+}
+
+// checkConstAssign 检查是否对const或close属性的局部变量、上值进行赋值，只在第一轮非实时检查时判断
+// varInfo为赋值语句左侧的变量查找到的定义
+func (a *Analysis) checkConstAssign(valExp ast.Exp, varInfo *common.VarInfo) {
+	if !a.isFirstTerm() || a.realTimeFlag {
+		return
+	}
+
+	nameExp, ok := valExp.(*ast.NameExp)
+	if !ok || varInfo == nil || varInfo.ExtraGlobal != nil || !varInfo.IsReadonly() {
+		return
+	}
+
+	if a.getFileConfig().IsGlobalIgnoreErrType(common.CheckErrorConstAssign) {
+		return
+	}
+
+	fileResult := a.curResult
+	errStr := fmt.Sprintf("attempt to assign to const variable '%s'", nameExp.Name)
+	relateVec := []common.RelateCheckInfo{
+		{
+			LuaFile: fileResult.Name,
+			ErrStr:  fmt.Sprintf("variable '%s' declared %s", nameExp.Name, varInfo.GetAttrStr()),
+			Loc:     varInfo.Loc,
+		},
+	}
+	fileResult.InsertRelateError(common.CheckErrorConstAssign, errStr, nameExp.Loc, relateVec)
+}
+
+// insertReadonlyColor 第六轮中，对引用的只读局部变量进行着色
+func (a *Analysis) insertReadonlyColor(varInfo *common.VarInfo, loc lexer.Location) {
+	if !a.isSixTerm() || !varInfo.IsReadonly() {
+		return
+	}
+
+	a.ColorResult.InsertOneColorElem(common.CTReadonlyVar, &loc)
+}
@@ -0,0 +1,97 @@
+package analysis
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"strings"
+)
+
+// pureModuleMap 函数都没有副作用的标准库模块，value为模块中有副作用的例外函数
+var pureModuleMap = map[string]map[string]bool{
+	"string": nil,
+	"utf8":   nil,
+	"math": {
+		"random":     true,
+		"randomseed": true,
+	},
+}
+
+// pureFuncMap 其他没有副作用的标准库函数
+var pureFuncMap = map[string]bool{
+	"table.concat": true,
+	"table.pack":   true,
+	"table.unpack": true,
+	"unpack":       true,
+	"select":       true,
+	"type":         true,
+	"tostring":     true,
+	"tonumber":     true,
+	"rawget":       true,
+	"rawequal":     true,
+	"rawlen":       true,
+	"getmetatable": true,
+	"next":         true,
+}
+
+// isPureFuncName 判断完整的函数名是否为没有副作用的标准库函数，例如string.format、math.max
+func isPureFuncName(strName string) bool {
+	if pureFuncMap[strName] {
+		return true
+	}
+
+	strVec := strings.Split(strName, ".")
+	if len(strVec) != 2 {
+		return false
+	}
+
+	exceptMap, ok := pureModuleMap[strVec[0]]
+	return ok && !exceptMap[strVec[1]]
+}
+
+// getPureCallName 判断函数调用是否为没有副作用的标准库函数，是的话返回函数名，否则返回空
+// 模块名被局部变量遮蔽时，例如local string = require("mystring")，不再认为是标准库
+func (a *Analysis) getPureCallName(callExp *ast.FuncCallExp) string {
+	// ("abc"):upper()，字符串常量的冒号调用为string库的函数
+	if callExp.NameExp != nil {
+		prefixExp := callExp.PrefixExp
+		if parensExp, ok := prefixExp.(*ast.ParensExp); ok {
+			prefixExp = parensExp.Exp
+		}
+		if _, ok := prefixExp.(*ast.StringExp); ok && isPureFuncName("string."+callExp.NameExp.Str) {
+			return "string." + callExp.NameExp.Str
+		}
+		return ""
+	}
+
+	strName := getDottedName(callExp.PrefixExp)
+	if strName == "" || !isPureFuncName(strName) {
+		return ""
+	}
+
+	strRoot := strings.Split(strName, ".")[0]
+	if ok, _ := a.curScope.FindLocVar(strRoot, callExp.Loc); ok {
+		return ""
+	}
+
+	return strName
+}
+
+// checkDiscardedResult 检查单独调用的没有副作用的函数，返回值被丢弃了，调用没有意义，只在第一轮非实时检查时判断
+func (a *Analysis) checkDiscardedResult(node *ast.FuncCallStat) {
+	if !a.isFirstTerm() || a.realTimeFlag {
+		return
+	}
+
+	if a.getFileConfig().IsGlobalIgnoreErrType(common.CheckErrorDiscardedResult) {
+		return
+	}
+
+	strName := a.getPureCallName(node)
+	if strName == "" {
+		return
+	}
+
+	errStr := fmt.Sprintf("result of '%s' is discarded, the call has no side effect", strName)
+	a.curResult.InsertError(common.CheckErrorDiscardedResult, errStr, node.Loc)
+}
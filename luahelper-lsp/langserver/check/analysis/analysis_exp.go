@@ -114,9 +114,12 @@ func (a *Analysis) cgFuncDefExp(node *ast.FuncDefExp) *common.FuncInfo {
 	for index, param := range node.ParList {
 		varIndex := uint8(index + 1)
 
+		// 冒号定义的函数，隐含的self参数不检查遮蔽
+		if !node.IsColon || index > 0 {
+			a.checkShadowVar(shadowDeclParam, param, node.ParLocList[index], subFi.MainScope, nil)
+		}
 		locVar := subFi.MainScope.AddLocVar(param, common.LuaTypeAll, nil, node.ParLocList[index], varIndex)
 		locVar.IsParam = true
-		locVar.IsUse = true
 
 		// 函数所有的参数放入数组进去，函数代码提示的时候有用
 		subFi.ParamList = append(subFi.ParamList, param)
@@ -130,6 +133,7 @@ func (a *Analysis) cgFuncDefExp(node *ast.FuncDefExp) *common.FuncInfo {
 	a.curScope = subFi.MainScope
 	a.cgBlock(node.Block)
 	a.exitScope()
+	a.checkFuncFlow(node.Block, node)
 
 	// 还原
 	a.curFunc = backupFunc
@@ -348,6 +352,12 @@ func (a *Analysis) cgFuncCallExp(node *ast.FuncCallExp) *common.ReferInfo {
 	// 第二轮或第三轮函数参数check
 	a.cgFuncCallParamCheck(node)
 
+	// 第一轮检查打tlog日志的struct与字段
+	a.checkTlogCall(node)
+
+	// 第一轮检查格式化字符串的参数
+	a.checkFormatCall(node)
+
 	return newRefer
 }
 
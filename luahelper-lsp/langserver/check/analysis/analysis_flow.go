@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/cfg"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+)
+
+// checkFuncFlow 根据函数的控制流图，检查不可达的代码、未使用的label与部分路径没有返回值
+// funcExp为nil时表示文件的主函数，主函数不检查返回值。只在第一轮非实时检查时判断
+func (a *Analysis) checkFuncFlow(block *ast.Block, funcExp *ast.FuncDefExp) {
+	if !a.isFirstTerm() || a.realTimeFlag || block == nil {
+		return
+	}
+
+	fileConfig := a.getFileConfig()
+	unreachableFlag := !fileConfig.IsGlobalIgnoreErrType(common.CheckErrorUnreachable)
+	labelFlag := !fileConfig.IsGlobalIgnoreErrType(common.CheckErrorUnusedLabel)
+	missingFlag := funcExp != nil && !fileConfig.IsGlobalIgnoreErrType(common.CheckErrorMissingReturn)
+	if !unreachableFlag && !labelFlag && !missingFlag {
+		return
+	}
+
+	graph := cfg.CreateGraph(block)
+	fileResult := a.curResult
+	if unreachableFlag {
+		for _, loc := range graph.GetUnreachableLocs() {
+			fileResult.InsertError(common.CheckErrorUnreachable, "unreachable code", loc)
+		}
+	}
+
+	if labelFlag {
+		for _, labelStat := range graph.GetUnusedLabels() {
+			errStr := fmt.Sprintf("label '%s' defined and not used", labelStat.Name)
+			fileResult.InsertError(common.CheckErrorUnusedLabel, errStr, labelStat.Loc)
+		}
+	}
+
+	if !missingFlag || !graph.IsEndReachable() {
+		return
+	}
+
+	var relateVec []common.RelateCheckInfo
+	for _, retBlock := range graph.GetReturnBlocks() {
+		if len(retBlock.RetExps) == 0 {
+			continue
+		}
+
+		relateVec = append(relateVec, common.RelateCheckInfo{
+			LuaFile: fileResult.Name,
+			ErrStr:  "return with value",
+			Loc:     retBlock.RetLoc,
+		})
+	}
+	if len(relateVec) == 0 {
+		return
+	}
+
+	// 告警的位置为函数的end关键字
+	endLoc := funcExp.Loc
+	if endLoc.EndColumn >= 3 {
+		endLoc = lexer.Location{
+			StartLine:   endLoc.EndLine,
+			StartColumn: endLoc.EndColumn - 3,
+			EndLine:     endLoc.EndLine,
+			EndColumn:   endLoc.EndColumn,
+		}
+	}
+	fileResult.InsertRelateError(common.CheckErrorMissingReturn, "not all paths return a value", endLoc, relateVec)
+}
@@ -0,0 +1,145 @@
+package analysis
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+)
+
+// getDottedName 获取由名称与.组成的表达式的完整名称，例如string.format，其他的表达式返回空
+func getDottedName(exp ast.Exp) string {
+	switch subExp := exp.(type) {
+	case *ast.NameExp:
+		return subExp.Name
+	case *ast.TableAccessExp:
+		keyExp, ok := subExp.KeyExp.(*ast.StringExp)
+		if !ok {
+			return ""
+		}
+
+		strPre := getDottedName(subExp.PrefixExp)
+		if strPre == "" {
+			return ""
+		}
+		return strPre + "." + keyExp.Str
+	}
+
+	return ""
+}
+
+// getCallFuncName 获取函数调用的完整函数名，例如string.format、log:info
+func getCallFuncName(callExp *ast.FuncCallExp) string {
+	strName := getDottedName(callExp.PrefixExp)
+	if strName == "" || callExp.NameExp == nil {
+		return strName
+	}
+
+	return strName + ":" + callExp.NameExp.Str
+}
+
+// isMultiValueExp 判断表达式作为最后一个参数时，是否可能展开为多个值
+func isMultiValueExp(exp ast.Exp) bool {
+	switch exp.(type) {
+	case *ast.FuncCallExp, *ast.VarargExp:
+		return true
+	}
+	return false
+}
+
+// getFormatCallArgs 判断是否为格式化字符串的函数调用，格式化字符串需要为字符串常量
+// 包括string.format("%d", n)、("%d"):format(n)，以及luahelper.json中FormatFuncs配置的函数
+// 返回函数名、格式化字符串、后面对应的参数，以及格式化字符串是第几个参数(冒号调用包括self)
+func (a *Analysis) getFormatCallArgs(callExp *ast.FuncCallExp) (strFunc string, fmtExp *ast.StringExp,
+	argExps []ast.Exp, fmtNum int) {
+	// ("%d"):format(n)，格式化字符串作为self
+	if callExp.NameExp != nil && callExp.NameExp.Str == "format" {
+		prefixExp := callExp.PrefixExp
+		if parensExp, ok := prefixExp.(*ast.ParensExp); ok {
+			prefixExp = parensExp.Exp
+		}
+		if strExp, ok := prefixExp.(*ast.StringExp); ok {
+			return "format", strExp, callExp.Args, 1
+		}
+	}
+
+	strFunc = getCallFuncName(callExp)
+	if strFunc == "" {
+		return "", nil, nil, 0
+	}
+
+	paramIndex := 1
+	if strFunc != "string.format" {
+		paramIndex = a.getFileConfig().GetFormatFuncParamIndex(strFunc)
+	}
+	if paramIndex <= 0 || paramIndex > len(callExp.Args) {
+		return "", nil, nil, 0
+	}
+
+	fmtExp, ok := callExp.Args[paramIndex-1].(*ast.StringExp)
+	if !ok {
+		return "", nil, nil, 0
+	}
+
+	fmtNum = paramIndex
+	if callExp.NameExp != nil {
+		fmtNum++
+	}
+	return strFunc, fmtExp, callExp.Args[paramIndex:], fmtNum
+}
+
+// checkFormatCall 检查格式化字符串的函数调用，格式非法、参数个数不匹配或字面量参数的类型不匹配时告警
+// 只在第一轮非实时检查时判断
+func (a *Analysis) checkFormatCall(node *ast.FuncCallExp) {
+	if !a.isFirstTerm() || a.realTimeFlag {
+		return
+	}
+
+	if a.getFileConfig().IsGlobalIgnoreErrType(common.CheckErrorFormatString) {
+		return
+	}
+
+	strFunc, fmtExp, argExps, fmtNum := a.getFormatCallArgs(node)
+	if fmtExp == nil {
+		return
+	}
+
+	fileResult := a.curResult
+	itemVec, errStr := common.ParseFormatString(fmtExp.Str)
+	if errStr != "" {
+		errStr = fmt.Sprintf("%s in format string of '%s'", errStr, strFunc)
+		fileResult.InsertError(common.CheckErrorFormatString, errStr, fmtExp.Loc)
+		return
+	}
+
+	// 最后一个参数为函数调用或是...时，参数的个数不确定
+	nArgs := len(argExps)
+	multiFlag := nArgs > 0 && isMultiValueExp(argExps[nArgs-1])
+	knownArgs := nArgs
+	if multiFlag {
+		knownArgs--
+	}
+	if knownArgs > len(itemVec) || (!multiFlag && nArgs < len(itemVec)) {
+		errStr = fmt.Sprintf("format string of '%s' needs %d argument(s), but %d given", strFunc, len(itemVec),
+			knownArgs)
+		fileResult.InsertError(common.CheckErrorFormatString, errStr, fmtExp.Loc)
+	}
+
+	for i, oneItem := range itemVec {
+		if i >= nArgs {
+			break
+		}
+
+		argErr := common.GetFormatArgErr(oneItem.Conv, argExps[i])
+		if argErr == "" {
+			continue
+		}
+
+		// 数字常量没有位置信息，告警整个函数调用
+		loc := common.GetExpLoc(argExps[i])
+		if loc.EndLine == 0 {
+			loc = node.Loc
+		}
+		errStr = fmt.Sprintf("bad argument #%d to '%s' for '%s' (%s)", fmtNum+i+1, strFunc, oneItem.Str, argErr)
+		fileResult.InsertError(common.CheckErrorFormatString, errStr, loc)
+	}
+}
@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/common"
+	"sort"
+)
+
+// checkAccidentalGlobal 检查函数内部意外定义的全局变量，例如函数中count = count + 1遗漏了local
+// 变量在文件顶层定义过、通过_G.定义或是在IgnoreModules等忽略列表中的不告警，只告警第一次赋值的位置
+// 该告警默认关闭，诊断级别配置为off时不检查。需要遍历完整个文件后判断，只在第一轮非实时检查时判断
+func (a *Analysis) checkAccidentalGlobal() {
+	if !a.isFirstTerm() || a.realTimeFlag {
+		return
+	}
+
+	fileConfig := a.getFileConfig()
+	if fileConfig.IsGlobalIgnoreErrType(common.CheckErrorAccidentalGlobal) ||
+		fileConfig.GetErrorSeverity(common.CheckErrorAccidentalGlobal) == common.SeverityOff {
+		return
+	}
+
+	// 按名称排序后再告警，保证每次分析的告警顺序一致
+	fileResult := a.curResult
+	nameVec := make([]string, 0, len(fileResult.GlobalMaps))
+	for strName := range fileResult.GlobalMaps {
+		nameVec = append(nameVec, strName)
+	}
+	sort.Strings(nameVec)
+
+	for _, strName := range nameVec {
+		if fileConfig.IsIgnoreNameVar(strName) {
+			continue
+		}
+
+		oneVar := fileResult.GlobalMaps[strName]
+
+		// 同名全局变量的所有定义都在函数内部，才告警最先的赋值
+		var firstVar *common.VarInfo
+		for ; oneVar != nil; oneVar = oneVar.ExtraGlobal.Prev {
+			if oneVar.ExtraGlobal.FuncLv == 0 || oneVar.ExtraGlobal.GFlag {
+				firstVar = nil
+				break
+			}
+
+			if firstVar == nil || oneVar.Loc.StartLine < firstVar.Loc.StartLine ||
+				(oneVar.Loc.StartLine == firstVar.Loc.StartLine && oneVar.Loc.StartColumn < firstVar.Loc.StartColumn) {
+				firstVar = oneVar
+			}
+		}
+
+		if firstVar == nil {
+			continue
+		}
+
+		errStr := fmt.Sprintf("global variable '%s' is assigned inside function, but not declared at top level", strName)
+		fileResult.InsertError(common.CheckErrorAccidentalGlobal, errStr, firstVar.Loc)
+	}
+}
@@ -15,7 +15,7 @@ import (
 // strProPre 为协议的前缀，例如c2s. s2s
 func (a *Analysis) findStrFuncRefer(loc lexer.Location, strName string, gFlag bool, strProPre string) *common.FuncInfo {
 	// 1) 判断该变量是否为lua模块自带或是框架需要屏蔽的变量
-	if common.GConfig.IsIgnoreNameVar(strName) {
+	if a.getFileConfig().IsIgnoreNameVar(strName) {
 		return nil
 	}
 
@@ -81,7 +81,7 @@ func (a *Analysis) findStrFuncRefer(loc lexer.Location, strName string, gFlag bo
 		}
 
 		// 查找所有的
-		findOk, oneVar := a.AnalysisThird.ThirdStruct.FindThirdGlobalGInfo(gFlag, strName, strProPre)
+		findOk, oneVar := a.AnalysisThird.ThirdStruct.FindThirdGlobalGInfo(fileResult.Name, gFlag, strName, strProPre)
 		if findOk {
 			return oneVar.ReferFunc
 		}
@@ -121,7 +121,7 @@ func (a *Analysis) getFuncCallReferFunc(node *ast.FuncCallStat) (referFunc *comm
 		return referFunc, strKeyName
 	} else if strings.HasPrefix(strTabName, "!") && !strings.Contains(strTabName, ".") && common.JudgeSimpleStr(strKeyName) {
 		// 判断是否为直接协议的调用c2s 或是s2s
-		strProPre := common.GConfig.GetStrProtocol(strTabName)
+		strProPre := a.getFileConfig().GetStrProtocol(strTabName)
 		if strProPre != "" {
 			// 为协议的调用
 			referFunc = a.findStrFuncRefer(loc, strKeyName, false, strProPre)
@@ -252,7 +252,7 @@ func (a *Analysis) findGlobalVar(strName string, loc lexer.Location, strProPre s
 	}
 
 	// 1）判断该变量是否为lua模块自带或是框架需要屏蔽的变量
-	if common.GConfig.IsIgnoreNameVar(strName) {
+	if a.getFileConfig().IsIgnoreNameVar(strName) {
 		return
 	}
 
@@ -278,7 +278,7 @@ func (a *Analysis) findGlobalVar(strName string, loc lexer.Location, strProPre s
 
 	// 4) 根据不同的轮数查找全局表中是否有该变量
 	if a.isSecondTerm() {
-		if strProPre != "" && common.GConfig.IsIgnoreProtocolPreVar() {
+		if strProPre != "" && a.getFileConfig().IsIgnoreProtocolPreVar() {
 			// 如果协议前缀的告警，忽略告警，不进行查找
 			return
 		}
@@ -341,7 +341,7 @@ func (a *Analysis) findGlobalVar(strName string, loc lexer.Location, strProPre s
 			secondFileResult.InsertError(common.CheckErrorNoDefine, errStr, loc)
 		}
 	} else if a.isThirdTerm() {
-		if strProPre != "" && common.GConfig.IsIgnoreProtocolPreVar() {
+		if strProPre != "" && a.getFileConfig().IsIgnoreProtocolPreVar() {
 			// 如果协议前缀的告警，忽略告警，不进行查找
 			return
 		}
@@ -372,7 +372,7 @@ func (a *Analysis) findGlobalVar(strName string, loc lexer.Location, strProPre s
 		}
 
 		// 向第三阶段，散落的全局结构获取全局对象
-		if ok, _ := a.AnalysisThird.ThirdStruct.FindThirdGlobalGInfo(gFindGlag, strName, strProPre); ok {
+		if ok, _ := a.AnalysisThird.ThirdStruct.FindThirdGlobalGInfo(thirdFileResult.Name, gFindGlag, strName, strProPre); ok {
 			return
 		}
 		errStr := fmt.Sprintf("var not define: %s%s", preShowStr, strName)
@@ -416,6 +416,7 @@ func (a *Analysis) findNameStr(node *ast.NameExp, binParentExp *ast.BinopExp) {
 
 	// 2) 查找局部变量或upvalue中是否有该变量
 	if ok, locVarInfo := scope.FindLocVar(strName, node.Loc); ok {
+		a.insertReadonlyColor(locVarInfo, node.Loc)
 		if a.isFourTerm() {
 			// 判断是否是自己所要的引用关系
 			a.ReferenceResult.MatchVarInfo(a, strName, fileResult.Name, locVarInfo, fi, "", node, false)
@@ -497,7 +498,7 @@ func (a *Analysis) findThreeLevelCall(node ast.Exp, nameExp ast.Exp) {
 		strTableArry = strTableArry[1:]
 		// 为协议的前缀
 		strProPre := ""
-		if common.GConfig.IsStrProtocol(strTwo) {
+		if a.getFileConfig().IsStrProtocol(strTwo) {
 			strProPre = strTwo
 		}
 
@@ -840,7 +841,7 @@ func (a *Analysis) findFuncColon(prefixExp ast.Exp, nameExp ast.Exp, nodeLoc lex
 
 	// 第二轮或第三轮判断table取值是否有定义
 	strTable := common.GetExpName(prefixExp)
-	strProPre := common.GConfig.GetStrProtocol(strTable)
+	strProPre := a.getFileConfig().GetStrProtocol(strTable)
 
 	// self进行转换
 	if strTable == "!self" {
@@ -1139,7 +1140,7 @@ func (a *Analysis) findTableDefine(node *ast.TableAccessExp) {
 	if strTable == "!self" {
 		strTable = a.ChangeSelfToReferVar(strTable, "!")
 	}
-	strProPre := common.GConfig.GetStrProtocol(strTable)
+	strProPre := a.getFileConfig().GetStrProtocol(strTable)
 
 	// 5) _G.aa这样的用例，判断全局变量aa是否有定义
 	if strTable == "!_G" {
@@ -1271,7 +1272,7 @@ func (a *Analysis) findTableDefine(node *ast.TableAccessExp) {
 	}
 
 	// 如果查找import的对象的全局函数，也是在屏蔽对象里面，返回，不进行告警
-	if _, ok := common.GConfig.LuaInMap[strKey]; ok {
+	if _, ok := a.getFileConfig().LuaInMap[strKey]; ok {
 		return
 	}
 
@@ -1351,7 +1352,7 @@ func (a *Analysis) GetImportReferByCallExp(funcExp *ast.FuncCallExp) *common.Ref
 		return nil
 	}
 
-	if !common.GConfig.ReferOtherFileMap[callExp.Name] {
+	if !a.getFileConfig().ReferOtherFileMap[callExp.Name] {
 		return nil
 	}
 
@@ -1361,7 +1362,7 @@ func (a *Analysis) GetImportReferByCallExp(funcExp *ast.FuncCallExp) *common.Ref
 		return nil
 	}
 
-	oneRefer := common.CreateOneReferInfo(callExp.Name, strFirst, funcExp.Loc)
+	oneRefer := common.CreateOneReferInfo(a.curResult.Name, callExp.Name, strFirst, funcExp.Loc)
 	if oneRefer == nil {
 		return nil
 	}
@@ -1428,7 +1429,7 @@ func (a *Analysis) isNeedAnalysisNameExp(strName string, loc lexer.Location) boo
 	}
 
 	// 判断是否为协议变量，如果是则返回
-	if common.GConfig.IsStrProtocol(strName) {
+	if a.getFileConfig().IsStrProtocol(strName) {
 		return false
 	}
 
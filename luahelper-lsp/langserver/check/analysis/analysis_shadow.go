@@ -0,0 +1,149 @@
+package analysis
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"luahelper-lsp/langserver/check/results"
+)
+
+// 遮蔽告警中，新定义的变量的种类
+const (
+	shadowDeclLocal = "local"
+	shadowDeclLoop  = "loop variable"
+	shadowDeclParam = "param"
+)
+
+// isLoopVar 判断局部变量是否为数值for或泛型for循环产生的变量
+func isLoopVar(varInfo *common.VarInfo) bool {
+	return varInfo.IsForNum || varInfo.IsForParam
+}
+
+// getShadowedKind 获取被遮蔽的局部变量的种类，sameFuncFlag表示是否与新定义的变量在同一个函数中
+func getShadowedKind(varInfo *common.VarInfo, sameFuncFlag bool) string {
+	if !sameFuncFlag {
+		return "upvalue"
+	}
+
+	if varInfo.IsParam {
+		return "param"
+	}
+
+	if isLoopVar(varInfo) {
+		return "loop variable"
+	}
+
+	return "local"
+}
+
+// findShadowedLocVar 查找新定义的变量遮蔽的外层局部变量，scope为新定义的变量所在的作用域
+// 同一个作用域中重复定义的局部变量不算遮蔽，但是函数体中重新定义函数参数或循环变量算遮蔽
+// 重复的函数参数由CheckErrorDuplicateParam告警，这里不再判断
+func findShadowedLocVar(declKind string, scope *common.ScopeInfo, strName string,
+	loc lexer.Location) (*common.VarInfo, string) {
+	curFunc := scope.FindMinFunc()
+	for oneScope := scope; oneScope != nil; oneScope = oneScope.Parent {
+		locInfoList := oneScope.LocVarMap[strName]
+		if locInfoList == nil {
+			continue
+		}
+
+		for i := len(locInfoList.VarVec) - 1; i >= 0; i-- {
+			locVar := locInfoList.VarVec[i]
+			if !locVar.Loc.IsBeforeLoc(loc) {
+				continue
+			}
+
+			if oneScope == scope && (declKind == shadowDeclParam || (!locVar.IsParam && !isLoopVar(locVar))) {
+				continue
+			}
+
+			return locVar, getShadowedKind(locVar, oneScope.FindMinFunc() == curFunc)
+		}
+	}
+
+	return nil, ""
+}
+
+// findShadowedGlobalVar 查找新定义的局部变量遮蔽的工程中的全局变量，只在第二轮或第三轮查找
+func (a *Analysis) findShadowedGlobalVar(strName string) *common.VarInfo {
+	fileResult := a.curResult
+	if firstFile := a.getFirstFileResult(fileResult.Name); firstFile != nil {
+		if findOk, oneVar := firstFile.FindGlobalVarInfo(strName, false, ""); findOk {
+			return oneVar
+		}
+	}
+
+	if a.isSecondTerm() {
+		if findOk, oneVar := a.SingleProjectResult.FindGlobalGInfo(strName, results.CheckTermFirst, ""); findOk {
+			return oneVar
+		}
+	} else if a.isThirdTerm() {
+		if findOk, oneVar := a.AnalysisThird.ThirdStruct.FindThirdGlobalGInfo(fileResult.Name, false, strName,
+			""); findOk {
+			return oneVar
+		}
+	}
+
+	return nil
+}
+
+// checkShadowVar 检查新定义的局部变量、for循环变量或函数参数是否遮蔽了外层同名的变量
+// 需要在变量插入scope之前调用，exp为局部变量定义时的表达式，local a = a 这样的定义忽略
+// 外层的局部变量在第一轮非实时检查时判断，全局变量在第二轮或第三轮判断
+func (a *Analysis) checkShadowVar(declKind string, strName string, loc lexer.Location, scope *common.ScopeInfo,
+	exp ast.Exp) {
+	if !(a.isFirstTerm() && !a.realTimeFlag) && !a.isNeedCheck() {
+		return
+	}
+
+	fileConfig := a.getFileConfig()
+	if fileConfig.IsGlobalIgnoreErrType(common.CheckErrorShadowVar) || fileConfig.IsIgnoreShadowVar(strName) {
+		return
+	}
+
+	if nameExp, ok := exp.(*ast.NameExp); ok && nameExp.Name == strName {
+		return
+	}
+
+	fileResult := a.curResult
+	shadowVar, shadowKind := findShadowedLocVar(declKind, scope, strName, loc)
+	if shadowVar != nil {
+		if !a.isFirstTerm() {
+			return
+		}
+
+		errStr := fmt.Sprintf("%s '%s' shadows %s '%s' defined at line %d", declKind, strName, shadowKind, strName,
+			shadowVar.Loc.StartLine)
+		relateVec := []common.RelateCheckInfo{
+			{
+				LuaFile: fileResult.Name,
+				ErrStr:  fmt.Sprintf("shadowed %s '%s'", shadowKind, strName),
+				Loc:     shadowVar.Loc,
+			},
+		}
+		fileResult.InsertRelateError(common.CheckErrorShadowVar, errStr, loc, relateVec)
+		return
+	}
+
+	if !a.isNeedCheck() {
+		return
+	}
+
+	globalVar := a.findShadowedGlobalVar(strName)
+	if globalVar == nil || globalVar.ExtraGlobal == nil {
+		return
+	}
+
+	errStr := fmt.Sprintf("%s '%s' shadows global '%s' defined in %s", declKind, strName, strName,
+		globalVar.ExtraGlobal.FileName)
+	relateVec := []common.RelateCheckInfo{
+		{
+			LuaFile: globalVar.ExtraGlobal.FileName,
+			ErrStr:  fmt.Sprintf("shadowed global '%s'", strName),
+			Loc:     globalVar.Loc,
+		},
+	}
+	fileResult.InsertRelateError(common.CheckErrorShadowVar, errStr, loc, relateVec)
+}
@@ -62,6 +62,59 @@ func (a *Analysis) cgFuncCallStat(node *ast.FuncCallStat) {
 
 	// 第二轮或第三轮函数参数check
 	a.cgFuncCallParamCheck(node)
+
+	// 第一轮检查打tlog日志的struct与字段
+	a.checkTlogCall(node)
+
+	// 第一轮检查格式化字符串的参数
+	a.checkFormatCall(node)
+
+	// 第一轮检查没有副作用的函数调用，返回值被丢弃
+	a.checkDiscardedResult(node)
+}
+
+// checkTlogCall 检查打tlog日志的函数调用，struct或是字段在tlog xml中未定义时告警
+// 例如 tlog("PlayerLogin", { GameSvrId = 1 })
+func (a *Analysis) checkTlogCall(node *ast.FuncCallExp) {
+	if !a.isFirstTerm() || a.realTimeFlag || a.Projects == nil {
+		return
+	}
+
+	fileConfig := a.getFileConfig()
+	if fileConfig.IsGlobalIgnoreErrType(common.CheckErrorTlog) {
+		return
+	}
+
+	strExp, tableExp, ok := fileConfig.GetTlogCallArgs(node)
+	if !ok {
+		return
+	}
+
+	tlogStruct, loadFlag := a.Projects.GetTlogStruct(a.curResult.Name, strExp.Str)
+	if !loadFlag {
+		return
+	}
+
+	fileResult := a.curResult
+	if tlogStruct == nil {
+		errStr := fmt.Sprintf("tlog struct not found: %s", strExp.Str)
+		fileResult.InsertError(common.CheckErrorTlog, errStr, strExp.Loc)
+		return
+	}
+
+	if tableExp == nil {
+		return
+	}
+
+	for _, keyExp := range tableExp.KeyExps {
+		strKey, ok := keyExp.(*ast.StringExp)
+		if !ok || tlogStruct.FindEntry(strKey.Str) != nil {
+			continue
+		}
+
+		errStr := fmt.Sprintf("tlog struct %s has no entry: %s", tlogStruct.Name, strKey.Str)
+		fileResult.InsertError(common.CheckErrorTlog, errStr, strKey.Loc)
+	}
 }
 
 // 检查调用函数匹配的参数
@@ -73,7 +126,7 @@ func (a *Analysis) cgFuncCallParamCheck(node *ast.FuncCallStat) {
 	}
 
 	// 判断是否开启了函数调用参数个数不匹配的校验
-	if common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorCallParam) {
+	if a.getFileConfig().IsGlobalIgnoreErrType(common.CheckErrorCallParam) {
 		return
 	}
 
@@ -284,7 +337,9 @@ func (a *Analysis) cgForNumStat(node *ast.ForNumStat) {
 	a.cgExp(node.StepExp, nil, nil)
 	a.cgExp(node.LimitExp, nil, nil)
 
+	a.checkShadowVar(shadowDeclLoop, node.VarName, node.VarLoc, subScope, nil)
 	locVar := subScope.AddLocVar(node.VarName, common.LuaTypeInter, nil, node.VarLoc, 1)
+	locVar.IsForNum = true
 	locVar.IsUse = true
 
 	a.cgBlock(node.Block)
@@ -345,6 +400,7 @@ func (a *Analysis) cgForInStat(node *ast.ForInStat) {
 	referExp, ipairsFlag := getForCycleData(node.ExpList)
 	for index, name := range node.NameList {
 		varIndex := uint8(index + 1)
+		a.checkShadowVar(shadowDeclLoop, name, node.NameLocList[index], subScope, nil)
 		locVar := subScope.AddLocVar(name, common.LuaTypeRefer, nil, node.NameLocList[index], varIndex)
 		locVar.IsForParam = true
 		locVar.IsUse = true
@@ -404,6 +460,7 @@ func (a *Analysis) cgGotoStat(node *ast.GotoStat) {
 
 func (a *Analysis) cgLocalFuncDefStat(node *ast.LocalFuncDefStat) {
 	scope := a.curScope
+	a.checkShadowVar(shadowDeclLocal, node.Name, node.NameLoc, scope, nil)
 	locVar := scope.AddLocVar(node.Name, common.LuaTypeFunc, node.Exp, node.NameLoc, 1)
 
 	subFi := a.cgFuncDefExp(node.Exp)
@@ -419,6 +476,9 @@ func (a *Analysis) cgLocalVarDeclStat(node *ast.LocalVarDeclStat) {
 		fileResult.InsertError(common.CheckErrorLocalParamNum, errStr, node.Loc)
 	}
 
+	// 检查lua5.4 close属性的变量
+	a.checkLocalAttr(node)
+
 	// 最后一个表达式，是否为函数调用
 	lastExpFuncFlag := false
 	scope := a.curScope
@@ -438,11 +498,9 @@ func (a *Analysis) cgLocalVarDeclStat(node *ast.LocalVarDeclStat) {
 		}
 
 		nowLoc := node.VarLocList[i]
+		a.checkShadowVar(shadowDeclLocal, strName, nowLoc, scope, exp)
 		varInfo := scope.AddLocVar(strName, common.GetExpType(exp), exp, nowLoc, varIndex)
-		oneAttr := node.AttrList[i]
-		if oneAttr == ast.RDKTOCLOSE {
-			varInfo.IsClose = true
-		}
+		a.setLocalAttr(varInfo, node.AttrList[i])
 
 		switch exp.(type) {
 		case *ast.FuncDefExp:
@@ -478,18 +536,15 @@ func (a *Analysis) cgLocalVarDeclStat(node *ast.LocalVarDeclStat) {
 		varIndex := uint8(i + 1)
 		nowLoc := node.VarLocList[i]
 		oneAttr := node.AttrList[i]
+		a.checkShadowVar(shadowDeclLocal, node.NameList[i], nowLoc, scope, nil)
 		if lastExpFuncFlag {
 			locVar := scope.AddLocVar(node.NameList[i], common.LuaTypeRefer, nil, nowLoc, varIndex)
-			if oneAttr == ast.RDKTOCLOSE {
-				locVar.IsClose = true
-			}
+			a.setLocalAttr(locVar, oneAttr)
 			// 关联到函数的表达式
 			locVar.ReferExp = node.ExpList[nExps-1]
 		} else {
 			locVar := scope.AddLocVar(node.NameList[i], common.LuaTypeNil, nil, nowLoc, varIndex)
-			if oneAttr == ast.RDKTOCLOSE {
-				locVar.IsClose = true
-			}
+			a.setLocalAttr(locVar, oneAttr)
 			locVar.IsExpEmpty = true
 		}
 	}
@@ -558,7 +613,7 @@ func (a *Analysis) checkLeftAssign(valExp ast.Exp) (needDefine bool, flagG bool,
 	}
 
 	tabName := common.GetExpName(taExp.PrefixExp)
-	strProPre = common.GConfig.GetStrProtocol(tabName)
+	strProPre = a.getFileConfig().GetStrProtocol(tabName)
 
 	loc = common.GetExpLoc(taExp.KeyExp)
 	if loc.IsInitialLoc() {
@@ -847,6 +902,7 @@ func (a *Analysis) cgAssignStat(node *ast.AssignStat) {
 		// strProPre 表示是否为协议的前缀 为c2s 或是s2s
 		// strName 赋值变量，前半部分名字, 去掉了_G.
 		needDefineFlag, gGlag, strName, strProPre, loc, strVec, locList, findVar := a.checkLeftAssign(valExp)
+		a.checkConstAssign(valExp, findVar)
 
 		// 需要定义变量
 		if needDefineFlag {
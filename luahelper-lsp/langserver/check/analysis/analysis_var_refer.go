@@ -66,7 +66,7 @@ func (a *Analysis) getExpReferVarInfo(node ast.Exp) (locVar *common.VarInfo) {
 // 查找一个变量的直接引用, 另外一个变量VarInfo
 func (a *Analysis) findStrReferVarInfo(strName string, loc lexer.Location, gFlag bool, strProPre string) *common.VarInfo {
 	// 1) 判断该变量是否为lua模块自带或是框架需要屏蔽的变量
-	if common.GConfig.IsIgnoreNameVar(strName) {
+	if a.getFileConfig().IsIgnoreNameVar(strName) {
 		return nil
 	}
 
@@ -131,7 +131,7 @@ func (a *Analysis) findStrReferVarInfo(strName string, loc lexer.Location, gFlag
 		}
 
 		// 查找所有的
-		if ok, oneVar := a.AnalysisThird.ThirdStruct.FindThirdGlobalGInfo(gFlag, strName, strProPre); ok {
+		if ok, oneVar := a.AnalysisThird.ThirdStruct.FindThirdGlobalGInfo(fileResult.Name, gFlag, strName, strProPre); ok {
 			return oneVar
 		}
 	}
@@ -0,0 +1,246 @@
+package cfg
+
+import (
+	"luahelper-lsp/langserver/check/compiler/ast"
+)
+
+// builder 遍历函数体的AST，构造控制流图
+type builder struct {
+	g        *Graph
+	cur      *BasicBlock              // 当前的基本块，跳转语句之后为一个没有前驱的新基本块
+	breakVec []*BasicBlock            // 嵌套的循环，break跳转到的基本块
+	labelVec []map[string]*BasicBlock // 嵌套的代码块中定义的label，goto跳转到label对应的基本块
+}
+
+// IsNoReturnCall 判断函数调用是否不会返回，目前为error()与os.exit()
+func IsNoReturnCall(callExp *ast.FuncCallExp) bool {
+	if callExp.NameExp != nil {
+		return false
+	}
+
+	switch prefixExp := callExp.PrefixExp.(type) {
+	case *ast.NameExp:
+		return prefixExp.Name == "error"
+	case *ast.TableAccessExp:
+		nameExp, ok := prefixExp.PrefixExp.(*ast.NameExp)
+		if !ok || nameExp.Name != "os" {
+			return false
+		}
+		keyExp, ok := prefixExp.KeyExp.(*ast.StringExp)
+		return ok && keyExp.Str == "exit"
+	}
+
+	return false
+}
+
+// isTrueExp 判断条件表达式是否恒为真，if语句的else分支也用true表示
+func isTrueExp(exp ast.Exp) bool {
+	_, ok := exp.(*ast.TrueExp)
+	return ok
+}
+
+// isFalseExp 判断条件表达式是否恒为假
+func isFalseExp(exp ast.Exp) bool {
+	switch exp.(type) {
+	case *ast.FalseExp, *ast.NilExp:
+		return true
+	}
+	return false
+}
+
+// jump 当前基本块跳转走了，之后的语句放到一个没有前驱的新基本块中
+func (b *builder) jump() {
+	b.cur = b.g.newBlock()
+}
+
+// findLabel 从内到外查找可见的label
+func (b *builder) findLabel(strName string) *BasicBlock {
+	for i := len(b.labelVec) - 1; i >= 0; i-- {
+		if target, ok := b.labelVec[i][strName]; ok {
+			return target
+		}
+	}
+
+	return nil
+}
+
+// buildBlock 构造代码块，label在整个代码块中可见，先为所有的label创建基本块
+func (b *builder) buildBlock(block *ast.Block) {
+	if block == nil {
+		return
+	}
+
+	labelMap := map[string]*BasicBlock{}
+	for _, stat := range block.Stats {
+		if labelStat, ok := stat.(*ast.LabelStat); ok {
+			if _, ok := labelMap[labelStat.Name]; !ok {
+				labelMap[labelStat.Name] = b.g.newBlock()
+			}
+		}
+	}
+	b.labelVec = append(b.labelVec, labelMap)
+
+	for _, stat := range block.Stats {
+		b.buildStat(stat, labelMap)
+	}
+
+	if block.RetExps != nil {
+		b.g.retBlockMap[block] = b.cur
+		b.cur.RetFlag = true
+		b.cur.RetExps = block.RetExps
+		b.cur.RetLoc = block.RetLoc
+		link(b.cur, b.g.Exit)
+		b.jump()
+	}
+
+	b.labelVec = b.labelVec[:len(b.labelVec)-1]
+}
+
+// addStat 语句放入当前的基本块
+func (b *builder) addStat(stat ast.Stat) {
+	b.cur.Stats = append(b.cur.Stats, stat)
+	b.g.statBlockMap[stat] = b.cur
+}
+
+// buildStat 构造单条语句，labelMap为语句所在代码块中定义的label
+func (b *builder) buildStat(stat ast.Stat, labelMap map[string]*BasicBlock) {
+	switch subStat := stat.(type) {
+	case *ast.LabelStat:
+		target := labelMap[subStat.Name]
+		if target.Stats != nil {
+			// 重复定义的label，goto只跳转到第一个
+			b.addStat(stat)
+			return
+		}
+		link(b.cur, target)
+		b.cur = target
+		b.addStat(stat)
+		b.g.labelVec = append(b.g.labelVec, subStat)
+	case *ast.GotoStat:
+		b.addStat(stat)
+		if target := b.findLabel(subStat.Name); target != nil {
+			link(b.cur, target)
+			b.g.gotoMap[target] = true
+		}
+		b.jump()
+	case *ast.BreakStat:
+		b.addStat(stat)
+		if len(b.breakVec) > 0 {
+			link(b.cur, b.breakVec[len(b.breakVec)-1])
+		}
+		b.jump()
+	case *ast.FuncCallStat:
+		b.addStat(stat)
+		if IsNoReturnCall(subStat) {
+			b.cur.AbortFlag = true
+			b.jump()
+		}
+	case *ast.DoStat:
+		b.addStat(stat)
+		b.buildBlock(subStat.Block)
+	case *ast.IfStat:
+		b.buildIfStat(subStat)
+	case *ast.WhileStat:
+		b.buildWhileStat(subStat)
+	case *ast.RepeatStat:
+		b.buildRepeatStat(subStat)
+	case *ast.ForNumStat:
+		b.buildForStat(stat, subStat.Block)
+	case *ast.ForInStat:
+		b.buildForStat(stat, subStat.Block)
+	default:
+		b.addStat(stat)
+	}
+}
+
+// buildIfStat 构造if语句，条件恒为真的分支(包括else)之后的分支不可达
+func (b *builder) buildIfStat(stat *ast.IfStat) {
+	b.addStat(stat)
+	condBlock := b.cur
+	afterBlock := b.g.newBlock()
+	elseFlag := false
+	for i, block := range stat.Blocks {
+		b.cur = b.g.newBlock()
+		if !elseFlag {
+			link(condBlock, b.cur)
+		}
+		if i < len(stat.Exps) && isTrueExp(stat.Exps[i]) {
+			elseFlag = true
+		}
+
+		b.buildBlock(block)
+		link(b.cur, afterBlock)
+	}
+
+	if !elseFlag {
+		link(condBlock, afterBlock)
+	}
+	b.cur = afterBlock
+}
+
+// buildWhileStat 构造while循环，每次循环都回到条件判断的基本块
+func (b *builder) buildWhileStat(stat *ast.WhileStat) {
+	headBlock := b.g.newBlock()
+	link(b.cur, headBlock)
+	b.cur = headBlock
+	b.addStat(stat)
+
+	afterBlock := b.g.newBlock()
+	bodyBlock := b.g.newBlock()
+	if !isFalseExp(stat.Exp) {
+		link(headBlock, bodyBlock)
+	}
+	if !isTrueExp(stat.Exp) {
+		link(headBlock, afterBlock)
+	}
+
+	b.breakVec = append(b.breakVec, afterBlock)
+	b.cur = bodyBlock
+	b.buildBlock(stat.Block)
+	link(b.cur, headBlock)
+	b.breakVec = b.breakVec[:len(b.breakVec)-1]
+
+	b.cur = afterBlock
+}
+
+// buildRepeatStat 构造repeat循环，until的条件在循环体的末尾判断
+func (b *builder) buildRepeatStat(stat *ast.RepeatStat) {
+	b.addStat(stat)
+	bodyBlock := b.g.newBlock()
+	link(b.cur, bodyBlock)
+
+	afterBlock := b.g.newBlock()
+	b.breakVec = append(b.breakVec, afterBlock)
+	b.cur = bodyBlock
+	b.buildBlock(stat.Block)
+	if !isTrueExp(stat.Exp) {
+		link(b.cur, bodyBlock)
+	}
+	if !isFalseExp(stat.Exp) {
+		link(b.cur, afterBlock)
+	}
+	b.breakVec = b.breakVec[:len(b.breakVec)-1]
+
+	b.cur = afterBlock
+}
+
+// buildForStat 构造数值for循环或泛型for循环，循环体可能一次都不执行
+func (b *builder) buildForStat(stat ast.Stat, block *ast.Block) {
+	headBlock := b.g.newBlock()
+	link(b.cur, headBlock)
+	b.cur = headBlock
+	b.addStat(stat)
+
+	afterBlock := b.g.newBlock()
+	bodyBlock := b.g.newBlock()
+	link(headBlock, bodyBlock)
+	link(headBlock, afterBlock)
+
+	b.breakVec = append(b.breakVec, afterBlock)
+	b.cur = bodyBlock
+	b.buildBlock(block)
+	link(b.cur, headBlock)
+	b.breakVec = b.breakVec[:len(b.breakVec)-1]
+
+	b.cur = afterBlock
+}
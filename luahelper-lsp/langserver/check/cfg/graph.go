@@ -0,0 +1,184 @@
+package cfg
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+)
+
+// BasicBlock 控制流图中的基本块，块内的语句顺序执行
+// 复合语句(if、while、for等)放在其条件判断所在的基本块中，内部的代码块单独构造基本块
+type BasicBlock struct {
+	Index     int            // 在Graph.Blocks中的索引
+	Stats     []ast.Stat     // 块内顺序执行的语句
+	Succs     []*BasicBlock  // 后继基本块
+	Preds     []*BasicBlock  // 前驱基本块
+	RetFlag   bool           // 是否以return语句结束
+	RetExps   []ast.Exp      // return语句的返回值
+	RetLoc    lexer.Location // return关键字的位置
+	AbortFlag bool           // 是否以不会返回的函数调用结束，例如error()、os.exit()
+}
+
+// Graph 单个函数的控制流图，嵌套定义的函数不包含在内，需要单独构造
+type Graph struct {
+	Entry  *BasicBlock   // 函数入口
+	Exit   *BasicBlock   // 函数出口，return语句与函数末尾的隐式返回都连接到这里
+	Blocks []*BasicBlock // 所有的基本块，包括不可达的
+
+	funcBlock    *ast.Block                 // 函数体
+	endBlock     *BasicBlock                // 执行到函数末尾，隐式返回的基本块
+	statBlockMap map[ast.Stat]*BasicBlock   // 语句所在的基本块
+	retBlockMap  map[*ast.Block]*BasicBlock // 代码块中return语句所在的基本块
+	reachMap     map[*BasicBlock]bool       // 从入口可达的基本块
+	labelVec     []*ast.LabelStat           // 定义的label，同一代码块中重复定义的只记录第一个
+	gotoMap      map[*BasicBlock]bool       // goto跳转到的label所在的基本块
+}
+
+// CreateGraph 根据函数体构造控制流图
+func CreateGraph(funcBlock *ast.Block) *Graph {
+	g := &Graph{
+		funcBlock:    funcBlock,
+		statBlockMap: map[ast.Stat]*BasicBlock{},
+		retBlockMap:  map[*ast.Block]*BasicBlock{},
+		gotoMap:      map[*BasicBlock]bool{},
+	}
+
+	b := &builder{g: g}
+	g.Entry = g.newBlock()
+	g.Exit = g.newBlock()
+	b.cur = g.Entry
+	b.buildBlock(funcBlock)
+
+	// 执行到函数末尾，隐式返回
+	g.endBlock = b.cur
+	link(b.cur, g.Exit)
+
+	g.reachMap = g.computeReach()
+	return g
+}
+
+// newBlock 创建一个新的基本块
+func (g *Graph) newBlock() *BasicBlock {
+	block := &BasicBlock{
+		Index: len(g.Blocks),
+	}
+	g.Blocks = append(g.Blocks, block)
+	return block
+}
+
+// link 增加一条from到to的边
+func link(from, to *BasicBlock) {
+	from.Succs = append(from.Succs, to)
+	to.Preds = append(to.Preds, from)
+}
+
+// computeReach 从入口开始遍历，计算所有可达的基本块
+func (g *Graph) computeReach() map[*BasicBlock]bool {
+	reachMap := map[*BasicBlock]bool{g.Entry: true}
+	queue := []*BasicBlock{g.Entry}
+	for len(queue) > 0 {
+		block := queue[0]
+		queue = queue[1:]
+		for _, succ := range block.Succs {
+			if !reachMap[succ] {
+				reachMap[succ] = true
+				queue = append(queue, succ)
+			}
+		}
+	}
+
+	return reachMap
+}
+
+// IsReachable 判断基本块是否从函数入口可达
+func (g *Graph) IsReachable(block *BasicBlock) bool {
+	return block != nil && g.reachMap[block]
+}
+
+// StatBlock 获取语句所在的基本块，语句不在该函数中时返回nil
+func (g *Graph) StatBlock(stat ast.Stat) *BasicBlock {
+	return g.statBlockMap[stat]
+}
+
+// IsStatReachable 判断语句是否可能被执行到
+func (g *Graph) IsStatReachable(stat ast.Stat) bool {
+	return g.IsReachable(g.statBlockMap[stat])
+}
+
+// IsEndReachable 判断是否可能执行到函数末尾，没有return语句而隐式返回
+func (g *Graph) IsEndReachable() bool {
+	return g.IsReachable(g.endBlock)
+}
+
+// GetReturnBlocks 获取所有可达的以return语句结束的基本块
+func (g *Graph) GetReturnBlocks() (blockVec []*BasicBlock) {
+	for _, block := range g.Blocks {
+		if block.RetFlag && g.IsReachable(block) {
+			blockVec = append(blockVec, block)
+		}
+	}
+
+	return blockVec
+}
+
+// GetUnusedLabels 获取没有被任何goto跳转的label
+func (g *Graph) GetUnusedLabels() (labelVec []*ast.LabelStat) {
+	for _, labelStat := range g.labelVec {
+		if !g.gotoMap[g.statBlockMap[labelStat]] {
+			labelVec = append(labelVec, labelStat)
+		}
+	}
+
+	return labelVec
+}
+
+// GetUnreachableLocs 获取所有不可达代码的位置，同一个代码块中连续的不可达语句合并成一个位置
+// 不可达的复合语句内部不再重复获取
+func (g *Graph) GetUnreachableLocs() (locVec []lexer.Location) {
+	g.collectUnreachable(g.funcBlock, &locVec)
+	return locVec
+}
+
+// collectUnreachable 收集代码块中连续的不可达语句的位置
+func (g *Graph) collectUnreachable(block *ast.Block, locVec *[]lexer.Location) {
+	var beginLoc, endLoc lexer.Location
+	runFlag := false
+	for _, stat := range block.Stats {
+		loc, _ := ast.GetStatLoc(stat)
+		if !g.IsStatReachable(stat) {
+			if !runFlag {
+				runFlag = true
+				beginLoc = loc
+			}
+			endLoc = loc
+			continue
+		}
+
+		if runFlag {
+			runFlag = false
+			*locVec = append(*locVec, lexer.GetRangeLoc(&beginLoc, &endLoc))
+		}
+
+		for _, subBlock := range ast.GetSubBlocks(stat) {
+			g.collectUnreachable(subBlock, locVec)
+		}
+	}
+
+	if block.RetExps != nil && !g.IsReachable(g.retBlockMap[block]) {
+		if !runFlag {
+			runFlag = true
+			beginLoc = block.RetLoc
+		}
+		endLoc = block.RetLoc
+		if len(block.RetExps) > 0 {
+			// 数字等常量表达式没有位置信息，这时只取return关键字的位置
+			if expLoc := common.GetExpLoc(block.RetExps[len(block.RetExps)-1]); expLoc.EndLine > 0 {
+				endLoc = expLoc
+			}
+		}
+	}
+
+	if runFlag {
+		*locVec = append(*locVec, lexer.GetRangeLoc(&beginLoc, &endLoc))
+	}
+}
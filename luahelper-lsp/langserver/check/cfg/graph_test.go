@@ -0,0 +1,63 @@
+package cfg
+
+import (
+	"luahelper-lsp/langserver/check/compiler/parser"
+	"testing"
+)
+
+func TestCreateGraph(t *testing.T) {
+	contentStr := `local i = 0
+::top::
+i = i + 1
+if i < 10 then
+	goto top
+end
+while true do
+	if i > 20 then
+		break
+	end
+	i = i + 1
+end
+print(i)
+::unused::
+error("stop")
+print("dead")`
+	block, _, err := parser.CreateParser([]byte(contentStr), "test").BeginAnalyze()
+	if err != nil {
+		t.Fatalf("parser fatal, errstr=%s", err.Error())
+	}
+
+	graph := CreateGraph(block)
+	if !graph.IsStatReachable(block.Stats[1]) || graph.StatBlock(block.Stats[1]) == graph.Entry {
+		t.Fatalf("label should start a reachable block")
+	}
+
+	// goto构成的循环，label所在的基本块有两个前驱
+	if preds := graph.StatBlock(block.Stats[1]).Preds; len(preds) != 2 {
+		t.Fatalf("label block preds num error, preds=%d", len(preds))
+	}
+
+	// while true只能通过break退出
+	if !graph.IsStatReachable(block.Stats[5]) {
+		t.Fatalf("stat after while true with break should be reachable")
+	}
+
+	lastStat := block.Stats[len(block.Stats)-1]
+	if graph.IsStatReachable(lastStat) || graph.IsEndReachable() {
+		t.Fatalf("stat after error() should be unreachable")
+	}
+	if abortBlock := graph.StatBlock(block.Stats[7]); !abortBlock.AbortFlag || len(abortBlock.Succs) != 0 {
+		t.Fatalf("error() should end the block without successors")
+	}
+
+	locVec := graph.GetUnreachableLocs()
+	if len(locVec) != 1 || locVec[0].StartLine != 16 {
+		t.Fatalf("unreachable locs error, locs=%v", locVec)
+	}
+
+	// top被goto跳转了，unused没有
+	labelVec := graph.GetUnusedLabels()
+	if len(labelVec) != 1 || labelVec[0].Name != "unused" {
+		t.Fatalf("unused labels error, labels=%v", labelVec)
+	}
+}
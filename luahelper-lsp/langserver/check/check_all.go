@@ -38,11 +38,43 @@ type AllProject struct {
 	// 管理所有的注释创建的type类型，key值为名称，value是这个类型的列表，允许多个存在
 	createTypeMap map[string]common.CreateTypeList
 
+	// 配置的.proto文件生成的注解信息，key值为.proto文件名
+	protoAnnotateMap map[string]*common.AnnotateFile
+
+	// 主工程以及各工作区文件夹配置的tlog xml解析后的信息，key值为xml文件名
+	tlogXMLMap map[string]*tlogXMLInfo
+
 	// 代码补全cache
 	completeCache *common.CompleteCache
 
 	// 整体分析的阶段数
 	checkTerm results.CheckTerm
+
+	// 最近一次整体分析各阶段的耗时
+	checkTime CheckTimeInfo
+
+	// 第一阶段结果的磁盘缓存，为nil表示没有开启
+	diskCache *DiskCache
+
+	// 第一阶段分析时，是否允许由磁盘缓存的摘要还原文件
+	summaryFlag bool
+
+	// 第二、三阶段多协程分析时为true，获取第一阶段结果时不补全磁盘缓存的摘要，需要遍历AST的文件在分析之前补全
+	summaryOnlyFlag bool
+
+	// 文件之间的依赖关系图
+	dependGraph *DependGraph
+
+	// 最近一次文件变化后，诊断信息可能有变化的文件，为nil表示所有的文件
+	diagnosticFileMap map[string]struct{}
+}
+
+// CheckTimeInfo 整体分析各阶段的耗时，单位为毫秒
+type CheckTimeInfo struct {
+	All    int64 // 总的耗时
+	First  int64 // 第一阶段耗时
+	Second int64 // 第二阶段耗时
+	Third  int64 // 第三阶段耗时
 }
 
 // CreateAllProject 创建整个检查工程
@@ -56,9 +88,11 @@ func CreateAllProject(allFilesList []string, entryFileArr []string, clientExpPat
 		analysisSecondMap: map[string]*results.SingleProjectResult{},
 		thirdStruct:       nil,
 		createTypeMap:     map[string]common.CreateTypeList{},
+		protoAnnotateMap:  map[string]*common.AnnotateFile{},
 		checkTerm:         results.CheckTermFirst,
 		completeCache:     common.CreateCompleteCache(),
 		fileLRUMap:        common.NewLRUCache(20),
+		dependGraph:       CreateDependGraph(),
 	}
 
 	// 传人的所有文件列表转换成map
@@ -82,8 +116,11 @@ func (a *AllProject) HandleCheck() {
 	common.GConfig.ClearCacheFileMap()
 
 	a.setCheckTerm(results.CheckTermFirst)
-	// 1) 进行第一轮分析, 所有扫描的lua文件生成AST以及第一遍扫描
+	// 1) 进行第一轮分析, 所有扫描的lua文件生成AST以及第一遍扫描，第一轮会检查tlog的struct与字段，先加载tlog xml
+	a.loadTlogXML()
 	a.HandleFirstAllProject()
+	a.rebuildDependGraph()
+	a.diagnosticFileMap = nil
 
 	ftime1 := time.Since(time1).Milliseconds()
 
@@ -107,24 +144,52 @@ func (a *AllProject) HandleCheck() {
 		if mainDir != "" {
 			time2 := time.Now()
 			a.setCheckTerm(results.CheckTermSecond)
+			// 磁盘缓存中有上一次第二、三阶段的结果时，按增量分析处理，只重新遍历受影响的文件
+			affectMap := a.restoreCheckSummary()
+
 			// 2) 进行第二轮分析，以入口工程的文件进行分析
-			a.HandleAllSecondProject()
+			if affectMap != nil {
+				a.handleChangeProjects(a.entryFilesList, affectMap)
+			} else {
+				a.HandleAllSecondProject()
+			}
 			ftime2 = time.Since(time2).Milliseconds()
 
 			time3 := time.Now()
 			a.setCheckTerm(results.CheckTermThird)
 			// 3) 进行第三轮分析，主要分析不在工程中的散落文件
-			a.HandleAllThirdFile()
+			if affectMap != nil {
+				a.HandleChangeThirdFile(affectMap)
+			} else {
+				a.HandleAllThirdFile()
+			}
 			ftime3 = time.Since(time3).Milliseconds()
 		}
 	}
 
-	// 4) 重新创建所有的createTypeMap 注释类型
+	// 4) 重新创建所有的createTypeMap 注释类型，包括.proto文件与tlog xml生成的类型
+	a.loadProtoFiles()
 	a.rebuidCreateTypeMap()
 	a.checkAllAnnotate()
 
 	ftime := time.Since(time1).Milliseconds()
 	log.Debug("HandleCheck,  all time=%d, first=%d, second=%d, third=%d", ftime, ftime1, ftime2, ftime3)
+	a.checkTime = CheckTimeInfo{
+		All:    ftime,
+		First:  ftime1,
+		Second: ftime2,
+		Third:  ftime3,
+	}
+}
+
+// GetCheckTimeInfo 获取最近一次整体分析各阶段的耗时
+func (a *AllProject) GetCheckTimeInfo() CheckTimeInfo {
+	return a.checkTime
+}
+
+// GetFileCacheStat 获取实时修改文件缓存的命中统计
+func (a *AllProject) GetFileCacheStat() (hitNum, missNum uint64) {
+	return a.fileLRUMap.GetHitStat()
 }
 
 //  重新创建所有的createTypeMap 注释类型
@@ -135,8 +200,19 @@ func (a *AllProject) rebuidCreateTypeMap() {
 	a.createTypeMap = map[string]common.CreateTypeList{}
 
 	// 遍历所有文件的注释类型，整合成一个整体
+	annotateFileList := make([]*common.AnnotateFile, 0, len(a.fileStructMap)+len(a.protoAnnotateMap))
 	for _, fileStruct := range a.fileStructMap {
-		for strName, createTypeList := range fileStruct.AnnotateFile.CreateTypeMap {
+		annotateFileList = append(annotateFileList, fileStruct.AnnotateFile)
+	}
+	for _, annotateFile := range a.protoAnnotateMap {
+		annotateFileList = append(annotateFileList, annotateFile)
+	}
+	for _, xmlInfo := range a.tlogXMLMap {
+		annotateFileList = append(annotateFileList, xmlInfo.annotateFile)
+	}
+
+	for _, annotateFile := range annotateFileList {
+		for strName, createTypeList := range annotateFile.CreateTypeMap {
 			typeList, ok := a.createTypeMap[strName]
 			if ok {
 				typeList.List = append(typeList.List, createTypeList.List...)
@@ -180,5 +256,8 @@ func (a *AllProject) RemoveFile(strFile string) {
 
 	// 3) 删除cache的内容
 	a.RemoveCacheContent(strFile)
+
+	// 4) 删除依赖关系
+	a.dependGraph.RemoveFile(strFile)
 	log.Debug("delete strFile=%s, beforeFlag=%t, endFlag=%t", strFile, beforeExitFlag, endExitFlag)
 }
@@ -0,0 +1,350 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/annotation/annotateast"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/results"
+	"sort"
+)
+
+// 文件之间的依赖关系图，文件变化后，只需要重新分析该文件以及依赖它的文件
+// 依赖有三种来源：
+// 1) 通过require、dofile等方式引用了其他的文件
+// 2) 使用了其他文件定义的全局变量（第一阶段中没有在本文件定义，但是出现了的全局变量）
+// 3) 注解中使用了其他文件定义的类型（---@class、---@alias），定义了同名类型的文件之间也相互依赖，会有重复定义的告警
+
+// stringSet 字符串集合
+type stringSet map[string]struct{}
+
+// insertSetMap 往map[key]集合中插入一个值
+func insertSetMap(setMap map[string]stringSet, key string, value string) {
+	oneSet, ok := setMap[key]
+	if !ok {
+		oneSet = stringSet{}
+		setMap[key] = oneSet
+	}
+	oneSet[value] = struct{}{}
+}
+
+// removeSetMap 从map[key]集合中删除一个值，集合为空时删除这个key
+func removeSetMap(setMap map[string]stringSet, key string, value string) {
+	oneSet, ok := setMap[key]
+	if !ok {
+		return
+	}
+
+	delete(oneSet, value)
+	if len(oneSet) == 0 {
+		delete(setMap, key)
+	}
+}
+
+// sortedSet 集合转换成排好序的列表
+func sortedSet(oneSet stringSet) []string {
+	strVec := make([]string, 0, len(oneSet))
+	for str := range oneSet {
+		strVec = append(strVec, str)
+	}
+	sort.Strings(strVec)
+	return strVec
+}
+
+// DependGraph 文件之间的依赖关系图
+type DependGraph struct {
+	referMap    map[string]stringSet // 文件引用的其他文件，key为文件名
+	referByMap  map[string]stringSet // 反向边，文件被哪些文件引用，key为被引用的文件名
+	defineMap   map[string]stringSet // 文件定义的全局变量名，key为文件名
+	useMap      map[string]stringSet // 文件使用的其他文件的全局变量名，key为文件名
+	defineByMap map[string]stringSet // 全局变量名被哪些文件定义，key为全局变量名
+	useByMap    map[string]stringSet // 全局变量名被哪些文件使用，key为全局变量名
+
+	typeDefineMap   map[string]stringSet // 文件注解定义的类型名，key为文件名
+	typeUseMap      map[string]stringSet // 文件注解使用的类型名，key为文件名
+	typeDefineByMap map[string]stringSet // 注解类型名被哪些文件定义，key为类型名
+	typeUseByMap    map[string]stringSet // 注解类型名被哪些文件使用，key为类型名
+}
+
+// CreateDependGraph 创建空的依赖关系图
+func CreateDependGraph() *DependGraph {
+	return &DependGraph{
+		referMap:    map[string]stringSet{},
+		referByMap:  map[string]stringSet{},
+		defineMap:   map[string]stringSet{},
+		useMap:      map[string]stringSet{},
+		defineByMap: map[string]stringSet{},
+		useByMap:    map[string]stringSet{},
+
+		typeDefineMap:   map[string]stringSet{},
+		typeUseMap:      map[string]stringSet{},
+		typeDefineByMap: map[string]stringSet{},
+		typeUseByMap:    map[string]stringSet{},
+	}
+}
+
+// getAnnotateUseTypes 获取注解中使用到的所有类型名称，包括class继承的父类
+func getAnnotateUseTypes(annotateFile *common.AnnotateFile) stringSet {
+	typeSet := stringSet{}
+	forEachAnnotateType(annotateFile, func(fragment *common.FragementInfo, oneType annotateast.Type) {
+		strList, _ := annotateast.GetAllStrAndLocList(oneType)
+		for _, str := range strList {
+			if str != "..." && !common.GConfig.IsDefaultAnnotateType(str) {
+				typeSet[str] = struct{}{}
+			}
+		}
+	})
+
+	for _, oneFragment := range annotateFile.FragementMap {
+		if oneFragment.ClassInfo == nil {
+			continue
+		}
+
+		for _, oneClass := range oneFragment.ClassInfo.ClassList {
+			for _, strParent := range oneClass.ClassState.ParentNameList {
+				typeSet[strParent] = struct{}{}
+			}
+		}
+	}
+
+	return typeSet
+}
+
+// UpdateFile 文件第一阶段分析完成后，重新生成这个文件的依赖关系
+func (d *DependGraph) UpdateFile(strFile string, fileResult *results.FileResult, annotateFile *common.AnnotateFile) {
+	d.RemoveFile(strFile)
+	if annotateFile != nil {
+		for strName := range annotateFile.CreateTypeMap {
+			insertSetMap(d.typeDefineMap, strFile, strName)
+			insertSetMap(d.typeDefineByMap, strName, strFile)
+		}
+
+		for strName := range getAnnotateUseTypes(annotateFile) {
+			insertSetMap(d.typeUseMap, strFile, strName)
+			insertSetMap(d.typeUseByMap, strName, strFile)
+		}
+	}
+
+	if fileResult == nil {
+		return
+	}
+
+	for _, referInfo := range fileResult.ReferVec {
+		if !referInfo.Valid || referInfo.ReferValidStr == "" || referInfo.ReferValidStr == strFile {
+			continue
+		}
+
+		insertSetMap(d.referMap, strFile, referInfo.ReferValidStr)
+		insertSetMap(d.referByMap, referInfo.ReferValidStr, strFile)
+	}
+
+	for strName := range fileResult.GlobalMaps {
+		insertSetMap(d.defineMap, strFile, strName)
+		insertSetMap(d.defineByMap, strName, strFile)
+	}
+
+	for strName := range fileResult.NodefineMaps {
+		insertSetMap(d.useMap, strFile, strName)
+		insertSetMap(d.useByMap, strName, strFile)
+	}
+}
+
+// RemoveFile 删除文件的所有依赖关系，被其他文件引用的反向边保留，文件重新创建时仍然有效
+func (d *DependGraph) RemoveFile(strFile string) {
+	for referFile := range d.referMap[strFile] {
+		removeSetMap(d.referByMap, referFile, strFile)
+	}
+	delete(d.referMap, strFile)
+
+	for strName := range d.defineMap[strFile] {
+		removeSetMap(d.defineByMap, strName, strFile)
+	}
+	delete(d.defineMap, strFile)
+
+	for strName := range d.useMap[strFile] {
+		removeSetMap(d.useByMap, strName, strFile)
+	}
+	delete(d.useMap, strFile)
+
+	for strName := range d.typeDefineMap[strFile] {
+		removeSetMap(d.typeDefineByMap, strName, strFile)
+	}
+	delete(d.typeDefineMap, strFile)
+
+	for strName := range d.typeUseMap[strFile] {
+		removeSetMap(d.typeUseByMap, strName, strFile)
+	}
+	delete(d.typeUseMap, strFile)
+}
+
+// insertTypeFiles 把定义了这些注解类型的其他文件插入到集合中
+func (d *DependGraph) insertTypeFiles(strFile string, typeSet stringSet, fileSet stringSet) {
+	for strName := range typeSet {
+		for defineFile := range d.typeDefineByMap[strName] {
+			if defineFile != strFile {
+				fileSet[defineFile] = struct{}{}
+			}
+		}
+	}
+}
+
+// getDepends 获取文件直接依赖的其他文件
+func (d *DependGraph) getDepends(strFile string) stringSet {
+	dependSet := stringSet{}
+	for referFile := range d.referMap[strFile] {
+		dependSet[referFile] = struct{}{}
+	}
+
+	for strName := range d.useMap[strFile] {
+		for defineFile := range d.defineByMap[strName] {
+			if defineFile != strFile {
+				dependSet[defineFile] = struct{}{}
+			}
+		}
+	}
+
+	// 使用的注解类型以及定义了同名类型的文件
+	d.insertTypeFiles(strFile, d.typeUseMap[strFile], dependSet)
+	d.insertTypeFiles(strFile, d.typeDefineMap[strFile], dependSet)
+
+	return dependSet
+}
+
+// getDependents 获取直接依赖这个文件的其他文件
+func (d *DependGraph) getDependents(strFile string) stringSet {
+	dependentSet := stringSet{}
+	for referByFile := range d.referByMap[strFile] {
+		dependentSet[referByFile] = struct{}{}
+	}
+
+	for strName := range d.defineMap[strFile] {
+		for useFile := range d.useByMap[strName] {
+			if useFile != strFile {
+				dependentSet[useFile] = struct{}{}
+			}
+		}
+	}
+
+	// 使用了定义的注解类型的文件，以及定义了同名类型的文件
+	for strName := range d.typeDefineMap[strFile] {
+		for useFile := range d.typeUseByMap[strName] {
+			if useFile != strFile {
+				dependentSet[useFile] = struct{}{}
+			}
+		}
+	}
+	d.insertTypeFiles(strFile, d.typeDefineMap[strFile], dependentSet)
+
+	return dependentSet
+}
+
+// GetAffectFiles 获取受这些文件变化影响的所有文件，包括文件自身以及传递依赖它们的文件
+func (d *DependGraph) GetAffectFiles(fileVec []string, affectMap map[string]struct{}) {
+	queue := []string{}
+	for _, strFile := range fileVec {
+		if _, ok := affectMap[strFile]; ok {
+			continue
+		}
+
+		affectMap[strFile] = struct{}{}
+		queue = append(queue, strFile)
+	}
+
+	for len(queue) > 0 {
+		strFile := queue[0]
+		queue = queue[1:]
+
+		for dependentFile := range d.getDependents(strFile) {
+			if _, ok := affectMap[dependentFile]; ok {
+				continue
+			}
+
+			affectMap[dependentFile] = struct{}{}
+			queue = append(queue, dependentFile)
+		}
+	}
+}
+
+// DependEdge 依赖关系图中的一条边
+type DependEdge struct {
+	File    string   `json:"file"`              // 依赖的文件
+	Refer   bool     `json:"refer"`             // 是否通过require、dofile等方式引用
+	Globals []string `json:"globals,omitempty"` // 使用到的对方文件定义的全局变量
+	Types   []string `json:"types,omitempty"`   // 使用到的或者同名定义的对方文件的注解类型
+}
+
+// DependNode 依赖关系图中的单个文件
+type DependNode struct {
+	File       string       `json:"file"`       // 文件名
+	Depends    []DependEdge `json:"depends"`    // 依赖的其他文件
+	Dependents []string     `json:"dependents"` // 直接依赖这个文件的其他文件
+}
+
+// Dump 导出依赖关系图，strFile为空时导出所有的文件，用于调试
+func (d *DependGraph) Dump(strFile string) (nodeVec []DependNode) {
+	fileSet := stringSet{}
+	if strFile != "" {
+		fileSet[strFile] = struct{}{}
+	} else {
+		for _, oneMap := range []map[string]stringSet{d.referMap, d.referByMap, d.defineMap, d.useMap,
+			d.typeDefineMap, d.typeUseMap} {
+			for oneFile := range oneMap {
+				fileSet[oneFile] = struct{}{}
+			}
+		}
+	}
+
+	for _, oneFile := range sortedSet(fileSet) {
+		node := DependNode{
+			File:       oneFile,
+			Depends:    []DependEdge{},
+			Dependents: sortedSet(d.getDependents(oneFile)),
+		}
+
+		for _, dependFile := range sortedSet(d.getDepends(oneFile)) {
+			edge := DependEdge{
+				File: dependFile,
+			}
+			_, edge.Refer = d.referMap[oneFile][dependFile]
+
+			globalSet := stringSet{}
+			for strName := range d.useMap[oneFile] {
+				if _, ok := d.defineByMap[strName][dependFile]; ok {
+					globalSet[strName] = struct{}{}
+				}
+			}
+			if len(globalSet) > 0 {
+				edge.Globals = sortedSet(globalSet)
+			}
+
+			typeSet := stringSet{}
+			for _, oneTypeMap := range []stringSet{d.typeUseMap[oneFile], d.typeDefineMap[oneFile]} {
+				for strName := range oneTypeMap {
+					if _, ok := d.typeDefineByMap[strName][dependFile]; ok {
+						typeSet[strName] = struct{}{}
+					}
+				}
+			}
+			if len(typeSet) > 0 {
+				edge.Types = sortedSet(typeSet)
+			}
+
+			node.Depends = append(node.Depends, edge)
+		}
+
+		nodeVec = append(nodeVec, node)
+	}
+
+	return nodeVec
+}
+
+// rebuildDependGraph 第一阶段完成后，由所有文件的结果重新生成依赖关系图
+func (a *AllProject) rebuildDependGraph() {
+	a.dependGraph = CreateDependGraph()
+	for strFile, fileStruct := range a.fileStructMap {
+		a.dependGraph.UpdateFile(strFile, fileStruct.FileResult, fileStruct.AnnotateFile)
+	}
+}
+
+// GetDependGraph 导出文件的依赖关系图，strFile为空时导出所有的文件
+func (a *AllProject) GetDependGraph(strFile string) []DependNode {
+	return a.dependGraph.Dump(strFile)
+}
@@ -0,0 +1,431 @@
+package check
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/results"
+	"luahelper-lsp/langserver/log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// 磁盘缓存，保存所有文件第一阶段结果的摘要，下次启动时内容没有变化的文件直接由摘要还原，不需要再生成AST
+// 摘要还原的文件只有全局变量、引用、函数签名、注解这些信息，首次用到AST时（例如打开文件、查找引用），再进行完整的分析
+// 第二、三阶段只使用摘要，需要遍历AST的文件在分析之前才进行完整的分析
+// 同时保存第二、三阶段的结果，下次启动时按增量分析处理，只有内容变化的文件以及依赖它们的文件需要遍历AST
+
+// diskCacheVersion 磁盘缓存格式的版本号，摘要的结构有变动时需要增加
+const diskCacheVersion int = 4
+
+// diskCacheHeader 磁盘缓存的头部信息，任何一项不一致，缓存都失效
+type diskCacheHeader struct {
+	Version       int    // 缓存格式的版本号
+	ServerVersion string // lsp server的版本号
+	ConfigHash    string // luahelper.json以及初始化参数的hash值
+}
+
+// diskCacheData 磁盘缓存文件的完整内容
+type diskCacheData struct {
+	Header       diskCacheHeader
+	SummaryMap   map[string]*results.FileSummary
+	CheckSummary *results.CheckSummary
+}
+
+// DiskCache 第一阶段结果的磁盘缓存
+type DiskCache struct {
+	path       string                          // 缓存文件的路径
+	header     diskCacheHeader                 // 当前的头部信息
+	summaryMap map[string]*results.FileSummary // 所有文件的摘要，key值为文件名
+
+	// 上一次第二、三阶段的结果，启动时取出一次，为nil表示没有
+	checkSummary *results.CheckSummary
+	mutex        sync.Mutex
+}
+
+// CreateDiskCache 创建磁盘缓存，如果缓存文件存在且版本一致，加载进来
+// serverVersion 为lsp server的版本号，configHash为配置的hash值，有变化时之前的缓存失效
+func CreateDiskCache(path string, serverVersion string, configHash string) *DiskCache {
+	diskCache := &DiskCache{
+		path: path,
+		header: diskCacheHeader{
+			Version:       diskCacheVersion,
+			ServerVersion: serverVersion,
+			ConfigHash:    configHash,
+		},
+		summaryMap: map[string]*results.FileSummary{},
+	}
+
+	diskCache.load()
+	return diskCache
+}
+
+// GetContentHash 获取文件内容的hash值
+func GetContentHash(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// load 加载缓存文件
+func (d *DiskCache) load() {
+	time1 := time.Now()
+	file, err := os.Open(d.path)
+	if err != nil {
+		log.Debug("open disk cache=%s err=%s", d.path, err.Error())
+		return
+	}
+	defer file.Close()
+
+	decoder := gob.NewDecoder(bufio.NewReader(file))
+	var header diskCacheHeader
+	if err := decoder.Decode(&header); err != nil {
+		log.Error("decode disk cache header err=%s", err.Error())
+		return
+	}
+
+	if header != d.header {
+		log.Debug("disk cache is out of date, version=%d, serverVersion=%s", header.Version, header.ServerVersion)
+		return
+	}
+
+	var summaryMap map[string]*results.FileSummary
+	if err := decoder.Decode(&summaryMap); err != nil {
+		log.Error("decode disk cache err=%s", err.Error())
+		return
+	}
+
+	var checkSummary results.CheckSummary
+	if err := decoder.Decode(&checkSummary); err != nil {
+		log.Error("decode disk cache check summary err=%s", err.Error())
+		return
+	}
+
+	d.summaryMap = summaryMap
+	d.checkSummary = &checkSummary
+	log.Debug("load disk cache=%s, file num=%d, cost time=%d(ms)", d.path, len(summaryMap),
+		time.Since(time1).Milliseconds())
+}
+
+// Get 获取文件的摘要，文件内容的hash值不一致时返回nil
+func (d *DiskCache) Get(strFile string, contentHash string) *results.FileSummary {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	summary, ok := d.summaryMap[strFile]
+	if !ok || summary.ContentHash != contentHash {
+		return nil
+	}
+
+	return summary
+}
+
+// GetSummaryHash 获取文件摘要对应的内容hash值，没有摘要时返回空
+func (d *DiskCache) GetSummaryHash(strFile string) string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if summary, ok := d.summaryMap[strFile]; ok {
+		return summary.ContentHash
+	}
+	return ""
+}
+
+// TakeCheckSummary 取出缓存中上一次第二、三阶段的结果，只能取出一次，再次调用返回nil
+func (d *DiskCache) TakeCheckSummary() *results.CheckSummary {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	checkSummary := d.checkSummary
+	d.checkSummary = nil
+	return checkSummary
+}
+
+// Put 插入或更新文件的摘要
+func (d *DiskCache) Put(summary *results.FileSummary) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.summaryMap[summary.StrFile] = summary
+}
+
+// Save 保存到缓存文件中，allFilesMap为当前所有的文件，不在其中的摘要会被删除
+// checkSummary 为当前第二、三阶段的结果
+func (d *DiskCache) Save(allFilesMap map[string]struct{}, checkSummary *results.CheckSummary) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	time1 := time.Now()
+	for strFile := range d.summaryMap {
+		if _, ok := allFilesMap[strFile]; !ok {
+			delete(d.summaryMap, strFile)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(d.path), 0755); err != nil {
+		return err
+	}
+
+	// 先写入临时文件，再重命名，防止写了一半的缓存文件
+	tmpPath := d.path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := gob.NewEncoder(writer)
+	err = encoder.Encode(d.header)
+	if err == nil {
+		err = encoder.Encode(d.summaryMap)
+	}
+	if err == nil {
+		err = encoder.Encode(checkSummary)
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	file.Close()
+
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, d.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	log.Debug("save disk cache=%s, file num=%d, cost time=%d(ms)", d.path, len(d.summaryMap),
+		time.Since(time1).Milliseconds())
+	return nil
+}
+
+// SetDiskCache 设置第一阶段结果的磁盘缓存，需要在HandleCheck之前调用
+func (a *AllProject) SetDiskCache(diskCache *DiskCache) {
+	a.diskCache = diskCache
+}
+
+// SaveDiskCache 把所有文件第一阶段的摘要以及第二、三阶段的结果保存到磁盘缓存中
+// asyncFlag 为true时，在协程中写文件
+func (a *AllProject) SaveDiskCache(asyncFlag bool) {
+	if a.diskCache == nil {
+		return
+	}
+
+	allFilesMap := make(map[string]struct{}, len(a.allFilesMap))
+	for strFile := range a.allFilesMap {
+		allFilesMap[strFile] = struct{}{}
+	}
+
+	checkSummary := a.createCheckSummary()
+	saveFunc := func() {
+		if err := a.diskCache.Save(allFilesMap, checkSummary); err != nil {
+			log.Error("save disk cache err=%s", err.Error())
+		}
+	}
+
+	if asyncFlag {
+		go saveFunc()
+	} else {
+		saveFunc()
+	}
+}
+
+// createCheckSummary 生成当前第二、三阶段结果的摘要，没有进行第二、三阶段的分析时为空
+func (a *AllProject) createCheckSummary() *results.CheckSummary {
+	checkSummary := &results.CheckSummary{
+		FileHashMap:  map[string]string{},
+		DependentMap: map[string][]string{},
+		ProjectMap:   map[string]*results.ProjectSummary{},
+	}
+	if len(a.entryFilesList) == 0 && !common.GConfig.IsSpecialCheck() {
+		return checkSummary
+	}
+
+	for strFile := range a.allFilesMap {
+		// 分析失败的文件，磁盘缓存中的摘要可能是之前的内容，不记录hash值，下次启动时重新分析
+		fileStruct, ok := a.fileStructMap[strFile]
+		if !ok || fileStruct.HandleResult != results.FileHandleOk {
+			continue
+		}
+
+		if contentHash := a.diskCache.GetSummaryHash(strFile); contentHash != "" {
+			checkSummary.FileHashMap[strFile] = contentHash
+		}
+
+		if dependentSet := a.dependGraph.getDependents(strFile); len(dependentSet) > 0 {
+			checkSummary.DependentMap[strFile] = sortedSet(dependentSet)
+		}
+	}
+
+	for strEntryFile, second := range a.analysisSecondMap {
+		checkSummary.ProjectMap[strEntryFile] = results.CreateProjectSummary(second)
+	}
+
+	if a.thirdStruct != nil {
+		checkSummary.Third = results.CreateThirdSummary(a.thirdStruct)
+	}
+	return checkSummary
+}
+
+// restoreCheckSummary 启动时由磁盘缓存还原上一次第二、三阶段的结果，作为增量分析的旧结果
+// 返回受影响的文件：内容有变化的文件（包括新增、删除、分析失败的），以及变化之前、之后传递依赖它们的文件
+// 返回nil表示缓存中没有上一次的结果，需要全量分析
+func (a *AllProject) restoreCheckSummary() map[string]struct{} {
+	if a.diskCache == nil {
+		return nil
+	}
+
+	checkSummary := a.diskCache.TakeCheckSummary()
+	if checkSummary == nil || len(checkSummary.FileHashMap) == 0 {
+		return nil
+	}
+
+	var changeFileVec []string
+	for strFile := range a.allFilesMap {
+		contentHash := ""
+		if fileStruct, ok := a.fileStructMap[strFile]; ok && fileStruct.HandleResult == results.FileHandleOk {
+			contentHash = a.diskCache.GetSummaryHash(strFile)
+		}
+
+		if contentHash == "" || contentHash != checkSummary.FileHashMap[strFile] {
+			changeFileVec = append(changeFileVec, strFile)
+		}
+	}
+	for strFile := range checkSummary.FileHashMap {
+		if _, ok := a.allFilesMap[strFile]; !ok {
+			changeFileVec = append(changeFileVec, strFile)
+		}
+	}
+
+	// 变化之前依赖这些文件的，由缓存的依赖关系获取
+	affectMap := map[string]struct{}{}
+	queue := []string{}
+	for _, strFile := range changeFileVec {
+		if _, ok := affectMap[strFile]; !ok {
+			affectMap[strFile] = struct{}{}
+			queue = append(queue, strFile)
+		}
+	}
+	for len(queue) > 0 {
+		strFile := queue[0]
+		queue = queue[1:]
+		for _, dependentFile := range checkSummary.DependentMap[strFile] {
+			if _, ok := affectMap[dependentFile]; !ok {
+				affectMap[dependentFile] = struct{}{}
+				queue = append(queue, dependentFile)
+			}
+		}
+	}
+
+	// 变化之后依赖这些文件的
+	newAffectMap := map[string]struct{}{}
+	a.dependGraph.GetAffectFiles(changeFileVec, newAffectMap)
+	for strFile := range newAffectMap {
+		affectMap[strFile] = struct{}{}
+	}
+
+	for strEntryFile, projectSummary := range checkSummary.ProjectMap {
+		a.analysisSecondMap[strEntryFile] = projectSummary.CreateSecondProject(strEntryFile)
+	}
+	if checkSummary.Third != nil {
+		a.thirdStruct = checkSummary.Third.CreateAnalysisThird()
+	}
+
+	log.Debug("restore check summary, change file num=%d, affect file num=%d", len(changeFileVec), len(affectMap))
+	return affectMap
+}
+
+// createSummaryFileStruct 由磁盘缓存的摘要，还原出文件第一阶段的结构
+func (a *AllProject) createSummaryFileStruct(f *results.FileStruct, summary *results.FileSummary) {
+	fileResult := summary.CreateFileResult()
+
+	// 文件列表可能有变化，引用关系需要重新扫描
+	fileResult.RecheckAllReferInfo(a.allFilesMap)
+
+	f.FileResult = fileResult
+	f.HandleResult = results.FileHandleOk
+	f.SummaryFlag = true
+	f.Contents = nil
+
+	// 注解由缓存的注释重新生成
+	f.AnnotateFile.AnalysisAllComment(fileResult.CommentMap)
+	f.AnnotateFile.RelateTypeVarInfo(fileResult.GlobalMaps, fileResult.MainFunc.MainScope)
+}
+
+// loadSummaryFileStruct 文件的结果如果只是磁盘缓存的摘要，重新进行第一阶段完整的分析
+func (a *AllProject) loadSummaryFileStruct(fileStruct *results.FileStruct) {
+	if fileStruct == nil || !fileStruct.SummaryFlag {
+		return
+	}
+
+	fileStruct.LoadSummary(func(f *results.FileStruct) {
+		log.Debug("load summary file=%s", f.StrFile)
+		newStruct := results.CreateFileStruct(f.StrFile)
+		handleResult, changeFlag, _ := a.analysisFirstLuaFile(newStruct, f.StrFile, nil, false, false)
+		if !changeFlag {
+			return
+		}
+
+		f.HandleResult = handleResult
+		f.FileResult = newStruct.FileResult
+		f.AnnotateFile = newStruct.AnnotateFile
+		f.IsCommonFile = newStruct.IsCommonFile
+	})
+}
+
+// loadSummaryFiles 多协程对只是磁盘缓存摘要的文件进行完整的分析，第二、三阶段分析之前补全需要遍历AST的文件
+func (a *AllProject) loadSummaryFiles(fileVec []string) {
+	var summaryVec []*results.FileStruct
+	summaryMap := map[string]struct{}{}
+	for _, strFile := range fileVec {
+		if _, ok := summaryMap[strFile]; ok {
+			continue
+		}
+
+		if fileStruct, ok := a.getFirstFileStuct(strFile); ok && fileStruct.SummaryFlag {
+			summaryMap[strFile] = struct{}{}
+			summaryVec = append(summaryVec, fileStruct)
+		}
+	}
+	if len(summaryVec) == 0 {
+		return
+	}
+
+	time1 := time.Now()
+	corNum := runtime.NumCPU() + 2
+	if len(summaryVec) < corNum {
+		corNum = len(summaryVec)
+	}
+
+	fileChan := make(chan *results.FileStruct)
+	var wg sync.WaitGroup
+	for i := 0; i < corNum; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fileStruct := range fileChan {
+				a.loadSummaryFileStruct(fileStruct)
+			}
+		}()
+	}
+
+	for _, fileStruct := range summaryVec {
+		fileChan <- fileStruct
+	}
+	close(fileChan)
+	wg.Wait()
+
+	log.Debug("load summary files num=%d, cost time=%d(ms)", len(summaryVec), time.Since(time1).Milliseconds())
+}
+
+// IsSummaryFile 判断文件第一阶段的结果是否只是磁盘缓存的摘要，还没有进行完整的分析
+func (a *AllProject) IsSummaryFile(strFile string) bool {
+	fileStruct, ok := a.getFirstFileStuct(strFile)
+	return ok && fileStruct.SummaryFlag
+}
@@ -144,7 +144,7 @@ func (a *AllProject) findLocReferSymbol(fileResult *results.FileResult, posLine
 		}
 
 		// 查找所有的
-		findOk, oneVar = comParam.thirdStruct.FindThirdGlobalGInfo(gFlag, strName, "")
+		findOk, oneVar = comParam.thirdStruct.FindThirdGlobalGInfo(fileResult.Name, gFlag, strName, "")
 		if findOk {
 			return a.createAnnotateSymbol(oneVar.ExtraGlobal.FileName, strName, oneVar)
 		}
@@ -523,7 +523,7 @@ func (a *AllProject) getBindAnnotateSetType(luaInFile string, strFuncName string
 	matchFlag = false
 
 	// 如果没有配置自动的类型推导直接返回
-	flag, oneSet := common.GConfig.MatchAnnotateSet(strFuncName)
+	flag, oneSet := common.GConfig.GetFileConfig(luaInFile).MatchAnnotateSet(strFuncName)
 	if !flag {
 		return
 	}
@@ -667,7 +667,7 @@ func (a *AllProject) getImportReferSymbol(luaInFile string, funcExp *ast.FuncCal
 		return nil
 	}
 
-	if !common.GConfig.ReferOtherFileMap[callExp.Name] {
+	if !common.GConfig.GetFileConfig(luaInFile).ReferOtherFileMap[callExp.Name] {
 		return nil
 	}
 
@@ -682,7 +682,7 @@ func (a *AllProject) getImportReferSymbol(luaInFile string, funcExp *ast.FuncCal
 
 	strFirst := firstExp.(*ast.StringExp).Str
 
-	oneRefer := common.CreateOneReferInfo(callExp.Name, strFirst, funcExp.Loc)
+	oneRefer := common.CreateOneReferInfo(luaInFile, callExp.Name, strFirst, funcExp.Loc)
 	if oneRefer == nil {
 		return nil
 	}
@@ -719,7 +719,7 @@ func (a *AllProject) getFuncRelateSymbol(luaInFile string, node *ast.FuncCallExp
 	if nameExp, ok := node.PrefixExp.(*ast.NameExp); ok && node.NameExp == nil {
 		strName := nameExp.Name
 		// 这两个函数，在变量的referInfo里面已经存在了
-		if strName == "require" || common.GConfig.IsFrameReferOtherFile(strName) {
+		if strName == "require" || common.GConfig.GetFileConfig(luaInFile).IsFrameReferOtherFile(strName) {
 			//return nil
 			 referSymbol := a.getImportReferSymbol(luaInFile, node, comParam, findExpList)
 			 return referSymbol
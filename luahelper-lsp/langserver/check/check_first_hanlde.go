@@ -3,7 +3,6 @@ package check
 import (
 	"bytes"
 	"fmt"
-	"io/ioutil"
 	"luahelper-lsp/langserver/check/analysis"
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/check/compiler/lexer"
@@ -46,7 +45,8 @@ func (allProject *AllProject) analysisFirstLuaFile(f *results.FileStruct, luaFil
 	if content != nil {
 		f.Contents = content
 	} else {
-		data, err1 := ioutil.ReadFile(luaFile)
+		// 非utf-8的文件按配置的编码转换为utf-8，位置都基于转换后的内容
+		data, err1 := common.GConfig.ReadLuaFile(luaFile)
 		f.Contents = data
 		if err1 != nil {
 			errStr := fmt.Sprintf("read file=%s error", luaFile)
@@ -56,13 +56,30 @@ func (allProject *AllProject) analysisFirstLuaFile(f *results.FileStruct, luaFil
 			return
 		}
 
-		beforeStruct, _ = allProject.GetFirstFileStuct(luaFile)
+		beforeStruct, _ = allProject.getFirstFileStuct(luaFile)
 		if beforeStruct != nil && bytes.Equal(beforeStruct.Contents, f.Contents) {
 			log.Debug("strFile=%s content is the same", luaFile)
 			return beforeStruct.HandleResult, false, beforeStruct
 		}
 	}
 
+	// 非实时分析的结果，才放入磁盘缓存
+	contentHash := ""
+	if allProject.diskCache != nil && !realTimeFlag {
+		contentHash = GetContentHash(f.Contents)
+
+		// 启动时，内容没有变化的文件直接由磁盘缓存的摘要还原
+		if allProject.summaryFlag {
+			if summary := allProject.diskCache.Get(luaFile, contentHash); summary != nil {
+				allProject.createSummaryFileStruct(f, summary)
+				if !saveFlag {
+					f.Contents = nil
+				}
+				return results.FileHandleOk, true, nil
+			}
+		}
+	}
+
 	ftime1 := time.Since(time1).Milliseconds()
 
 	// 获取文件的修改时间
@@ -117,6 +134,10 @@ func (allProject *AllProject) analysisFirstLuaFile(f *results.FileStruct, luaFil
 	f.AnnotateFile.RelateTypeVarInfo(firstFile.GlobalMaps, firstFile.MainFunc.MainScope)
 	ftime4 := time.Since(time4).Milliseconds()
 
+	if contentHash != "" {
+		allProject.diskCache.Put(results.CreateFileSummary(luaFile, contentHash, firstFile))
+	}
+
 	ftime5 := time.Since(time1).Milliseconds()
 	log.Debug("handleFirstTraverseAST strFile=%s, readTime=%d, astTime=%d, firstTraTime=%d, annotatetime=%d, alltime=%d",
 		luaFile, ftime1, ftime2, ftime3, ftime4, ftime5)
@@ -271,7 +292,10 @@ func (a *AllProject) HandleFirstAllProject() {
 	// 重建
 	common.GConfig.RebuildSameFileNameVar(a.allFilesMap)
 
+	// 启动时的分析，允许由磁盘缓存的摘要还原
+	a.summaryFlag = true
 	a.firstCreateAndTraverseAst(filesList, false)
+	a.summaryFlag = false
 
 	tc := time.Since(time1)
 	ftime := tc.Milliseconds()
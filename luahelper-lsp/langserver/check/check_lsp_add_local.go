@@ -0,0 +1,26 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+)
+
+// GetAddLocalLoc 函数内意外定义的全局变量，nameLoc为第一次赋值时变量名的位置，返回插入local的位置
+// 只有赋值语句左侧为单个变量时才能添加local，例如count = count + 1、function helper() end
+func (a *AllProject) GetAddLocalLoc(strFile string, nameLoc lexer.Location) (insertLoc lexer.Location, ok bool) {
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil || fileStruct.FileResult.Block == nil {
+		return
+	}
+
+	ast.Inspect(fileStruct.FileResult.Block, func(node ast.Node) bool {
+		if assignStat, isAssign := node.(*ast.AssignStat); isAssign && len(assignStat.VarList) == 1 {
+			if nameExp, isName := assignStat.VarList[0].(*ast.NameExp); isName && nameExp.Loc == nameLoc {
+				insertLoc, ok = assignStat.Loc, true
+			}
+		}
+		return !ok
+	})
+
+	return insertLoc, ok
+}
@@ -46,64 +46,71 @@ func (a *AllProject) checkOneFileType(annotateFile *common.AnnotateFile, fragemn
 	}
 }
 
-// 单个注解文件进行check
-func (a *AllProject) checkFileAnnotate(fileStruct *results.FileStruct) {
-	annotateFile := fileStruct.AnnotateFile
-	if annotateFile == nil {
-		return
-	}
-
-	// 清除部分错误，常规的注解语法错误保留
-	annotateFile.ClearCheckError()
-
-	// 遍历所有的注解代码块
+// forEachAnnotateType 遍历注解文件中所有使用到的类型，handleFunc的参数为类型所在的注释块以及类型
+func forEachAnnotateType(annotateFile *common.AnnotateFile,
+	handleFunc func(fragment *common.FragementInfo, oneType annotateast.Type)) {
 	for _, oneFragment := range annotateFile.FragementMap {
 		if oneFragment.AliasInfo != nil {
 			for _, oneAlias := range oneFragment.AliasInfo.AliasList {
-				aliasType := oneAlias.AliasState.AliasType
-				a.checkOneFileType(annotateFile, oneFragment, aliasType)
+				handleFunc(oneFragment, oneAlias.AliasState.AliasType)
 			}
 		}
 
 		if oneFragment.TypeInfo != nil {
 			for _, oneType := range oneFragment.TypeInfo.TypeList {
-				a.checkOneFileType(annotateFile, oneFragment, oneType)
+				handleFunc(oneFragment, oneType)
 			}
 		}
 
 		if oneFragment.ClassInfo != nil {
 			for _, oneClass := range oneFragment.ClassInfo.ClassList {
 				for _, oneField := range oneClass.FieldMap {
-					a.checkOneFileType(annotateFile, oneFragment, oneField.FiledType)
+					handleFunc(oneFragment, oneField.FiledType)
 				}
 			}
 		}
 
 		if oneFragment.ParamInfo != nil {
 			for _, oneParam := range oneFragment.ParamInfo.ParamList {
-				a.checkOneFileType(annotateFile, oneFragment, oneParam.ParamType)
+				handleFunc(oneFragment, oneParam.ParamType)
 			}
 		}
 
 		if oneFragment.ReturnInfo != nil {
 			for _, oneType := range oneFragment.ReturnInfo.ReturnTypeList {
-				a.checkOneFileType(annotateFile, oneFragment, oneType)
+				handleFunc(oneFragment, oneType)
 			}
 		}
 
 		if oneFragment.OverloadInfo != nil {
 			for _, oneLoad := range oneFragment.OverloadInfo.OverloadList {
-				a.checkOneFileType(annotateFile, oneFragment, oneLoad.OverFunType)
+				handleFunc(oneFragment, oneLoad.OverFunType)
 			}
 		}
 
 		if oneFragment.VarargInfo != nil {
 			oneVararg := oneFragment.VarargInfo.VarargInfo
 			if oneVararg != nil {
-				a.checkOneFileType(annotateFile, oneFragment, oneVararg.VarargType)
+				handleFunc(oneFragment, oneVararg.VarargType)
 			}
 		}
 	}
+}
+
+// 单个注解文件进行check
+func (a *AllProject) checkFileAnnotate(fileStruct *results.FileStruct) {
+	annotateFile := fileStruct.AnnotateFile
+	if annotateFile == nil {
+		return
+	}
+
+	// 清除部分错误，常规的注解语法错误保留
+	annotateFile.ClearCheckError()
+
+	// 遍历所有的注解代码块
+	forEachAnnotateType(annotateFile, func(fragment *common.FragementInfo, oneType annotateast.Type) {
+		a.checkOneFileType(annotateFile, fragment, oneType)
+	})
 
 	// 所有的错误告警信息，进行排序，因为在比对注解告警信息的时候，希望是有序的
 	annotateFile.RankCheckError()
@@ -191,6 +198,14 @@ func (a *AllProject) checkAllAnnotate() {
 
 // 根据文件名称，获取到文件的注释结构
 func (a *AllProject) getAnnotateFile(strFile string) (annotateFile *common.AnnotateFile) {
+	// 0) .proto文件生成的注解信息
+	if protoAnnotateFile, ok := a.protoAnnotateMap[strFile]; ok {
+		return protoAnnotateFile
+	}
+	if xmlInfo, ok := a.tlogXMLMap[strFile]; ok {
+		return xmlInfo.annotateFile
+	}
+
 	// 1）先查找该文件是否存在
 	fileStruct, _ := a.GetCacheFileStruct(strFile)
 	if fileStruct == nil {
@@ -224,6 +239,21 @@ func (a *AllProject) GetFuncParamInfo(fileName string, lastLine int) (paramInfo
 	return fragmentInfo.ParamInfo
 }
 
+// GetFuncOverloadInfo 获取函数定义前面注释块中的---@overload 重载信息
+func (a *AllProject) GetFuncOverloadInfo(fileName string, lastLine int) (overloadInfo *common.FragementOverloadInfo) {
+	annotateFile := a.getAnnotateFile(fileName)
+	if annotateFile == nil {
+		return
+	}
+
+	fragmentInfo := annotateFile.GetLineFragementInfo(lastLine)
+	if fragmentInfo == nil {
+		return
+	}
+
+	return fragmentInfo.OverloadInfo
+}
+
 // GetAstTypeFuncType 获取注解astType具体的指向的注解函数
 func (a *AllProject) GetAstTypeFuncType(astType annotateast.Type, fileName string,
 	lastLine int) (funcType *annotateast.FuncType) {
@@ -1144,6 +1174,11 @@ func (a *AllProject) getInfoFileAnnotateType(strName string, symbol *common.Symb
 			return astType, strComment, "param"
 		}
 
+		// 协议函数的参数，为.proto文件中同名的message
+		if astType = a.getProtocolParamType(symbol); astType != nil {
+			return astType, "", "param"
+		}
+
 		return
 	}
 
@@ -1156,6 +1191,10 @@ func (a *AllProject) getInfoFileAnnotateType(strName string, symbol *common.Symb
 		if astType != nil {
 			return astType, strComment, "param"
 		}
+
+		if astType = a.getProtocolParamType(symbol); astType != nil {
+			return astType, "", "param"
+		}
 	}
 
 	// 再次判断是否为for 函数的参数
@@ -0,0 +1,243 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"luahelper-lsp/langserver/check/results"
+	"sort"
+	"strings"
+)
+
+// ClassAnnotateField 生成的class注解中的一个field
+type ClassAnnotateField struct {
+	Name    string
+	TypeStr string
+}
+
+// ClassAnnotateInfo 由table的成员生成的class注解
+type ClassAnnotateInfo struct {
+	Loc        lexer.Location       // table定义语句的位置，注解插入到这一行的前面
+	ClassName  string               // class的名称，为table的变量名
+	ParentName string               // setmetatable指定的__index，作为父类，没有时为空
+	FieldVec   []ClassAnnotateField // 所有的成员，按定义的位置排序
+}
+
+// getClassTableExp 判断表达式是否为table的构造，或是 setmetatable({}, {__index = Base}) 的调用
+// 返回父类的名称，没有设置__index时为空
+func getClassTableExp(exp ast.Exp) (ok bool, strParent string) {
+	switch subExp := exp.(type) {
+	case *ast.TableConstructorExp:
+		return true, ""
+	case *ast.FuncCallExp:
+		if _, flag := getSetmetatableArgs(subExp); !flag || len(subExp.Args) == 0 {
+			return false, ""
+		}
+
+		if _, flag := subExp.Args[0].(*ast.TableConstructorExp); !flag {
+			return false, ""
+		}
+
+		strParent, _ = getSetmetatableArgs(subExp)
+		return true, strParent
+	}
+
+	return false, ""
+}
+
+// getSetmetatableArgs 判断是否为 setmetatable(x, {__index = Base}) 的调用，返回Base的名称
+func getSetmetatableArgs(callExp *ast.FuncCallExp) (strParent string, ok bool) {
+	nameExp, flag := callExp.PrefixExp.(*ast.NameExp)
+	if !flag || callExp.NameExp != nil || nameExp.Name != "setmetatable" || len(callExp.Args) != 2 {
+		return "", false
+	}
+
+	tableExp, flag := callExp.Args[1].(*ast.TableConstructorExp)
+	if !flag {
+		return "", true
+	}
+
+	for i, keyExp := range tableExp.KeyExps {
+		strKey, flag := keyExp.(*ast.StringExp)
+		if !flag || strKey.Str != "__index" {
+			continue
+		}
+
+		switch tableExp.ValExps[i].(type) {
+		case *ast.NameExp, *ast.TableAccessExp:
+			return strings.TrimPrefix(common.GetExpName(tableExp.ValExps[i]), "!"), true
+		}
+	}
+
+	return "", true
+}
+
+// findClassTableVar 查找文件最外层包含指定行的table定义，例如 local M = {} 或是 M = {}
+func findClassTableVar(fileResult *results.FileResult, posLine int) (info ClassAnnotateInfo, varInfo *common.VarInfo) {
+	for _, stat := range fileResult.Block.Stats {
+		switch subStat := stat.(type) {
+		case *ast.LocalVarDeclStat:
+			if posLine < subStat.Loc.StartLine || posLine > subStat.Loc.EndLine ||
+				len(subStat.NameList) != 1 || len(subStat.ExpList) != 1 {
+				continue
+			}
+
+			ok, strParent := getClassTableExp(subStat.ExpList[0])
+			locVarList := fileResult.MainFunc.MainScope.LocVarMap[subStat.NameList[0]]
+			if !ok || locVarList == nil {
+				return
+			}
+
+			for _, oneVar := range locVarList.VarVec {
+				if oneVar.Loc == subStat.VarLocList[0] {
+					varInfo = oneVar
+					break
+				}
+			}
+
+			info = ClassAnnotateInfo{
+				Loc:        subStat.Loc,
+				ClassName:  subStat.NameList[0],
+				ParentName: strParent,
+			}
+			return info, varInfo
+		case *ast.AssignStat:
+			if posLine < subStat.Loc.StartLine || posLine > subStat.Loc.EndLine ||
+				len(subStat.VarList) != 1 || len(subStat.ExpList) != 1 {
+				continue
+			}
+
+			nameExp, ok := subStat.VarList[0].(*ast.NameExp)
+			if !ok {
+				return
+			}
+
+			ok, strParent := getClassTableExp(subStat.ExpList[0])
+			if !ok {
+				return
+			}
+
+			for oneVar := fileResult.GlobalMaps[nameExp.Name]; oneVar != nil; oneVar = oneVar.ExtraGlobal.Prev {
+				if oneVar.Loc == nameExp.Loc {
+					varInfo = oneVar
+					break
+				}
+			}
+
+			info = ClassAnnotateInfo{
+				Loc:        subStat.Loc,
+				ClassName:  nameExp.Name,
+				ParentName: strParent,
+			}
+			return info, varInfo
+		}
+	}
+
+	return
+}
+
+// getSetmetatableParent 查找文件最外层 setmetatable(M, {__index = Base}) 语句中指定的父类
+func getSetmetatableParent(fileResult *results.FileResult, strName string) string {
+	for _, stat := range fileResult.Block.Stats {
+		callStat, ok := stat.(*ast.FuncCallStat)
+		if !ok || len(callStat.Args) == 0 {
+			continue
+		}
+
+		nameExp, ok := callStat.Args[0].(*ast.NameExp)
+		if !ok || nameExp.Name != strName {
+			continue
+		}
+
+		if strParent, ok := getSetmetatableArgs(callStat); ok && strParent != "" {
+			return strParent
+		}
+	}
+
+	return ""
+}
+
+// getFieldTypeStr 获取table成员的类型，函数时包含参数与返回值的类型
+// 冒号函数的第一个参数为self，例如 fun(self:M, a:number):boolean
+func (a *AllProject) getFieldTypeStr(strFile string, fileResult *results.FileResult, className string,
+	subVar *common.VarInfo) string {
+	if subVar.ReferFunc != nil {
+		commentInfo := a.getFuncCommentInfo(strFile, fileResult, subVar.ReferFunc, nil)
+		paramVec := []string{}
+		if subVar.ReferFunc.IsColon {
+			paramVec = append(paramVec, "self:"+className)
+		}
+		for _, oneParam := range commentInfo.ParamVec {
+			paramVec = append(paramVec, oneParam.Name+":"+oneParam.TypeStr)
+		}
+
+		strType := "fun(" + strings.Join(paramVec, ", ") + ")"
+		if len(commentInfo.ReturnVec) > 0 {
+			strType = strType + ":" + strings.Join(commentInfo.ReturnVec, ", ")
+		}
+		return strType
+	}
+
+	luaType := subVar.VarType
+	if subVar.ReferExp != nil {
+		luaType = common.GetExpType(subVar.ReferExp)
+	}
+
+	switch luaType {
+	case common.LuaTypeBool:
+		return "boolean"
+	case common.LuaTypeNumber, common.LuaTypeInter, common.LuaTypeFloat:
+		return "number"
+	case common.LuaTypeString:
+		return "string"
+	case common.LuaTypeTable:
+		return "table"
+	case common.LuaTypeFunc:
+		return "function"
+	}
+
+	return "any"
+}
+
+// GetClassAnnotate 获取posLine行定义的table，由其所有成员生成的class注解，posLine从1开始
+// 例如下面的，生成 ---@class M : Base 以及 ---@field a number、---@field foo fun(self:M):any
+// local M = setmetatable({}, {__index = Base})
+// M.a = 1
+// function M:foo() end
+func (a *AllProject) GetClassAnnotate(strFile string, posLine int) (info ClassAnnotateInfo, ok bool) {
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil || fileStruct.FileResult.Block == nil {
+		return
+	}
+
+	fileResult := fileStruct.FileResult
+	info, varInfo := findClassTableVar(fileResult, posLine)
+	if varInfo == nil || isFuncAnnotated(fileResult, info.Loc.StartLine) {
+		return info, false
+	}
+
+	if info.ParentName == "" {
+		info.ParentName = getSetmetatableParent(fileResult, info.ClassName)
+	}
+
+	nameVec := make([]string, 0, len(varInfo.SubMaps))
+	for strName := range varInfo.SubMaps {
+		nameVec = append(nameVec, strName)
+	}
+	sort.Slice(nameVec, func(i, j int) bool {
+		iLoc, jLoc := varInfo.SubMaps[nameVec[i]].Loc, varInfo.SubMaps[nameVec[j]].Loc
+		if iLoc.StartLine != jLoc.StartLine {
+			return iLoc.StartLine < jLoc.StartLine
+		}
+		return iLoc.StartColumn < jLoc.StartColumn
+	})
+
+	for _, strName := range nameVec {
+		info.FieldVec = append(info.FieldVec, ClassAnnotateField{
+			Name:    strName,
+			TypeStr: a.getFieldTypeStr(strFile, fileResult, info.ClassName, varInfo.SubMaps[strName]),
+		})
+	}
+
+	return info, true
+}
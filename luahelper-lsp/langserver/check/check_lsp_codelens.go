@@ -0,0 +1,227 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"luahelper-lsp/langserver/check/results"
+	"sort"
+)
+
+// CodeLensKind codeLens的类型
+type CodeLensKind int
+
+const (
+	// CodeLensReferences 函数的引用数量，延迟计算
+	CodeLensReferences CodeLensKind = 1
+	// CodeLensSubClasses 注解class的子类数量，延迟计算
+	CodeLensSubClasses CodeLensKind = 2
+	// CodeLensOverride 函数覆盖了父类的方法
+	CodeLensOverride CodeLensKind = 3
+)
+
+// CodeLensInfo 文件中一个codeLens的信息
+type CodeLensInfo struct {
+	Kind      CodeLensKind
+	Loc       lexer.Location // 函数名或是class名称的位置
+	Name      string         // 函数名或是class名称
+	Title     string         // 覆盖父类方法时，显示的内容，例如overrides Parent:method
+	DefineVec []DefineStruct // 覆盖父类方法时，父类方法的位置
+}
+
+// getFuncNameExpLoc 获取函数定义的名称表达式中，最后一段名称的位置，以及前缀的名称表达式
+// 例如 function a.b:c() 中c的位置以及a.b
+func getFuncNameExpLoc(exp ast.Exp) (name string, loc lexer.Location, prefixExp ast.Exp) {
+	switch subExp := exp.(type) {
+	case *ast.NameExp:
+		return subExp.Name, subExp.Loc, nil
+	case *ast.TableAccessExp:
+		if keyExp, ok := subExp.KeyExp.(*ast.StringExp); ok {
+			return keyExp.Str, keyExp.Loc, subExp.PrefixExp
+		}
+	}
+
+	return "", loc, nil
+}
+
+// getPrefixClassInfo 获取函数定义的前缀变量所关联的注解class，例如 function Dog:bark() 中Dog所关联的class
+func (a *AllProject) getPrefixClassInfo(fileResult *results.FileResult, annotateFile *common.AnnotateFile,
+	prefixExp ast.Exp) *common.OneClassInfo {
+	nameExp, ok := prefixExp.(*ast.NameExp)
+	if !ok {
+		return nil
+	}
+
+	// 1) 优先为前缀变量关联的class
+	findFlag, varInfo := fileResult.MainFunc.MainScope.FindLocVar(nameExp.Name, nameExp.Loc)
+	if !findFlag {
+		_, varInfo = fileResult.FindGlobalVarInfo(nameExp.Name, false, "")
+	}
+
+	for _, typeList := range annotateFile.CreateTypeMap {
+		for _, oneCreate := range typeList.List {
+			if oneCreate.ClassInfo != nil && varInfo != nil && oneCreate.ClassInfo.RelateVar == varInfo {
+				return oneCreate.ClassInfo
+			}
+		}
+	}
+
+	// 2) 其次为同名的class
+	createType := a.getAnnotateStrTypeInfo(nameExp.Name, fileResult.Name, nameExp.Loc.StartLine)
+	if createType == nil {
+		return nil
+	}
+
+	return createType.ClassInfo
+}
+
+// getOverrideCodeLens 判断class的方法是否覆盖了父类的同名方法，父类的方法可以为---@field 或是关联变量的成员
+func (a *AllProject) getOverrideCodeLens(classInfo *common.OneClassInfo, funcName string,
+	loc lexer.Location) (codeLens CodeLensInfo, ok bool) {
+	for _, strParent := range classInfo.ClassState.ParentNameList {
+		if strParent == classInfo.ClassState.Name {
+			continue
+		}
+
+		repeatTypeList := &common.CreateTypeList{}
+		parentList := a.getClassTypeInfoList(strParent, classInfo.LuaFile, classInfo.LastLine, repeatTypeList,
+			map[string]bool{})
+		for _, parentInfo := range parentList {
+			var defineStruct DefineStruct
+			if fieldState, ok := parentInfo.FieldMap[funcName]; ok {
+				defineStruct = DefineStruct{
+					StrFile: parentInfo.LuaFile,
+					Loc:     fieldState.NameLoc,
+				}
+			} else if parentInfo.RelateVar != nil && parentInfo.RelateVar.SubMaps[funcName] != nil {
+				defineStruct = DefineStruct{
+					StrFile: parentInfo.LuaFile,
+					Loc:     parentInfo.RelateVar.SubMaps[funcName].Loc,
+				}
+			} else {
+				continue
+			}
+
+			codeLens = CodeLensInfo{
+				Kind:      CodeLensOverride,
+				Loc:       loc,
+				Name:      funcName,
+				Title:     "overrides " + parentInfo.ClassState.Name + ":" + funcName,
+				DefineVec: []DefineStruct{defineStruct},
+			}
+			return codeLens, true
+		}
+	}
+
+	return codeLens, false
+}
+
+// getFuncCodeLens 获取文件最外层定义的函数的codeLens
+func (a *AllProject) getFuncCodeLens(fileResult *results.FileResult,
+	annotateFile *common.AnnotateFile) (codeLensVec []CodeLensInfo) {
+	for _, stat := range fileResult.Block.Stats {
+		var nameExp ast.Exp
+		switch subStat := stat.(type) {
+		case *ast.LocalFuncDefStat:
+			codeLensVec = append(codeLensVec, CodeLensInfo{
+				Kind: CodeLensReferences,
+				Loc:  subStat.NameLoc,
+				Name: subStat.Name,
+			})
+			continue
+		case *ast.AssignStat:
+			if len(subStat.VarList) == 1 && len(subStat.ExpList) == 1 {
+				if _, ok := subStat.ExpList[0].(*ast.FuncDefExp); ok {
+					nameExp = subStat.VarList[0]
+				}
+			}
+		}
+
+		funcName, loc, prefixExp := getFuncNameExpLoc(nameExp)
+		if funcName == "" {
+			continue
+		}
+
+		codeLensVec = append(codeLensVec, CodeLensInfo{
+			Kind: CodeLensReferences,
+			Loc:  loc,
+			Name: funcName,
+		})
+
+		if prefixExp == nil || annotateFile == nil {
+			continue
+		}
+
+		classInfo := a.getPrefixClassInfo(fileResult, annotateFile, prefixExp)
+		if classInfo == nil {
+			continue
+		}
+
+		if codeLens, ok := a.getOverrideCodeLens(classInfo, funcName, loc); ok {
+			codeLensVec = append(codeLensVec, codeLens)
+		}
+	}
+
+	return codeLensVec
+}
+
+// GetCodeLens 获取文件中所有的codeLens，包括最外层函数的引用数量、覆盖的父类方法以及注解class的子类数量
+func (a *AllProject) GetCodeLens(strFile string) (codeLensVec []CodeLensInfo) {
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil || fileStruct.FileResult.Block == nil {
+		return
+	}
+
+	// 1) 最外层定义的函数
+	annotateFile := a.getAnnotateFile(strFile)
+	codeLensVec = a.getFuncCodeLens(fileStruct.FileResult, annotateFile)
+	if annotateFile == nil {
+		return
+	}
+
+	// 2) 注解定义的class
+	for strName, typeList := range annotateFile.CreateTypeMap {
+		for _, oneCreate := range typeList.List {
+			if oneCreate.ClassInfo == nil {
+				continue
+			}
+
+			codeLensVec = append(codeLensVec, CodeLensInfo{
+				Kind: CodeLensSubClasses,
+				Loc:  oneCreate.ClassInfo.ClassState.NameLoc,
+				Name: strName,
+			})
+		}
+	}
+
+	sort.SliceStable(codeLensVec, func(i, j int) bool {
+		return codeLensVec[i].Loc.StartLine < codeLensVec[j].Loc.StartLine
+	})
+	return codeLensVec
+}
+
+// FindSubClasses 查找所有直接继承指定class的子类，返回子类名称的位置
+func (a *AllProject) FindSubClasses(className string) (defineVecs []DefineStruct) {
+	for _, typeList := range a.createTypeMap {
+		for _, oneCreate := range typeList.List {
+			if oneCreate.ClassInfo == nil {
+				continue
+			}
+
+			classState := oneCreate.ClassInfo.ClassState
+			for _, strParent := range classState.ParentNameList {
+				if strParent != className || classState.Name == className {
+					continue
+				}
+
+				defineVecs = append(defineVecs, DefineStruct{
+					StrFile: oneCreate.ClassInfo.LuaFile,
+					Loc:     classState.NameLoc,
+				})
+				break
+			}
+		}
+	}
+
+	return defineVecs
+}
@@ -98,7 +98,7 @@ func (a *AllProject) CodeCompleteFile(strFile string, referNameStr string, refer
 	}
 
 	// 如果为框架中引入其他的文件，判断是否要包含文件的后缀名
-	suffixFlag := common.JudgeReferSuffixFlag(referType, referNameStr)
+	suffixFlag := common.JudgeReferSuffixFlag(strFile, referType, referNameStr)
 
 	dirManager := common.GConfig.GetDirManager()
 	mainDir := dirManager.GetMainDir()
@@ -107,7 +107,7 @@ func (a *AllProject) CodeCompleteFile(strFile string, referNameStr string, refer
 	dirManager.GetAllCompleFile(mainDir, referType, suffixFlag, &tipList)
 	for _, oneTip := range tipList {
 		var strName string
-		if common.GConfig.ReferMatchPathFlag {
+		if common.GConfig.GetFileConfig(strFile).ReferMatchPathFlag {
 			if !strings.HasPrefix(oneTip.StrName, preStr) {
 				continue
 			}
@@ -174,6 +174,11 @@ func (a *AllProject) CodeComplete(strFile string, completeVar common.CompleteVar
 
 	a.completeCache.SetColonFlag(completeVar.ColonFlag)
 	a.lspCodeComplete(comParam, &completeVar)
+
+	// 6) 客户端支持snippet时，函数补全插入带参数占位符的代码片段
+	if common.GConfig.IsCallSnippet() {
+		a.callSnippetComplete()
+	}
 	return
 }
 
@@ -192,19 +197,18 @@ func (a *AllProject) FuncCommentComplete(strFile string, posLine int) (completeV
 		return
 	}
 
-	// 4) 组装想要的数据
+	// 4) 组装想要的数据，参数与返回值的类型根据函数体推导
+	commentInfo := a.getFuncCommentInfo(strFile, fileStruct.FileResult, funcInfo, nil)
 	insertText := "--- ${1:func desc}"
-	// 变量所有的参数
 	index := 2
-	for i, oneParam := range funcInfo.ParamList {
-		// 忽略掉第一个参数
-		if funcInfo.IsColon && i == 0 {
-			continue
-		}
+	for _, oneParam := range commentInfo.ParamVec {
+		insertText = insertText + fmt.Sprintf("\n---@param %s ${%d:%s}", oneParam.Name, index, oneParam.TypeStr)
+		index++
+	}
 
-		oneParamFormatStr := fmt.Sprintf("\n---@param %s ${%d:any}", oneParam, index)
+	for _, strReturn := range commentInfo.ReturnVec {
+		insertText = insertText + fmt.Sprintf("\n---@return ${%d:%s}", index, strReturn)
 		index++
-		insertText = insertText + oneParamFormatStr
 	}
 
 	oneFuncComment := common.CompletionItemStruct{
@@ -220,7 +224,8 @@ func (a *AllProject) FuncCommentComplete(strFile string, posLine int) (completeV
 }
 
 // 查找所有的协议前缀
-func (a *AllProject) protocolCodeComplete(strProPre string, secondProject *results.SingleProjectResult, thirdStruct *results.AnalysisThird) {
+func (a *AllProject) protocolCodeComplete(strFile string, strProPre string, secondProject *results.SingleProjectResult,
+	thirdStruct *results.AnalysisThird) {
 	var globalGmaps map[string]*common.VarInfoList
 
 	// 向工程的globalMaps中查找变量
@@ -245,6 +250,10 @@ func (a *AllProject) protocolCodeComplete(strProPre string, secondProject *resul
 				continue
 			}
 
+			if !common.GConfig.IsFolderVisible(strFile, oneVar.ExtraGlobal.FileName) {
+				continue
+			}
+
 			a.completeCache.InsertCompleteVar(oneVar.ExtraGlobal.FileName, strName, oneVar)
 		}
 	}
@@ -297,7 +306,14 @@ func (a *AllProject) gValueComplete(comParam *CommonFuncParam, completeVar *comm
 			continue
 		}
 
-		oneVar := varInfoList.GetLastOneVar()
+		// 多根工作区时，只提示当前文件可见的工作区文件夹中定义的变量
+		var oneVar *common.VarInfo
+		for i := len(varInfoList.VarVec) - 1; i >= 0; i-- {
+			if common.GConfig.IsFolderVisible(comParam.fileResult.Name, varInfoList.VarVec[i].ExtraGlobal.FileName) {
+				oneVar = varInfoList.VarVec[i]
+				break
+			}
+		}
 		if oneVar == nil {
 			continue
 		}
@@ -591,9 +607,9 @@ func (a *AllProject) otherPreComplete(comParam *CommonFuncParam, completeVar *co
 	if lenStrVec == 1 && completeVar.LastEmptyFlag {
 		
 		// 2) 是否为协议前缀
-		if common.GConfig.IsStrProtocol(strFind) {
+		if common.GConfig.GetFileConfig(comParam.fileResult.Name).IsStrProtocol(strFind) {
 			// 为协议前缀，返回所有的返回
-			a.protocolCodeComplete(strFind, comParam.secondProject, comParam.thirdStruct)
+			a.protocolCodeComplete(comParam.fileResult.Name, strFind, comParam.secondProject, comParam.thirdStruct)
 			return
 		}
 	}
@@ -628,8 +644,12 @@ func (a *AllProject) otherPreComplete(comParam *CommonFuncParam, completeVar *co
 // 没有前缀的代码补全
 func (a *AllProject) noPreComplete(comParam *CommonFuncParam, completeVar *common.CompleteVarStruct) {
 	// 3) 单纯的文件范围内代码补全
-	// 3.1) 先把文件的局部范围变量放进来
 	fileName := comParam.fileResult.Name
+
+	// 3.0) 光标在table构造中key的位置，优先补全期望的class的field
+	a.tableKeyComplete(fileName, completeVar)
+
+	// 3.1) 先把文件的局部范围变量放进来
 	comParam.scope.GetCompleteVar(completeVar, fileName, comParam.loc, a.completeCache)
 
 	// 3.2) 再把这个文件的全局变量放进来
@@ -655,7 +675,7 @@ func (a *AllProject) noPreComplete(comParam *CommonFuncParam, completeVar *commo
 	a.gValueComplete(comParam, completeVar, false, fileName)
 
 	// 3.5) 把框架中引入的其他文件的方式，函数也包含进来
-	referFrameFiles := common.GConfig.GetFrameReferFiles()
+	referFrameFiles := common.GConfig.GetFileConfig(fileName).GetFrameReferFiles()
 	for _, strOne := range referFrameFiles {
 		if !common.IsCompleteNeedShow(strOne, completeVar) || a.completeCache.ExistStr(strOne) {
 			continue
@@ -675,6 +695,9 @@ func (a *AllProject) noPreComplete(comParam *CommonFuncParam, completeVar *commo
 		a.completeCache.InsertCompleteNormal(strName, detail, "", common.IKVariable)
 	}
 
+	// 3.7 其他文件返回的模块，补全后自动插入引入语句
+	a.requireComplete(comParam, completeVar)
+
 	return
 }
 
@@ -0,0 +1,216 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"luahelper-lsp/langserver/check/results"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// isLuaIdentifier 判断字符串是否能作为lua的变量名
+func isLuaIdentifier(strName string) bool {
+	if strName == "" || lexer.IsKeyword(strName) {
+		return false
+	}
+
+	for i, ch := range strName {
+		if ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') {
+			continue
+		}
+
+		if i > 0 && ch >= '0' && ch <= '9' {
+			continue
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// getReferStyle 获取当前文件引入其他文件的方式，优先沿用文件中已有的类似require的引入方式
+func (a *AllProject) getReferStyle(fileResult *results.FileResult) (referName string, suffixFlag bool) {
+	for _, referInfo := range fileResult.ReferVec {
+		if referInfo.ReferType != common.ReferTypeRequire && referInfo.ReferType != common.ReferTypeFrame {
+			continue
+		}
+
+		if a.GetReferFrameType(referInfo) != common.RtypeRequire {
+			continue
+		}
+
+		return referInfo.ReferTypeStr, common.JudgeReferSuffixFlag(fileResult.Name, referInfo.ReferType, referInfo.ReferTypeStr)
+	}
+
+	referName = common.GConfig.GetFileConfig(fileResult.Name).GetRequireReferName()
+	referType := common.StrToReferType(fileResult.Name, referName)
+	return referName, common.JudgeReferSuffixFlag(fileResult.Name, referType, referName)
+}
+
+// getReferInsertLine 获取引入语句插入的行，插入到文件开头连续的引入语句的后面，从0开始
+func getReferInsertLine(fileResult *results.FileResult) (line int) {
+	var lineVec []int
+	for _, referInfo := range fileResult.ReferVec {
+		if referInfo.ReferVarLocal {
+			lineVec = append(lineVec, referInfo.Loc.EndLine)
+		}
+	}
+	if len(lineVec) == 0 {
+		return 0
+	}
+
+	sort.Ints(lineVec)
+	line = lineVec[0]
+	for _, oneLine := range lineVec[1:] {
+		// 中间最多隔一个空行
+		if oneLine > line+2 {
+			break
+		}
+		line = oneLine
+	}
+
+	return line
+}
+
+// getFileReferStr 获取引入文件时的路径字符串
+func getFileReferStr(strFile string, pathSeparator string, suffixFlag bool) string {
+	strPath := common.GConfig.GetDirManager().RemovePathDirPre(strFile)
+	if !suffixFlag {
+		strPath = strings.TrimSuffix(strPath, filepath.Ext(strPath))
+	}
+
+	if pathSeparator != "/" {
+		dirPart, filePart := filepath.Split(strPath)
+		strPath = strings.Replace(dirPart, "/", pathSeparator, -1) + filePart
+	}
+
+	return strPath
+}
+
+// isFileReferred 判断文件是否已经引入了指定的文件
+func isFileReferred(fileResult *results.FileResult, strFile string) bool {
+	for _, referInfo := range fileResult.ReferVec {
+		if referInfo.ReferValidStr == strFile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getFileReturnVar 获取文件最后返回的模块，没有返回时find为false，返回的不是变量时varInfo为nil
+func getFileReturnVar(fileResult *results.FileResult) (find bool, varInfo *common.VarInfo) {
+	returnVecs := fileResult.MainFunc.ReturnVecs
+	for i := len(returnVecs) - 1; i >= 0; i-- {
+		if len(returnVecs[i].ReturnVarVec) == 0 {
+			continue
+		}
+
+		// require一个文件时，可能提前返回nil，忽略
+		oneReturn := returnVecs[i].ReturnVarVec[0]
+		if _, ok := oneReturn.ReturnExp.(*ast.NilExp); ok {
+			continue
+		}
+
+		return true, oneReturn.VarInfo
+	}
+
+	return false, nil
+}
+
+// getCompleteVarKind 获取变量补全时显示的类型
+func getCompleteVarKind(varInfo *common.VarInfo) common.ItemKind {
+	if varInfo.ReferFunc != nil {
+		return common.IKFunction
+	}
+
+	return common.IKVariable
+}
+
+// insertFileExportComplete 把文件导出的符号加入到补全中：返回的模块及其成员，文件顶层定义的全局表和全局函数
+func (a *AllProject) insertFileExportComplete(fileResult *results.FileResult, completeVar *common.CompleteVarStruct,
+	referStr string, referName string, referLine int) {
+	strFile := fileResult.Name
+	strRequire := referName + "(\"" + referStr + "\")\n"
+
+	// 1) 文件返回的模块，以及模块的成员，选中后以文件名作为局部变量引入
+	if find, returnVar := getFileReturnVar(fileResult); find {
+		strName := strings.TrimSuffix(filepath.Base(strFile), filepath.Ext(strFile))
+		if isLuaIdentifier(strName) {
+			strRefer := "local " + strName + " = " + strRequire
+			if common.IsCompleteNeedShow(strName, completeVar) && !a.completeCache.ExistStr(strName) {
+				a.completeCache.InsertCompleteRefer(strFile, strName, "", strRefer, referLine, common.IKModule)
+			}
+
+			var memVec []string
+			if returnVar != nil {
+				for strMem := range returnVar.SubMaps {
+					memVec = append(memVec, strMem)
+				}
+			}
+			sort.Strings(memVec)
+
+			for _, strMem := range memVec {
+				strLabel := strName + "." + strMem
+				if !common.IsCompleteNeedShow(strLabel, completeVar) || a.completeCache.ExistStr(strLabel) {
+					continue
+				}
+
+				kind := getCompleteVarKind(returnVar.SubMaps[strMem])
+				a.completeCache.InsertCompleteRefer(strFile, strLabel, strLabel, strRefer, referLine, kind)
+			}
+		}
+	}
+
+	// 2) 文件顶层定义的全局表和全局函数，选中后只引入文件
+	var globalVec []string
+	for strName, varInfo := range fileResult.GlobalMaps {
+		if varInfo.ExtraGlobal.FuncLv != 0 || (varInfo.ReferFunc == nil && varInfo.VarType != common.LuaTypeTable) {
+			continue
+		}
+		globalVec = append(globalVec, strName)
+	}
+	sort.Strings(globalVec)
+
+	for _, strName := range globalVec {
+		if !common.IsCompleteNeedShow(strName, completeVar) || a.completeCache.ExistStr(strName) {
+			continue
+		}
+
+		kind := getCompleteVarKind(fileResult.GlobalMaps[strName])
+		a.completeCache.InsertCompleteRefer(strFile, strName, "", strRequire, referLine, kind)
+	}
+}
+
+// requireComplete 工程中其他文件导出的符号，文件还没有被当前文件引入时，补全后自动插入引入语句
+func (a *AllProject) requireComplete(comParam *CommonFuncParam, completeVar *common.CompleteVarStruct) {
+	fileResult := comParam.fileResult
+	fileConfig := common.GConfig.GetFileConfig(fileResult.Name)
+	referName, suffixFlag := a.getReferStyle(fileResult)
+	referLine := getReferInsertLine(fileResult)
+
+	// 按文件名排序，保证每次补全的顺序一致
+	fileVec := make([]string, 0, len(a.allFilesMap))
+	for strFile := range a.allFilesMap {
+		fileVec = append(fileVec, strFile)
+	}
+	sort.Strings(fileVec)
+
+	for _, strFile := range fileVec {
+		if strFile == fileResult.Name || !common.GConfig.IsFolderVisible(fileResult.Name, strFile) ||
+			isFileReferred(fileResult, strFile) {
+			continue
+		}
+
+		fileStruct, ok := a.GetFirstFileStuct(strFile)
+		if !ok || fileStruct.FileResult == nil {
+			continue
+		}
+
+		referStr := getFileReferStr(strFile, fileConfig.GetPathSeparator(), suffixFlag)
+		a.insertFileExportComplete(fileStruct.FileResult, completeVar, referStr, referName, referLine)
+	}
+}
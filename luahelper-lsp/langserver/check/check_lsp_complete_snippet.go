@@ -0,0 +1,112 @@
+package check
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/annotation/annotateast"
+	"luahelper-lsp/langserver/check/common"
+	"strings"
+)
+
+// getCallSnippetStr 生成函数调用的代码片段，每个参数为一个占位符
+// 没有参数但是含有可变参数时，光标停留在括号内
+func getCallSnippetStr(funcName string, paramList []string, isVararg bool) string {
+	if len(paramList) == 0 {
+		if isVararg {
+			return funcName + "($1)"
+		}
+		return funcName + "()"
+	}
+
+	strVec := make([]string, 0, len(paramList))
+	for index, oneParam := range paramList {
+		strVec = append(strVec, fmt.Sprintf("${%d:%s}", index+1, oneParam))
+	}
+
+	return funcName + "(" + strings.Join(strVec, ", ") + ")"
+}
+
+// getFuncSnippetParams 获取函数补全时需要填写的参数，冒号调用冒号函数时去掉隐含的self
+func getFuncSnippetParams(funcInfo *common.FuncInfo, colonFlag bool) []string {
+	if colonFlag && funcInfo.IsColon && len(funcInfo.ParamList) > 0 {
+		return funcInfo.ParamList[1:]
+	}
+
+	return funcInfo.ParamList
+}
+
+// getFieldSnippetParams 获取注解class的函数field补全时需要填写的参数
+func getFieldSnippetParams(funcType *annotateast.FuncType, colonType annotateast.FieldColonType,
+	colonFlag bool) []string {
+	if colonFlag && colonType == annotateast.FieldColonHide && len(funcType.ParamNameList) > 0 {
+		// ---@field FunctionC fun(self:ClassA):void, 冒号调用时去掉第一个参数
+		return funcType.ParamNameList[1:]
+	}
+
+	if !colonFlag && colonType == annotateast.FieldColonYes {
+		// ---@field FunctionC : fun():void, 点调用时需要补充self
+		return append([]string{"self"}, funcType.ParamNameList...)
+	}
+
+	return funcType.ParamNameList
+}
+
+// getOverloadSnippetParams 获取重载函数补全时需要填写的参数，重载的注解中不包含冒号函数隐含的self
+func getOverloadSnippetParams(funcInfo *common.FuncInfo, funcType *annotateast.FuncType, colonFlag bool) []string {
+	if !colonFlag && funcInfo.IsColon {
+		return append([]string{"self"}, funcType.ParamNameList...)
+	}
+
+	return funcType.ParamNameList
+}
+
+// callSnippetComplete 补全的结果中，函数补全为带参数占位符的代码片段，函数的每个---@overload 单独作为一个补全项
+func (a *AllProject) callSnippetComplete() {
+	colonFlag := a.completeCache.GetColonFlag()
+	dataList := a.completeCache.GetDataList()
+
+	// 重载的补全项追加在后面，只遍历已有的补全项
+	itemLen := len(dataList)
+	for index := 0; index < itemLen; index++ {
+		item := dataList[index]
+		if item.InsetText != "" {
+			// 已经指定了插入内容的，例如table构造中key的补全
+			continue
+		}
+
+		if item.CacheKind == common.CkindClassField {
+			funcType, _ := annotateast.GetAllFuncType(item.FieldState.FiledType).(*annotateast.FuncType)
+			if funcType == nil {
+				continue
+			}
+
+			paramList := getFieldSnippetParams(funcType, item.FieldColonFlag, colonFlag)
+			a.completeCache.SetItemSnippet(index, getCallSnippetStr(item.Label, paramList, false))
+			continue
+		}
+
+		if item.CacheKind != common.CKindVar || item.VarInfo == nil || item.VarInfo.ReferFunc == nil {
+			continue
+		}
+
+		funcInfo := item.VarInfo.ReferFunc
+		paramList := getFuncSnippetParams(funcInfo, colonFlag)
+		a.completeCache.SetItemSnippet(index, getCallSnippetStr(item.Label, paramList, funcInfo.IsVararg))
+
+		overloadInfo := a.GetFuncOverloadInfo(item.LuaFile, item.VarInfo.Loc.EndLine-1)
+		if overloadInfo == nil {
+			continue
+		}
+
+		for _, oneOverload := range overloadInfo.OverloadList {
+			if oneOverload.OverFunType == nil {
+				continue
+			}
+
+			paramList := getOverloadSnippetParams(funcInfo, oneOverload.OverFunType, colonFlag)
+			strDetail := "function " + item.Label +
+				strings.TrimPrefix(annotateast.FuncTypeConvertStr(oneOverload.OverFunType, 0), "function")
+			a.completeCache.InsertCompleteSnippet(item.LuaFile, item.Label, getCallSnippetStr(item.Label, paramList, false),
+				strDetail, oneOverload.Comment)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/annotation/annotateast"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"strings"
+)
+
+// tableConstructorCtx table构造所在的位置，用于推导table期望的class类型
+// 以下三种来源只会有一种
+// 1) local cfg = {} 赋值语句中的table，期望的类型为语句前面的---@type 注解
+// 2) func({}) 函数调用的实参，期望的类型为函数对应参数的---@param 注解
+// 3) { key = {} } 外层table中key对应的值，期望的类型为外层期望的class中key对应的field类型
+type tableConstructorCtx struct {
+	tableExp  *ast.TableConstructorExp
+	typeLine  int                  // 为赋值语句中的table时，语句开始的行号，从1开始，0表示不是
+	typeIndex int                  // 为赋值语句中的第几个值，对应---@type 注解的第几个类型
+	callExp   *ast.FuncCallExp     // 为函数调用的实参时，指向函数调用
+	argIndex  int                  // 为函数调用的第几个实参
+	parent    *tableConstructorCtx // 为外层table中key对应的值时，指向外层的table
+	parentKey string               // 外层table中对应的key
+}
+
+// tableConstructorFinder 查找包含指定位置的最内层的table构造
+type tableConstructorFinder struct {
+	posLine int // 行号，从1开始
+	posCh   int // 列号，从0开始
+	ctx     *tableConstructorCtx
+
+	// 子表达式中table所在的位置，由父节点设置
+	ctxMap map[*ast.TableConstructorExp]*tableConstructorCtx
+}
+
+// isInTableBrace 位置是否在table构造的大括号之内
+func (f *tableConstructorFinder) isInTableBrace(tableExp *ast.TableConstructorExp) bool {
+	loc := tableExp.Loc
+	if !loc.IsInLocStruct(f.posLine, f.posCh) {
+		return false
+	}
+
+	if f.posLine == loc.StartLine && f.posCh == loc.StartColumn {
+		return false
+	}
+
+	if f.posLine == loc.EndLine && f.posCh == loc.EndColumn {
+		return false
+	}
+
+	return true
+}
+
+// setExpCtx 设置子表达式为table构造时，table所在的位置
+func (f *tableConstructorFinder) setExpCtx(exp ast.Exp, ctx *tableConstructorCtx) {
+	if tableExp, ok := exp.(*ast.TableConstructorExp); ok {
+		f.ctxMap[tableExp] = ctx
+	}
+}
+
+// Visit 遍历节点，父节点先设置好子表达式中table所在的位置，没有设置的table推导不出期望的类型
+func (f *tableConstructorFinder) Visit(node ast.Node) ast.Visitor {
+	switch subNode := node.(type) {
+	case *ast.LocalVarDeclStat:
+		for i, exp := range subNode.ExpList {
+			f.setExpCtx(exp, &tableConstructorCtx{
+				typeLine:  subNode.Loc.StartLine,
+				typeIndex: i,
+			})
+		}
+	case *ast.AssignStat:
+		for i, exp := range subNode.ExpList {
+			f.setExpCtx(exp, &tableConstructorCtx{
+				typeLine:  subNode.Loc.StartLine,
+				typeIndex: i,
+			})
+		}
+	case *ast.FuncCallExp:
+		for i, argExp := range subNode.Args {
+			f.setExpCtx(argExp, &tableConstructorCtx{
+				callExp:  subNode,
+				argIndex: i,
+			})
+		}
+	case *ast.FuncDefExp:
+		if !subNode.Loc.IsInLocStruct(f.posLine, f.posCh) {
+			return nil
+		}
+	case *ast.TableConstructorExp:
+		if !f.isInTableBrace(subNode) {
+			return nil
+		}
+
+		ctx := f.ctxMap[subNode]
+		if ctx == nil {
+			ctx = &tableConstructorCtx{}
+		}
+		ctx.tableExp = subNode
+		f.ctx = ctx
+
+		for i, valExp := range subNode.ValExps {
+			if strKey, ok := subNode.KeyExps[i].(*ast.StringExp); ok {
+				f.setExpCtx(valExp, &tableConstructorCtx{
+					parent:    ctx,
+					parentKey: strKey.Str,
+				})
+			}
+		}
+	}
+
+	return f
+}
+
+// findTableConstructor 查找文件中包含指定位置的最内层的table构造，posLine从1开始
+func (a *AllProject) findTableConstructor(strFile string, posLine, posCh int) *tableConstructorCtx {
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil {
+		return nil
+	}
+
+	finder := &tableConstructorFinder{
+		posLine: posLine,
+		posCh:   posCh,
+		ctxMap:  map[*ast.TableConstructorExp]*tableConstructorCtx{},
+	}
+	ast.Walk(finder, fileStruct.FileResult.Block)
+	return finder.ctx
+}
+
+// isTableKeyPos 判断位置是否为table构造中可以输入key的位置，例如 { | } 或是 { a = 1, na| }
+func isTableKeyPos(tableExp *ast.TableConstructorExp, posLine, posCh int) bool {
+	for i, valExp := range tableExp.ValExps {
+		keyExp := tableExp.KeyExps[i]
+		if keyExp != nil {
+			keyLoc := common.GetExpLoc(keyExp)
+			if keyLoc.IsInLocStruct(posLine, posCh) {
+				return false
+			}
+		}
+
+		valLoc := common.GetExpLoc(valExp)
+		if !valLoc.IsInLocStruct(posLine, posCh) {
+			continue
+		}
+
+		// 正在输入的key，会被当成数组的值
+		_, ok := valExp.(*ast.NameExp)
+		return ok && keyExp == nil
+	}
+
+	return true
+}
+
+// getTableCallParamType 获取函数调用实参对应的---@param 注解类型
+func (a *AllProject) getTableCallParamType(strFile string, ctx *tableConstructorCtx) (astType annotateast.Type,
+	fileName string, line int) {
+	callExp := ctx.callExp
+	strVec, colonFlag := getCallNameVec(callExp)
+	if len(strVec) == 0 {
+		return
+	}
+
+	varStruct := common.DefineVarStruct{
+		PosLine:   callExp.Loc.StartLine - 1,
+		PosCh:     callExp.Loc.StartColumn,
+		ValidFlag: true,
+		StrVec:    strVec,
+		IsFuncVec: make([]bool, len(strVec)),
+		ColonFlag: colonFlag,
+	}
+	_, symList := a.FindVarDefine(strFile, &varStruct)
+	if len(symList) == 0 {
+		return
+	}
+
+	lastSymbol := symList[len(symList)-1]
+	if lastSymbol.VarInfo == nil || lastSymbol.VarInfo.ReferFunc == nil {
+		return
+	}
+
+	// 冒号调用时，第一个参数为调用者
+	referFunc := lastSymbol.VarInfo.ReferFunc
+	paramIndex := ctx.argIndex
+	if colonFlag {
+		paramIndex++
+	}
+	if paramIndex >= len(referFunc.ParamList) {
+		return
+	}
+
+	line = lastSymbol.VarInfo.Loc.EndLine - 1
+	paramInfo := a.GetFuncParamInfo(lastSymbol.FileName, line)
+	if paramInfo == nil {
+		return
+	}
+
+	for _, oneParam := range paramInfo.ParamList {
+		if oneParam.Name == referFunc.ParamList[paramIndex] {
+			return oneParam.ParamType, lastSymbol.FileName, line
+		}
+	}
+
+	return
+}
+
+// getTableExpectClassList 获取table构造期望的class列表，包含所有的父类型
+func (a *AllProject) getTableExpectClassList(strFile string, ctx *tableConstructorCtx) (classList []*common.OneClassInfo) {
+	// 1) 外层table中key对应的值
+	if ctx.parent != nil {
+		for _, oneClass := range a.getTableExpectClassList(strFile, ctx.parent) {
+			fieldState, ok := oneClass.FieldMap[ctx.parentKey]
+			if !ok {
+				continue
+			}
+
+			classList = append(classList, a.getAllNormalAnnotateClass(fieldState.FiledType, oneClass.LuaFile,
+				oneClass.LastLine)...)
+		}
+		return classList
+	}
+
+	// 2) 函数调用的实参，打tlog日志的字段table期望的类型为对应的struct
+	if ctx.callExp != nil {
+		if classInfo := a.getTlogCallClassInfo(strFile, ctx); classInfo != nil {
+			return []*common.OneClassInfo{classInfo}
+		}
+
+		astType, fileName, line := a.getTableCallParamType(strFile, ctx)
+		return a.getAllNormalAnnotateClass(astType, fileName, line)
+	}
+
+	// 3) 赋值语句中的table
+	if ctx.typeLine == 0 {
+		return
+	}
+
+	annotateFile := a.getAnnotateFile(strFile)
+	if annotateFile == nil {
+		return
+	}
+
+	fragmentInfo := annotateFile.GetLineFragementInfo(ctx.typeLine - 1)
+	if fragmentInfo == nil || fragmentInfo.TypeInfo == nil || ctx.typeIndex >= len(fragmentInfo.TypeInfo.TypeList) {
+		return
+	}
+
+	return a.getAllNormalAnnotateClass(fragmentInfo.TypeInfo.TypeList[ctx.typeIndex], strFile, ctx.typeLine-1)
+}
+
+// tableKeyComplete 光标在table构造中key的位置时，补全期望的class所有的field，已经存在的key不再提示
+// 例如下面的
+// ---@type ServerConfig
+// local cfg = { | }
+func (a *AllProject) tableKeyComplete(strFile string, completeVar *common.CompleteVarStruct) {
+	posLine := completeVar.PosLine + 1
+	ctx := a.findTableConstructor(strFile, posLine, completeVar.PosCh)
+	if ctx == nil || !isTableKeyPos(ctx.tableExp, posLine, completeVar.PosCh) {
+		return
+	}
+
+	classList := a.getTableExpectClassList(strFile, ctx)
+	if len(classList) == 0 {
+		return
+	}
+
+	existMap := map[string]bool{}
+	for _, keyExp := range ctx.tableExp.KeyExps {
+		if strKey, ok := keyExp.(*ast.StringExp); ok {
+			existMap[strKey.Str] = true
+		}
+	}
+
+	for _, oneClass := range classList {
+		for strName, fieldState := range oneClass.FieldMap {
+			if existMap[strName] || !common.IsCompleteNeedShow(strName, completeVar) {
+				continue
+			}
+
+			// 子类的field优先，父类同名的忽略
+			existMap[strName] = true
+			a.completeCache.InsertCompleteTableKey(oneClass.LuaFile, strName, fieldState)
+		}
+	}
+}
+
+// TableKeyComplete 没有输入任何字符时，光标在table构造中key的位置，补全期望的class所有的field
+func (a *AllProject) TableKeyComplete(strFile string, posLine, posCh int) {
+	completeVar := common.CompleteVarStruct{
+		PosLine: posLine,
+		PosCh:   posCh,
+	}
+	a.tableKeyComplete(strFile, &completeVar)
+}
+
+// GetTableKeyHover 悬停在table构造中的key上时，显示期望的class中对应field的注解与注释
+func (a *AllProject) GetTableKeyHover(strFile string, posLine, posCh int) (lableStr, docStr, luaFileStr string) {
+	ctx := a.findTableConstructor(strFile, posLine+1, posCh)
+	if ctx == nil {
+		return
+	}
+
+	strName := ""
+	for _, keyExp := range ctx.tableExp.KeyExps {
+		strKey, ok := keyExp.(*ast.StringExp)
+		if ok && strKey.Loc.IsInLocStruct(posLine+1, posCh) {
+			strName = strKey.Str
+			break
+		}
+	}
+	if strName == "" {
+		return
+	}
+
+	for _, oneClass := range a.getTableExpectClassList(strFile, ctx) {
+		fieldState, ok := oneClass.FieldMap[strName]
+		if !ok {
+			continue
+		}
+
+		lableStr = oneClass.ClassState.Name + "." + strName + " : " + annotateast.TypeConvertStr(fieldState.FiledType)
+		docStr = strings.ReplaceAll(fieldState.Comment, "\n", "  \n")
+		luaFileStr = common.GConfig.GetDirManager().RemovePathDirPre(oneClass.LuaFile)
+		return
+	}
+
+	return
+}
@@ -27,7 +27,7 @@ func (a *AllProject) findGlobalVarDefineInfo(comParam *CommonFuncParam, strName
 
 	if comParam.thirdStruct != nil {
 		// 向工程的第一阶段全局_G符号表中查找
-		if ok, findVar := comParam.thirdStruct.FindThirdGlobalGInfo(gFlag, strName, strProPre); ok {
+		if ok, findVar := comParam.thirdStruct.FindThirdGlobalGInfo(comParam.fileResult.Name, gFlag, strName, strProPre); ok {
 			return findVar.ExtraGlobal.FileName, findVar
 		}
 	}
@@ -370,7 +370,7 @@ func (a *AllProject) findOldDefineInfo(comParam *CommonFuncParam, varStruct *com
 	// 2) 有前缀，先找到前缀指向的地方
 	// 2) 先判断是否为协议前缀
 	strProPre := ""
-	if len(varStruct.StrVec) >= 2 && common.GConfig.IsStrProtocol(varStruct.StrVec[0]) &&
+	if len(varStruct.StrVec) >= 2 && common.GConfig.GetFileConfig(comParam.fileResult.Name).IsStrProtocol(varStruct.StrVec[0]) &&
 		dirManager.IsInDir(comParam.fileResult.Name) && varStruct.StrVec[0] != "" {
 		// 如果为协议前缀，要进行切分
 		strProPre = varStruct.StrVec[0]
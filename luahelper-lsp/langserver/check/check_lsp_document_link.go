@@ -0,0 +1,164 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 注释中的url
+var commentURLRegexp = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+// 注释中---@see 后面的路径，注释的内容去掉了前面的--
+var commentSeeRegexp = regexp.MustCompile(`^-@see\s+(\S+)`)
+
+// DocumentLinkInfo 文件中可以点击跳转的链接
+type DocumentLinkInfo struct {
+	Loc     lexer.Location // 链接的位置
+	LuaFile string         // 链接指向的lua文件，为空时表示为url
+	URL     string         // 链接指向的url
+}
+
+// getReferStrLoc 获取引用其他文件的函数调用中，字符串参数内容的位置，例如require("one") 中one的位置
+func getReferStrLoc(lineVec []string, callLoc lexer.Location) (loc lexer.Location, ok bool) {
+	for line := callLoc.StartLine; line <= callLoc.EndLine && line <= len(lineVec); line++ {
+		runeVec := []rune(lineVec[line-1])
+		beginCol := 0
+		if line == callLoc.StartLine {
+			beginCol = callLoc.StartColumn
+		}
+
+		for col := beginCol; col < len(runeVec); col++ {
+			strBegin, strEnd := "", ""
+			switch {
+			case runeVec[col] == '"' || runeVec[col] == '\'':
+				strBegin, strEnd = string(runeVec[col]), string(runeVec[col])
+			case runeVec[col] == '[' && col+1 < len(runeVec) && runeVec[col+1] == '[':
+				strBegin, strEnd = "[[", "]]"
+			default:
+				continue
+			}
+
+			beginIndex := col + utf8.RuneCountInString(strBegin)
+			endIndex := strings.Index(string(runeVec[beginIndex:]), strEnd)
+			if endIndex < 0 {
+				return loc, false
+			}
+
+			loc = lexer.Location{
+				StartLine:   line,
+				StartColumn: beginIndex,
+				EndLine:     line,
+				EndColumn:   beginIndex + utf8.RuneCountInString(string(runeVec[beginIndex:])[:endIndex]),
+			}
+			return loc, true
+		}
+	}
+
+	return loc, false
+}
+
+// getSeeFilePath 获取---@see 后面的路径指向的lua文件，优先为相对当前文件的路径
+func (a *AllProject) getSeeFilePath(strFile string, strPath string) string {
+	if filepath.Ext(strPath) == "" {
+		return ""
+	}
+
+	relatePath := filepath.ToSlash(filepath.Join(filepath.Dir(strFile), strPath))
+	if common.GConfig.FileExistCache(relatePath) {
+		return relatePath
+	}
+
+	dirManager := common.GConfig.GetDirManager()
+	if matchPath := dirManager.MatchCompleteReferFile(strFile, strPath); matchPath != "" {
+		return matchPath
+	}
+
+	return common.GetBestMatchReferFile(strFile, strPath, a.allFilesMap)
+}
+
+// getCommentLinks 获取注释中的url以及---@see 指向的文件
+func (a *AllProject) getCommentLinks(strFile string, lineVec []string, commentMap map[int]*lexer.CommentInfo) (
+	linkVec []DocumentLinkInfo) {
+	for _, commentInfo := range commentMap {
+		for _, commentLine := range commentInfo.LineVec {
+			if commentLine.Line < 1 || commentLine.Line > len(lineVec) {
+				continue
+			}
+
+			// 注释的列号为字节的偏移，转换为字符的偏移
+			strLine := lineVec[commentLine.Line-1]
+			if commentLine.Col < 0 || commentLine.Col > len(strLine) ||
+				!strings.HasPrefix(strLine[commentLine.Col:], commentLine.Str) {
+				continue
+			}
+			getLoc := func(beginIndex, endIndex int) lexer.Location {
+				return lexer.Location{
+					StartLine:   commentLine.Line,
+					StartColumn: utf8.RuneCountInString(strLine[:commentLine.Col+beginIndex]),
+					EndLine:     commentLine.Line,
+					EndColumn:   utf8.RuneCountInString(strLine[:commentLine.Col+endIndex]),
+				}
+			}
+
+			for _, indexVec := range commentURLRegexp.FindAllStringIndex(commentLine.Str, -1) {
+				linkVec = append(linkVec, DocumentLinkInfo{
+					Loc: getLoc(indexVec[0], indexVec[1]),
+					URL: commentLine.Str[indexVec[0]:indexVec[1]],
+				})
+			}
+
+			indexVec := commentSeeRegexp.FindStringSubmatchIndex(commentLine.Str)
+			if len(indexVec) < 4 {
+				continue
+			}
+
+			if seeFile := a.getSeeFilePath(strFile, commentLine.Str[indexVec[2]:indexVec[3]]); seeFile != "" {
+				linkVec = append(linkVec, DocumentLinkInfo{
+					Loc:     getLoc(indexVec[2], indexVec[3]),
+					LuaFile: seeFile,
+				})
+			}
+		}
+	}
+
+	return linkVec
+}
+
+// GetDocumentLinks 获取文件中所有的链接，包括require、dofile等引用其他文件的路径，注释中的url以及---@see 的路径
+// contents 为文件当前的内容
+func (a *AllProject) GetDocumentLinks(strFile string, contents []byte) (linkVec []DocumentLinkInfo) {
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil {
+		return
+	}
+
+	fileResult := fileStruct.FileResult
+	lineVec := strings.Split(string(contents), "\n")
+
+	// 1) 引用的其他文件
+	for _, referInfo := range fileResult.ReferVec {
+		if referInfo.ReferValidStr == "" {
+			continue
+		}
+
+		if loc, ok := getReferStrLoc(lineVec, referInfo.Loc); ok {
+			linkVec = append(linkVec, DocumentLinkInfo{
+				Loc:     loc,
+				LuaFile: referInfo.ReferValidStr,
+			})
+		}
+	}
+
+	// 2) 注释中的链接
+	linkVec = append(linkVec, a.getCommentLinks(strFile, lineVec, fileResult.CommentMap)...)
+
+	sort.Slice(linkVec, func(i, j int) bool {
+		return linkVec[i].Loc.IsBeforeLoc(linkVec[j].Loc) && linkVec[i].Loc != linkVec[j].Loc
+	})
+	return linkVec
+}
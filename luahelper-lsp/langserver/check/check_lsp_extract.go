@@ -0,0 +1,588 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"luahelper-lsp/langserver/check/results"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ExtractLocalInfo 选中的表达式提取为局部变量的信息
+type ExtractLocalInfo struct {
+	Loc       lexer.Location // 选中的表达式的位置
+	InsertLoc lexer.Location // 局部变量定义插入的位置，为包含表达式的语句的开头
+	Text      string         // 选中的表达式的内容
+	Name      string         // 新的局部变量的名称，不与文件中已有的变量重名
+}
+
+// ExtractFuncInfo 选中的语句提取为局部函数的信息
+type ExtractFuncInfo struct {
+	Loc       lexer.Location // 选中的所有语句的位置
+	Text      string         // 选中的所有语句的内容
+	Name      string         // 新的局部函数的名称，不与文件中已有的变量重名
+	ParamVec  []string       // 选中的语句中用到的外部的局部变量，作为新函数的参数
+	ReturnVec []string       // 选中的语句中定义或是赋值，后面又用到的局部变量，作为新函数的返回值
+	LocalVec  []string       // ReturnVec中在选中的语句中定义的局部变量，调用的地方需要重新定义
+	IsVararg  bool           // 选中的语句中是否用到了...，需要透传给新函数
+	ErrStr    string         // 不能提取时的原因，例如包含跳出选中范围的break、goto、return
+}
+
+// isPosBefore 判断位置1是否在位置2之前
+func isPosBefore(line1, col1, line2, col2 int) bool {
+	return line1 < line2 || (line1 == line2 && col1 < col2)
+}
+
+// isLocOverlap 判断两个位置是否有重叠
+func isLocOverlap(loc1, loc2 lexer.Location) bool {
+	return !isPosBefore(loc1.EndLine, loc1.EndColumn, loc2.StartLine, loc2.StartColumn) &&
+		!isPosBefore(loc2.EndLine, loc2.EndColumn, loc1.StartLine, loc1.StartColumn)
+}
+
+// getLineRunes 获取某一行的所有字符，line从1开始
+func getLineRunes(lineVec []string, line int) []rune {
+	if line < 1 || line > len(lineVec) {
+		return nil
+	}
+
+	return []rune(strings.TrimSuffix(lineVec[line-1], "\r"))
+}
+
+// getLocText 获取位置对应的文本内容
+func getLocText(lineVec []string, loc lexer.Location) string {
+	var builder strings.Builder
+	for line := loc.StartLine; line <= loc.EndLine; line++ {
+		lineRunes := getLineRunes(lineVec, line)
+		beginCol, endCol := 0, len(lineRunes)
+		if line == loc.StartLine {
+			beginCol = loc.StartColumn
+		}
+		if line == loc.EndLine && loc.EndColumn < endCol {
+			endCol = loc.EndColumn
+		}
+		if beginCol < endCol {
+			builder.WriteString(string(lineRunes[beginCol:endCol]))
+		}
+		if line != loc.EndLine {
+			builder.WriteString("\n")
+		}
+	}
+
+	return builder.String()
+}
+
+// trimSelectLoc 去掉选中范围首尾的空白字符
+func trimSelectLoc(lineVec []string, loc lexer.Location) lexer.Location {
+	for loc.StartLine < loc.EndLine || (loc.StartLine == loc.EndLine && loc.StartColumn < loc.EndColumn) {
+		lineRunes := getLineRunes(lineVec, loc.StartLine)
+		if loc.StartColumn >= len(lineRunes) {
+			if loc.StartLine >= loc.EndLine {
+				break
+			}
+			loc.StartLine++
+			loc.StartColumn = 0
+			continue
+		}
+		if !unicode.IsSpace(lineRunes[loc.StartColumn]) {
+			break
+		}
+		loc.StartColumn++
+	}
+
+	for loc.StartLine < loc.EndLine || (loc.StartLine == loc.EndLine && loc.StartColumn < loc.EndColumn) {
+		lineRunes := getLineRunes(lineVec, loc.EndLine)
+		if loc.EndColumn > len(lineRunes) {
+			loc.EndColumn = len(lineRunes)
+		}
+		if loc.EndColumn == 0 {
+			if loc.EndLine <= loc.StartLine {
+				break
+			}
+			loc.EndLine--
+			loc.EndColumn = len(getLineRunes(lineVec, loc.EndLine))
+			continue
+		}
+		if !unicode.IsSpace(lineRunes[loc.EndColumn-1]) {
+			break
+		}
+		loc.EndColumn--
+	}
+
+	return loc
+}
+
+// isBlankText 判断文本是否只包含空白与单行注释
+func isBlankText(strText string) bool {
+	for _, strLine := range strings.Split(strText, "\n") {
+		strLine = strings.TrimSpace(strLine)
+		if strLine != "" && !strings.HasPrefix(strLine, "--") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// extractCollector 收集语句中用到的变量、跳转等信息
+type extractCollector struct {
+	nameVec   []*ast.NameExp   // 所有引用的变量
+	assignVec []*ast.NameExp   // 所有被赋值的变量
+	gotoVec   []*ast.GotoStat  // 当前函数中所有的goto
+	labelVec  []*ast.LabelStat // 当前函数中所有的标签
+	hasReturn bool             // 当前函数中是否有return
+	hasBreak  bool             // 是否有不在循环中的break
+	hasVararg bool             // 当前函数中是否用到了...
+}
+
+// collect 收集语句或是代码块中的信息
+func (c *extractCollector) collect(node ast.Node) {
+	ast.Walk(extractVisitor{collector: c}, node)
+}
+
+// extractVisitor 遍历时的层级，funcLv为0时表示在开始收集的函数中，嵌套函数中的return、break、goto不会跳出选中的范围
+type extractVisitor struct {
+	collector *extractCollector
+	funcLv    int
+	loopLv    int
+}
+
+func (v extractVisitor) Visit(node ast.Node) ast.Visitor {
+	c := v.collector
+	switch subNode := node.(type) {
+	case nil:
+		return nil
+	case *ast.Block:
+		if subNode.RetExps != nil && v.funcLv == 0 {
+			c.hasReturn = true
+		}
+	case *ast.BreakStat:
+		if v.funcLv == 0 && v.loopLv == 0 {
+			c.hasBreak = true
+		}
+	case *ast.GotoStat:
+		if v.funcLv == 0 {
+			c.gotoVec = append(c.gotoVec, subNode)
+		}
+	case *ast.LabelStat:
+		if v.funcLv == 0 {
+			c.labelVec = append(c.labelVec, subNode)
+		}
+	case *ast.AssignStat:
+		for _, varExp := range subNode.VarList {
+			if nameExp, ok := varExp.(*ast.NameExp); ok {
+				c.assignVec = append(c.assignVec, nameExp)
+			}
+		}
+	case *ast.WhileStat, *ast.RepeatStat, *ast.ForNumStat, *ast.ForInStat:
+		v.loopLv++
+	case *ast.NameExp:
+		c.nameVec = append(c.nameVec, subNode)
+	case *ast.VarargExp:
+		if v.funcLv == 0 {
+			c.hasVararg = true
+		}
+	case *ast.FuncDefExp:
+		v.funcLv++
+		v.loopLv = 0
+	}
+
+	return v
+}
+
+// findNameExpVar 查找变量引用对应的局部变量，全局变量返回nil
+func findNameExpVar(fileResult *results.FileResult, nameExp *ast.NameExp) *common.VarInfo {
+	mainScope := fileResult.MainFunc.MainScope
+	scope := mainScope.FindMinScope(nameExp.Loc.StartLine, nameExp.Loc.StartColumn)
+	if scope == nil {
+		scope = mainScope
+	}
+
+	if ok, varInfo := scope.FindLocVar(nameExp.Name, nameExp.Loc); ok {
+		return varInfo
+	}
+	return nil
+}
+
+// getExtractNewName 获取提取生成的变量名称，不与文件中已有的变量重名
+func getExtractNewName(fileResult *results.FileResult, strBase string) string {
+	nameMap := map[string]bool{}
+	for strName := range fileResult.GlobalMaps {
+		nameMap[strName] = true
+	}
+
+	var insertScopeNames func(scope *common.ScopeInfo)
+	insertScopeNames = func(scope *common.ScopeInfo) {
+		for strName := range scope.LocVarMap {
+			nameMap[strName] = true
+		}
+		for _, subScope := range scope.SubScopes {
+			insertScopeNames(subScope)
+		}
+	}
+	insertScopeNames(fileResult.MainFunc.MainScope)
+
+	collector := &extractCollector{}
+	collector.collect(fileResult.Block)
+	for _, nameExp := range collector.nameVec {
+		nameMap[nameExp.Name] = true
+	}
+
+	strName := strBase
+	for i := 1; nameMap[strName]; i++ {
+		strName = strBase + strconv.Itoa(i)
+	}
+	return strName
+}
+
+// extractStatRange 选中范围内的连续语句
+type extractStatRange struct {
+	stats     []ast.Stat
+	block     *ast.Block       // 语句所在的代码块
+	funcBlock *ast.Block       // 语句所在函数的代码块，最外层时为整个文件
+	loopLocs  []lexer.Location // 在同一个函数中，包含选中语句的所有循环语句的位置
+}
+
+// findExtractStats 查找选中范围内的连续语句，选中的语句需要在同一个代码块中，不能只选中语句的一部分
+func findExtractStats(block *ast.Block, statRange extractStatRange, selLoc lexer.Location) (extractStatRange, bool) {
+	if block == nil {
+		return statRange, false
+	}
+
+	first, last := -1, -1
+	for i, stat := range block.Stats {
+		loc, ok := ast.GetStatLoc(stat)
+		if !ok || !isLocOverlap(loc, selLoc) {
+			continue
+		}
+
+		if selLoc.IsContainLoc(loc) {
+			if first < 0 {
+				first = i
+			}
+			last = i
+			continue
+		}
+
+		// 只选中了语句的一部分，判断是否选中了语句中的代码块里面的语句
+		if first >= 0 || !loc.IsContainLoc(selLoc) {
+			return statRange, false
+		}
+
+		var funcExp *ast.FuncDefExp
+		walkFuncDefExp(stat, func(statLoc lexer.Location, oneFuncExp *ast.FuncDefExp) bool {
+			if oneFuncExp.Loc.IsContainLoc(selLoc) {
+				funcExp = oneFuncExp
+				return false
+			}
+			return true
+		})
+		if funcExp != nil {
+			return findExtractStats(funcExp.Block, extractStatRange{funcBlock: funcExp.Block}, selLoc)
+		}
+
+		if ast.IsLoopStat(stat) {
+			statRange.loopLocs = append(statRange.loopLocs, loc)
+		}
+		for _, subBlock := range ast.GetSubBlocks(stat) {
+			if subRange, ok := findExtractStats(subBlock, statRange, selLoc); ok {
+				return subRange, true
+			}
+		}
+		return statRange, false
+	}
+
+	if first < 0 {
+		return statRange, false
+	}
+
+	statRange.stats = block.Stats[first : last+1]
+	statRange.block = block
+	return statRange, true
+}
+
+// isSelectReturn 判断选中的范围是否从return语句开始
+func isSelectReturn(block *ast.Block, selLoc lexer.Location) (findFlag bool) {
+	ast.Inspect(block, func(node ast.Node) bool {
+		if subBlock, ok := node.(*ast.Block); ok && subBlock.RetExps != nil &&
+			subBlock.RetLoc.StartLine == selLoc.StartLine && subBlock.RetLoc.StartColumn == selLoc.StartColumn {
+			findFlag = true
+		}
+		return !findFlag
+	})
+
+	return findFlag
+}
+
+// GetExtractFunc 获取选中的语句提取为局部函数的信息，selLoc为选中的范围，contents为文件当前的内容
+// 选中语句中用到的外部局部变量作为新函数的参数，定义或赋值后在后面用到的局部变量作为返回值
+// 包含跳出选中范围的break、goto、return时，ErrStr为不能提取的原因
+func (a *AllProject) GetExtractFunc(strFile string, contents []byte, selLoc lexer.Location) (info ExtractFuncInfo,
+	ok bool) {
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil || fileStruct.FileResult.Block == nil {
+		return
+	}
+
+	fileResult := fileStruct.FileResult
+	lineVec := strings.Split(string(contents), "\n")
+	selLoc = trimSelectLoc(lineVec, selLoc)
+	statRange, ok := findExtractStats(fileResult.Block, extractStatRange{funcBlock: fileResult.Block}, selLoc)
+	if !ok {
+		// 只选中了return
+		if isSelectReturn(fileResult.Block, selLoc) {
+			info.Loc = selLoc
+			info.ErrStr = "selected statements contain a return"
+			return info, true
+		}
+		return info, false
+	}
+
+	firstLoc, _ := ast.GetStatLoc(statRange.stats[0])
+	lastLoc, _ := ast.GetStatLoc(statRange.stats[len(statRange.stats)-1])
+	info.Loc = lexer.GetRangeLoc(&firstLoc, &lastLoc)
+	info.Text = getLocText(lineVec, info.Loc)
+	info.Name = getExtractNewName(fileResult, "newFunction")
+
+	// 1) 选中的语句前后不能有其他的内容，例如选中了代码块最后的return
+	if statRange.block.RetExps != nil && isLocOverlap(statRange.block.RetLoc, selLoc) {
+		info.ErrStr = "selected statements contain a return"
+		return info, true
+	}
+	endLoc := lexer.Location{
+		StartLine:   info.Loc.EndLine,
+		StartColumn: info.Loc.EndColumn,
+		EndLine:     selLoc.EndLine,
+		EndColumn:   selLoc.EndColumn,
+	}
+	if !isBlankText(getLocText(lineVec, endLoc)) {
+		return info, false
+	}
+
+	// 2) 选中的语句中不能有跳出选中范围的return、break、goto
+	collector := &extractCollector{}
+	for _, stat := range statRange.stats {
+		collector.collect(stat)
+	}
+	if collector.hasReturn {
+		info.ErrStr = "selected statements contain a return"
+		return info, true
+	}
+	if collector.hasBreak {
+		info.ErrStr = "selected statements contain a break outside of a loop"
+		return info, true
+	}
+
+	labelMap := map[string]bool{}
+	for _, labelStat := range collector.labelVec {
+		labelMap[labelStat.Name] = true
+	}
+	for _, gotoStat := range collector.gotoVec {
+		if !labelMap[gotoStat.Name] {
+			info.ErrStr = "selected statements contain a goto to a label outside: " + gotoStat.Name
+			return info, true
+		}
+	}
+
+	funcCollector := &extractCollector{}
+	funcCollector.collect(statRange.funcBlock)
+	for _, gotoStat := range funcCollector.gotoVec {
+		if labelMap[gotoStat.Name] && !info.Loc.IsContainLoc(gotoStat.Loc) {
+			info.ErrStr = "selected statements contain a label used by a goto outside: " + gotoStat.Name
+			return info, true
+		}
+	}
+
+	// 3) 选中语句中引用的外部局部变量，作为参数
+	info.IsVararg = collector.hasVararg
+	paramMap := map[*common.VarInfo]bool{}
+	for _, nameExp := range collector.nameVec {
+		varInfo := findNameExpVar(fileResult, nameExp)
+		if varInfo == nil || info.Loc.IsContainLoc(varInfo.Loc) || paramMap[varInfo] {
+			continue
+		}
+
+		paramMap[varInfo] = true
+		info.ParamVec = append(info.ParamVec, nameExp.Name)
+	}
+
+	// 4) 选中语句中定义或是赋值的局部变量，在后面又用到了，作为返回值
+	// 选中的语句在循环中时，循环中前面的语句也可能用到
+	assignMap := map[*common.VarInfo]bool{}
+	for _, nameExp := range collector.assignVec {
+		if varInfo := findNameExpVar(fileResult, nameExp); varInfo != nil {
+			assignMap[varInfo] = true
+		}
+	}
+
+	isUsedAfter := func(loc lexer.Location) bool {
+		if info.Loc.IsContainLoc(loc) {
+			return false
+		}
+		if isPosBefore(info.Loc.EndLine, info.Loc.EndColumn, loc.StartLine, loc.StartColumn+1) {
+			return true
+		}
+		for _, loopLoc := range statRange.loopLocs {
+			if loopLoc.IsContainLoc(loc) {
+				return true
+			}
+		}
+		return false
+	}
+
+	returnMap := map[*common.VarInfo]string{}
+	fileCollector := &extractCollector{}
+	fileCollector.collect(fileResult.Block)
+	for _, nameExp := range fileCollector.nameVec {
+		if !isUsedAfter(nameExp.Loc) {
+			continue
+		}
+
+		varInfo := findNameExpVar(fileResult, nameExp)
+		if varInfo == nil || (!info.Loc.IsContainLoc(varInfo.Loc) && !assignMap[varInfo]) {
+			continue
+		}
+		returnMap[varInfo] = nameExp.Name
+	}
+
+	returnVars := make([]*common.VarInfo, 0, len(returnMap))
+	for varInfo := range returnMap {
+		returnVars = append(returnVars, varInfo)
+	}
+	sort.Slice(returnVars, func(i, j int) bool {
+		return returnVars[i].Loc.IsBeforeLoc(returnVars[j].Loc) && returnVars[i].Loc != returnVars[j].Loc
+	})
+	for _, varInfo := range returnVars {
+		info.ReturnVec = append(info.ReturnVec, returnMap[varInfo])
+		if info.Loc.IsContainLoc(varInfo.Loc) {
+			info.LocalVec = append(info.LocalVec, returnMap[varInfo])
+		}
+	}
+
+	return info, true
+}
+
+// extractExpFinder 查找与选中范围一致的表达式，以及包含表达式的最内层语句
+type extractExpFinder struct {
+	selLoc     lexer.Location
+	exp        ast.Exp
+	validFlag  bool              // 表达式是否可以提取
+	insertLoc  lexer.Location    // 包含表达式的最内层语句的位置，表达式在return语句中时为return关键字的位置
+	invalidMap map[ast.Exp]bool  // 不能提取的表达式，例如赋值语句的左边、while的条件，其中的子表达式也不能提取
+	callStats  map[ast.Stat]bool // 函数调用语句，语句本身不能提取为表达式，实参等可以提取
+}
+
+// extractExpVisitor 遍历时的状态，validFlag表示当前的表达式是否可以提取
+type extractExpVisitor struct {
+	finder    *extractExpFinder
+	validFlag bool
+}
+
+func (v extractExpVisitor) Visit(node ast.Node) ast.Visitor {
+	f := v.finder
+	if node == nil || f.exp != nil {
+		return nil
+	}
+
+	if block, ok := node.(*ast.Block); ok {
+		for _, stat := range block.Stats {
+			if loc, ok := ast.GetStatLoc(stat); ok && loc.IsContainLoc(f.selLoc) {
+				f.insertLoc = loc
+				if _, ok := stat.(*ast.FuncCallStat); ok {
+					f.callStats[stat] = true
+				}
+			}
+		}
+		for _, exp := range block.RetExps {
+			if common.GetExpLoc(exp).IsContainLoc(f.selLoc) {
+				f.insertLoc = block.RetLoc
+			}
+		}
+		return v
+	}
+
+	// 只遍历包含选中范围的语句与表达式
+	loc, statFlag := ast.GetStatLoc(node)
+	if _, ok := node.(*ast.FuncCallExp); ok || !statFlag {
+		loc, statFlag = common.GetExpLoc(node), f.callStats[node]
+	}
+	if !loc.IsContainLoc(f.selLoc) {
+		return nil
+	}
+
+	if f.invalidMap[node] {
+		v.validFlag = false
+	}
+	if loc == f.selLoc && !statFlag {
+		f.exp = node
+		f.validFlag = v.validFlag
+		return nil
+	}
+
+	switch subNode := node.(type) {
+	case *ast.AssignStat:
+		for _, varExp := range subNode.VarList {
+			if common.GetExpLoc(varExp) == f.selLoc {
+				f.invalidMap[varExp] = true
+			}
+		}
+	case *ast.WhileStat:
+		f.invalidMap[subNode.Exp] = true
+	case *ast.RepeatStat:
+		f.invalidMap[subNode.Exp] = true
+	case *ast.IfStat:
+		// elseif的条件不能提取
+		for i, exp := range subNode.Exps {
+			if i > 0 {
+				f.invalidMap[exp] = true
+			}
+		}
+	case *ast.FuncDefExp:
+		v.validFlag = true
+	case *ast.TableConstructorExp:
+		// a = 1 这样的key不能提取
+		for _, keyExp := range subNode.KeyExps {
+			if _, ok := keyExp.(*ast.StringExp); ok {
+				f.invalidMap[keyExp] = true
+			}
+		}
+	case *ast.TableAccessExp:
+		// a.b 这样的key不能提取
+		if _, ok := subNode.KeyExp.(*ast.StringExp); ok {
+			f.invalidMap[subNode.KeyExp] = true
+		}
+	}
+
+	return v
+}
+
+// GetExtractLocal 获取选中的表达式提取为局部变量的信息，selLoc为选中的范围，contents为文件当前的内容
+// 局部变量定义在包含表达式的语句前面，while、repeat的条件以及elseif的条件会多次求值，不能提取
+func (a *AllProject) GetExtractLocal(strFile string, contents []byte, selLoc lexer.Location) (info ExtractLocalInfo,
+	ok bool) {
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil || fileStruct.FileResult.Block == nil {
+		return
+	}
+
+	fileResult := fileStruct.FileResult
+	lineVec := strings.Split(string(contents), "\n")
+	finder := &extractExpFinder{
+		selLoc:     trimSelectLoc(lineVec, selLoc),
+		invalidMap: map[ast.Exp]bool{},
+		callStats:  map[ast.Stat]bool{},
+	}
+	ast.Walk(extractExpVisitor{finder: finder, validFlag: true}, fileResult.Block)
+	if finder.exp == nil || !finder.validFlag {
+		return info, false
+	}
+
+	info.Loc = finder.selLoc
+	info.InsertLoc = finder.insertLoc
+
+	info.Text = getLocText(lineVec, info.Loc)
+	info.Name = getExtractNewName(fileResult, "newLocal")
+	return info, true
+}
@@ -8,32 +8,35 @@ import (
 )
 
 // HandleFileEventChanges 项目工程文件的变化
-// 返回值表示诊断信息是否有变化
+// 返回值表示诊断信息是否有变化，诊断信息可能变化的文件通过GetDiagnosticFiles获取
 func (a *AllProject) HandleFileEventChanges(fileEventVec []FileEventStruct) (changeDiagnostic bool) {
 	// 0) 清除掉文件的cache
 	common.GConfig.ClearCacheFileMap()
 
-	// 判断该文件是属于哪些第二阶段的工程
-	belongProjectMap := map[string]struct{}{}
-
 	// 需要重新分析的文件列表(包括第一遍生成AST)
 	needAgainFileVec := []string{}
 
 	// 需要重新分析的引用关系，其他文件引用了这个文件，都需要重新分析引用关系；文件新增或删除，都会导致原有的引用关系改变
 	needReferFileMap := map[string]struct{}{}
 
-	// 是否处理所有的文件
-	handleAllFlag := false
-	thirdFlag := false
+	// 所有变化的文件
+	changeFileVec := []string{}
+	for _, fileEvents := range fileEventVec {
+		changeFileVec = append(changeFileVec, fileEvents.StrFile)
+	}
+
+	// 受这次变化影响的文件，包括变化的文件以及传递依赖它们的文件；变化之前依赖这些文件的，也会受影响
+	affectMap := map[string]struct{}{}
+	a.dependGraph.GetAffectFiles(changeFileVec, affectMap)
+
+	// 是否有文件新增或删除
+	fileListFlag := false
 
 	// 是否有变化
 	changeFlag := false
 	changeDiagnostic = false
 	dirManager := common.GConfig.GetDirManager()
 
-	// 删除文件的列表
-	deleteFileMap := map[string]struct{}{}
-
 	// 1) 处理有变化的文件
 	time0 := time.Now()
 	for _, fileEvents := range fileEventVec {
@@ -43,48 +46,26 @@ func (a *AllProject) HandleFileEventChanges(fileEventVec []FileEventStruct) (cha
 			needAgainFileVec = append(needAgainFileVec, strFile)
 			if dirManager.IsInDir(strFile) {
 				needReferFileMap[strFile] = struct{}{}
-				// 新创建的文件，全量的工程分析
-				handleAllFlag = true
-				thirdFlag = true
-				log.Debug("add strFile=%s need complete handle all files", strFile)
+				fileListFlag = true
+				log.Debug("add strFile=%s need handle refer files", strFile)
 			}
 		} else if fileEvents.Type == FileEventChanged {
 			needAgainFileVec = append(needAgainFileVec, strFile)
 		} else if fileEvents.Type == FileEventDeleted {
 			a.RemoveFile(strFile)
-			deleteFileMap[strFile] = struct{}{}
 
 			if dirManager.IsInDir(strFile) {
 				needReferFileMap[strFile] = struct{}{}
-				// 删除了文件，尽量全量的工程分析下
-				handleAllFlag = true
-				thirdFlag = true
-			}
-		}
-
-		for strEntryFile, analysisSecond := range a.analysisSecondMap {
-			if _, ok := analysisSecond.AllFiles[strFile]; ok {
-				//log.Debug("strFile=%s is belong project=%s", strFile, strEntryFile)
-				belongProjectMap[strEntryFile] = struct{}{}
-			}
-		}
-
-		if a.thirdStruct != nil {
-			if _, ok := a.thirdStruct.AllIncludeFile[strFile]; ok {
-				//log.Debug("strFile=%s is belong third files", strFile)
-				thirdFlag = true
+				fileListFlag = true
 			}
 		}
 	}
 
 	// 有文件新增或删除，重建
-	if handleAllFlag {
+	if fileListFlag {
 		common.GConfig.RebuildSameFileNameVar(a.allFilesMap)
 	}
 
-	// 2.1) 如果之前的文件有包含错误码为6的错误，这次有新的文件增加，之前的文件，还需要进行扫描下
-	// needAgainFileVec = a.handleNeedAgainFileVec(needAgainFileVec, deleteFileMap)
-
 	// 2) 判断是否有必要重新进行一阶段分析的文件
 	time1 := time.Now()
 	if len(needAgainFileVec) > 0 {
@@ -118,17 +99,45 @@ func (a *AllProject) HandleFileEventChanges(fileEventVec []FileEventStruct) (cha
 		}
 		// 引用关系变了，诊断信息也要跟着改变
 		changeDiagnostic = true
+
+		// 引用关系变了，依赖关系图整体重建
+		a.rebuildDependGraph()
+	} else {
+		for _, strFile := range needAgainFileVec {
+			if fileStruct, ok := a.fileStructMap[strFile]; ok {
+				a.dependGraph.UpdateFile(strFile, fileStruct.FileResult, fileStruct.AnnotateFile)
+			}
+		}
 	}
 
-	log.Debug("needReferFileMap len=%d, costTime=%d, changeFlag=%t", len(needReferFileMap),
-		time.Since(time2).Milliseconds(), changeFlag)
-	if !changeFlag && !handleAllFlag {
+	// 3.1) 变化之后依赖这些文件的，也会受影响
+	newAffectMap := map[string]struct{}{}
+	a.dependGraph.GetAffectFiles(changeFileVec, newAffectMap)
+	for strFile := range newAffectMap {
+		affectMap[strFile] = struct{}{}
+	}
+	a.diagnosticFileMap = affectMap
+
+	log.Debug("needReferFileMap len=%d, affect len=%d, costTime=%d, changeFlag=%t", len(needReferFileMap),
+		len(affectMap), time.Since(time2).Milliseconds(), changeFlag)
+	if !changeFlag && !fileListFlag {
 		log.Debug("HandleFileEventChanges change false, changeDiagnostic=%t", changeDiagnostic)
 		return changeDiagnostic
 	}
 
 	changeDiagnostic = true
 
+	// 受影响的文件是否属于散落的文件
+	thirdFlag := fileListFlag
+	if a.thirdStruct != nil {
+		for strFile := range affectMap {
+			if _, ok := a.thirdStruct.AllIncludeFile[strFile]; ok {
+				thirdFlag = true
+				break
+			}
+		}
+	}
+
 	// 判断是否要进行特殊的校验
 	if len(a.entryFilesList) == 0 && !common.GConfig.IsSpecialCheck() {
 		time5 := time.Now()
@@ -142,39 +151,23 @@ func (a *AllProject) HandleFileEventChanges(fileEventVec []FileEventStruct) (cha
 		log.Debug("HandleAllThirdFile thirdFlag=%t, third costTime=%d, all_time=%d", thirdFlag,
 			time.Since(time5).Milliseconds(), time.Since(time0).Milliseconds())
 	} else {
-		// 5) 对有需要的工程做第二遍工程遍历
-		handleProjectLen := 0
+		// 5) 只对包含受影响文件的工程做第二遍工程遍历
 		time4 := time.Now()
-		if handleAllFlag {
-			// 设置第二轮标记
-			a.setCheckTerm(results.CheckTermSecond)
-			a.handleProjectEntryFileVec(a.entryFilesList)
-			handleProjectLen = len(a.entryFilesList)
-		} else {
-			// 设置第二轮标记
-			a.setCheckTerm(results.CheckTermSecond)
-
-			var belongProjectVec []string
-			for belongFile := range belongProjectMap {
-				belongProjectVec = append(belongProjectVec, belongFile)
-			}
-			if len(belongProjectVec) > 0 {
-				a.handleProjectEntryFileVec(belongProjectVec)
-			}
-			handleProjectLen = len(belongProjectVec)
-		}
+		a.setCheckTerm(results.CheckTermSecond)
+		belongProjectVec := a.getAffectProjects(affectMap)
+		a.handleChangeProjects(belongProjectVec, affectMap)
 
-		log.Debug("handleProject len=%d, costTime=%d", handleProjectLen, time.Since(time4).Milliseconds())
+		log.Debug("handleProject len=%d, costTime=%d", len(belongProjectVec), time.Since(time4).Milliseconds())
 		time5 := time.Now()
 
-		// 6) 对有需要的散落文件做第三遍的散落文件遍历
+		// 6) 对有需要的散落文件做第三遍的散落文件遍历，只重新遍历受影响的文件
 		if thirdFlag {
 			// 设置第三轮标记
 			a.setCheckTerm(results.CheckTermThird)
-			a.HandleAllThirdFile()
+			a.HandleChangeThirdFile(affectMap)
 		}
 
-		log.Debug("HandleAllThirdFile flag=%t, costTime=%d, all_time=%d", thirdFlag,
+		log.Debug("HandleChangeThirdFile flag=%t, costTime=%d, all_time=%d", thirdFlag,
 			time.Since(time5).Milliseconds(), time.Since(time0).Milliseconds())
 	}
 
@@ -182,55 +175,85 @@ func (a *AllProject) HandleFileEventChanges(fileEventVec []FileEventStruct) (cha
 	a.rebuidCreateTypeMap()
 
 	a.checkAllAnnotate()
+
 	return true
 }
 
-// 判断是否需要处理重新分享第一轮的文件列表
-func (a *AllProject) handleNeedAgainFileVec(needAgainFileVec []string, deleteFileMap map[string]struct{}) (retFileVec []string) {
-	// 重建所有需要分析的map
-	needAgainFileMap := map[string]struct{}{}
-	for _, strFile := range needAgainFileVec {
-		needAgainFileMap[strFile] = struct{}{}
-	}
-
-	retFileVec = needAgainFileVec
-
-	// 遍历项目中的所有告警信息，判断是否有6这种告警的
-	// 1) 获取第一阶段的错误
-	for strFile, fileStruct := range a.fileStructMap {
-		if fileStruct.HandleResult == results.FileHandleReadErr {
+// getAffectProjects 获取包含受影响文件的第二阶段工程入口文件，还没有分析过的工程也需要分析
+func (a *AllProject) getAffectProjects(affectMap map[string]struct{}) (projectVec []string) {
+	for _, strEntryFile := range a.entryFilesList {
+		analysisSecond, ok := a.analysisSecondMap[strEntryFile]
+		if !ok {
+			projectVec = append(projectVec, strEntryFile)
 			continue
 		}
 
-		// 已经在分析的列表里面了
-		if _, ok := needAgainFileMap[strFile]; ok {
+		if _, ok := affectMap[strEntryFile]; ok {
+			projectVec = append(projectVec, strEntryFile)
 			continue
 		}
 
-		// 文件已经被删除了，忽略
-		if _, ok := deleteFileMap[strFile]; ok {
-			continue
+		for strFile := range affectMap {
+			if _, ok := analysisSecond.AllFiles[strFile]; ok {
+				projectVec = append(projectVec, strEntryFile)
+				break
+			}
 		}
+	}
 
-		// 判断是否有包含CheckErrorNoFile的错误码
-		needFlag := false
-		fileResult := fileStruct.FileResult
-		for _, oneErr := range fileResult.CheckErrVec {
-			if oneErr.ErrType == common.CheckErrorNoFile {
-				needFlag = true
-				break
+	return projectVec
+}
+
+// handleChangeProjects 增量分析第二阶段的工程，只重新遍历受影响的文件，工程包含的文件有变化时，进出工程的文件诊断信息也会变化，加入到affectMap中
+func (a *AllProject) handleChangeProjects(projectVec []string, affectMap map[string]struct{}) {
+	if len(projectVec) == 0 {
+		return
+	}
+
+	oldFilesMap := map[string]map[string]struct{}{}
+	for _, strEntryFile := range projectVec {
+		if analysisSecond, ok := a.analysisSecondMap[strEntryFile]; ok {
+			oldFilesMap[strEntryFile] = analysisSecond.AllFiles
+		}
+	}
+
+	// 之前分析过的工程，只重新遍历受影响的文件
+	a.handleProjectEntryFileVec(projectVec, affectMap)
+
+	for _, strEntryFile := range projectVec {
+		oldFiles := oldFilesMap[strEntryFile]
+		var newFiles map[string]struct{}
+		if analysisSecond, ok := a.analysisSecondMap[strEntryFile]; ok {
+			newFiles = analysisSecond.AllFiles
+		}
+
+		for strFile := range oldFiles {
+			if _, ok := newFiles[strFile]; !ok {
+				affectMap[strFile] = struct{}{}
 			}
 		}
 
-		if !needFlag {
-			continue
+		for strFile := range newFiles {
+			if _, ok := oldFiles[strFile]; !ok {
+				affectMap[strFile] = struct{}{}
+			}
 		}
+	}
+}
+
+// GetDiagnosticFiles 获取最近一次文件变化后，诊断信息可能有变化的文件，为nil时表示所有的文件
+func (a *AllProject) GetDiagnosticFiles() map[string]struct{} {
+	return a.diagnosticFileMap
+}
 
-		retFileVec = append(retFileVec, strFile)
-		needAgainFileMap[strFile] = struct{}{}
+// GetSecondReplayFiles 获取最近一次增量分析中，工程没有重新遍历AST、由第一阶段结果还原的文件
+func (a *AllProject) GetSecondReplayFiles(strEntryFile string) []string {
+	analysisSecond, ok := a.analysisSecondMap[strEntryFile]
+	if !ok {
+		return nil
 	}
 
-	return retFileVec
+	return analysisSecond.GetReplayFiles()
 }
 
 // RemoveCacheContent 删除cache的结构
@@ -0,0 +1,742 @@
+package check
+
+import (
+	"luahelper-lsp/langserver/check/annotation/annotateast"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"luahelper-lsp/langserver/check/results"
+	"sort"
+	"strings"
+)
+
+// 推导参数类型的依据，值越小优先级越高，只采用优先级最高的那一类依据
+const (
+	paramTypeByTypeCall = iota // 通过type(a) == "string" 这样的判断
+	paramTypeByAnnotate        // 作为实参传给了有注解的函数
+	paramTypeByUsage           // 被调用、被索引、与字面量比较、参与运算
+	paramTypeLevelMax
+)
+
+// 最多向前推导局部变量指向表达式的层数，防止循环引用
+const maxInferReferDepth = 5
+
+// stringMethodMap 字符串常用的成员函数，a:sub(1, 2) 这样调用时推导为string
+var stringMethodMap = map[string]bool{
+	"byte":    true,
+	"find":    true,
+	"format":  true,
+	"gmatch":  true,
+	"gsub":    true,
+	"len":     true,
+	"lower":   true,
+	"match":   true,
+	"rep":     true,
+	"reverse": true,
+	"sub":     true,
+	"upper":   true,
+}
+
+// builtinFirstParamMap 系统函数第一个参数的类型，为空的表示不能推导出类型
+var builtinFirstParamMap = map[string]string{
+	"pairs":        "table",
+	"ipairs":       "table",
+	"next":         "table",
+	"rawget":       "table",
+	"rawset":       "table",
+	"setmetatable": "table",
+	"unpack":       "table",
+	"table":        "table",
+	"string":       "string",
+	"type":         "",
+	"tostring":     "",
+	"tonumber":     "",
+	"print":        "",
+	"assert":       "",
+	"error":        "",
+}
+
+// FuncCommentParam 生成注释时，单个参数的名称与推导的类型
+type FuncCommentParam struct {
+	Name    string
+	TypeStr string
+}
+
+// FuncCommentInfo 生成注释时，函数推导出来的参数与返回值类型
+type FuncCommentInfo struct {
+	ParamVec  []FuncCommentParam // 所有的参数，冒号函数不包含self
+	ReturnVec []string           // 每一个返回值的类型
+}
+
+// FileFuncComment 文件中没有注解的函数，以及为其生成的注解
+type FileFuncComment struct {
+	Loc     lexer.Location  // 函数定义语句的位置，注解插入到这一行的前面
+	Comment FuncCommentInfo // 推导出来的注解信息
+}
+
+// paramTypeInfo 单个参数收集到的所有类型依据
+type paramTypeInfo struct {
+	levelTypes [paramTypeLevelMax][]string
+}
+
+// funcCommentInfer 遍历函数体，推导参数与返回值的类型
+type funcCommentInfer struct {
+	allProject *AllProject
+	strFile    string
+	funcInfo   *common.FuncInfo
+	paramMap   map[string]*paramTypeInfo
+}
+
+// mergeTypeStr 合并到已有的类型列表中，any包含所有类型，integer合并到number中
+func mergeTypeStr(typeVec []string, strType string) []string {
+	if strType == "" {
+		return typeVec
+	}
+
+	for _, oneType := range strings.Split(strType, "|") {
+		if len(typeVec) == 1 && typeVec[0] == "any" {
+			return typeVec
+		}
+
+		switch oneType {
+		case "any":
+			return []string{"any"}
+		case "integer":
+			if isTypeStrExist(typeVec, "number") {
+				continue
+			}
+		case "number":
+			for i, existType := range typeVec {
+				if existType == "integer" {
+					typeVec[i] = "number"
+				}
+			}
+		}
+
+		if !isTypeStrExist(typeVec, oneType) {
+			typeVec = append(typeVec, oneType)
+		}
+	}
+
+	return typeVec
+}
+
+// isTypeStrExist 判断类型是否已经在列表中
+func isTypeStrExist(typeVec []string, strType string) bool {
+	for _, oneType := range typeVec {
+		if oneType == strType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getTypeVecStr 类型列表转换为注解的字符串，为空时为any
+func getTypeVecStr(typeVec []string) string {
+	if len(typeVec) == 0 {
+		return "any"
+	}
+
+	return strings.Join(typeVec, "|")
+}
+
+func (p *paramTypeInfo) addType(level int, strType string) {
+	p.levelTypes[level] = mergeTypeStr(p.levelTypes[level], strType)
+}
+
+// getTypeStr 获取优先级最高的依据推导出来的类型
+func (p *paramTypeInfo) getTypeStr() string {
+	for _, typeVec := range p.levelTypes {
+		if len(typeVec) > 0 {
+			return getTypeVecStr(typeVec)
+		}
+	}
+
+	return "any"
+}
+
+// copyShadowMap 进入新的作用域时，拷贝被局部变量遮挡的参数名
+func copyShadowMap(shadowMap map[string]bool) map[string]bool {
+	newMap := make(map[string]bool, len(shadowMap))
+	for strName := range shadowMap {
+		newMap[strName] = true
+	}
+	return newMap
+}
+
+// getParamName 表达式为当前函数的参数时，返回参数名
+func (f *funcCommentInfer) getParamName(exp ast.Exp, shadowMap map[string]bool) string {
+	switch subExp := exp.(type) {
+	case *ast.ParensExp:
+		return f.getParamName(subExp.Exp, shadowMap)
+	case *ast.NameExp:
+		if _, ok := f.paramMap[subExp.Name]; ok && !shadowMap[subExp.Name] {
+			return subExp.Name
+		}
+	}
+
+	return ""
+}
+
+// addParamType 给参数增加一个类型依据
+func (f *funcCommentInfer) addParamType(exp ast.Exp, shadowMap map[string]bool, level int, strType string) {
+	if strName := f.getParamName(exp, shadowMap); strName != "" {
+		f.paramMap[strName].addType(level, strType)
+	}
+}
+
+// inferVisitor 遍历函数体，收集参数的使用方式，shadowMap为当前作用域中被局部变量遮挡的参数名
+type inferVisitor struct {
+	infer     *funcCommentInfer
+	shadowMap map[string]bool
+}
+
+// newScope 进入新的作用域，names为新作用域中定义的局部变量
+func (v *inferVisitor) newScope(names ...string) *inferVisitor {
+	shadowMap := copyShadowMap(v.shadowMap)
+	for _, strName := range names {
+		shadowMap[strName] = true
+	}
+	return &inferVisitor{
+		infer:     v.infer,
+		shadowMap: shadowMap,
+	}
+}
+
+// Visit 遍历单个节点，局部变量在定义语句的表达式之后才生效
+func (v *inferVisitor) Visit(node ast.Node) ast.Visitor {
+	f, shadowMap := v.infer, v.shadowMap
+	switch subNode := node.(type) {
+	case nil:
+		return nil
+	case *ast.Block:
+		return v.newScope()
+	case *ast.LocalVarDeclStat:
+		for _, exp := range subNode.ExpList {
+			ast.Walk(v, exp)
+		}
+		for _, strName := range subNode.NameList {
+			shadowMap[strName] = true
+		}
+		return nil
+	case *ast.LocalFuncDefStat:
+		shadowMap[subNode.Name] = true
+	case *ast.ForNumStat:
+		for _, exp := range []ast.Exp{subNode.InitExp, subNode.LimitExp, subNode.StepExp} {
+			ast.Walk(v, exp)
+		}
+		ast.Walk(v.newScope(subNode.VarName), subNode.Block)
+		return nil
+	case *ast.ForInStat:
+		for _, exp := range subNode.ExpList {
+			ast.Walk(v, exp)
+		}
+		ast.Walk(v.newScope(subNode.NameList...), subNode.Block)
+		return nil
+	case *ast.FuncDefExp:
+		// 子函数中仍然可以引用到参数，但同名的参数会遮挡
+		return v.newScope(subNode.ParList...)
+	case *ast.UnopExp:
+		if subNode.Op == lexer.TkOpUnm {
+			f.addParamType(subNode.Exp, shadowMap, paramTypeByUsage, "number")
+		}
+	case *ast.BinopExp:
+		f.inferBinopExp(subNode, shadowMap)
+	case *ast.TableAccessExp:
+		f.addParamType(subNode.PrefixExp, shadowMap, paramTypeByUsage, "table")
+	case *ast.FuncCallExp:
+		if subNode.NameExp == nil {
+			f.addParamType(subNode.PrefixExp, shadowMap, paramTypeByUsage, "function")
+		} else if stringMethodMap[subNode.NameExp.Str] {
+			f.addParamType(subNode.PrefixExp, shadowMap, paramTypeByUsage, "string")
+		} else {
+			f.addParamType(subNode.PrefixExp, shadowMap, paramTypeByUsage, "table")
+		}
+		f.inferCallArgs(subNode, shadowMap)
+	}
+
+	return v
+}
+
+// getTypeCallParam 判断是否为type(a)的调用，返回参数a的名称
+func (f *funcCommentInfer) getTypeCallParam(exp ast.Exp, shadowMap map[string]bool) string {
+	callExp, ok := exp.(*ast.FuncCallExp)
+	if !ok || callExp.NameExp != nil || len(callExp.Args) != 1 {
+		return ""
+	}
+
+	nameExp, ok := callExp.PrefixExp.(*ast.NameExp)
+	if !ok || nameExp.Name != "type" {
+		return ""
+	}
+
+	return f.getParamName(callExp.Args[0], shadowMap)
+}
+
+// getLiteralTypeStr 获取字面量常量的类型
+func getLiteralTypeStr(exp ast.Exp) string {
+	switch exp.(type) {
+	case *ast.StringExp:
+		return "string"
+	case *ast.IntegerExp, *ast.FloatExp:
+		return "number"
+	}
+
+	return ""
+}
+
+// inferBinopExp 二元运算推导参数类型
+func (f *funcCommentInfer) inferBinopExp(binExp *ast.BinopExp, shadowMap map[string]bool) {
+	switch binExp.Op {
+	case lexer.TkOpEq, lexer.TkOpNe:
+		// type(a) == "string"
+		for _, expPair := range [][2]ast.Exp{{binExp.Exp1, binExp.Exp2}, {binExp.Exp2, binExp.Exp1}} {
+			strName := f.getTypeCallParam(expPair[0], shadowMap)
+			strExp, ok := expPair[1].(*ast.StringExp)
+			if strName != "" && ok {
+				f.paramMap[strName].addType(paramTypeByTypeCall, strExp.Str)
+				return
+			}
+		}
+
+		f.addParamType(binExp.Exp1, shadowMap, paramTypeByUsage, getLiteralTypeStr(binExp.Exp2))
+		f.addParamType(binExp.Exp2, shadowMap, paramTypeByUsage, getLiteralTypeStr(binExp.Exp1))
+	case lexer.TkOpLt, lexer.TkOpLe, lexer.TkOpGt, lexer.TkOpGe:
+		f.addParamType(binExp.Exp1, shadowMap, paramTypeByUsage, getLiteralTypeStr(binExp.Exp2))
+		f.addParamType(binExp.Exp2, shadowMap, paramTypeByUsage, getLiteralTypeStr(binExp.Exp1))
+	case lexer.TkOpAdd, lexer.TkOpSub, lexer.TkOpMul, lexer.TkOpDiv, lexer.TkOpIdiv,
+		lexer.TkOpMod, lexer.TkOpPow:
+		f.addParamType(binExp.Exp1, shadowMap, paramTypeByUsage, "number")
+		f.addParamType(binExp.Exp2, shadowMap, paramTypeByUsage, "number")
+	}
+}
+
+// getCallNameVec 获取函数调用的名称切分，例如a.b:c() 返回[a b c]
+func getCallNameVec(callExp *ast.FuncCallExp) (strVec []string, colonFlag bool) {
+	prefixExp := callExp.PrefixExp
+	for {
+		switch subExp := prefixExp.(type) {
+		case *ast.NameExp:
+			strVec = append([]string{subExp.Name}, strVec...)
+			if callExp.NameExp != nil {
+				strVec = append(strVec, callExp.NameExp.Str)
+				colonFlag = true
+			}
+			return strVec, colonFlag
+		case *ast.TableAccessExp:
+			keyExp, ok := subExp.KeyExp.(*ast.StringExp)
+			if !ok {
+				return nil, false
+			}
+			strVec = append([]string{keyExp.Str}, strVec...)
+			prefixExp = subExp.PrefixExp
+		default:
+			return nil, false
+		}
+	}
+}
+
+// inferCallArgs 参数作为实参传给其他函数时，根据被调函数参数的注解推导类型
+func (f *funcCommentInfer) inferCallArgs(callExp *ast.FuncCallExp, shadowMap map[string]bool) {
+	hasParamFlag := false
+	for _, argExp := range callExp.Args {
+		if f.getParamName(argExp, shadowMap) != "" {
+			hasParamFlag = true
+			break
+		}
+	}
+	if !hasParamFlag {
+		return
+	}
+
+	strVec, colonFlag := getCallNameVec(callExp)
+	if len(strVec) == 0 {
+		return
+	}
+
+	// 1) 系统函数
+	if strType, ok := builtinFirstParamMap[strVec[0]]; ok && len(strVec) <= 2 {
+		if strType != "" && len(callExp.Args) > 0 {
+			f.addParamType(callExp.Args[0], shadowMap, paramTypeByAnnotate, strType)
+		}
+		return
+	}
+	if strVec[0] == "math" && len(strVec) == 2 {
+		for _, argExp := range callExp.Args {
+			f.addParamType(argExp, shadowMap, paramTypeByAnnotate, "number")
+		}
+		return
+	}
+
+	// 2) 工程中有注解的函数
+	varStruct := common.DefineVarStruct{
+		PosLine:   callExp.Loc.StartLine - 1,
+		PosCh:     callExp.Loc.StartColumn,
+		ValidFlag: true,
+		StrVec:    strVec,
+		IsFuncVec: make([]bool, len(strVec)),
+		ColonFlag: colonFlag,
+	}
+	_, symList := f.allProject.FindVarDefine(f.strFile, &varStruct)
+	if len(symList) == 0 {
+		return
+	}
+
+	lastSymbol := symList[len(symList)-1]
+	if lastSymbol.VarInfo == nil || lastSymbol.VarInfo.ReferFunc == nil {
+		return
+	}
+
+	referFunc := lastSymbol.VarInfo.ReferFunc
+	paramInfo := f.allProject.GetFuncParamInfo(lastSymbol.FileName, lastSymbol.VarInfo.Loc.EndLine-1)
+	if paramInfo == nil {
+		return
+	}
+
+	for i, argExp := range callExp.Args {
+		paramIndex := i
+		if colonFlag && referFunc.IsColon {
+			// 冒号调用时，第一个参数为self
+			paramIndex++
+		}
+		if paramIndex >= len(referFunc.ParamList) {
+			break
+		}
+
+		for _, oneParam := range paramInfo.ParamList {
+			if oneParam.Name != referFunc.ParamList[paramIndex] {
+				continue
+			}
+
+			strType := annotateast.TypeConvertStr(oneParam.ParamType)
+			if strType != "" && strType != "any" {
+				f.addParamType(argExp, shadowMap, paramTypeByAnnotate, strings.Replace(strType, " ", "", -1))
+			}
+			break
+		}
+	}
+}
+
+// collectReturns 按照在源码中的顺序，收集函数体内所有的return语句，不包含子函数的
+func collectReturns(block *ast.Block) (retVec [][]ast.Exp) {
+	var retBlocks []*ast.Block
+	ast.Inspect(block, func(node ast.Node) bool {
+		switch subNode := node.(type) {
+		case *ast.FuncDefExp:
+			return false
+		case *ast.Block:
+			if subNode.RetExps != nil {
+				retBlocks = append(retBlocks, subNode)
+			}
+		}
+		return true
+	})
+
+	// 外层代码块的return在内层代码块的后面
+	sort.Slice(retBlocks, func(i, j int) bool {
+		return retBlocks[i].RetLoc.IsBeforeLoc(retBlocks[j].RetLoc)
+	})
+	for _, retBlock := range retBlocks {
+		retVec = append(retVec, retBlock.RetExps)
+	}
+
+	return retVec
+}
+
+// isMultiResultExp 表达式是否可能返回多个值
+func isMultiResultExp(exp ast.Exp) bool {
+	switch exp.(type) {
+	case *ast.FuncCallExp, *ast.VarargExp:
+		return true
+	}
+
+	return false
+}
+
+// getExpTypeStr 推导表达式的类型
+func (f *funcCommentInfer) getExpTypeStr(exp ast.Exp, depth int) string {
+	switch subExp := exp.(type) {
+	case *ast.NilExp:
+		return "nil"
+	case *ast.TrueExp, *ast.FalseExp:
+		return "boolean"
+	case *ast.IntegerExp:
+		return "integer"
+	case *ast.FloatExp:
+		return "number"
+	case *ast.StringExp:
+		return "string"
+	case *ast.TableConstructorExp:
+		return "table"
+	case *ast.FuncDefExp:
+		return "function"
+	case *ast.ParensExp:
+		return f.getExpTypeStr(subExp.Exp, depth)
+	case *ast.UnopExp:
+		switch subExp.Op {
+		case lexer.TkOpNot:
+			return "boolean"
+		case lexer.TkOpNen, lexer.TkOpBnot:
+			return "integer"
+		case lexer.TkOpUnm:
+			return getTypeVecStr(mergeTypeStr(nil, f.getNumberTypeStr(subExp.Exp, depth)))
+		}
+	case *ast.BinopExp:
+		return f.getBinopTypeStr(subExp, depth)
+	case *ast.NameExp:
+		return f.getNameTypeStr(subExp, depth)
+	}
+
+	return "any"
+}
+
+// getNumberTypeStr 数值运算的操作数为integer时保持integer，其他的为number
+func (f *funcCommentInfer) getNumberTypeStr(exp ast.Exp, depth int) string {
+	if f.getExpTypeStr(exp, depth) == "integer" {
+		return "integer"
+	}
+
+	return "number"
+}
+
+// getBinopTypeStr 推导二元运算的类型
+func (f *funcCommentInfer) getBinopTypeStr(binExp *ast.BinopExp, depth int) string {
+	switch binExp.Op {
+	case lexer.TkOpEq, lexer.TkOpNe, lexer.TkOpLt, lexer.TkOpLe, lexer.TkOpGt, lexer.TkOpGe:
+		return "boolean"
+	case lexer.TkOpConcat:
+		return "string"
+	case lexer.TkOpDiv, lexer.TkOpPow:
+		return "number"
+	case lexer.TkOpAdd, lexer.TkOpSub, lexer.TkOpMul, lexer.TkOpIdiv, lexer.TkOpMod:
+		typeVec := mergeTypeStr(nil, f.getNumberTypeStr(binExp.Exp1, depth))
+		return getTypeVecStr(mergeTypeStr(typeVec, f.getNumberTypeStr(binExp.Exp2, depth)))
+	case lexer.TkOpBand, lexer.TkOpBor, lexer.TkOpBxor, lexer.TkOpShl, lexer.TkOpShr:
+		return "integer"
+	case lexer.TkOpAnd:
+		return f.getExpTypeStr(binExp.Exp2, depth)
+	case lexer.TkOpOr:
+		// a or b，a为nil时取b的值
+		var typeVec []string
+		for _, oneType := range strings.Split(f.getExpTypeStr(binExp.Exp1, depth), "|") {
+			if oneType != "nil" {
+				typeVec = mergeTypeStr(typeVec, oneType)
+			}
+		}
+		return getTypeVecStr(mergeTypeStr(typeVec, f.getExpTypeStr(binExp.Exp2, depth)))
+	}
+
+	return "any"
+}
+
+// getNameTypeStr 推导变量的类型，参数取推导的类型，局部变量取定义时的表达式类型
+func (f *funcCommentInfer) getNameTypeStr(nameExp *ast.NameExp, depth int) string {
+	if depth >= maxInferReferDepth || f.funcInfo.MainScope == nil {
+		return "any"
+	}
+
+	scope := f.funcInfo.MainScope.FindMinScope(nameExp.Loc.StartLine, nameExp.Loc.StartColumn)
+	if scope == nil {
+		return "any"
+	}
+
+	findFlag, varInfo := scope.FindLocVar(nameExp.Name, nameExp.Loc)
+	if !findFlag || varInfo == nil {
+		return "any"
+	}
+
+	if varInfo.IsParam {
+		if oneParam, ok := f.paramMap[nameExp.Name]; ok {
+			return oneParam.getTypeStr()
+		}
+		return "any"
+	}
+
+	if varInfo.ReferFunc != nil {
+		return "function"
+	}
+
+	if varInfo.ReferExp == nil || varInfo.ForCycle != nil {
+		return "any"
+	}
+
+	return f.getExpTypeStr(varInfo.ReferExp, depth+1)
+}
+
+// inferReturnTypes 根据所有的return语句推导每一个返回值的类型
+func (f *funcCommentInfer) inferReturnTypes(block *ast.Block) (returnVec []string) {
+	retVec := collectReturns(block)
+
+	maxNum := 0
+	for _, retExps := range retVec {
+		if len(retExps) > maxNum {
+			maxNum = len(retExps)
+		}
+	}
+
+	for index := 0; index < maxNum; index++ {
+		var typeVec []string
+		for _, retExps := range retVec {
+			if index < len(retExps) {
+				typeVec = mergeTypeStr(typeVec, f.getExpTypeStr(retExps[index], 0))
+			} else if len(retExps) == 0 || !isMultiResultExp(retExps[len(retExps)-1]) {
+				// 这处return返回的值更少，缺少的为nil
+				typeVec = mergeTypeStr(typeVec, "nil")
+			}
+		}
+
+		returnVec = append(returnVec, getTypeVecStr(typeVec))
+	}
+
+	return returnVec
+}
+
+// findFuncDefExp 在语法树中查找指定位置的函数定义
+func findFuncDefExp(block *ast.Block, loc lexer.Location) (funcExp *ast.FuncDefExp) {
+	walkFuncDefExp(block, func(statLoc lexer.Location, oneExp *ast.FuncDefExp) bool {
+		if oneExp.Loc == loc {
+			funcExp = oneExp
+			return false
+		}
+		return true
+	})
+
+	return funcExp
+}
+
+// getStatFuncDefExp 获取直接定义在语句中的函数，例如 local function a() end、a.b = function() end
+func getStatFuncDefExp(node ast.Node) *ast.FuncDefExp {
+	switch subStat := node.(type) {
+	case *ast.LocalFuncDefStat:
+		return subStat.Exp
+	case *ast.LocalVarDeclStat:
+		if len(subStat.ExpList) == 1 && len(subStat.NameList) == 1 {
+			funcExp, _ := subStat.ExpList[0].(*ast.FuncDefExp)
+			return funcExp
+		}
+	case *ast.AssignStat:
+		if len(subStat.ExpList) == 1 && len(subStat.VarList) == 1 {
+			funcExp, _ := subStat.ExpList[0].(*ast.FuncDefExp)
+			return funcExp
+		}
+	}
+
+	return nil
+}
+
+// walkFuncDefExp 遍历语法树中所有的函数定义，statLoc为函数所在语句的位置，若函数不是直接定义在语句中，statLoc为空
+// fn返回false时停止遍历
+func walkFuncDefExp(root ast.Node, fn func(statLoc lexer.Location, funcExp *ast.FuncDefExp) bool) {
+	stopFlag := false
+	statLocMap := map[*ast.FuncDefExp]lexer.Location{}
+	ast.Inspect(root, func(node ast.Node) bool {
+		if stopFlag {
+			return false
+		}
+
+		if funcExp := getStatFuncDefExp(node); funcExp != nil {
+			statLocMap[funcExp], _ = ast.GetStatLoc(node)
+		}
+
+		if funcExp, ok := node.(*ast.FuncDefExp); ok && !fn(statLocMap[funcExp], funcExp) {
+			stopFlag = true
+		}
+		return !stopFlag
+	})
+}
+
+// getFuncCommentInfo 推导函数参数与返回值的类型
+func (a *AllProject) getFuncCommentInfo(strFile string, fileResult *results.FileResult, funcInfo *common.FuncInfo,
+	funcExp *ast.FuncDefExp) (commentInfo FuncCommentInfo) {
+	infer := &funcCommentInfer{
+		allProject: a,
+		strFile:    strFile,
+		funcInfo:   funcInfo,
+		paramMap:   map[string]*paramTypeInfo{},
+	}
+
+	for i, strParam := range funcInfo.ParamList {
+		// 忽略掉冒号函数的self参数
+		if funcInfo.IsColon && i == 0 {
+			continue
+		}
+		infer.paramMap[strParam] = &paramTypeInfo{}
+	}
+
+	if funcExp == nil {
+		funcExp = findFuncDefExp(fileResult.Block, funcInfo.Loc)
+	}
+	if funcExp != nil {
+		ast.Walk(&inferVisitor{infer: infer, shadowMap: map[string]bool{}}, funcExp.Block)
+		commentInfo.ReturnVec = infer.inferReturnTypes(funcExp.Block)
+	}
+
+	for i, strParam := range funcInfo.ParamList {
+		if funcInfo.IsColon && i == 0 {
+			continue
+		}
+
+		commentInfo.ParamVec = append(commentInfo.ParamVec, FuncCommentParam{
+			Name:    strParam,
+			TypeStr: infer.paramMap[strParam].getTypeStr(),
+		})
+	}
+
+	return commentInfo
+}
+
+// isFuncAnnotated 函数定义的前面是否已经有了注解
+func isFuncAnnotated(fileResult *results.FileResult, line int) bool {
+	oneComment := fileResult.GetFileLineComment(line - 1)
+	if oneComment == nil || !oneComment.HeadFlag {
+		return false
+	}
+
+	for _, oneLine := range oneComment.LineVec {
+		if strings.HasPrefix(strings.TrimSpace(oneLine.Str), "-@") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetFileFuncComments 获取文件中所有没有注解的函数，并推导生成注解
+func (a *AllProject) GetFileFuncComments(strFile string) (commentVec []FileFuncComment) {
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil || fileStruct.FileResult.Block == nil {
+		return
+	}
+
+	fileResult := fileStruct.FileResult
+	funcInfoMap := map[lexer.Location]*common.FuncInfo{}
+	for _, funcInfo := range fileResult.FuncIDVec {
+		funcInfoMap[funcInfo.Loc] = funcInfo
+	}
+
+	walkFuncDefExp(fileResult.Block, func(statLoc lexer.Location, funcExp *ast.FuncDefExp) bool {
+		// 只处理直接定义在语句中的函数，匿名函数不处理
+		if statLoc.StartLine == 0 || isFuncAnnotated(fileResult, statLoc.StartLine) {
+			return true
+		}
+
+		funcInfo, ok := funcInfoMap[funcExp.Loc]
+		if !ok {
+			return true
+		}
+
+		commentInfo := a.getFuncCommentInfo(strFile, fileResult, funcInfo, funcExp)
+		if len(commentInfo.ParamVec) == 0 && len(commentInfo.ReturnVec) == 0 {
+			return true
+		}
+
+		commentVec = append(commentVec, FileFuncComment{
+			Loc:     statLoc,
+			Comment: commentInfo,
+		})
+		return true
+	})
+
+	return commentVec
+}
@@ -41,6 +41,12 @@ func (a *AllProject) GetLspHoverVarStr(strFile string, varStruct *common.DefineV
 		return
 	}
 
+	// 局部变量lua5.4的属性，显示在变量名的后面
+	strAttr := ""
+	if firstVar := findList[0].VarInfo; firstVar != nil && firstVar.ExtraGlobal == nil && len(varStruct.StrVec) == 1 {
+		strAttr = firstVar.GetAttrStr()
+	}
+
 	strOneComment := ""
 	strLastBefore := ""
 	strLastType := ""
@@ -72,6 +78,7 @@ func (a *AllProject) GetLspHoverVarStr(strFile string, varStruct *common.DefineV
 				}
 			}
 
+			lableStr = addLocalAttrStr(lableStr, varStruct.StrVec[0], strAttr)
 			docStr = strDoc1
 			luaFileStr = dirManager.RemovePathDirPre(oneSymbol.FileName)
 			return
@@ -104,10 +111,26 @@ func (a *AllProject) GetLspHoverVarStr(strFile string, varStruct *common.DefineV
 		}
 	}
 
+	lableStr = addLocalAttrStr(lableStr, varStruct.StrVec[0], strAttr)
 	docStr = strOneComment
 	return
 }
 
+// addLocalAttrStr 局部变量有lua5.4的属性时，在显示的变量名后面加上属性，例如local a <const> : number
+func addLocalAttrStr(lableStr, strName, strAttr string) string {
+	strPre := "local " + strName
+	if strAttr == "" || !strings.HasPrefix(lableStr, strPre) {
+		return lableStr
+	}
+
+	strLeft := strings.TrimPrefix(lableStr, strPre)
+	if strLeft != "" && strLeft[0] != ' ' {
+		return lableStr
+	}
+
+	return strPre + " " + strAttr + strLeft
+}
+
 func (a *AllProject) getVarHoverInfo(symbol *common.Symbol, varStruct *common.DefineVarStruct) (strType string,
 	strLable, strDoc, strPre string, findFlag bool) {
 	// 1) 首先提取注解类型
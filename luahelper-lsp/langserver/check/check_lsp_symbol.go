@@ -818,7 +818,8 @@ func goroutineFindSymbols(ch chan symbolsChan) {
 			break
 		}
 		a := request.sendAllProject
-		fileResult := a.fileStructMap[request.strfile].FileResult
+		fileStruct, _ := a.GetFirstFileStuct(request.strfile)
+		fileResult := fileStruct.FileResult
 		resultSorter := request.sendResultSorter
 		resultSorter.getQuerySymbols(fileResult)
 
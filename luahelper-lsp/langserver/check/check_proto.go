@@ -0,0 +1,290 @@
+package check
+
+import (
+	"io/ioutil"
+	"luahelper-lsp/langserver/check/annotation/annotateast"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/protoparser"
+	"luahelper-lsp/langserver/log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// protoScalarTypeMap protobuf的基础类型对应的注解类型
+var protoScalarTypeMap = map[string]string{
+	"double":   "number",
+	"float":    "number",
+	"int32":    "integer",
+	"int64":    "integer",
+	"uint32":   "integer",
+	"uint64":   "integer",
+	"sint32":   "integer",
+	"sint64":   "integer",
+	"fixed32":  "integer",
+	"fixed64":  "integer",
+	"sfixed32": "integer",
+	"sfixed64": "integer",
+	"bool":     "boolean",
+	"string":   "string",
+	"bytes":    "string",
+}
+
+// getProtoFileList 获取配置的所有.proto文件，配置的为文件夹时，包含文件夹下所有的.proto文件
+func getProtoFileList(pathList []string) (fileList []string) {
+	fileMap := map[string]struct{}{}
+	for _, strPath := range pathList {
+		filepath.Walk(strPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				log.Error("read proto path=%s err=%s", path, err.Error())
+				return nil
+			}
+
+			if info.IsDir() || filepath.Ext(path) != ".proto" {
+				return nil
+			}
+
+			path = filepath.ToSlash(path)
+			if _, ok := fileMap[path]; !ok {
+				fileMap[path] = struct{}{}
+				fileList = append(fileList, path)
+			}
+			return nil
+		})
+	}
+
+	return fileList
+}
+
+// resolveProtoTypeName 获取字段类型引用的message或enum在注解中的名称
+// 注解中的名称不包含package，嵌套的类型包含外层的名称，例如 .pkg.Outer.Inner 为Outer.Inner
+// strScope 为字段所在的message名称，引用的类型优先在message内部查找
+func resolveProtoTypeName(typeName, strScope, strPackage string, typeNameMap map[string]struct{}) string {
+	if strings.HasPrefix(typeName, ".") {
+		typeName = typeName[1:]
+	} else {
+		// 相对的名称，从内到外依次查找
+		for strScope != "" {
+			if _, ok := typeNameMap[strScope+"."+typeName]; ok {
+				return strScope + "." + typeName
+			}
+
+			index := strings.LastIndex(strScope, ".")
+			if index < 0 {
+				break
+			}
+			strScope = strScope[:index]
+		}
+	}
+
+	if _, ok := typeNameMap[typeName]; ok {
+		return typeName
+	}
+
+	// 去掉package前缀
+	if strPackage != "" && strings.HasPrefix(typeName, strPackage+".") {
+		return typeName[len(strPackage)+1:]
+	}
+
+	for strName := range typeNameMap {
+		if strings.HasSuffix(typeName, "."+strName) {
+			return strName
+		}
+	}
+
+	return typeName
+}
+
+// getProtoFieldType 获取message字段对应的注解类型
+func getProtoFieldType(field *protoparser.ProtoField, strScope, strPackage string,
+	typeNameMap map[string]struct{}) annotateast.Type {
+	getOneType := func(typeName string) annotateast.Type {
+		strName, ok := protoScalarTypeMap[typeName]
+		if !ok {
+			strName = resolveProtoTypeName(typeName, strScope, strPackage, typeNameMap)
+		}
+
+		return &annotateast.NormalType{
+			StrName: strName,
+		}
+	}
+
+	valueType := getOneType(field.TypeName)
+	if field.KeyType != "" {
+		return &annotateast.TableType{
+			KeyType:   getOneType(field.KeyType),
+			ValueType: valueType,
+		}
+	}
+
+	if field.Repeated {
+		return &annotateast.ArrayType{
+			ItemType: valueType,
+		}
+	}
+
+	return valueType
+}
+
+// createProtoAnnotateFile 把.proto文件中的message转换为注解的class，enum转换为integer的alias
+func createProtoAnnotateFile(protoFile string, result *protoparser.ProtoFile,
+	typeNameMap map[string]struct{}) *common.AnnotateFile {
+	annotateFile := common.CreateAnnotateFile(protoFile)
+	for _, message := range result.MessageList {
+		classInfo := &common.OneClassInfo{
+			LastLine: message.NameLoc.StartLine,
+			ClassState: &annotateast.AnnotateClassState{
+				Name:    message.Name,
+				NameLoc: message.NameLoc,
+				Comment: message.Comment,
+			},
+			FieldMap: map[string]*annotateast.AnnotateFieldState{},
+			LuaFile:  protoFile,
+		}
+
+		for _, field := range message.FieldList {
+			classInfo.FieldMap[field.Name] = &annotateast.AnnotateFieldState{
+				Name:      field.Name,
+				NameLoc:   field.NameLoc,
+				FiledType: getProtoFieldType(field, message.Name, result.Package, typeNameMap),
+				Comment:   field.Comment,
+			}
+		}
+
+		annotateFile.InsertCreateType(message.Name, &common.CreateTypeInfo{
+			LastLine:  message.NameLoc.StartLine,
+			ClassInfo: classInfo,
+		})
+	}
+
+	for _, enum := range result.EnumList {
+		strComment := enum.Comment
+		for _, enumValue := range enum.ValueList {
+			strComment = strings.TrimSpace(strComment + "\n" + enumValue.Name + " = " + enumValue.Number)
+		}
+
+		annotateFile.InsertCreateType(enum.Name, &common.CreateTypeInfo{
+			LastLine: enum.NameLoc.StartLine,
+			AliasInfo: &common.OneAliasInfo{
+				AliasState: &annotateast.AnnotateAliasState{
+					Name:    enum.Name,
+					NameLoc: enum.NameLoc,
+					AliasType: &annotateast.NormalType{
+						StrName: "integer",
+					},
+					Comment: strComment,
+				},
+				LuaFile: protoFile,
+			},
+		})
+	}
+
+	return annotateFile
+}
+
+// loadProtoFiles 解析配置的所有.proto文件，生成对应的注解信息
+func (a *AllProject) loadProtoFiles() {
+	time1 := time.Now()
+	a.protoAnnotateMap = map[string]*common.AnnotateFile{}
+
+	fileList := getProtoFileList(common.GConfig.GetProtoPaths())
+	if len(fileList) == 0 {
+		return
+	}
+
+	// 1) 先解析所有的文件，获取所有定义的类型名称，字段引用其他文件的类型时需要用到
+	resultMap := map[string]*protoparser.ProtoFile{}
+	typeNameMap := map[string]struct{}{}
+	for _, protoFile := range fileList {
+		content, err := ioutil.ReadFile(protoFile)
+		if err != nil {
+			log.Error("read proto file=%s err=%s", protoFile, err.Error())
+			continue
+		}
+
+		result := protoparser.ParseProto(string(content))
+		for _, oneErr := range result.ErrList {
+			log.Error("parse proto file=%s, line=%d, err=%s", protoFile, oneErr.Loc.StartLine, oneErr.ErrStr)
+		}
+
+		for _, message := range result.MessageList {
+			typeNameMap[message.Name] = struct{}{}
+		}
+		for _, enum := range result.EnumList {
+			typeNameMap[enum.Name] = struct{}{}
+		}
+		resultMap[protoFile] = result
+	}
+
+	// 2) 再转换为注解的信息
+	for protoFile, result := range resultMap {
+		a.protoAnnotateMap[protoFile] = createProtoAnnotateFile(protoFile, result, typeNameMap)
+	}
+
+	log.Debug("loadProtoFiles files num=%d, cost time=%d(ms)", len(resultMap), time.Since(time1).Milliseconds())
+}
+
+// getProtoMessageName 获取协议函数对应的message名称，没有同名的message时返回空
+// 例如 function c2s.LoginReq(msg) 中，msg的类型为同名的message LoginReq
+func (a *AllProject) getProtoMessageName(strName string) string {
+	for _, annotateFile := range a.protoAnnotateMap {
+		typeList, ok := annotateFile.CreateTypeMap[strName]
+		if ok && len(typeList.List) > 0 && typeList.List[0].ClassInfo != nil {
+			return strName
+		}
+	}
+
+	return ""
+}
+
+// getProtocolParamType 获取协议函数第一个参数对应的message类型
+// 协议函数为以ProtocolVars为前缀定义的最外层函数，例如 function c2s.LoginReq(msg)
+func (a *AllProject) getProtocolParamType(symbol *common.Symbol) (astType annotateast.Type) {
+	if len(a.protoAnnotateMap) == 0 || !symbol.VarInfo.IsParam {
+		return nil
+	}
+
+	fileResult := a.getFileAnalysis(symbol.FileName)
+	if fileResult == nil || fileResult.Block == nil {
+		return nil
+	}
+
+	fileConfig := common.GConfig.GetFileConfig(symbol.FileName)
+	for _, stat := range fileResult.Block.Stats {
+		assignStat, ok := stat.(*ast.AssignStat)
+		if !ok || len(assignStat.VarList) != 1 || len(assignStat.ExpList) != 1 {
+			continue
+		}
+
+		funcExp, ok := assignStat.ExpList[0].(*ast.FuncDefExp)
+		if !ok || !funcExp.Loc.IsInLocStruct(symbol.VarInfo.Loc.StartLine, symbol.VarInfo.Loc.StartColumn) {
+			continue
+		}
+
+		// 第一个参数，冒号函数为self之后的参数
+		paramIndex := 0
+		if funcExp.IsColon {
+			paramIndex = 1
+		}
+		if paramIndex >= len(funcExp.ParLocList) || funcExp.ParLocList[paramIndex] != symbol.VarInfo.Loc {
+			return nil
+		}
+
+		funcName, _, prefixExp := getFuncNameExpLoc(assignStat.VarList[0])
+		nameExp, ok := prefixExp.(*ast.NameExp)
+		if !ok || !fileConfig.IsStrProtocol(nameExp.Name) {
+			return nil
+		}
+
+		if strName := a.getProtoMessageName(funcName); strName != "" {
+			return &annotateast.NormalType{
+				StrName: strName,
+			}
+		}
+		return nil
+	}
+
+	return nil
+}
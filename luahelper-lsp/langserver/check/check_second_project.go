@@ -15,6 +15,7 @@ type SecondProjectChan struct {
 	sendRunFlag  bool                         // 是否继续运行
 	strFile      string                       // 需要处理的工程入口文件
 	allProject   *AllProject                  // 全局处理指针，方便所有到第一阶段的数据
+	sendSecond   *results.SingleProjectResult // 已经扫描了工程文件的第二阶段结果
 	returnSecond *results.SingleProjectResult // 协程的结果
 }
 
@@ -27,7 +28,7 @@ func goSecondProject(ch chan SecondProjectChan) {
 			break
 		}
 
-		analysisSecond := results.CreateAnalysisSecondProject(request.strFile)
+		analysisSecond := request.sendSecond
 		request.allProject.checkOneProject(analysisSecond)
 
 		chanResult := SecondProjectChan{
@@ -49,12 +50,32 @@ func (a *AllProject) recvSecondProject(projectChan SecondProjectChan) {
 }
 
 // 传人工程入口文件vec，对这些工程入口文件进行分析
-func (a *AllProject) handleProjectEntryFileVec(projectVec []string) {
+// affectMap 不为nil时进行增量分析，之前分析过的工程，只重新遍历affectMap中的文件以及新加入工程的文件
+func (a *AllProject) handleProjectEntryFileVec(projectVec []string, affectMap map[string]struct{}) {
 	vecLen := len(projectVec)
 	if vecLen == 0 {
 		return
 	}
 
+	// 第二阶段只使用第一阶段的摘要，由摘要扫描工程包含的文件，需要遍历AST的文件先进行完整的分析
+	a.summaryOnlyFlag = true
+	defer func() {
+		a.summaryOnlyFlag = false
+	}()
+
+	secondVec := make([]*results.SingleProjectResult, vecLen)
+	var traverseVec []string
+	for i, strEntryFile := range projectVec {
+		var oldSecond *results.SingleProjectResult
+		if affectMap != nil {
+			oldSecond = a.analysisSecondMap[strEntryFile]
+		}
+
+		secondVec[i] = a.prepareSecondProject(strEntryFile, oldSecond, affectMap)
+		traverseVec = append(traverseVec, secondVec[i].GetTraverseFiles()...)
+	}
+	a.loadSummaryFiles(traverseVec)
+
 	//获取本机核心数
 	corNum := runtime.NumCPU() + 2
 	if vecLen < corNum {
@@ -78,6 +99,7 @@ func (a *AllProject) handleProjectEntryFileVec(projectVec []string) {
 			sendRunFlag: true,
 			strFile:     projectVec[i],
 			allProject:  a,
+			sendSecond:  secondVec[i],
 		}
 		chs[i] <- chanRequest
 	}
@@ -100,6 +122,7 @@ func (a *AllProject) handleProjectEntryFileVec(projectVec []string) {
 				sendRunFlag: true,
 				strFile:     projectVec[recvNum+corNum],
 				allProject:  a,
+				sendSecond:  secondVec[recvNum+corNum],
 			}
 			chs[chosen] <- chanRequest
 		} else {
@@ -129,7 +152,7 @@ func (a *AllProject) HandleAllSecondProject() {
 	time1 := time.Now()
 
 	// 对所有的工程进行分析
-	a.handleProjectEntryFileVec(a.entryFilesList)
+	a.handleProjectEntryFileVec(a.entryFilesList, nil)
 
 	tc := time.Since(time1)
 	ftime := tc.Milliseconds()
@@ -242,7 +265,7 @@ func (a *AllProject) scanProjectAllFiles(second *results.SingleProjectResult, st
 	strFile = pathpre.GetRemovePreStr(strFile)
 	second.AllFiles[strFile] = struct{}{}
 
-	fileStruct, _ := a.GetFirstFileStuct(strFile)
+	fileStruct, _ := a.getFirstFileStuct(strFile)
 	if fileStruct == nil {
 		log.Error("second checkOneProject entryluafile:%s not exist", second.EntryFile)
 		return
@@ -269,6 +292,63 @@ func (a *AllProject) scanProjectAllFiles(second *results.SingleProjectResult, st
 	}
 }
 
+// getProjectEnterFiles 由第一阶段的引用信息，得到第二轮分析时按执行顺序依次进入的文件
+// 与第二轮遍历AST时跟进引用文件的顺序一致，只有第一次引用时才进入文件
+func (a *AllProject) getProjectEnterFiles(fileResult *results.FileResult, enterMap map[string]struct{},
+	enterVec []string) []string {
+	enterMap[fileResult.Name] = struct{}{}
+	enterVec = append(enterVec, fileResult.Name)
+	for _, referInfo := range fileResult.ReferVec {
+		referFile := a.GetFirstReferFileResult(referInfo)
+		if referFile == nil {
+			continue
+		}
+
+		if _, ok := enterMap[referFile.Name]; ok {
+			continue
+		}
+
+		enterVec = a.getProjectEnterFiles(referFile, enterMap, enterVec)
+	}
+
+	return enterVec
+}
+
+// prepareSecondProject 第二轮分析工程之前，由第一阶段的摘要扫描工程包含的文件，得到需要遍历AST的文件
+// oldSecond不为nil时进行增量分析，只重新遍历affectMap中的文件以及新加入工程的文件
+func (a *AllProject) prepareSecondProject(strEntryFile string, oldSecond *results.SingleProjectResult,
+	affectMap map[string]struct{}) *results.SingleProjectResult {
+	second := results.CreateAnalysisSecondProject(strEntryFile)
+	strFile := pathpre.GetRemovePreStr(strEntryFile)
+	fileStruct, _ := a.getFirstFileStuct(strFile)
+	if fileStruct == nil || fileStruct.HandleResult != results.FileHandleOk || fileStruct.FileResult == nil {
+		return second
+	}
+
+	// 加入插件客户端额外提供的文件夹文件
+	a.addClientExpLuaFile(second)
+
+	// 扫描工程中加载的所有文件
+	a.scanProjectAllFiles(second, strFile)
+
+	enterVec := a.getProjectEnterFiles(fileStruct.FileResult, map[string]struct{}{}, nil)
+	changeFileMap := map[string]struct{}{}
+	if oldSecond != nil {
+		for strOne := range affectMap {
+			changeFileMap[strOne] = struct{}{}
+		}
+		for strOne := range second.AllFiles {
+			if _, ok := oldSecond.AllFiles[strOne]; !ok {
+				changeFileMap[strOne] = struct{}{}
+			}
+		}
+	}
+
+	second.PrepareTraverse(enterVec, oldSecond, changeFileMap)
+	return second
+}
+
+// checkOneProject 第二轮分析单个工程，工程包含的文件以及需要遍历AST的文件已经由prepareSecondProject得到
 func (a *AllProject) checkOneProject(second *results.SingleProjectResult) {
 	log.Debug(" entryfile=%s", second.EntryFile)
 
@@ -292,12 +372,6 @@ func (a *AllProject) checkOneProject(second *results.SingleProjectResult) {
 		return
 	}
 
-	// 加入插件客户端额外提供的文件夹文件
-	a.addClientExpLuaFile(second)
-
-	// 扫描工程中加载的所有文件
-	a.scanProjectAllFiles(second, strFile)
-
 	//第一阶段进行完毕后，扫描所有文件第一阶段结构文件，一次性生成完整的_G的全局符号表
 	a.generateAllFristGlobalGMaps(second)
 
@@ -53,17 +53,20 @@ func recvThirdFile(third *results.AnalysisThird, thirdChan thirdFileChan) {
 	third.FileErrorMap[thirdChan.strFile] = thirdChan.returnThirdFile.FileResult.CheckErrVec
 }
 
-//  进行第三轮分析，散落的文件
-func (a *AllProject) handleFiles(third *results.AnalysisThird) {
-	var fileList []string
-	for strFile := range third.AllFile {
-		fileList = append(fileList, strFile)
-	}
+//  进行第三轮分析，散落的文件，fileList为需要遍历的文件列表
+func (a *AllProject) handleFiles(third *results.AnalysisThird, fileList []string) {
 	listLen := len(fileList)
 	if listLen == 0 {
 		return
 	}
 
+	// 第三阶段只遍历散落文件的AST，引用的文件使用第一阶段的摘要，需要遍历的文件先进行完整的分析
+	a.loadSummaryFiles(fileList)
+	a.summaryOnlyFlag = true
+	defer func() {
+		a.summaryOnlyFlag = false
+	}()
+
 	//获取本机核心数
 	corNum := runtime.NumCPU() + 2
 	if listLen < corNum {
@@ -139,7 +142,7 @@ func (a *AllProject) scanFileIncludeAllFiles(third *results.AnalysisThird, strFi
 	strFile = pathpre.GetRemovePreStr(strFile)
 	third.AllIncludeFile[strFile] = true
 
-	fileStruct, _ := a.GetFirstFileStuct(strFile)
+	fileStruct, _ := a.getFirstFileStuct(strFile)
 	if fileStruct == nil {
 		log.Error("third file include luafile:%s not exist", strFile)
 		return
@@ -238,10 +241,76 @@ func (a *AllProject) HandleNotCheckThirdFile() {
 // HandleAllThirdFile 进行第三轮分析，主要分析哪些不在工程目录的文件
 func (a *AllProject) HandleAllThirdFile() {
 	log.Debug("begin HandleAllThirdFile")
+	time1 := time.Now()
+
+	thirdStruct := a.createThirdStruct()
+	if len(thirdStruct.AllFile) == 0 {
+		log.Debug("allNotIncludeFile num is zore, return")
+		return
+	}
+
+	// 处理所有散落的文件,多协程的方式
+	var fileList []string
+	for strFile := range thirdStruct.AllFile {
+		fileList = append(fileList, strFile)
+	}
+	a.handleFiles(thirdStruct, fileList)
+
+	tc := time.Since(time1)
+	ftime := tc.Milliseconds()
+	log.Debug("handlethirdFiles, filenum:%d, cost time=%d(ms)", len(thirdStruct.AllFile), ftime)
+}
+
+// HandleChangeThirdFile 文件变化后，增量进行第三轮分析
+// 全局符号表重新生成，但只重新遍历受影响的散落文件，其他文件沿用之前的分析错误
+// 新加入或者不再是散落文件的，诊断信息也会变化，加入到affectMap中
+func (a *AllProject) HandleChangeThirdFile(affectMap map[string]struct{}) {
+	oldThird := a.thirdStruct
+	if oldThird == nil {
+		a.HandleAllThirdFile()
+		return
+	}
+
+	log.Debug("begin HandleChangeThirdFile")
+	time1 := time.Now()
 
+	thirdStruct := a.createThirdStruct()
+	var fileList []string
+	for strFile := range thirdStruct.AllFile {
+		_, affectFlag := affectMap[strFile]
+		_, oldFlag := oldThird.AllFile[strFile]
+		if !oldFlag {
+			affectMap[strFile] = struct{}{}
+		}
+
+		if affectFlag || !oldFlag {
+			fileList = append(fileList, strFile)
+			continue
+		}
+
+		if errVec, ok := oldThird.FileErrorMap[strFile]; ok {
+			thirdStruct.FileErrorMap[strFile] = errVec
+		}
+	}
+
+	for strFile := range oldThird.AllFile {
+		if _, ok := thirdStruct.AllFile[strFile]; !ok {
+			affectMap[strFile] = struct{}{}
+		}
+	}
+
+	a.handleFiles(thirdStruct, fileList)
+
+	tc := time.Since(time1)
+	ftime := tc.Milliseconds()
+	log.Debug("HandleChangeThirdFile, filenum:%d, handle num:%d, cost time=%d(ms)", len(thirdStruct.AllFile),
+		len(fileList), ftime)
+}
+
+// createThirdStruct 找出所有散落的文件，生成第三阶段的全局符号表
+func (a *AllProject) createThirdStruct() *results.AnalysisThird {
 	// 清空一些东西
 	a.thirdStruct = nil
-	time1 := time.Now()
 
 	// 首先找出哪些不在任何工程分析中的文件
 	// 所有已经被工程分析过的文件
@@ -269,8 +338,7 @@ func (a *AllProject) HandleAllThirdFile() {
 
 	log.Debug("allIncludeFile num: %d, allNotIncludeFile: %d", len(allProjectIncludeFile), len(thirdStruct.AllFile))
 	if len(thirdStruct.AllFile) == 0 {
-		log.Debug("allNotIncludeFile num is zore, return")
-		return
+		return thirdStruct
 	}
 
 	// 所有散落的文件，组成一个新的符号表，_G的符号表
@@ -284,13 +352,7 @@ func (a *AllProject) HandleAllThirdFile() {
 	// 遍历包括所有的引用加载文件，处理require("one") 放入到全局变量中
 	// 只有当为服务器强依赖校验时候，才需要加入进来
 	a.generateAllGlobalMaps(thirdStruct)
-
-	// 处理所有散落的文件,多协程的方式
-	a.handleFiles(thirdStruct)
-
-	tc := time.Since(time1)
-	ftime := tc.Milliseconds()
-	log.Debug("handlethirdFiles, filenum:%d, cost time=%d(ms)", len(thirdStruct.AllFile), ftime)
+	return thirdStruct
 }
 
 // 客户端的模式，文件没有依赖关系，把所有文件的全局符号，导入进来(包括_G的符号)
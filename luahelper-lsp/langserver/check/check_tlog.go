@@ -0,0 +1,279 @@
+package check
+
+import (
+	"io/ioutil"
+	"luahelper-lsp/langserver/check/annotation/annotateast"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/tlogparser"
+	"luahelper-lsp/langserver/codingconv"
+	"luahelper-lsp/langserver/log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tlogTypeMap tlog字段的类型对应的注解类型，其他的类型为any
+var tlogTypeMap = map[string]string{
+	"tinyint":   "integer",
+	"tinyuint":  "integer",
+	"smallint":  "integer",
+	"smalluint": "integer",
+	"int":       "integer",
+	"uint":      "integer",
+	"bigint":    "integer",
+	"biguint":   "integer",
+	"long":      "integer",
+	"ulong":     "integer",
+	"byte":      "integer",
+	"char":      "integer",
+	"uchar":     "integer",
+	"float":     "number",
+	"double":    "number",
+	"string":    "string",
+	"wstring":   "string",
+	"datetime":  "string",
+	"date":      "string",
+	"time":      "string",
+	"ip":        "string",
+}
+
+// createTlogAnnotateFile 把tlog xml中的struct转换为注解的class，entry转换为class的field
+func createTlogAnnotateFile(xmlFile string, meta *tlogparser.TlogMeta) *common.AnnotateFile {
+	annotateFile := common.CreateAnnotateFile(xmlFile)
+	for _, tlogStruct := range meta.StructList {
+		classInfo := &common.OneClassInfo{
+			LastLine: tlogStruct.NameLoc.StartLine,
+			ClassState: &annotateast.AnnotateClassState{
+				Name:    tlogStruct.Name,
+				NameLoc: tlogStruct.NameLoc,
+				Comment: tlogStruct.Desc,
+			},
+			FieldMap: map[string]*annotateast.AnnotateFieldState{},
+			LuaFile:  xmlFile,
+		}
+
+		for _, entry := range tlogStruct.EntryList {
+			strType, ok := tlogTypeMap[strings.ToLower(entry.Type)]
+			if !ok {
+				strType = "any"
+			}
+
+			classInfo.FieldMap[entry.Name] = &annotateast.AnnotateFieldState{
+				Name:    entry.Name,
+				NameLoc: entry.NameLoc,
+				FiledType: &annotateast.NormalType{
+					StrName: strType,
+				},
+				Comment: entry.Desc,
+			}
+		}
+
+		annotateFile.InsertCreateType(tlogStruct.Name, &common.CreateTypeInfo{
+			LastLine:  tlogStruct.NameLoc.StartLine,
+			ClassInfo: classInfo,
+		})
+	}
+
+	return annotateFile
+}
+
+// tlogXMLInfo 一个tlog xml文件解析后的信息
+type tlogXMLInfo struct {
+	structMap    map[string]*tlogparser.TlogStruct // xml中定义的struct，key值为struct名称
+	annotateFile *common.AnnotateFile              // xml中struct生成的注解信息
+}
+
+// loadTlogXML 解析主工程以及各工作区文件夹配置的tlog xml文件，生成struct的信息以及对应的注解信息
+func (a *AllProject) loadTlogXML() {
+	time1 := time.Now()
+	a.tlogXMLMap = map[string]*tlogXMLInfo{}
+
+	for _, xmlFile := range common.GConfig.GetTlogXMLPaths() {
+		content, err := ioutil.ReadFile(xmlFile)
+		if err != nil {
+			log.Error("read tlog xml file=%s err=%s", xmlFile, err.Error())
+			continue
+		}
+
+		// tlog的xml文件常为gbk编码，统一转换为utf8
+		meta, err := tlogparser.ParseTlogXML([]byte(codingconv.ConvertStrToUtf8(string(content))))
+		if err != nil {
+			log.Error("parse tlog xml file=%s err=%s", xmlFile, err.Error())
+		}
+
+		xmlInfo := &tlogXMLInfo{
+			structMap:    map[string]*tlogparser.TlogStruct{},
+			annotateFile: createTlogAnnotateFile(xmlFile, meta),
+		}
+		for _, tlogStruct := range meta.StructList {
+			xmlInfo.structMap[tlogStruct.Name] = tlogStruct
+		}
+		a.tlogXMLMap[xmlFile] = xmlInfo
+
+		log.Debug("loadTlogXML file=%s, struct num=%d", xmlFile, len(xmlInfo.structMap))
+	}
+
+	log.Debug("loadTlogXML xml num=%d, cost time=%d(ms)", len(a.tlogXMLMap), time.Since(time1).Milliseconds())
+}
+
+// getFileTlogXML 获取lua文件所使用的配置中的tlog xml文件，以及解析后的信息，没有配置或是没有加载成功时info为nil
+func (a *AllProject) getFileTlogXML(strFile string) (xmlFile string, info *tlogXMLInfo) {
+	xmlFile = common.GConfig.GetFileConfig(strFile).TlogXMLPath
+	if xmlFile == "" {
+		return "", nil
+	}
+
+	return xmlFile, a.tlogXMLMap[xmlFile]
+}
+
+// GetTlogStruct 获取lua文件配置的tlog xml中定义的struct，loadFlag表示是否加载了tlog xml
+func (a *AllProject) GetTlogStruct(strFile string, strName string) (tlogStruct *tlogparser.TlogStruct, loadFlag bool) {
+	_, xmlInfo := a.getFileTlogXML(strFile)
+	if xmlInfo == nil {
+		return nil, false
+	}
+
+	return xmlInfo.structMap[strName], true
+}
+
+// tlogCallFinder 查找包含指定位置的打tlog日志的函数调用
+type tlogCallFinder struct {
+	fileConfig *common.GlobalConfig
+	posLine    int // 行号，从1开始
+	posCh      int // 列号，从0开始
+	strExp     *ast.StringExp
+	tableExp   *ast.TableConstructorExp
+}
+
+// Visit 遍历节点，只进入包含指定位置的函数定义与函数调用，内层的函数调用覆盖外层的
+func (f *tlogCallFinder) Visit(node ast.Node) ast.Visitor {
+	switch subNode := node.(type) {
+	case *ast.FuncDefExp:
+		if !subNode.Loc.IsInLocStruct(f.posLine, f.posCh) {
+			return nil
+		}
+	case *ast.FuncCallExp:
+		if !subNode.Loc.IsInLocStruct(f.posLine, f.posCh) {
+			return nil
+		}
+
+		if strExp, tableExp, ok := f.fileConfig.GetTlogCallArgs(subNode); ok {
+			f.strExp = strExp
+			f.tableExp = tableExp
+		}
+	}
+
+	return f
+}
+
+// findTlogCall 查找文件中包含指定位置的最内层的打tlog日志的函数调用，posLine从1开始
+func (a *AllProject) findTlogCall(strFile string, posLine, posCh int) (strExp *ast.StringExp,
+	tableExp *ast.TableConstructorExp) {
+	if _, xmlInfo := a.getFileTlogXML(strFile); xmlInfo == nil {
+		return
+	}
+
+	fileStruct := a.getVailidCacheFileStruct(strFile)
+	if fileStruct == nil || fileStruct.FileResult == nil {
+		return
+	}
+
+	finder := &tlogCallFinder{
+		fileConfig: common.GConfig.GetFileConfig(strFile),
+		posLine:    posLine,
+		posCh:      posCh,
+	}
+	ast.Walk(finder, fileStruct.FileResult.Block)
+	return finder.strExp, finder.tableExp
+}
+
+// getTlogCallClassInfo 获取函数调用的实参为打tlog日志的字段table时，struct对应的注解class
+// 例如 tlog("PlayerLogin", { | }) 中table期望的类型为PlayerLogin
+func (a *AllProject) getTlogCallClassInfo(strFile string, ctx *tableConstructorCtx) *common.OneClassInfo {
+	_, xmlInfo := a.getFileTlogXML(strFile)
+	if xmlInfo == nil || ctx.argIndex != 1 {
+		return nil
+	}
+
+	strExp, _, ok := common.GConfig.GetFileConfig(strFile).GetTlogCallArgs(ctx.callExp)
+	if !ok {
+		return nil
+	}
+
+	typeList, ok := xmlInfo.annotateFile.CreateTypeMap[strExp.Str]
+	if !ok || len(typeList.List) == 0 {
+		return nil
+	}
+
+	return typeList.List[0].ClassInfo
+}
+
+// TlogStructComplete 光标在打tlog日志的函数调用的第一个参数字符串中时，补全文件配置的tlog xml中所有的struct名称
+func (a *AllProject) TlogStructComplete(strFile string) {
+	_, xmlInfo := a.getFileTlogXML(strFile)
+	if xmlInfo == nil {
+		return
+	}
+
+	annotateFile := xmlInfo.annotateFile
+	nameVec := make([]string, 0, len(annotateFile.CreateTypeMap))
+	for strName := range annotateFile.CreateTypeMap {
+		nameVec = append(nameVec, strName)
+	}
+	sort.Strings(nameVec)
+
+	for _, strName := range nameVec {
+		typeList := annotateFile.CreateTypeMap[strName]
+		if len(typeList.List) > 0 {
+			a.completeCache.InsertCompleteInnotateType(strName, typeList.List[0])
+		}
+	}
+}
+
+// FindTlogDefine 查找打tlog日志的函数调用中，struct名称或是字段名称在tlog xml中的定义位置
+// 例如 tlog("PlayerLogin", { GameSvrId = 1 }) 中的PlayerLogin或是GameSvrId，posLine从0开始
+func (a *AllProject) FindTlogDefine(strFile string, posLine, posCh int) (defineVecs []DefineStruct) {
+	strExp, tableExp := a.findTlogCall(strFile, posLine+1, posCh)
+	if strExp == nil {
+		return
+	}
+
+	xmlFile, xmlInfo := a.getFileTlogXML(strFile)
+	if xmlInfo == nil {
+		return
+	}
+
+	tlogStruct := xmlInfo.structMap[strExp.Str]
+	if tlogStruct == nil {
+		return
+	}
+
+	if strExp.Loc.IsInLocStruct(posLine+1, posCh) {
+		return append(defineVecs, DefineStruct{
+			StrFile: xmlFile,
+			Loc:     tlogStruct.NameLoc,
+		})
+	}
+
+	if tableExp == nil {
+		return
+	}
+
+	for _, keyExp := range tableExp.KeyExps {
+		strKey, ok := keyExp.(*ast.StringExp)
+		if !ok || !strKey.Loc.IsInLocStruct(posLine+1, posCh) {
+			continue
+		}
+
+		if entry := tlogStruct.FindEntry(strKey.Str); entry != nil {
+			defineVecs = append(defineVecs, DefineStruct{
+				StrFile: xmlFile,
+				Loc:     entry.NameLoc,
+			})
+		}
+		return
+	}
+
+	return
+}
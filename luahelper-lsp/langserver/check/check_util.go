@@ -64,8 +64,23 @@ func (a *AllProject) copyFileErr(strFile string, fileError []common.CheckError,
 
 // GetAllFileErrorInfo 获取所有检测的错误返回，按类型来排
 func (a *AllProject) GetAllFileErrorInfo() map[string][]common.CheckError {
+	return a.GetFilesErrorInfo(nil)
+}
+
+// GetFilesErrorInfo 获取指定文件的检测错误，fileMap为nil时获取所有的文件
+func (a *AllProject) GetFilesErrorInfo(fileMap map[string]struct{}) map[string][]common.CheckError {
 	fileErrorMap := make(map[string][]common.CheckError)
 
+	// 判断文件是否需要获取
+	isNeedFile := func(strFile string) bool {
+		if fileMap == nil {
+			return true
+		}
+
+		_, ok := fileMap[strFile]
+		return ok
+	}
+
 	// 所有分析文件去重的信息map
 	onlyStrFileMap := make(map[string](map[string]bool))
 
@@ -81,6 +96,10 @@ func (a *AllProject) GetAllFileErrorInfo() map[string][]common.CheckError {
 
 	// 1) 获取第一阶段的错误
 	for strFile, fileStruct := range a.fileStructMap {
+		if !isNeedFile(strFile) {
+			continue
+		}
+
 		if fileStruct.HandleResult == results.FileHandleReadErr {
 			log.Error("read file:%s error", strFile)
 			continue
@@ -113,7 +132,7 @@ func (a *AllProject) GetAllFileErrorInfo() map[string][]common.CheckError {
 	// 2) 获取第二阶段的错误
 	for _, analysisSecond := range a.analysisSecondMap {
 		for strFile, fileAnalsisError := range analysisSecond.FileErrorMap {
-			if len(fileAnalsisError) == 0 {
+			if len(fileAnalsisError) == 0 || !isNeedFile(strFile) {
 				continue
 			}
 
@@ -125,7 +144,7 @@ func (a *AllProject) GetAllFileErrorInfo() map[string][]common.CheckError {
 	if a.thirdStruct != nil {
 		// 3) 获取第三阶段的错误
 		for strFile, fileAnalsisError := range a.thirdStruct.FileErrorMap {
-			if len(fileAnalsisError) == 0 {
+			if len(fileAnalsisError) == 0 || !isNeedFile(strFile) {
 				continue
 			}
 
@@ -253,8 +272,19 @@ func (a *AllProject) setCheckTerm(checkTerm results.CheckTerm) {
 	a.checkTerm = checkTerm
 }
 
-// GetFirstFileStuct 获取第一阶段文件处理的结果
+// GetFirstFileStuct 获取第一阶段文件处理的结果，若结果只是磁盘缓存的摘要，先进行完整的分析
+// 第二、三阶段分析过程中直接返回摘要，只有全局变量、引用、函数签名、注解这些信息
 func (a *AllProject) GetFirstFileStuct(strFile string) (*results.FileStruct, bool) {
+	fileStruct, ok := a.getFirstFileStuct(strFile)
+	if ok && !a.summaryOnlyFlag {
+		a.loadSummaryFileStruct(fileStruct)
+	}
+
+	return fileStruct, ok
+}
+
+// getFirstFileStuct 获取第一阶段文件处理的结果，不处理磁盘缓存的摘要
+func (a *AllProject) getFirstFileStuct(strFile string) (*results.FileStruct, bool) {
 	if a.checkTerm == results.CheckTermFirst {
 		a.fileStructMutex.Lock()
 		defer a.fileStructMutex.Unlock()
@@ -279,8 +309,8 @@ func (a *AllProject) GetReferFrameType(referInfo *common.ReferInfo) (subReferTyp
 		return subReferType
 	}
 
-	// 如果是框架子定义的引入方式
-	subReferType = common.GConfig.GetReferFrameSubType(referInfo.ReferTypeStr)
+	// 如果是框架子定义的引入方式，多个工作区文件夹时按引用所在文件的配置获取
+	subReferType = common.GConfig.GetFileConfig(referInfo.StrFile).GetReferFrameSubType(referInfo.ReferTypeStr)
 	if subReferType != common.RtypeAuto {
 		return subReferType
 	}
@@ -556,6 +556,11 @@ func (af *AnnotateFile) insertNewType(name string, oneTypeInfo *CreateTypeInfo)
 	}
 }
 
+// InsertCreateType 插入一个不是由注释产生的新类型，例如.proto文件中的message
+func (af *AnnotateFile) InsertCreateType(name string, oneTypeInfo *CreateTypeInfo) {
+	af.insertNewType(name, oneTypeInfo)
+}
+
 // 这个文件的所有注释块依据行号，提取这个文件的所有符号
 func (af *AnnotateFile) generateNewType() {
 	for _, fragment := range af.sortFragement.results {
@@ -22,6 +22,10 @@ type LRUCache struct {
 	dlist    *list.List
 	cacheMap map[interface{}]*list.Element
 	cacheMutex sync.Mutex
+
+	// 命中与未命中的次数统计
+	hitNum  uint64
+	missNum uint64
 }
 
 // NewLRUCache 创建一个整体封装结构指针
@@ -79,11 +83,20 @@ func (lru *LRUCache) Get(k interface{}) (v interface{}, ret bool, err error) {
 
 	if pElement, ok := lru.cacheMap[k]; ok {
 		lru.dlist.MoveToFront(pElement)
+		lru.hitNum++
 		return pElement.Value.(*CacheNode).Value, true, nil
 	}
+	lru.missNum++
 	return v, false, nil
 }
 
+// GetHitStat 返回命中与未命中的次数
+func (lru *LRUCache) GetHitStat() (hitNum, missNum uint64) {
+	lru.cacheMutex.Lock()
+	defer lru.cacheMutex.Unlock()
+	return lru.hitNum, lru.missNum
+}
+
 // Remove 删除节点
 func (lru *LRUCache) Remove(k interface{}) bool {
 	lru.cacheMutex.Lock()
@@ -2,6 +2,7 @@ package common
 
 import (
 	"luahelper-lsp/langserver/check/annotation/annotateast"
+	"strings"
 )
 
 /*
@@ -46,6 +47,9 @@ type OneCompleteData struct {
 	FieldColonFlag	annotateast.FieldColonType		// 当为FieldState时候，是否为：函数
 	//IncludeSelfParam bool	// 当为VarInfo时候，是否补充第一个参数为self
 	CreateTypeInfo *CreateTypeInfo
+	ReferStr       string // 自动引入其他文件的补全，选中后需要插入的引入语句
+	ReferLine      int    // 引入语句插入的行，从0开始
+	SnippetFlag    bool   // InsetText是否为带有参数占位符的代码片段
 }
 
 // CompleteCache 缓存所有的补全信息
@@ -217,6 +221,65 @@ func (cache *CompleteCache) InsertCompleteNormal(label, detail, documentation st
 	cache.existMap[label] = len(cache.dataList) - 1
 }
 
+// InsertCompleteRefer 插入自动引入其他文件的补全，选中后插入insertText，同时在referLine行插入referStr引入语句
+// insertText为空时插入label
+func (cache *CompleteCache) InsertCompleteRefer(luaFile, label, insertText, referStr string, referLine int,
+	kind ItemKind) {
+	oneComplete := OneCompleteData{
+		Label:         label,
+		InsetText:     insertText,
+		Detail:        strings.TrimSpace(referStr),
+		Documentation: "auto require",
+		LuaFile:       luaFile,
+		Kind:          kind,
+		CacheKind:     CKindNormal,
+		ReferStr:      referStr,
+		ReferLine:     referLine,
+	}
+	cache.dataList = append(cache.dataList, oneComplete)
+	cache.existMap[label] = len(cache.dataList) - 1
+}
+
+// InsertCompleteTableKey 插入table构造中key的补全，为期望的class的field，选中后插入 key =
+func (cache *CompleteCache) InsertCompleteTableKey(luaFile, label string, field *annotateast.AnnotateFieldState) {
+	oneComplete := OneCompleteData{
+		Label:          label,
+		InsetText:      label + " = ",
+		LuaFile:        luaFile,
+		Kind:           IKField,
+		FieldState:     field,
+		CacheKind:      CkindClassField,
+		FieldColonFlag: annotateast.FieldColonNo,
+	}
+	cache.dataList = append(cache.dataList, oneComplete)
+	cache.existMap[label] = len(cache.dataList) - 1
+}
+
+// InsertCompleteSnippet 插入函数重载的补全，与函数同名，选中后插入重载参数的代码片段
+func (cache *CompleteCache) InsertCompleteSnippet(luaFile, label, insertText, detail, documentation string) {
+	oneComplete := OneCompleteData{
+		Label:         label,
+		InsetText:     insertText,
+		Detail:        detail,
+		Documentation: documentation,
+		LuaFile:       luaFile,
+		Kind:          IKFunction,
+		CacheKind:     CKindNormal,
+		SnippetFlag:   true,
+	}
+	cache.dataList = append(cache.dataList, oneComplete)
+}
+
+// SetItemSnippet 设置已有的补全项选中后插入的代码片段
+func (cache *CompleteCache) SetItemSnippet(index int, insertText string) {
+	if index < 0 || index >= len(cache.dataList) {
+		return
+	}
+
+	cache.dataList[index].InsetText = insertText
+	cache.dataList[index].SnippetFlag = true
+}
+
 // InsertCompleteInnotateType 插入关键字的代码补全
 func (cache *CompleteCache) InsertCompleteInnotateType(label string, creatType *CreateTypeInfo) {
 	oneComplete := OneCompleteData{
@@ -0,0 +1,510 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"luahelper-lsp/langserver/codingconv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// luahelper.json可以通过extends继承其他的配置文件，例如多个仓库共用一份基础的配置
+// 子目录下也可以放置luahelper.json，覆盖上层目录的配置，只对子目录下的文件生效
+// 读取配置文件时会校验配置的内容，未知的配置项、类型错误、非法的正则表达式都会作为配置文件的诊断信息推送给客户端
+
+const (
+	// configExtendsKey 继承其他配置文件的配置项，值为一个路径或是路径数组，相对于配置文件所在的目录
+	configExtendsKey = "extends"
+
+	// configSchemaKey 编辑器关联json schema的配置项，读取时忽略
+	configSchemaKey = "$schema"
+)
+
+// rootOnlyConfigKeys 只在工程或工作区文件夹根目录的配置文件中生效的配置项
+var rootOnlyConfigKeys = map[string]bool{
+	"BaseDir":      true,
+	"ProjectFiles": true,
+	"LinkFolders":  true,
+	"ProtoPaths":   true,
+	"tlogXmlPath":  true,
+}
+
+// deprecatedConfigKeys 老版本的配置项，已经不再使用
+var deprecatedConfigKeys = map[string]bool{
+	"ShareSymbolsFlag": true,
+	"GvalTipFlag":      true,
+}
+
+// rawJSONConfig 未解析的配置内容，key为配置项的名称
+type rawJSONConfig map[string]json.RawMessage
+
+// mergeRawConfig 合并配置的内容，child中的配置项覆盖base中的
+func mergeRawConfig(base, child rawJSONConfig) rawJSONConfig {
+	mergeConfig := rawJSONConfig{}
+	for key, value := range base {
+		mergeConfig[key] = value
+	}
+
+	for key, value := range child {
+		mergeConfig[key] = value
+	}
+
+	return mergeConfig
+}
+
+// parseRawJSONConfig 把合并后的配置内容解析为JSONConfig
+func parseRawJSONConfig(rawConfig rawJSONConfig, configFileName string) (*JSONConfig, error) {
+	bytes, err := json.Marshal(rawConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseJSONConfig(bytes, configFileName)
+}
+
+// configKeyInfo 配置文件中的一个顶层配置项，以及配置项在文件中的偏移
+type configKeyInfo struct {
+	name       string
+	value      json.RawMessage
+	keyStart   int
+	keyEnd     int
+	valueStart int
+	valueEnd   int
+}
+
+// scanConfigKeys 按顺序获取配置文件中所有顶层的配置项
+func scanConfigKeys(data []byte) (keyVec []configKeyInfo, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, errors.New("the top level value must be an object")
+	}
+
+	for decoder.More() {
+		if token, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+
+		oneKey := configKeyInfo{}
+		oneKey.name, _ = token.(string)
+		oneKey.keyEnd = int(decoder.InputOffset())
+		if oneKey.keyStart = bytes.LastIndexByte(data[:oneKey.keyEnd-1], '"'); oneKey.keyStart < 0 {
+			oneKey.keyStart = 0
+		}
+
+		if err = decoder.Decode(&oneKey.value); err != nil {
+			return nil, err
+		}
+		oneKey.valueEnd = int(decoder.InputOffset())
+		oneKey.valueStart = oneKey.valueEnd - len(oneKey.value)
+		keyVec = append(keyVec, oneKey)
+	}
+
+	if _, err = decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	return keyVec, nil
+}
+
+// offsetToLocation 把文件内容的偏移转换为行列的位置
+func offsetToLocation(data []byte, start, end int) lexer.Location {
+	if end <= start && start < len(data) {
+		end = start + 1
+	}
+
+	lineColumn := func(offset int) (int, int) {
+		if offset > len(data) {
+			offset = len(data)
+		}
+
+		// 列按字符计数，与语法分析的位置一致
+		line := bytes.Count(data[:offset], []byte("\n")) + 1
+		column := utf8.RuneCount(data[bytes.LastIndexByte(data[:offset], '\n')+1 : offset])
+		return line, column
+	}
+
+	loc := lexer.Location{}
+	loc.StartLine, loc.StartColumn = lineColumn(start)
+	loc.EndLine, loc.EndColumn = lineColumn(end)
+	return loc
+}
+
+// getJSONConfigFields 获取JSONConfig所有配置项的名称与对应的字段
+func getJSONConfigFields() map[string]reflect.StructField {
+	fieldMap := map[string]reflect.StructField{}
+	configType := reflect.TypeOf(JSONConfig{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		fieldMap[field.Tag.Get("json")] = field
+	}
+
+	return fieldMap
+}
+
+// getEditDistance 获取两个字符串的编辑距离
+func getEditDistance(strA, strB string) int {
+	lastRow := make([]int, len(strB)+1)
+	for j := range lastRow {
+		lastRow[j] = j
+	}
+
+	for i := 1; i <= len(strA); i++ {
+		curRow := make([]int, len(strB)+1)
+		curRow[0] = i
+		for j := 1; j <= len(strB); j++ {
+			cost := 1
+			if strA[i-1] == strB[j-1] {
+				cost = 0
+			}
+
+			curRow[j] = minInt(minInt(lastRow[j]+1, curRow[j-1]+1), lastRow[j-1]+cost)
+		}
+		lastRow = curRow
+	}
+
+	return lastRow[len(strB)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// getSimilarConfigKey 获取与未知配置项最相近的配置项，没有相近的返回空
+func getSimilarConfigKey(strName string, fieldMap map[string]reflect.StructField) string {
+	bestName := ""
+	bestDistance := len(strName)/3 + 1
+	if bestDistance < 3 {
+		bestDistance = 3
+	}
+
+	for fieldName := range fieldMap {
+		distance := getEditDistance(strings.ToLower(strName), strings.ToLower(fieldName))
+		if distance < bestDistance || (distance == bestDistance && fieldName < bestName) {
+			bestDistance = distance
+			bestName = fieldName
+		}
+	}
+
+	return bestName
+}
+
+// configLoader 读取一个配置文件以及它继承的所有配置文件，收集所有配置文件的诊断信息
+type configLoader struct {
+	fileVec    []string                // 读取到的所有配置文件，包含继承的配置文件
+	errMap     map[string][]CheckError // 每个配置文件的诊断信息
+	loadingMap map[string]bool         // 正在读取的配置文件，用于检测循环继承
+}
+
+// createConfigLoader 创建配置文件的读取对象
+func createConfigLoader() *configLoader {
+	return &configLoader{
+		fileVec:    []string{},
+		errMap:     map[string][]CheckError{},
+		loadingMap: map[string]bool{},
+	}
+}
+
+// addError 增加配置文件的一个诊断信息，start和end为出错内容在文件中的偏移
+func (c *configLoader) addError(strPath string, data []byte, start, end int, errStr string) {
+	c.errMap[strPath] = append(c.errMap[strPath], CheckError{
+		ErrType: CheckErrorConfig,
+		ErrStr:  errStr,
+		Loc:     offsetToLocation(data, start, end),
+	})
+}
+
+// loadFile 读取一个配置文件，返回合并了继承的配置后的内容
+// nestedFlag 表示是否为子目录下的配置文件，子目录的配置文件中只在根目录生效的配置项会告警
+func (c *configLoader) loadFile(strPath string, nestedFlag bool) (rawJSONConfig, error) {
+	data, err := ioutil.ReadFile(strPath)
+	if err != nil {
+		return nil, err
+	}
+	c.fileVec = append(c.fileVec, strPath)
+
+	keyVec, err := scanConfigKeys(data)
+	if err != nil {
+		offset := 0
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			offset = int(syntaxErr.Offset)
+		}
+		c.addError(strPath, data, offset, offset, "json format error: "+err.Error())
+		return nil, err
+	}
+
+	c.loadingMap[strPath] = true
+	defer delete(c.loadingMap, strPath)
+
+	fieldMap := getJSONConfigFields()
+	baseConfig := rawJSONConfig{}
+	rawConfig := rawJSONConfig{}
+	for _, oneKey := range keyVec {
+		if oneKey.name == configSchemaKey {
+			continue
+		}
+
+		if oneKey.name == configExtendsKey {
+			baseConfig = mergeRawConfig(baseConfig, c.loadExtends(strPath, data, &oneKey))
+			continue
+		}
+
+		if deprecatedConfigKeys[oneKey.name] {
+			errStr := fmt.Sprintf("key \"%s\" is no longer used and can be removed", oneKey.name)
+			c.addError(strPath, data, oneKey.keyStart, oneKey.keyEnd, errStr)
+			continue
+		}
+
+		strName := oneKey.name
+		field, ok := fieldMap[strName]
+		if !ok {
+			similarName := getSimilarConfigKey(strName, fieldMap)
+			if similarName == "" {
+				c.addError(strPath, data, oneKey.keyStart, oneKey.keyEnd, fmt.Sprintf("unknown key \"%s\"", strName))
+				continue
+			}
+
+			if !strings.EqualFold(similarName, strName) {
+				errStr := fmt.Sprintf("unknown key \"%s\", did you mean \"%s\"?", strName, similarName)
+				c.addError(strPath, data, oneKey.keyStart, oneKey.keyEnd, errStr)
+				continue
+			}
+
+			// 大小写不一致的配置项，json解析时也能匹配上，提示后仍然生效
+			errStr := fmt.Sprintf("key \"%s\" should be written as \"%s\"", strName, similarName)
+			c.addError(strPath, data, oneKey.keyStart, oneKey.keyEnd, errStr)
+			strName = similarName
+			field = fieldMap[strName]
+		}
+
+		if nestedFlag && rootOnlyConfigKeys[strName] {
+			errStr := fmt.Sprintf("key \"%s\" only takes effect in the root luahelper.json", strName)
+			c.addError(strPath, data, oneKey.keyStart, oneKey.keyEnd, errStr)
+			continue
+		}
+
+		if !c.checkValueType(strPath, data, &oneKey, strName, field.Type) {
+			continue
+		}
+
+		c.checkValueRegexp(strPath, data, &oneKey, strName)
+		c.checkValueEncoding(strPath, data, &oneKey, strName)
+		c.checkValueSeverity(strPath, data, &oneKey, strName)
+		rawConfig[strName] = oneKey.value
+	}
+
+	return mergeRawConfig(baseConfig, rawConfig), nil
+}
+
+// loadExtends 读取继承的配置文件，多个继承的配置文件按顺序合并，后面的覆盖前面的
+func (c *configLoader) loadExtends(strPath string, data []byte, oneKey *configKeyInfo) rawJSONConfig {
+	var extendsVec []string
+	var oneExtends string
+	if err := json.Unmarshal(oneKey.value, &oneExtends); err == nil {
+		extendsVec = []string{oneExtends}
+	} else if err := json.Unmarshal(oneKey.value, &extendsVec); err != nil {
+		errStr := fmt.Sprintf("%s: wrong type, expected string or array of string", configExtendsKey)
+		c.addError(strPath, data, oneKey.valueStart, oneKey.valueEnd, errStr)
+		return nil
+	}
+
+	baseConfig := rawJSONConfig{}
+	strDir := filepath.Dir(strPath)
+	for _, oneExtends := range extendsVec {
+		start, end := findValueString(data, oneKey, oneExtends)
+		extendsPath := getConfigRelativePath(strDir, oneExtends)
+		if c.loadingMap[extendsPath] {
+			c.addError(strPath, data, start, end, fmt.Sprintf("extends \"%s\": circular reference", oneExtends))
+			continue
+		}
+
+		extendsConfig, err := c.loadFile(extendsPath, false)
+		if os.IsNotExist(err) {
+			c.addError(strPath, data, start, end, fmt.Sprintf("extends \"%s\": file not found", oneExtends))
+			continue
+		} else if err != nil {
+			c.addError(strPath, data, start, end, fmt.Sprintf("extends \"%s\": %s", oneExtends, err.Error()))
+			continue
+		}
+
+		baseConfig = mergeRawConfig(baseConfig, extendsConfig)
+	}
+
+	return baseConfig
+}
+
+// checkValueType 校验配置项值的类型，类型错误的配置项忽略，使用默认值
+// 配置项中对象包含的未知成员只告警，不影响配置项生效
+func (c *configLoader) checkValueType(strPath string, data []byte, oneKey *configKeyInfo, strName string,
+	valueType reflect.Type) bool {
+	if err := json.Unmarshal(oneKey.value, reflect.New(valueType).Interface()); err != nil {
+		errStr := fmt.Sprintf("%s: %s", strName, err.Error())
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			fieldName := strName
+			if typeErr.Field != "" {
+				fieldName = strName + "." + typeErr.Field
+			}
+			errStr = fmt.Sprintf("%s: wrong type, expected %s, got %s", fieldName, getSchemaTypeName(typeErr.Type),
+				typeErr.Value)
+		}
+		c.addError(strPath, data, oneKey.valueStart, oneKey.valueEnd, errStr)
+		return false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(oneKey.value))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(reflect.New(valueType).Interface()); err != nil {
+		errStr := fmt.Sprintf("%s: %s", strName, strings.TrimPrefix(err.Error(), "json: "))
+		c.addError(strPath, data, oneKey.valueStart, oneKey.valueEnd, errStr)
+	}
+
+	return true
+}
+
+// checkValueRegexp 校验支持正则表达式的配置项，非法的正则表达式只会按子串匹配
+func (c *configLoader) checkValueRegexp(strPath string, data []byte, oneKey *configKeyInfo, strName string) {
+	var patternVec []string
+	switch strName {
+	case "IgnoreFileOrFloder", "IgnoreFileErr":
+		json.Unmarshal(oneKey.value, &patternVec)
+	case "IgnoreFileVars", "IgnoreFileErrTypes":
+		var fileVec []struct {
+			File string `json:"File"`
+		}
+		json.Unmarshal(oneKey.value, &fileVec)
+		for _, oneFile := range fileVec {
+			patternVec = append(patternVec, oneFile.File)
+		}
+	}
+
+	for _, strPattern := range patternVec {
+		if _, err := regexp.Compile(strPattern); err != nil {
+			start, end := findValueString(data, oneKey, strPattern)
+			errStr := fmt.Sprintf("%s: invalid regular expression \"%s\": %s", strName, strPattern, err.Error())
+			c.addError(strPath, data, start, end, errStr)
+		}
+	}
+}
+
+// checkValueEncoding 校验文件编码的配置项，不支持的编码告警，按默认的gbk解码
+func (c *configLoader) checkValueEncoding(strPath string, data []byte, oneKey *configKeyInfo, strName string) {
+	if strName != "FileEncoding" {
+		return
+	}
+
+	var strEncoding string
+	json.Unmarshal(oneKey.value, &strEncoding)
+	if _, ok := codingconv.GetEncoding(strEncoding); !ok {
+		errStr := fmt.Sprintf("%s: unsupported encoding \"%s\"", strName, strEncoding)
+		c.addError(strPath, data, oneKey.valueStart, oneKey.valueEnd, errStr)
+	}
+}
+
+// checkValueSeverity 校验各错误类型的诊断级别，非法的错误类型或是级别忽略
+func (c *configLoader) checkValueSeverity(strPath string, data []byte, oneKey *configKeyInfo, strName string) {
+	if strName != "DiagnosticSeverity" {
+		return
+	}
+
+	severityMap := map[string]string{}
+	json.Unmarshal(oneKey.value, &severityMap)
+	for strType, strSeverity := range severityMap {
+		if errType, err := strconv.Atoi(strType); err != nil || errType <= 0 {
+			start, end := findValueString(data, oneKey, strType)
+			c.addError(strPath, data, start, end, fmt.Sprintf("%s: invalid warning type \"%s\"", strName, strType))
+			continue
+		}
+
+		if !IsValidSeverity(strSeverity) {
+			start, end := findValueString(data, oneKey, strSeverity)
+			errStr := fmt.Sprintf("%s: invalid severity \"%s\", expected error, warning, info, hint or off", strName,
+				strSeverity)
+			c.addError(strPath, data, start, end, errStr)
+		}
+	}
+}
+
+// findValueString 查找配置项的值中字符串所在的偏移，找不到时返回整个值的偏移
+func findValueString(data []byte, oneKey *configKeyInfo, strValue string) (start, end int) {
+	strJSON, _ := json.Marshal(strValue)
+	if index := bytes.Index(oneKey.value, strJSON); index >= 0 {
+		start = oneKey.valueStart + index
+		return start, start + len(strJSON)
+	}
+
+	return oneKey.valueStart, oneKey.valueEnd
+}
+
+// setConfigLoader 保存目录下读取配置文件的结果
+func (g *GlobalConfig) setConfigLoader(strDir string, loader *configLoader) {
+	g.configLoaderMutex.Lock()
+	defer g.configLoaderMutex.Unlock()
+
+	if g.configLoaderMap == nil {
+		g.configLoaderMap = map[string]*configLoader{}
+	}
+	g.configLoaderMap[strings.TrimSuffix(strDir, "/")] = loader
+}
+
+// removeConfigLoader 删除目录以及子目录下读取配置文件的结果
+func (g *GlobalConfig) removeConfigLoader(strDir string) {
+	g.configLoaderMutex.Lock()
+	defer g.configLoaderMutex.Unlock()
+
+	for loaderDir := range g.configLoaderMap {
+		if isPathInDir(loaderDir, strDir) {
+			delete(g.configLoaderMap, loaderDir)
+		}
+	}
+}
+
+// GetConfigErrors 获取所有配置文件的诊断信息，key为配置文件的完整路径
+func (g *GlobalConfig) GetConfigErrors() map[string][]CheckError {
+	g.configLoaderMutex.Lock()
+	defer g.configLoaderMutex.Unlock()
+
+	errMap := map[string][]CheckError{}
+	for _, loader := range g.configLoaderMap {
+		for strPath, errVec := range loader.errMap {
+			errMap[strPath] = errVec
+		}
+	}
+
+	return errMap
+}
+
+// GetConfigFiles 获取读取到的所有配置文件，包含继承的配置文件以及子目录下的配置文件
+func (g *GlobalConfig) GetConfigFiles() (fileVec []string) {
+	g.configLoaderMutex.Lock()
+	defer g.configLoaderMutex.Unlock()
+
+	fileMap := map[string]bool{}
+	for _, loader := range g.configLoaderMap {
+		for _, strPath := range loader.fileVec {
+			if !fileMap[strPath] {
+				fileMap[strPath] = true
+				fileVec = append(fileVec, strPath)
+			}
+		}
+	}
+
+	sort.Strings(fileVec)
+	return fileVec
+}
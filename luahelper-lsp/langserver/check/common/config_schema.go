@@ -0,0 +1,137 @@
+package common
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// luahelper.json的json schema根据JSONConfig的定义生成，编辑器用来补全配置文件
+// 未知的配置项由服务端推送诊断信息，schema中不限制额外的配置项，避免与服务端重复告警
+// 修改了JSONConfig后，需要重新生成luahelper-vscode/schemas/luahelper.schema.json：luahelper-lsp -schema <path>
+
+// configDescMap 配置项的描述，对象中的成员以 配置项.成员 为key
+var configDescMap = map[string]string{
+	configExtendsKey:             "Inherit other config files, paths are relative to this file. Keys in this file override the inherited ones.",
+	"BaseDir":                    "Root directory of the Lua files, relative to this file.",
+	"ShowWarnFlag":               "Whether to show warnings, 0 hides all warnings.",
+	"ReferMatchPathFlag":         "Whether referring to another Lua file must match the full path, 1 is yes.",
+	"IgnoreFileNameVarFlag":      "Whether to ignore variables with the same name as a Lua file, 1 is yes.",
+	"ProjectFiles":               "Entry files of the project.",
+	"IgnoreModules":              "Undefined global variables that are ignored.",
+	"IgnoreWildcardModules":      "Undefined global variables that are ignored, wildcards are supported.",
+	"IgnoreFileVars":             "Undefined variables that are ignored in the specified files.",
+	"IgnoreFileVars.File":        "File path or regular expression.",
+	"IgnoreFileVars.Vars":        "Ignored variable names.",
+	"IgnoreReadFiles":            "Files that are not reported when they can not be found.",
+	"IgnoreErrorTypes":           "Warning types that are ignored.",
+	"IgnoreFileOrFloder":         "Files or folders that are not analysed, regular expressions are supported.",
+	"IgnoreFileErr":              "Files or folders whose warnings are ignored, regular expressions are supported.",
+	"IgnoreFileErrTypes":         "Warning types that are ignored in the specified files.",
+	"IgnoreFileErrTypes.File":    "File path or regular expression.",
+	"IgnoreFileErrTypes.Types":   "Ignored warning types.",
+	"IgnoreLocalNoUseVars":       "Local variables that are not reported when they are unused.",
+	"IgnoreShadowVars":           "Local variable names that are not reported when they shadow an outer variable.",
+	"ProtocolVars":               "Protocol prefixes of the project, for example c2s, s2s.",
+	"ProtocolPreIngoreFlag":      "Whether to ignore undefined protocol prefix variables, 1 is yes.",
+	"ProtoPaths":                 "Protobuf .proto files or folders, relative to this file. Each message becomes an annotation class.",
+	"ReferFrameFiles":            "Functions of the framework that load other Lua files, like require.",
+	"ReferFrameFiles.Name":       "Function name, for example import.",
+	"ReferFrameFiles.type":       "0 is like import, 1 is like require, 2 is decided by the return of the file.",
+	"ReferFrameFiles.SuffixFlag": "Whether the loaded file name contains the suffix, 1 is yes.",
+	"PathSeparator":              "Path separator when requiring other Lua files, default is \".\".",
+	"tlogXmlPath":                "Path of the tlog xml file, relative to this file. Each struct becomes an annotation class.",
+	"TlogFuncs":                  "Functions that write tlog logs, the first argument is the struct name, default is tlog.",
+	"FormatFuncs":                "Wrapper functions whose arguments are checked like string.format, for example log functions.",
+	"FormatFuncs.FuncName":       "Function name, for example LOG_INFO. Names with . or : must match the full call, for example log:info.",
+	"FormatFuncs.ParamIndex":     "Index of the format string argument, starting from 1 and not counting self. Default is 1.",
+	"AnntotateSets":              "Functions whose parameter is used to deduce the annotation type.",
+	"LinkFolders":                "Other workspace folders that are visible to this folder, relative to this file.",
+	"DiagnosticSeverity":         "Severity of each warning type, the key is the warning type, the value is error, warning, info, hint or off.",
+	"FileEncoding":               "Encoding of the Lua files that are not UTF-8, for example gbk, gb18030, big5, shift_jis. Default is gbk.",
+}
+
+// getSchemaTypeName 获取go类型对应的json schema类型
+func getSchemaTypeName(valueType reflect.Type) string {
+	switch valueType.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	}
+
+	return "null"
+}
+
+// getTypeSchema 获取go类型对应的json schema，strPre为对象成员描述的前缀
+func getTypeSchema(valueType reflect.Type, strPre string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type": getSchemaTypeName(valueType),
+	}
+
+	switch valueType.Kind() {
+	case reflect.Slice, reflect.Array:
+		schema["items"] = getTypeSchema(valueType.Elem(), strPre)
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		for i := 0; i < valueType.NumField(); i++ {
+			field := valueType.Field(i)
+			strName := field.Tag.Get("json")
+			if strName == "" || strName == "-" {
+				continue
+			}
+
+			fieldSchema := getTypeSchema(field.Type, strPre+"."+strName)
+			if desc, ok := configDescMap[strPre+"."+strName]; ok {
+				fieldSchema["description"] = desc
+			}
+			properties[strName] = fieldSchema
+		}
+		schema["properties"] = properties
+	}
+
+	return schema
+}
+
+// GetJSONConfigSchema 生成luahelper.json的json schema，包含每个配置项的类型、描述与默认值
+func GetJSONConfigSchema() ([]byte, error) {
+	properties := map[string]interface{}{
+		configSchemaKey: map[string]interface{}{
+			"type": "string",
+		},
+		configExtendsKey: map[string]interface{}{
+			"description": configDescMap[configExtendsKey],
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": "string"},
+				map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			},
+		},
+	}
+
+	defaultValue := reflect.ValueOf(newDefaultJSONConfig()).Elem()
+	configType := defaultValue.Type()
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		strName := field.Tag.Get("json")
+		fieldSchema := getTypeSchema(field.Type, strName)
+		fieldSchema["default"] = defaultValue.Field(i).Interface()
+		if desc, ok := configDescMap[strName]; ok {
+			fieldSchema["description"] = desc
+		}
+		properties[strName] = fieldSchema
+	}
+
+	schema := map[string]interface{}{
+		"$schema":    "http://json-schema.org/draft-07/schema#",
+		"title":      "LuaHelper luahelper.json",
+		"type":       "object",
+		"properties": properties,
+	}
+
+	return json.MarshalIndent(schema, "", "    ")
+}
@@ -324,7 +324,7 @@ func (d *DirManager) MatchAllDirReferFile(curFile string, referStr string) (refe
 
 	g := GConfig
 	strPath := d.GetCompletePath(d.mainDir, referStr)
-	if g.FileExistCache(strPath) {
+	if g.IsFolderVisible(curFile, strPath) && g.FileExistCache(strPath) {
 		// lua文件存在，正常
 		referFile = strPath
 		return
@@ -332,7 +332,7 @@ func (d *DirManager) MatchAllDirReferFile(curFile string, referStr string) (refe
 
 	for _, subDir := range d.subDirVec {
 		strPath := d.GetCompletePath(subDir, referStr)
-		if g.FileExistCache(strPath) {
+		if g.IsFolderVisible(curFile, strPath) && g.FileExistCache(strPath) {
 			// lua文件存在，正常
 			referFile = strPath
 			return
@@ -605,6 +605,11 @@ func GetBestMatchReferFile(curFile string, referFile string, allFilesMap map[str
 			}
 		}
 
+		// 多根工作区时，其他没有关联的工作区文件夹中的文件不可见
+		if !GConfig.IsFolderVisible(curFile, strFile) {
+			continue
+		}
+
 		// 包含引入的后缀
 		candidateVec = append(candidateVec, strFile)
 	}
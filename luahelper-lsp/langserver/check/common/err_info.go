@@ -59,4 +59,77 @@ const (
 	
 	// CheckErrorAnnotate 注解系统引入的错误
 	CheckErrorAnnotate = 18
+
+	// CheckErrorConfig luahelper.json配置文件的错误，例如未知的配置项、类型错误、非法的正则表达式
+	CheckErrorConfig = 19
+
+	// CheckErrorTlog 打tlog日志时，引用了tlog xml中未定义的struct或是字段
+	CheckErrorTlog = 20
+
+	// CheckErrorShadowVar 局部变量、for循环变量或函数参数遮蔽了外层同名的局部变量、上值、函数参数或全局变量
+	CheckErrorShadowVar = 21
+
+	// CheckErrorUnreachable 不可达的代码，例如return、break、goto、error()之后的语句
+	CheckErrorUnreachable = 22
+
+	// CheckErrorMissingReturn 函数部分路径有返回值，但有的路径执行到函数末尾没有返回值
+	CheckErrorMissingReturn = 23
+
+	// CheckErrorConstAssign 对lua5.4中const或close属性的局部变量、上值进行赋值
+	CheckErrorConstAssign = 24
+
+	// CheckErrorCloseVar lua5.4中close属性的变量使用错误，例如一条语句定义多个close变量，或是赋值为不能close的值
+	CheckErrorCloseVar = 25
+
+	// CheckErrorFormatString string.format等格式化字符串的函数调用，格式非法、参数个数或字面量参数的类型不匹配
+	CheckErrorFormatString = 26
+
+	// CheckErrorAccidentalGlobal 函数内部对全局变量赋值，且该变量没有在文件顶层定义，可能是遗漏了local，默认不告警
+	CheckErrorAccidentalGlobal = 27
+
+	// CheckErrorUnusedParam 定义了的函数参数未使用，忽略self与_开头的参数
+	CheckErrorUnusedParam = 28
+
+	// CheckErrorUnusedLabel 定义了的label没有被goto跳转
+	CheckErrorUnusedLabel = 29
+
+	// CheckErrorDiscardedResult 没有副作用的函数调用，返回值被丢弃了，例如单独一行调用string.format()
+	CheckErrorDiscardedResult = 30
+
+	// CheckErrorLast 最后一种错误类型，新增错误类型时需要同步修改
+	CheckErrorLast = CheckErrorDiscardedResult
 )
+
+// 诊断信息的级别，可以在luahelper.json的DiagnosticSeverity中按错误类型配置
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+	SeverityHint    = "hint"
+	SeverityOff     = "off" // 不显示这种类型的诊断信息
+)
+
+// IsValidSeverity 判断配置的诊断级别是否合法
+func IsValidSeverity(strSeverity string) bool {
+	switch strSeverity {
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityHint, SeverityOff:
+		return true
+	}
+
+	return false
+}
+
+// GetDefaultSeverity 获取错误类型默认的诊断级别，语法错误与lua5.4局部变量属性的错误为error，注解的错误为info，
+// 函数内意外定义的全局变量默认关闭，其他的为warning
+func GetDefaultSeverity(errType CheckErrorType) string {
+	switch errType {
+	case CheckErrorSyntax, CheckErrorConstAssign, CheckErrorCloseVar:
+		return SeverityError
+	case CheckErrorAnnotate:
+		return SeverityInfo
+	case CheckErrorAccidentalGlobal:
+		return SeverityOff
+	}
+
+	return SeverityWarning
+}
@@ -0,0 +1,365 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/pathpre"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 多根工作区时，每个工作区文件夹可以有自己的luahelper.json配置
+// 文件使用所在工作区文件夹的配置；不同工作区文件夹之间的分析是隔离的，除非在配置中通过LinkFolders显式关联
+
+// 子目录下的luahelper.json也用FolderConfig保存，只覆盖子目录下文件的配置，不影响文件夹之间的可见性
+
+// FolderConfig 单个工作区文件夹的配置
+type FolderConfig struct {
+	Dir      string        // 工作区文件夹的目录
+	Config   *GlobalConfig // 文件夹内luahelper.json的配置，为nil表示没有配置文件，使用主工程的配置
+	Override bool          // 是否为子目录下覆盖上层目录的配置
+}
+
+// getConfigRelativePath 获取配置文件中路径的完整路径（关联的文件夹、继承的配置文件），相对路径相对于配置文件所在的目录
+func getConfigRelativePath(strDir, strConfigPath string) string {
+	strPath := strConfigPath
+	if !filepath.IsAbs(strPath) {
+		strPath = filepath.Join(strDir, strPath)
+	}
+
+	strPath, _ = filepath.Abs(strPath)
+	return strings.TrimSuffix(pathpre.GeConvertPathFormat(strPath), "/")
+}
+
+// isPathInDir 判断文件是否在目录下
+func isPathInDir(strFile, strDir string) bool {
+	if strDir == "" {
+		return false
+	}
+
+	if strFile == strDir {
+		return true
+	}
+
+	return strings.HasPrefix(strFile, strings.TrimSuffix(strDir, "/")+"/")
+}
+
+// newFolderGlobalConfig 创建工作区文件夹的配置，与客户端相关的设置沿用主工程的
+func (g *GlobalConfig) newFolderGlobalConfig() *GlobalConfig {
+	folderConfig := &GlobalConfig{
+		ReferenceMaxNum:      g.ReferenceMaxNum,
+		ReferenceDefineFlag:  g.ReferenceDefineFlag,
+		GVarExtendGlobalFlag: g.GVarExtendGlobalFlag,
+		colonFlag:            g.colonFlag,
+		AssocialList:         g.AssocialList,
+		dirManager:           g.dirManager,
+	}
+	folderConfig.IntialGlobalVar()
+	return folderConfig
+}
+
+// insertFolderConfig 插入一个文件夹的配置，替换掉相同目录的配置
+func (g *GlobalConfig) insertFolderConfig(oneFolder *FolderConfig) {
+	g.folderMutex.Lock()
+	defer g.folderMutex.Unlock()
+
+	for index, oldFolder := range g.folderConfigs {
+		if oldFolder.Dir == oneFolder.Dir {
+			g.folderConfigs = append(g.folderConfigs[:index], g.folderConfigs[index+1:]...)
+			break
+		}
+	}
+	g.folderConfigs = append(g.folderConfigs, oneFolder)
+}
+
+// loadFolderGlobalConfig 读取目录下的配置文件，与baseConfig合并后生成目录的配置
+// 没有配置文件时返回nil，配置文件格式错误时返回错误
+func (g *GlobalConfig) loadFolderGlobalConfig(strDir, configFileName string, baseConfig rawJSONConfig,
+	nestedFlag bool) (*GlobalConfig, error) {
+	strPath := g.dirManager.GetCompletePath(strDir, configFileName)
+
+	loader := createConfigLoader()
+	g.setConfigLoader(strDir, loader)
+	rawConfig, err := loader.loadFile(strPath, nestedFlag)
+	if os.IsNotExist(err) {
+		log.Debug("folder=%s not find %s file", strDir, configFileName)
+		return nil, nil
+	} else if err != nil {
+		strErr := fmt.Sprintf("folder %s: read %s error=%s, json format error", strDir, configFileName, err.Error())
+		return nil, errors.New(strErr)
+	}
+
+	rawConfig = mergeRawConfig(baseConfig, rawConfig)
+	oneJSONConfig, err := parseRawJSONConfig(rawConfig, configFileName)
+	if err != nil {
+		strErr := fmt.Sprintf("folder %s: %s", strDir, err.Error())
+		return nil, errors.New(strErr)
+	}
+
+	folderConfig := g.newFolderGlobalConfig()
+	folderConfig.applyJSONConfig(oneJSONConfig, strDir)
+	folderConfig.rawConfig = rawConfig
+	if g.ignoreSystemFlag {
+		folderConfig.InsertIngoreSystemModule()
+		folderConfig.InsertIngoreSystemAnnotateType()
+	}
+
+	log.Debug("folder=%s read %s ok", strDir, configFileName)
+	return folderConfig, nil
+}
+
+// AddFolderConfig 增加一个工作区文件夹，读取文件夹下的配置文件
+// 没有配置文件时，文件夹内的文件使用主工程的配置；配置文件格式错误时，返回错误，同样使用主工程的配置
+func (g *GlobalConfig) AddFolderConfig(strDir, configFileName string) error {
+	oneFolder := &FolderConfig{
+		Dir: strings.TrimSuffix(strDir, "/"),
+	}
+	g.insertFolderConfig(oneFolder)
+
+	folderConfig, err := g.loadFolderGlobalConfig(strDir, configFileName, nil, false)
+	oneFolder.Config = folderConfig
+	return err
+}
+
+// LoadNestedConfigs 查找分析的文件所在的子目录下的配置文件，子目录的配置覆盖上层目录的配置，只对子目录下的文件生效
+// 上层目录的配置先读取，子目录的配置在上层目录合并后的配置基础上覆盖
+func (g *GlobalConfig) LoadNestedConfigs(fileList []string, configFileName string) (errVec []error) {
+	dirMap := map[string]bool{}
+	for _, strFile := range fileList {
+		rootDir, _ := g.getFileFolderDir(strFile)
+		if rootDir == "" {
+			continue
+		}
+
+		for strDir := path.Dir(strFile); strDir != rootDir && isPathInDir(strDir, rootDir); strDir = path.Dir(strDir) {
+			if dirMap[strDir] {
+				break
+			}
+			dirMap[strDir] = true
+		}
+	}
+
+	dirVec := make([]string, 0, len(dirMap))
+	for strDir := range dirMap {
+		if _, err := os.Stat(g.dirManager.GetCompletePath(strDir, configFileName)); err == nil {
+			dirVec = append(dirVec, strDir)
+		}
+	}
+	sort.Slice(dirVec, func(i, j int) bool {
+		return len(dirVec[i]) < len(dirVec[j]) || (len(dirVec[i]) == len(dirVec[j]) && dirVec[i] < dirVec[j])
+	})
+
+	for _, strDir := range dirVec {
+		if oneFolder := g.getFileFolder(strDir, true); oneFolder != nil && oneFolder.Dir == strDir && !oneFolder.Override {
+			continue
+		}
+
+		parentConfig := g.GetFileConfig(path.Dir(strDir))
+		nestedConfig, err := g.loadFolderGlobalConfig(strDir, configFileName, parentConfig.rawConfig, true)
+		if err != nil {
+			errVec = append(errVec, err)
+			continue
+		}
+
+		// tlogXmlPath只在根目录的配置中生效，继承的相对路径不能按子目录重新计算
+		if nestedConfig != nil {
+			nestedConfig.TlogXMLPath = parentConfig.TlogXMLPath
+		}
+
+		g.insertFolderConfig(&FolderConfig{
+			Dir:      strDir,
+			Config:   nestedConfig,
+			Override: true,
+		})
+	}
+
+	return errVec
+}
+
+// RemoveFolderConfig 移除一个工作区文件夹的配置，以及文件夹下子目录的配置
+func (g *GlobalConfig) RemoveFolderConfig(strDir string) {
+	strDir = strings.TrimSuffix(strDir, "/")
+	g.removeConfigLoader(strDir)
+
+	g.folderMutex.Lock()
+	defer g.folderMutex.Unlock()
+
+	folderConfigs := make([]*FolderConfig, 0, len(g.folderConfigs))
+	for _, oneFolder := range g.folderConfigs {
+		if !isPathInDir(oneFolder.Dir, strDir) {
+			folderConfigs = append(folderConfigs, oneFolder)
+		}
+	}
+	g.folderConfigs = folderConfigs
+}
+
+// getFileFolder 获取文件所属的工作区文件夹，属于主工程目录或是不属于任何工作区文件夹时返回nil
+// overrideFlag 表示是否包含子目录下覆盖上层目录的配置
+func (g *GlobalConfig) getFileFolder(strFile string, overrideFlag bool) (matchFolder *FolderConfig) {
+	g.folderMutex.RLock()
+	defer g.folderMutex.RUnlock()
+
+	if len(g.folderConfigs) == 0 {
+		return nil
+	}
+
+	bestLen := 0
+	mainDir := g.dirManager.GetMainDir()
+	if isPathInDir(strFile, mainDir) {
+		bestLen = len(mainDir)
+	}
+
+	for _, oneFolder := range g.folderConfigs {
+		if oneFolder.Override && !overrideFlag {
+			continue
+		}
+
+		if isPathInDir(strFile, oneFolder.Dir) && len(oneFolder.Dir) > bestLen {
+			bestLen = len(oneFolder.Dir)
+			matchFolder = oneFolder
+		}
+	}
+
+	return matchFolder
+}
+
+// GetFileConfig 获取文件所使用的配置，文件所在的子目录或工作区文件夹有配置文件时使用最近的配置，否则使用主工程的配置
+func (g *GlobalConfig) GetFileConfig(strFile string) *GlobalConfig {
+	oneFolder := g.getFileFolder(strFile, true)
+	if oneFolder == nil || oneFolder.Config == nil {
+		return g
+	}
+
+	return oneFolder.Config
+}
+
+// getFileFolderDir 获取文件所属的工作区文件夹目录，以及文件夹关联其他文件夹的配置
+// 不属于任何工作区文件夹时（例如客户端额外的Lua文件夹）返回空
+func (g *GlobalConfig) getFileFolderDir(strFile string) (strDir string, linkConfig *GlobalConfig) {
+	if oneFolder := g.getFileFolder(strFile, false); oneFolder != nil {
+		return oneFolder.Dir, oneFolder.Config
+	}
+
+	mainDir := g.dirManager.GetMainDir()
+	if isPathInDir(strFile, mainDir) {
+		return mainDir, g
+	}
+
+	return "", nil
+}
+
+// isLinkDir 判断配置中是否关联了指定的工作区文件夹
+func (g *GlobalConfig) isLinkDir(strDir string) bool {
+	if g == nil {
+		return false
+	}
+
+	for _, linkDir := range g.linkDirs {
+		if isPathInDir(strDir, linkDir) || isPathInDir(linkDir, strDir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsFolderVisible 判断curFile文件是否可以看到defineFile文件中的定义（引用文件、全局符号）
+// 同一个工作区文件夹内的文件相互可见；不属于任何工作区文件夹的文件（例如客户端额外的Lua文件夹）对所有文件可见
+// 不同的工作区文件夹，需要其中一个文件夹的配置中通过LinkFolders关联另外一个文件夹
+func (g *GlobalConfig) IsFolderVisible(curFile, defineFile string) bool {
+	g.folderMutex.RLock()
+	folderNum := len(g.folderConfigs)
+	g.folderMutex.RUnlock()
+	if folderNum == 0 || curFile == "" || defineFile == "" {
+		return true
+	}
+
+	curDir, curLinkConfig := g.getFileFolderDir(curFile)
+	defineDir, defineLinkConfig := g.getFileFolderDir(defineFile)
+	if curDir == "" || defineDir == "" || curDir == defineDir {
+		return true
+	}
+
+	return curLinkConfig.isLinkDir(defineDir) || defineLinkConfig.isLinkDir(curDir)
+}
+
+// getAllFolderConfigs 获取所有工作区文件夹中读取到的配置
+func (g *GlobalConfig) getAllFolderConfigs() (configVec []*GlobalConfig) {
+	g.folderMutex.RLock()
+	defer g.folderMutex.RUnlock()
+
+	for _, oneFolder := range g.folderConfigs {
+		if oneFolder.Config != nil {
+			configVec = append(configVec, oneFolder.Config)
+		}
+	}
+
+	return configVec
+}
+
+// GetFolderProjectFiles 获取所有工作区文件夹配置的工程入口文件，返回完整的路径
+func (g *GlobalConfig) GetFolderProjectFiles() (fileList []string) {
+	g.folderMutex.RLock()
+	defer g.folderMutex.RUnlock()
+
+	for _, oneFolder := range g.folderConfigs {
+		if oneFolder.Config == nil || oneFolder.Override {
+			continue
+		}
+
+		for _, luaFile := range oneFolder.Config.ProjectFiles {
+			luaFile = pathpre.GetRemovePreStr(luaFile)
+			fileList = append(fileList, g.dirManager.GetCompletePath(oneFolder.Dir, luaFile))
+		}
+	}
+
+	return fileList
+}
+
+// GetProtoPaths 获取主工程以及所有工作区文件夹配置的.proto文件或文件夹，返回完整的路径
+func (g *GlobalConfig) GetProtoPaths() (pathList []string) {
+	pathList = append(pathList, g.protoPaths...)
+
+	g.folderMutex.RLock()
+	defer g.folderMutex.RUnlock()
+
+	for _, oneFolder := range g.folderConfigs {
+		if oneFolder.Config == nil || oneFolder.Override {
+			continue
+		}
+
+		pathList = append(pathList, oneFolder.Config.protoPaths...)
+	}
+
+	return pathList
+}
+
+// GetTlogXMLPaths 获取主工程以及所有工作区文件夹配置的tlog xml文件，返回去重后的完整路径
+func (g *GlobalConfig) GetTlogXMLPaths() (pathList []string) {
+	pathMap := map[string]bool{}
+	if g.TlogXMLPath != "" {
+		pathMap[g.TlogXMLPath] = true
+		pathList = append(pathList, g.TlogXMLPath)
+	}
+
+	g.folderMutex.RLock()
+	defer g.folderMutex.RUnlock()
+
+	for _, oneFolder := range g.folderConfigs {
+		if oneFolder.Config == nil || oneFolder.Override {
+			continue
+		}
+
+		strPath := oneFolder.Config.TlogXMLPath
+		if strPath != "" && !pathMap[strPath] {
+			pathMap[strPath] = true
+			pathList = append(pathList, strPath)
+		}
+	}
+
+	return pathList
+}
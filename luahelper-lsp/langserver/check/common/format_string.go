@@ -0,0 +1,138 @@
+package common
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FormatItem 格式化字符串中需要一个参数的转换说明，例如%5.2f
+type FormatItem struct {
+	Conv byte   // 转换字符，例如d、s
+	Str  string // 完整的转换说明，例如%5.2f
+}
+
+// formatMaxDigits lua中转换说明的宽度与精度最多为两位数字
+const formatMaxDigits = 2
+
+// ParseFormatString 按照lua string.format的规则解析格式化字符串，返回所有需要参数的转换说明，%%不需要参数
+// 格式非法时返回错误的描述，例如invalid conversion '%y'
+func ParseFormatString(strFormat string) (itemVec []FormatItem, errStr string) {
+	for i := 0; i < len(strFormat); i++ {
+		if strFormat[i] != '%' {
+			continue
+		}
+
+		begin := i
+		i++
+		if i < len(strFormat) && strFormat[i] == '%' {
+			continue
+		}
+
+		for i < len(strFormat) && strings.IndexByte("-+ #0", strFormat[i]) >= 0 {
+			i++
+		}
+
+		// 宽度与精度最多为两位数字
+		digitBegin := i
+		i = skipFormatDigits(strFormat, i)
+		validFlag := i-digitBegin <= formatMaxDigits
+		if i < len(strFormat) && strFormat[i] == '.' {
+			digitBegin = i + 1
+			i = skipFormatDigits(strFormat, digitBegin)
+			validFlag = validFlag && i-digitBegin <= formatMaxDigits
+		}
+
+		if i >= len(strFormat) {
+			return itemVec, fmt.Sprintf("invalid conversion '%s'", strFormat[begin:])
+		}
+
+		strItem := strFormat[begin : i+1]
+		conv := strFormat[i]
+		if !validFlag || strings.IndexByte("cdiuoxXaAfFeEgGpqs", conv) < 0 {
+			return itemVec, fmt.Sprintf("invalid conversion '%s'", strItem)
+		}
+
+		if conv == 'q' && len(strItem) > 2 {
+			return itemVec, fmt.Sprintf("specifier '%s' cannot have modifiers", strItem)
+		}
+
+		itemVec = append(itemVec, FormatItem{
+			Conv: conv,
+			Str:  strItem,
+		})
+	}
+
+	return itemVec, ""
+}
+
+// skipFormatDigits 跳过连续的数字，返回第一个不是数字的位置
+func skipFormatDigits(strFormat string, i int) int {
+	for i < len(strFormat) && strFormat[i] >= '0' && strFormat[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+// getStrNumber 按照lua的规则，把字符串转换为数字，不能转换时ok为false
+func getStrNumber(str string) (val float64, ok bool) {
+	str = strings.TrimSpace(str)
+	if intVal, err := strconv.ParseInt(str, 0, 64); err == nil {
+		return float64(intVal), true
+	}
+
+	if floatVal, err := strconv.ParseFloat(str, 64); err == nil {
+		return floatVal, true
+	}
+
+	return 0, false
+}
+
+// GetFormatArgErr 判断字面量的参数与转换说明的类型是否明显不匹配，不匹配时返回原因，与lua运行时的报错一致
+// 不是字面量的参数无法判断，返回空
+func GetFormatArgErr(conv byte, argExp ast.Exp) string {
+	strType := ""
+	numFlag := false
+	numVal := 0.0
+	switch exp := argExp.(type) {
+	case *ast.IntegerExp:
+		strType, numFlag, numVal = "number", true, float64(exp.Val)
+	case *ast.FloatExp:
+		strType, numFlag, numVal = "number", true, exp.Val
+	case *ast.StringExp:
+		strType = "string"
+		numVal, numFlag = getStrNumber(exp.Str)
+	case *ast.TrueExp, *ast.FalseExp:
+		strType = "boolean"
+	case *ast.NilExp:
+		strType = "nil"
+	case *ast.TableConstructorExp:
+		strType = "table"
+	case *ast.FuncDefExp:
+		strType = "function"
+	default:
+		return ""
+	}
+
+	switch conv {
+	case 'c', 'd', 'i', 'u', 'o', 'x', 'X':
+		if !numFlag {
+			return fmt.Sprintf("number expected, got %s", strType)
+		}
+		if numVal != math.Trunc(numVal) {
+			return "number has no integer representation"
+		}
+	case 'a', 'A', 'f', 'F', 'e', 'E', 'g', 'G':
+		if !numFlag {
+			return fmt.Sprintf("number expected, got %s", strType)
+		}
+	case 'q':
+		if strType == "table" || strType == "function" {
+			return "value has no literal form"
+		}
+	}
+
+	return ""
+}
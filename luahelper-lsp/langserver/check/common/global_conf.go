@@ -5,11 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/codingconv"
 	"luahelper-lsp/langserver/filefolder"
 	"luahelper-lsp/langserver/log"
+	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -23,9 +27,15 @@ type GlobalConfig struct {
 	// 如果是包含工程的入口文件，配置读取，后台专门定制的特性
 	ProjectFiles []string
 
-	// 后台专业的，用于解释tlog，用于调整定义到tlog中
+	// 后台专业的，用于解释tlog，用于调整定义到tlog中，完整的路径
 	TlogXMLPath string
 
+	// 打tlog日志的函数名称，第一个参数为tlog的struct名称，第二个参数为各字段的table
+	tlogFuncs []string
+
+	// 格式化字符串的包装函数，例如打日志的函数LOG_INFO(fmt, ...)，string.format不需要配置
+	formatFuncs []formatFunc
+
 	// 是否开启告警
 	showWarnFlag bool
 
@@ -91,6 +101,18 @@ type GlobalConfig struct {
 	// 协议前缀变量未找到，是否告警, 默认告警, 默认告警，值为false
 	ProtocolPreIngoreFlag bool
 
+	// 协议定义的.proto文件或文件夹，完整的路径
+	protoPaths []string
+
+	// 非utf-8的Lua文件使用的编码，例如gbk、big5
+	fileEncoding string
+
+	// 配置的各错误类型的诊断级别，没有配置的使用默认的级别
+	severityMap map[CheckErrorType]string
+
+	// 局部变量遮蔽外层同名变量时，忽略这些变量名
+	ignoreShadowVarMap map[string]bool
+
 	// 代码补全时候，增加的提示关键字变量
 	CodeCompleteVarVec []string
 
@@ -126,11 +148,41 @@ type GlobalConfig struct {
 	// 是否区分 ：与 . 成员的调用标记。0表示.可以调用:用法；1表示两者不能相互调用；2表示两者可以相互调用
 	colonFlag int
 
+	// 补全函数时是否插入带参数占位符的代码片段，需要客户端支持snippet
+	callSnippetFlag bool
+
+	// 诊断信息中错误类型说明文档的地址，为空时使用DefaultDiagnosticDocURL
+	diagnosticDocURL string
+
 	// 配置的注解配置
 	anntotateSets []AnntotateSet
 
 	// 所有的目录管理
 	dirManager *DirManager
+
+	// 是否忽略了系统的模块和变量，本地运行时设置，工作区文件夹的配置也需要同样处理
+	ignoreSystemFlag bool
+
+	// 显式关联的其他工作区文件夹，关联的文件夹之间可以相互引用文件和全局符号
+	linkDirs []string
+
+	// 多根工作区中，其他工作区文件夹的配置
+	folderConfigs []*FolderConfig
+
+	// 工作区文件夹配置的读写锁
+	folderMutex sync.RWMutex
+
+	// 合并了继承的配置后的配置文件内容，子目录的配置文件在此基础上覆盖
+	rawConfig rawJSONConfig
+
+	// 指定文件忽略的变量，文件名为正则表达式时预先编译好
+	ignoreFileVarRegexMap map[string]*regexp.Regexp
+
+	// 每个目录下读取配置文件的结果，包含读取到的所有配置文件以及配置文件的诊断信息，key为配置文件所在的目录
+	configLoaderMap map[string]*configLoader
+
+	// 配置文件读取结果的互斥锁
+	configLoaderMutex sync.Mutex
 }
 
 // GConfig *GlobalConfig 全局配置对象初始化
@@ -154,6 +206,9 @@ func createDefaultGlobalConfig() {
 		IgnoreWildcarVarMap:    []string{},
 		PathSeparator:          ".",
 		anntotateSets:          []AnntotateSet{},
+		tlogFuncs:              []string{"tlog"},
+		formatFuncs:            []formatFunc{},
+		fileEncoding:           codingconv.DefaultFileEncoding,
 		dirManager:             createDirManager(),
 	}
 }
@@ -189,6 +244,15 @@ type (
 		SuffixFlag int `json:"SuffixFlag"`
 	}
 
+	// 格式化字符串的包装函数，参数按照string.format的规则检查
+	formatFunc struct {
+		// 函数的名称，例如LOG_INFO；包含.或:时需要完整匹配，例如log:info
+		FuncName string `json:"FuncName"`
+
+		// 格式化字符串是函数的第几个参数，默认从1开始，冒号调用时不包括self
+		ParamIndex int `json:"ParamIndex"`
+	}
+
 	// AnntotateSet 注解推导的方式，配置可以标明
 	AnntotateSet struct {
 		// 函数的名称，哪些函数能够尝试自动关联注解
@@ -233,12 +297,19 @@ type (
 		IgnoreFileErr         []string            `json:"IgnoreFileErr"`         // 忽略下列文件中的错误
 		IgnoreFileErrTypes    []ignoreFileErrType `json:"IgnoreFileErrTypes"`    // 忽略指定文件中的指定类型错误
 		IgnoreLocalNoUseVars  []string            `json:"IgnoreLocalNoUseVars"`  // 忽略哪些局部变量定义了未使用的
+		IgnoreShadowVars      []string            `json:"IgnoreShadowVars"`      // 忽略哪些局部变量遮蔽了外层同名的变量
 		ProtocolVars          []string            `json:"ProtocolVars"`          // 项目中特有的协议数组，例如有c2s, s2s
 		ProtocolPreIngoreFlag int                 `json:"ProtocolPreIngoreFlag"` // 协议前缀变量未找到，是否告警, 默认告警
+		ProtoPaths            []string            `json:"ProtoPaths"`            // 协议定义的.proto文件或文件夹，相对于配置文件所在的目录
 		ReferFrameFiles       []referFrameFile    `json:"ReferFrameFiles"`       // 项目中引用其他的框架文件
 		PathSeparator         string              `json:"PathSeparator"`         // 项目中引入其他文件，路径分隔符，默认为. 例如require("one.b") 表示引入one/b.lua 文件
-		TlogXMLPath           string              `json:"tlogXmlPath"`           // 所有工程的根目录
+		TlogXMLPath           string              `json:"tlogXmlPath"`           // tlog的xml描述文件，相对于配置文件所在的目录
+		TlogFuncs             []string            `json:"TlogFuncs"`             // 打tlog日志的函数名称，默认为tlog
+		FormatFuncs           []formatFunc        `json:"FormatFuncs"`           // 格式化字符串的包装函数，例如打日志的函数
 		AnntotateSets         []AnntotateSet      `json:"AnntotateSets"`         // 自动推导的注解方式
+		LinkFolders           []string            `json:"LinkFolders"`           // 关联的其他工作区文件夹，相对于配置文件所在的目录
+		FileEncoding          string              `json:"FileEncoding"`          // 非utf-8的Lua文件使用的编码，默认为gbk
+		DiagnosticSeverity    map[string]string   `json:"DiagnosticSeverity"`    // 各错误类型的诊断级别，key为错误类型
 	}
 )
 
@@ -247,7 +318,12 @@ var jsonConfig *JSONConfig
 
 // 创建默认的json config
 func createDefaultJSONCfig() {
-	jsonConfig = &JSONConfig{
+	jsonConfig = newDefaultJSONConfig()
+}
+
+// newDefaultJSONConfig 创建一份默认值的json config，每次读取配置文件时都基于新的默认值
+func newDefaultJSONConfig() *JSONConfig {
+	return &JSONConfig{
 		BaseDir:               "./",
 		ShowWarnFlag:          1,
 		ReferMatchPathFlag:    0,
@@ -262,12 +338,19 @@ func createDefaultJSONCfig() {
 		IgnoreFileErr:         []string{},
 		IgnoreFileErrTypes:    []ignoreFileErrType{},
 		IgnoreLocalNoUseVars:  []string{},
+		IgnoreShadowVars:      []string{},
 		ProtocolVars:          []string{},
 		ProtocolPreIngoreFlag: 0,
+		ProtoPaths:            []string{},
 		ReferFrameFiles:       []referFrameFile{{Name: "import", Type: 0, SuffixFlag: 1}},
 		PathSeparator:         ".",
 		TlogXMLPath:           "",
+		TlogFuncs:             []string{"tlog"},
+		FormatFuncs:           []formatFunc{},
 		AnntotateSets:         []AnntotateSet{},
+		LinkFolders:           []string{},
+		FileEncoding:          codingconv.DefaultFileEncoding,
+		DiagnosticSeverity:    map[string]string{},
 	}
 }
 
@@ -432,10 +515,10 @@ func (g *GlobalConfig) handleNotJSONCheckFlag(checkFlagList []bool, ignoreFileOr
 
 	// 添加引入文件的方式
 	for _, oneReferFrame := range jsonConfig.ReferFrameFiles {
-		GConfig.ReferOtherFileMap[oneReferFrame.Name] = true
-		GConfig.LuaInMap[oneReferFrame.Name] = "function"
+		g.ReferOtherFileMap[oneReferFrame.Name] = true
+		g.LuaInMap[oneReferFrame.Name] = "function"
 	}
-	GConfig.ReferFrameFiles = jsonConfig.ReferFrameFiles
+	g.ReferFrameFiles = jsonConfig.ReferFrameFiles
 
 	// 忽略对某些文件或文件夹进行check分析， 包含go语言的正则
 	g.IgnoreHandleFolderVec = make([]string, 0, 2)
@@ -466,10 +549,13 @@ func (g *GlobalConfig) handleNotJSONCheckFlag(checkFlagList []bool, ignoreFileOr
 		return
 	}
 
+	// 没有配置文件时，默认关闭的告警类型由客户端的开关打开
+	g.severityMap = map[CheckErrorType]string{}
+
 	// 是否全部屏蔽
 	if !checkFlagList[0] {
 		g.showWarnFlag = false
-		for i := CheckErrorSyntax; i <= CheckErrorAnnotate; i++ {
+		for i := CheckErrorSyntax; i <= CheckErrorLast; i++ {
 			g.IgnoreErrorTypeMap[(CheckErrorType)(i)] = true
 		}
 		return
@@ -477,13 +563,15 @@ func (g *GlobalConfig) handleNotJSONCheckFlag(checkFlagList []bool, ignoreFileOr
 
 	g.showWarnFlag = true
 	g.IgnoreErrorTypeMap = map[CheckErrorType]bool{}
-	for i := CheckErrorSyntax; i <= CheckErrorAnnotate; i++ {
+	for i := CheckErrorSyntax; i <= CheckErrorLast; i++ {
 		if i > listLen-1 {
 			g.IgnoreErrorTypeMap[(CheckErrorType)(i)] = true
 		} else {
 			oneFlag := checkFlagList[i]
 			if !oneFlag {
 				g.IgnoreErrorTypeMap[(CheckErrorType)(i)] = true
+			} else if GetDefaultSeverity((CheckErrorType)(i)) == SeverityOff {
+				g.severityMap[(CheckErrorType)(i)] = SeverityWarning
 			}
 		}
 	}
@@ -508,35 +596,61 @@ func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList [
 	ignoreFileOrDirErr []string) error {
 	strPath := g.dirManager.GetCompletePath(strDir, configFileName)
 
-	bytes, err := ioutil.ReadFile(strPath)
-	if err != nil {
+	loader := createConfigLoader()
+	g.setConfigLoader(strDir, loader)
+	rawConfig, err := loader.loadFile(strPath, false)
+	if os.IsNotExist(err) {
 		log.Debug("not find %s file", configFileName)
 		// 没有读取到配置文件，设置一些默认值，忽略特定的告警
 		g.handleNotJSONCheckFlag(checkFlagList, ignoreFileOrDir, ignoreFileOrDirErr)
 		return nil
+	} else if err != nil {
+		strErr := fmt.Sprintf("read %s error=%s, json format error", configFileName, err.Error())
+		return errors.New(strErr)
 	}
 
-	//var data configInfo
-	err = json.Unmarshal(bytes, jsonConfig)
+	oneJSONConfig, err := parseRawJSONConfig(rawConfig, configFileName)
 	if err != nil {
+		return err
+	}
+
+	g.dirManager.setConfigRelativeDir(oneJSONConfig.BaseDir)
+	g.ProjectFiles = oneJSONConfig.ProjectFiles
+	g.applyJSONConfig(oneJSONConfig, strDir)
+	g.rawConfig = rawConfig
+
+	log.Debug("read ok")
+	return nil
+}
+
+// parseJSONConfig 解析配置文件的内容，没有配置的项为默认值
+func parseJSONConfig(bytes []byte, configFileName string) (*JSONConfig, error) {
+	oneJSONConfig := newDefaultJSONConfig()
+	if err := json.Unmarshal(bytes, oneJSONConfig); err != nil {
 		strErr := fmt.Sprintf("read %s error=%s, json format error", configFileName, err.Error())
-		return errors.New(strErr)
+		return nil, errors.New(strErr)
+	}
+
+	if oneJSONConfig.BaseDir == "" {
+		oneJSONConfig.BaseDir = "./"
 	}
 
+	return oneJSONConfig, nil
+}
+
+// applyJSONConfig 把读取到的json配置设置到GlobalConfig中，strDir为配置文件所在的目录
+func (g *GlobalConfig) applyJSONConfig(jsonConfig *JSONConfig, strDir string) {
 	g.anntotateSets = jsonConfig.AnntotateSets
 
 	// 读取到了json文件
 	g.ReadJSONFlag = true
 
-	if jsonConfig.BaseDir == "" {
-		jsonConfig.BaseDir = "./"
+	g.TlogXMLPath = ""
+	if jsonConfig.TlogXMLPath != "" {
+		g.TlogXMLPath = getConfigRelativePath(strDir, jsonConfig.TlogXMLPath)
 	}
-
-	g.dirManager.setConfigRelativeDir(jsonConfig.BaseDir)
-
-	g.ProjectFiles = jsonConfig.ProjectFiles
-
-	g.TlogXMLPath = jsonConfig.TlogXMLPath
+	g.tlogFuncs = jsonConfig.TlogFuncs
+	g.formatFuncs = jsonConfig.FormatFuncs
 	g.ReferMatchPathFlag = (jsonConfig.ReferMatchPathFlag == 1)
 	g.showWarnFlag = (jsonConfig.ShowWarnFlag == 1)
 
@@ -548,6 +662,23 @@ func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList [
 		g.ProtocolPreIngoreFlag = true
 	}
 
+	g.fileEncoding = jsonConfig.FileEncoding
+
+	// 各错误类型的诊断级别，非法的配置忽略
+	g.severityMap = map[CheckErrorType]string{}
+	for strType, strSeverity := range jsonConfig.DiagnosticSeverity {
+		errType, err := strconv.Atoi(strType)
+		if err != nil || errType <= 0 || !IsValidSeverity(strSeverity) {
+			continue
+		}
+		g.severityMap[CheckErrorType(errType)] = strSeverity
+	}
+
+	g.protoPaths = []string{}
+	for _, protoPath := range jsonConfig.ProtoPaths {
+		g.protoPaths = append(g.protoPaths, getConfigRelativePath(strDir, protoPath))
+	}
+
 	// 忽略的模块
 	g.IgnoreVarMap = map[string]string{}
 	for _, modeStr := range jsonConfig.IgnoreModules {
@@ -559,6 +690,7 @@ func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList [
 
 	// 指定文件，忽略的变量
 	g.IgnoreFileDefineVarMap = map[string](map[string]bool){}
+	g.ignoreFileVarRegexMap = map[string]*regexp.Regexp{}
 	for _, ignoreFileVars := range jsonConfig.IgnoreFileVars {
 		fileMapVars := map[string]bool{}
 		for _, fileVarsStr := range ignoreFileVars.Vars {
@@ -566,6 +698,9 @@ func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList [
 		}
 
 		g.IgnoreFileDefineVarMap[ignoreFileVars.Name] = fileMapVars
+		if fileRegex, err := regexp.Compile(ignoreFileVars.Name); err == nil {
+			g.ignoreFileVarRegexMap[ignoreFileVars.Name] = fileRegex
+		}
 	}
 
 	// 忽略某些读不到的文件，不进行报错
@@ -621,6 +756,12 @@ func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList [
 		g.IgnoreLocalNoUseVarMap[noUseStr] = true
 	}
 
+	// 局部变量遮蔽了外层同名的变量，忽略
+	g.ignoreShadowVarMap = map[string]bool{}
+	for _, shadowStr := range jsonConfig.IgnoreShadowVars {
+		g.ignoreShadowVarMap[shadowStr] = true
+	}
+
 	// 默认为后台的hive框架，会import引入一个文件，并且包含后缀，引入的方式为 import("one.lua")
 	if len(jsonConfig.ReferFrameFiles) == 0 {
 		jsonConfig.ReferFrameFiles = []referFrameFile{{Name: "import", Type: 0, SuffixFlag: 1}}
@@ -631,14 +772,17 @@ func (g *GlobalConfig) ReadConfig(strDir, configFileName string, checkFlagList [
 		g.ReferOtherFileMap[oneReferFrame.Name] = true
 		g.LuaInMap[oneReferFrame.Name] = "function"
 	}
-	GConfig.ReferFrameFiles = jsonConfig.ReferFrameFiles
+	g.ReferFrameFiles = jsonConfig.ReferFrameFiles
 
 	if jsonConfig.PathSeparator != "" {
-		GConfig.PathSeparator = jsonConfig.PathSeparator
+		g.PathSeparator = jsonConfig.PathSeparator
 	}
 
-	log.Debug("read ok")
-	return nil
+	// 关联的其他工作区文件夹
+	g.linkDirs = []string{}
+	for _, linkFolder := range jsonConfig.LinkFolders {
+		g.linkDirs = append(g.linkDirs, getConfigRelativePath(strDir, linkFolder))
+	}
 }
 
 // SetRequirePathSeparator 设置require其他lua文件时候的路径分割符
@@ -652,11 +796,59 @@ func (g *GlobalConfig) SetRequirePathSeparator(pathSeparator string) {
 		pathSeparator = "."
 	}
 
-	GConfig.PathSeparator = pathSeparator
+	g.PathSeparator = pathSeparator
+}
+
+// SetCallSnippetFlag 设置补全函数时是否插入带参数占位符的代码片段
+func (g *GlobalConfig) SetCallSnippetFlag(flag bool) {
+	g.callSnippetFlag = flag
+}
+
+// IsCallSnippet 补全函数时是否插入带参数占位符的代码片段
+func (g *GlobalConfig) IsCallSnippet() bool {
+	return g.callSnippetFlag
+}
+
+// DefaultDiagnosticDocURL 默认的错误类型说明文档，即LuaHelper仓库的docs/manual/config.md
+const DefaultDiagnosticDocURL = "https://github.com/Tencent/LuaHelper/blob/master/docs/manual/config.md"
+
+// SetDiagnosticDocURL 设置错误类型说明文档的地址，为空时使用默认的地址
+func (g *GlobalConfig) SetDiagnosticDocURL(strURL string) {
+	g.diagnosticDocURL = strURL
+}
+
+// GetDiagnosticDocHref 获取错误类型在说明文档中的链接，链接到文档中对应错误类型的锚点
+func (g *GlobalConfig) GetDiagnosticDocHref(errType CheckErrorType) string {
+	strURL := g.diagnosticDocURL
+	if strURL == "" {
+		strURL = DefaultDiagnosticDocURL
+	}
+
+	return fmt.Sprintf("%s#warn-type-%d", strURL, errType)
+}
+
+// GetErrorSeverity 获取错误类型的诊断级别，优先使用luahelper.json中配置的级别
+func (g *GlobalConfig) GetErrorSeverity(errType CheckErrorType) string {
+	if strSeverity, ok := g.severityMap[errType]; ok {
+		return strSeverity
+	}
+
+	return GetDefaultSeverity(errType)
+}
+
+// ReadLuaFile 读取磁盘上的Lua文件，按文件所在目录配置的编码转换为utf-8
+func (g *GlobalConfig) ReadLuaFile(strFile string) ([]byte, error) {
+	data, err := ioutil.ReadFile(strFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return codingconv.DecodeFileContent(data, g.GetFileConfig(strFile).fileEncoding), nil
 }
 
 // InsertIngoreSystemModule 如果为本地形式运行，加载不了插件前端的Lua额外文件夹，忽略系统模块。批量插入
 func (g *GlobalConfig) InsertIngoreSystemModule() {
+	g.ignoreSystemFlag = true
 	g.IgnoreVarMap["debug"] = "module"
 	g.IgnoreVarMap["math"] = "module"
 	g.IgnoreVarMap["os"] = "module"
@@ -733,12 +925,23 @@ func (g *GlobalConfig) IsIngoreNeedReadFile(str string) bool {
 
 // IsIgnoreFileDefineVar 判断是否为忽略的文件中的定义的变量
 func (g *GlobalConfig) IsIgnoreFileDefineVar(luaFile string, strName string) bool {
+	if fileConfig := g.GetFileConfig(luaFile); fileConfig != g {
+		return fileConfig.IsIgnoreFileDefineVar(luaFile, strName)
+	}
+
 	// todo 这个函数被很多地方调用，是否有优化到空间
 	for fileName, sencondMap := range g.IgnoreFileDefineVarMap {
+		if _, ok := sencondMap[strName]; !ok {
+			continue
+		}
+
 		if strings.Contains(luaFile, fileName) {
-			if _, ok := sencondMap[strName]; ok {
-				return true
-			}
+			return true
+		}
+
+		// 文件名也支持正则
+		if fileRegex, ok := g.ignoreFileVarRegexMap[fileName]; ok && fileRegex.MatchString(luaFile) {
+			return true
 		}
 	}
 
@@ -747,6 +950,10 @@ func (g *GlobalConfig) IsIgnoreFileDefineVar(luaFile string, strName string) boo
 
 // IsIgnoreErrorFile 判断文件是否为需要忽略告警的
 func (g *GlobalConfig) IsIgnoreErrorFile(strFile string, errType CheckErrorType) bool {
+	if fileConfig := g.GetFileConfig(strFile); fileConfig != g {
+		return fileConfig.IsIgnoreErrorFile(strFile, errType)
+	}
+
 	if !g.showWarnFlag {
 		return true
 	}
@@ -851,6 +1058,12 @@ func (g *GlobalConfig) IsHandleAsLua(strFile string) bool {
 
 // IsIgnoreCompleteFile 给定完整的文件路径，以及lua项目的根目录，判断是否需要屏蔽分析该文件
 func (g *GlobalConfig) IsIgnoreCompleteFile(strFile string) bool {
+	// 工作区文件夹或子目录有自己的配置，用自己的配置判断，路径都相对于所在的工程或工作区文件夹
+	if fileConfig := g.GetFileConfig(strFile); fileConfig != g {
+		strDir, _ := g.getFileFolderDir(strFile)
+		return fileConfig.isIgnoreTrimFile(strings.TrimPrefix(strFile, strDir))
+	}
+
 	dirManager := g.dirManager
 	// 如果主dir没有，忽略
 	mainDir := dirManager.GetMainDir()
@@ -864,7 +1077,11 @@ func (g *GlobalConfig) IsIgnoreCompleteFile(strFile string) bool {
 	}
 
 	// 去掉前缀的文件名
-	strTrim := strings.TrimPrefix(strFile, mainDir)
+	return g.isIgnoreTrimFile(strings.TrimPrefix(strFile, mainDir))
+}
+
+// isIgnoreTrimFile 去掉工程目录前缀的文件名，判断是否需要屏蔽分析该文件
+func (g *GlobalConfig) isIgnoreTrimFile(strTrim string) bool {
 	if g.isIgnoreFile(strTrim) {
 		log.Debug("strFile is ignore handle file=%s", strTrim)
 		return true
@@ -898,6 +1115,72 @@ func (g *GlobalConfig) GetStrProtocol(str string) string {
 	return ""
 }
 
+// IsTlogFunc 判断函数名是否为打tlog日志的函数，例如 tlog("PlayerLogin", {...}) 中的tlog
+func (g *GlobalConfig) IsTlogFunc(strName string) bool {
+	for _, strFunc := range g.tlogFuncs {
+		if strName == strFunc {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetTlogCallArgs 判断是否为打tlog日志的函数调用，例如 tlog("PlayerLogin", {...}) 或是 log:tlog("PlayerLogin", {...})
+// 是的时候返回第一个参数struct名称的字符串，以及第二个参数各字段的table构造，第二个参数不是table构造时为nil
+func (g *GlobalConfig) GetTlogCallArgs(callExp *ast.FuncCallExp) (strExp *ast.StringExp,
+	tableExp *ast.TableConstructorExp, ok bool) {
+	strFunc := ""
+	if callExp.NameExp != nil {
+		strFunc = callExp.NameExp.Str
+	} else {
+		switch prefixExp := callExp.PrefixExp.(type) {
+		case *ast.NameExp:
+			strFunc = prefixExp.Name
+		case *ast.TableAccessExp:
+			if keyExp, flag := prefixExp.KeyExp.(*ast.StringExp); flag {
+				strFunc = keyExp.Str
+			}
+		}
+	}
+
+	if strFunc == "" || !g.IsTlogFunc(strFunc) || len(callExp.Args) == 0 {
+		return nil, nil, false
+	}
+
+	strExp, ok = callExp.Args[0].(*ast.StringExp)
+	if !ok {
+		return nil, nil, false
+	}
+
+	if len(callExp.Args) > 1 {
+		tableExp, _ = callExp.Args[1].(*ast.TableConstructorExp)
+	}
+	return strExp, tableExp, true
+}
+
+// GetFormatFuncParamIndex 判断函数是否为配置的格式化字符串的包装函数，是的时候返回格式化字符串是第几个参数，从1开始
+// strName为调用的完整函数名，例如log.info、log:info，配置的函数名不包含.或:时只匹配最后一段的名称
+func (g *GlobalConfig) GetFormatFuncParamIndex(strName string) int {
+	strLast := strName
+	if index := strings.LastIndexAny(strName, ".:"); index >= 0 {
+		strLast = strName[index+1:]
+	}
+
+	for _, oneFunc := range g.formatFuncs {
+		if oneFunc.FuncName != strName && (strings.ContainsAny(oneFunc.FuncName, ".:") || oneFunc.FuncName != strLast) {
+			continue
+		}
+
+		if oneFunc.ParamIndex <= 0 {
+			return 1
+		}
+		return oneFunc.ParamIndex
+	}
+
+	return 0
+}
+
 // IsStrProtocol 判断给定的字符串是否是协议组中的，例如c2s, s2s
 // 传人的字符为c2s或是s2s
 func (g *GlobalConfig) IsStrProtocol(str string) bool {
@@ -978,12 +1261,15 @@ func (g *GlobalConfig) IsIgnoreRequireModuleError(strName string) bool {
 // RebuildSameFileNameVar 重构应该忽略的同文件名的变量
 // allFilesMap为最新的加载的所有文件名，包含了前缀
 func (g *GlobalConfig) RebuildSameFileNameVar(allFilesMap map[string]struct{}) {
+	g.IgnoreSameFileNameMap = map[string]bool{}
+	for _, folderConfig := range g.getAllFolderConfigs() {
+		folderConfig.RebuildSameFileNameVar(allFilesMap)
+	}
+
 	if !g.IgnoreFileNameVarFlag {
 		return
 	}
 
-	g.IgnoreSameFileNameMap = map[string]bool{}
-
 	// 获取后缀 /
 	for strFile := range allFilesMap {
 		lastIndex := strings.LastIndex(strFile, "/")
@@ -1036,7 +1322,7 @@ func (g *GlobalConfig) IsHasProjectEntryFile() bool {
 
 // IsFrameReferOtherFile 判断传入的函数是否为新增的框架引入其他文件的方式
 func (g *GlobalConfig) IsFrameReferOtherFile(strFile string) bool {
-	for _, oneReferFrame := range GConfig.ReferFrameFiles {
+	for _, oneReferFrame := range g.ReferFrameFiles {
 		if oneReferFrame.Name == strFile {
 			return true
 		}
@@ -1047,7 +1333,7 @@ func (g *GlobalConfig) IsFrameReferOtherFile(strFile string) bool {
 
 // IsImportSuffixFlag 引起其他的框架文件，判断是否要包括.lua后缀
 func (g *GlobalConfig) IsImportSuffixFlag(referNameStr string) bool {
-	for _, oneReferFrame := range GConfig.ReferFrameFiles {
+	for _, oneReferFrame := range g.ReferFrameFiles {
 		if oneReferFrame.Name != referNameStr {
 			continue
 		}
@@ -1066,7 +1352,7 @@ func (g *GlobalConfig) IsImportSuffixFlag(referNameStr string) bool {
 func (g *GlobalConfig) GetReferFrameSubType(referNameStr string) (referSubType ReferFrameType) {
 	referSubType = RtypeImport
 
-	for _, oneReferFrame := range GConfig.ReferFrameFiles {
+	for _, oneReferFrame := range g.ReferFrameFiles {
 		if oneReferFrame.Name != referNameStr {
 			continue
 		}
@@ -1090,7 +1376,7 @@ func (g *GlobalConfig) GetAllReferFileTypes() (strArray []string) {
 	strArray = append(strArray, "dofile")
 	strArray = append(strArray, "loadfile")
 	strArray = append(strArray, "require")
-	for _, oneReferFrame := range GConfig.ReferFrameFiles {
+	for _, oneReferFrame := range g.ReferFrameFiles {
 		strArray = append(strArray, oneReferFrame.Name)
 	}
 
@@ -1099,13 +1385,24 @@ func (g *GlobalConfig) GetAllReferFileTypes() (strArray []string) {
 
 // GetFrameReferFiles 获取框架中引入的其他文件方式的列表
 func (g *GlobalConfig) GetFrameReferFiles() (strArray []string) {
-	for _, oneReferFrame := range GConfig.ReferFrameFiles {
+	for _, oneReferFrame := range g.ReferFrameFiles {
 		strArray = append(strArray, oneReferFrame.Name)
 	}
 
 	return strArray
 }
 
+// GetRequireReferName 获取自动引入其他文件时使用的函数名，优先使用框架中配置的类似require的函数
+func (g *GlobalConfig) GetRequireReferName() string {
+	for _, oneReferFrame := range g.ReferFrameFiles {
+		if oneReferFrame.Type == 1 {
+			return oneReferFrame.Name
+		}
+	}
+
+	return "require"
+}
+
 // GetPathSeparator 获取路径分割符
 func (g *GlobalConfig) GetPathSeparator() string {
 	return g.PathSeparator
@@ -1145,6 +1442,16 @@ func (g *GlobalConfig) IsIgnoreLocNotUseVar(strName string) bool {
 	return flag
 }
 
+// IsIgnoreShadowVar 判断局部变量遮蔽外层同名变量时，是否为配置忽略的变量，_ 始终忽略
+func (g *GlobalConfig) IsIgnoreShadowVar(strName string) bool {
+	if strName == "_" {
+		return true
+	}
+
+	_, flag := g.ignoreShadowVarMap[strName]
+	return flag
+}
+
 // IsIgnoreProtocolPreVar 获取协议前缀变量未找到，是否忽略告警
 func (g *GlobalConfig) IsIgnoreProtocolPreVar() bool {
 	return g.ProtocolPreIngoreFlag
@@ -1170,6 +1477,13 @@ func (g *GlobalConfig) MatchAnnotateSet(funcName string) (flag bool, oneSet Annt
 
 // IsSpecialCheck 判断是否需要进行特殊的关联检查
 func (g *GlobalConfig) IsSpecialCheck() bool {
+	// 任意一个工作区文件夹需要特殊的关联检查，整个工程都需要
+	for _, folderConfig := range g.getAllFolderConfigs() {
+		if folderConfig.IsSpecialCheck() {
+			return true
+		}
+	}
+
 	if !g.showWarnFlag {
 		return false
 	}
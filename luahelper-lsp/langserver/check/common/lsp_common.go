@@ -23,6 +23,8 @@ const (
 	IKAnnotateClass ItemKind = 5
 	// IKAnnotateAlias 注解的alias类型
 	IKAnnotateAlias ItemKind = 6
+	// IKModule 其他文件的模块
+	IKModule ItemKind = 7
 	// CIKSnippet 注释
 	IKSnippet ItemKind = 15
 )
@@ -116,6 +118,9 @@ const (
 
 	// CTAnnotate 注解产生的颜色类型
 	CTAnnotate ColorType = 2
+
+	// CTReadonlyVar lua5.4中const与close属性的只读局部变量
+	CTReadonlyVar ColorType = 3
 )
 
 // OneColorResut 一种颜色类型的返回的数据
@@ -54,11 +54,12 @@ type ReferInfo struct {
 	Loc           lexer.Location // 具体的位置信息
 	ReferVarLocal bool           // 引用如果赋值给了变量，true表示赋值的变量是否为local变量
 	Valid         bool           // 第一遍check AST是否有效，如果无效，引用不用跟入进去分析，默认为true
+	StrFile       string         // 引用所在的文件，自定义引入方式的子类型按该文件所使用的配置获取
 }
 
-// CreateOneReferInfo 创建一个引用信息
-func CreateOneReferInfo(referTypeStr, referStr string, loc lexer.Location) *ReferInfo {
-	referType := StrToReferType(referTypeStr)
+// CreateOneReferInfo 创建一个引用信息，strFile为引用所在的文件
+func CreateOneReferInfo(strFile, referTypeStr, referStr string, loc lexer.Location) *ReferInfo {
+	referType := StrToReferType(strFile, referTypeStr)
 	if referType == ReferNotValid {
 		return nil
 	}
@@ -71,11 +72,12 @@ func CreateOneReferInfo(referTypeStr, referStr string, loc lexer.Location) *Refe
 		ReferVarLocal: false,
 		Valid:         true,
 		Loc:           loc,
+		StrFile:       strFile,
 	}
 }
 
-// StrToReferType 字符串转换为对应的ReferType
-func StrToReferType(referTypeStr string) (referType ReferType) {
+// StrToReferType 字符串转换为对应的ReferType，strFile所使用的配置决定了框架中引入文件的方式
+func StrToReferType(strFile, referTypeStr string) (referType ReferType) {
 	if referTypeStr == "dofile" {
 		referType = ReferTypeDofile
 	} else if referTypeStr == "loadfile" {
@@ -83,7 +85,7 @@ func StrToReferType(referTypeStr string) (referType ReferType) {
 	} else if referTypeStr == "require" {
 		referType = ReferTypeRequire
 	} else {
-		if GConfig.IsFrameReferOtherFile(referTypeStr) {
+		if GConfig.GetFileConfig(strFile).IsFrameReferOtherFile(referTypeStr) {
 			referType = ReferTypeFrame
 		} else {
 			referType = ReferNotValid
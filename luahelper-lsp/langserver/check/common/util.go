@@ -1157,7 +1157,8 @@ func CompleteFilePathToPreStr(pathFile string) (preStr string) {
 }
 
 // JudgeReferSuffixFlag 判断引入其他文件的方式，是不要引入的路径要包含其他文件的后缀
-func JudgeReferSuffixFlag(referType ReferType, referTypeStr string) bool {
+// strFile 为引入其他文件所在的文件
+func JudgeReferSuffixFlag(strFile string, referType ReferType, referTypeStr string) bool {
 	if referType == ReferTypeDofile || referType == ReferTypeLoadfile {
 		return true
 	}
@@ -1170,7 +1171,7 @@ func JudgeReferSuffixFlag(referType ReferType, referTypeStr string) bool {
 		return true
 	}
 
-	isSuffixFlag := GConfig.IsImportSuffixFlag(referTypeStr)
+	isSuffixFlag := GConfig.GetFileConfig(strFile).IsImportSuffixFlag(referTypeStr)
 	return isSuffixFlag
 }
 
@@ -95,10 +95,12 @@ type VarInfo struct {
 	VarIndex        uint8               // 当一行语句声明了多个变量时候，例如 local a, b 语句，显示变量的index，默认的为1，例子中a的index为1，b的index为2
 	IsParam         bool                // 是否为函数定义的参数，默认为false
 	IsForParam      bool                // 是否为泛型for语句产生的参数，默认为false
+	IsForNum        bool                // 是否为数值for语句的循环变量，默认为false
 	IsUse           bool                // 局部变量是否被引用使用过了,第一轮检测的时候，若没有使用进行告警
 	IsExpEmpty      bool                // 默认为false，指向的ReferExp是否为empty，例如定义的时候 a = nil， 那么IsExpEmpty为true, 当被赋值后，就不为true
 	IsMemFlag       bool                // 是否为其他的变量的成员变量，默认为false
 	IsClose         bool                // 是否为lua5.4 close熟悉的变量
+	IsConst         bool                // 是否为lua5.4 const属性的变量
 }
 
 // VarGetFlag 变量信息获取的方式
@@ -262,6 +264,24 @@ func (varInfo *VarInfo) GetVarTypeDetail() string {
 	return GetLuaTypeString(varInfo.VarType, varInfo.ReferExp)
 }
 
+// IsReadonly 判断局部变量是否为只读的，lua5.4中const与close属性的变量都不能再赋值
+func (varInfo *VarInfo) IsReadonly() bool {
+	return varInfo.IsConst || varInfo.IsClose
+}
+
+// GetAttrStr 获取局部变量lua5.4的属性描述，例如<const>，没有属性时返回空
+func (varInfo *VarInfo) GetAttrStr() string {
+	if varInfo.IsConst {
+		return "<const>"
+	}
+
+	if varInfo.IsClose {
+		return "<close>"
+	}
+
+	return ""
+}
+
 // IsHasTabkeKeyStr 查看LocVarInfo是否为指向Table，如果是指向Table，判断是否有传人的key值字符串
 func (varInfo *VarInfo) IsHasTabkeKeyStr(strTableKey string) (bool, lexer.Location) {
 	var exp ast.Exp
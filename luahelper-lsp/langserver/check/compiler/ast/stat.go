@@ -31,7 +31,7 @@ type EmptyStat struct{} // ‘;’
 // BreakStat break语句
 // break
 type BreakStat struct {
-	//Loc lexer.LocInfo
+	Loc lexer.Location
 }
 
 // LabelStat goto对应的标识符
@@ -0,0 +1,187 @@
+package ast
+
+import (
+	"luahelper-lsp/langserver/check/compiler/lexer"
+)
+
+// Node 语法树的节点，为*Block、Stat或是Exp
+type Node interface{}
+
+// Visitor 遍历语法树的访问者
+// Walk遍历到每个节点时调用Visit(node)，返回的w不为nil时，用w遍历该节点的子节点，所有子节点遍历完后再调用w.Visit(nil)
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk 按照在源码中的顺序深度优先遍历语法树
+// 语句中的变量名称、冒号调用的函数名FuncCallExp.NameExp不是单独的节点，不会遍历到
+// 注意函数调用语句FuncCallStat与函数调用表达式为同一个类型
+func Walk(v Visitor, node Node) {
+	if node == nil {
+		return
+	}
+	if block, ok := node.(*Block); ok && block == nil {
+		return
+	}
+
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Block:
+		for _, stat := range n.Stats {
+			Walk(v, stat)
+		}
+		walkExpList(v, n.RetExps)
+
+	// 语句
+	case *DoStat:
+		Walk(v, n.Block)
+	case *WhileStat:
+		Walk(v, n.Exp)
+		Walk(v, n.Block)
+	case *RepeatStat:
+		Walk(v, n.Block)
+		Walk(v, n.Exp)
+	case *IfStat:
+		for i, block := range n.Blocks {
+			if i < len(n.Exps) {
+				Walk(v, n.Exps[i])
+			}
+			Walk(v, block)
+		}
+	case *ForNumStat:
+		Walk(v, n.InitExp)
+		Walk(v, n.LimitExp)
+		Walk(v, n.StepExp)
+		Walk(v, n.Block)
+	case *ForInStat:
+		walkExpList(v, n.ExpList)
+		Walk(v, n.Block)
+	case *AssignStat:
+		walkExpList(v, n.VarList)
+		walkExpList(v, n.ExpList)
+	case *LocalVarDeclStat:
+		walkExpList(v, n.ExpList)
+	case *LocalFuncDefStat:
+		if n.Exp != nil {
+			Walk(v, n.Exp)
+		}
+
+	// 表达式
+	case *FuncCallExp:
+		Walk(v, n.PrefixExp)
+		walkExpList(v, n.Args)
+	case *FuncDefExp:
+		Walk(v, n.Block)
+	case *ParensExp:
+		Walk(v, n.Exp)
+	case *UnopExp:
+		Walk(v, n.Exp)
+	case *BinopExp:
+		Walk(v, n.Exp1)
+		Walk(v, n.Exp2)
+	case *ConcatExp:
+		Walk(v, n.Exp1)
+		Walk(v, n.Exp2)
+	case *TableAccessExp:
+		Walk(v, n.PrefixExp)
+		Walk(v, n.KeyExp)
+	case *TableConstructorExp:
+		for i, valExp := range n.ValExps {
+			if i < len(n.KeyExps) {
+				Walk(v, n.KeyExps[i])
+			}
+			Walk(v, valExp)
+		}
+	}
+
+	v.Visit(nil)
+}
+
+func walkExpList(v Visitor, expList []Exp) {
+	for _, exp := range expList {
+		Walk(v, exp)
+	}
+}
+
+// inspector 把函数转换为Visitor
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect 按照在源码中的顺序深度优先遍历语法树，f返回false时不再遍历该节点的子节点
+// 所有子节点遍历完后，会调用f(nil)
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// GetStatLoc 获取语句的位置，只有;这样的空语句没有位置信息
+func GetStatLoc(stat Stat) (loc lexer.Location, ok bool) {
+	switch n := stat.(type) {
+	case *BreakStat:
+		return n.Loc, true
+	case *LabelStat:
+		return n.Loc, true
+	case *GotoStat:
+		return n.Loc, true
+	case *DoStat:
+		return n.Loc, true
+	case *WhileStat:
+		return n.Loc, true
+	case *RepeatStat:
+		return n.Loc, true
+	case *IfStat:
+		return n.Loc, true
+	case *ForNumStat:
+		return n.Loc, true
+	case *ForInStat:
+		return n.Loc, true
+	case *AssignStat:
+		return n.Loc, true
+	case *LocalVarDeclStat:
+		return n.Loc, true
+	case *LocalFuncDefStat:
+		return n.Loc, true
+	case *FuncCallStat:
+		return n.Loc, true
+	}
+
+	return loc, false
+}
+
+// GetSubBlocks 获取语句直接包含的代码块，不包括函数定义的代码块
+func GetSubBlocks(stat Stat) []*Block {
+	switch n := stat.(type) {
+	case *DoStat:
+		return []*Block{n.Block}
+	case *WhileStat:
+		return []*Block{n.Block}
+	case *RepeatStat:
+		return []*Block{n.Block}
+	case *IfStat:
+		return n.Blocks
+	case *ForNumStat:
+		return []*Block{n.Block}
+	case *ForInStat:
+		return []*Block{n.Block}
+	}
+
+	return nil
+}
+
+// IsLoopStat 判断是否为循环语句
+func IsLoopStat(stat Stat) bool {
+	switch stat.(type) {
+	case *WhileStat, *RepeatStat, *ForNumStat, *ForInStat:
+		return true
+	}
+
+	return false
+}
@@ -93,3 +93,9 @@ var keywords = map[string]TkKind{
 	"until":    TkKwUntil,
 	"while":    TkKwWhile,
 }
+
+// IsKeyword 判断字符串是否为lua的关键字
+func IsKeyword(strName string) bool {
+	_, ok := keywords[strName]
+	return ok
+}
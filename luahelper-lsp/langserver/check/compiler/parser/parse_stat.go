@@ -64,7 +64,7 @@ func (p *Parser) parseBreakStat() *ast.BreakStat {
 	p.l.NextTokenOfKind(lexer.TkKwBreak)
 
 	return &ast.BreakStat{
-		//Loc: l.GetNowTokenLoc(),
+		Loc: p.l.GetNowTokenLoc(),
 	}
 }
 
@@ -343,13 +343,10 @@ func (p *Parser) getLocalAttribute() ast.LocalAttr {
 // 5.4
 // namelist ::= Name attrib {‘,’ Name attrib}
 // attrib ::= [ '<' Name '>' ] 5.4
+// 一条语句中定义了多个close属性的变量，在检查阶段告警，这里不中断语法分析
 func (p *Parser) finishLocalNameList(name0 string, varLoc0 lexer.Location, kind ast.LocalAttr) ([]string,
 	[]lexer.Location, []ast.LocalAttr) {
 	l := p.l
-	index := -1
-	if kind == ast.RDKTOCLOSE {
-		index++
-	}
 	names := []string{name0}
 	kinds := []ast.LocalAttr{kind}
 	locs := []lexer.Location{varLoc0}
@@ -358,13 +355,6 @@ func (p *Parser) finishLocalNameList(name0 string, varLoc0 lexer.Location, kind
 		_, name := l.NextIdentifier() // Name
 		loc := l.GetNowTokenLoc()
 		kind := p.getLocalAttribute()
-		if kind == ast.RDKTOCLOSE {
-			if index != -1 {
-				l.ErrorPrint("more than one to_be_close variables found in local list")
-			} else {
-				index++
-			}
-		}
 		locs = append(locs, loc)
 		kinds = append(kinds, kind)
 		names = append(names, name)
@@ -1,6 +1,10 @@
 package parser
 
-import "testing"
+import (
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"strings"
+	"testing"
+)
 
 func TestParseConst(t *testing.T) {
 	parser := CreateParser([]byte("local a<const> = 1"), "test")
@@ -55,6 +59,57 @@ func TestParseJitNumber(t *testing.T) {
 	}
 }
 
+func TestWalkOrder(t *testing.T) {
+	contentStr := `local a = b + c
+while d do
+	if e then break elseif f then g(h, { i = j }) end
+end
+local function k(l) return m end
+return n`
+	parser := CreateParser([]byte(contentStr), "test")
+	block, _, err := parser.BeginAnalyze()
+	if err != nil {
+		t.Fatalf("parser walk fatal, errstr=%s", err.Error())
+	}
+
+	// 按源码中的顺序遍历，函数定义中的代码块也会遍历到
+	nameVec := []string{}
+	breakLine, retNum := 0, 0
+	ast.Inspect(block, func(node ast.Node) bool {
+		switch subNode := node.(type) {
+		case *ast.NameExp:
+			nameVec = append(nameVec, subNode.Name)
+		case *ast.BreakStat:
+			breakLine = subNode.Loc.StartLine
+		case *ast.Block:
+			if subNode.RetExps != nil {
+				retNum++
+			}
+		}
+		return true
+	})
+	if strings.Join(nameVec, ",") != "b,c,d,e,f,g,h,j,m,n" || breakLine != 3 || retNum != 2 {
+		t.Fatalf("walk order error, names=%v, breakLine=%d, retNum=%d", nameVec, breakLine, retNum)
+	}
+
+	// 返回false时不遍历子节点
+	nameVec = nameVec[:0]
+	ast.Inspect(block, func(node ast.Node) bool {
+		if nameExp, ok := node.(*ast.NameExp); ok {
+			nameVec = append(nameVec, nameExp.Name)
+		}
+		_, ok := node.(*ast.WhileStat)
+		return !ok
+	})
+	if strings.Join(nameVec, ",") != "b,c,m,n" {
+		t.Fatalf("walk skip error, names=%v", nameVec)
+	}
+
+	if loc, ok := ast.GetStatLoc(block.Stats[1]); !ok || loc.StartLine != 2 || loc.EndLine != 4 {
+		t.Fatalf("while stat loc error, loc=%v", loc)
+	}
+}
+
 func BenchmarkHello(b *testing.B) {
 
 }
@@ -3,6 +3,7 @@ package projects
 import (
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/check/results"
+	"luahelper-lsp/langserver/check/tlogparser"
 )
 
 // 定义analysis访问check的接口
@@ -23,4 +24,7 @@ type Projects interface{
 
 	// GetAllFilesMap 获取所有的文件map
 	GetAllFilesMap() map[string]struct{}
+
+	// GetTlogStruct 获取lua文件配置的tlog xml中定义的struct，loadFlag表示是否加载了tlog xml
+	GetTlogStruct(strFile string, strName string) (tlogStruct *tlogparser.TlogStruct, loadFlag bool)
 }
\ No newline at end of file
@@ -0,0 +1,188 @@
+package protoparser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// protobuf .proto文件的词法分析，只区分标识符、数字、字符串以及符号，注释单独记录下来
+
+// protoTokenKind 切词的类型
+type protoTokenKind int
+
+const (
+	tkEOF    protoTokenKind = iota // 文件结束
+	tkIdent                        // 标识符或是关键字，例如message、int32、a.b.c 这样的完整名称分开切词
+	tkNumber                       // 数字
+	tkString                       // 字符串
+	tkSymbol                       // 符号，例如 { } ; = < > , [ ] ( ) .
+)
+
+// protoToken 切出来的一个词
+type protoToken struct {
+	kind protoTokenKind
+	str  string // 字符串时为去掉引号后的内容
+	line int    // 行号，从1开始
+	col  int    // 列号，为字符的偏移，从0开始
+}
+
+// protoComment 一行的注释
+type protoComment struct {
+	str   string // 注释的内容，去掉了//以及前后的空格
+	alone bool   // 这一行是否只有注释
+}
+
+// protoLexer 词法分析器
+type protoLexer struct {
+	chunk      []rune
+	pos        int
+	line       int
+	col        int
+	lineToken  bool                  // 当前行是否已经有了非注释的内容
+	commentMap map[int]*protoComment // 所有的注释，key为行号
+}
+
+func createProtoLexer(content string) *protoLexer {
+	return &protoLexer{
+		chunk:      []rune(content),
+		line:       1,
+		commentMap: map[int]*protoComment{},
+	}
+}
+
+// next 向前移动一个字符
+func (l *protoLexer) next() {
+	if l.chunk[l.pos] == '\n' {
+		l.line++
+		l.col = 0
+		l.lineToken = false
+	} else {
+		l.col++
+	}
+	l.pos++
+}
+
+// peek 获取当前位置之后第n个字符，超出范围时返回0
+func (l *protoLexer) peek(n int) rune {
+	if l.pos+n >= len(l.chunk) {
+		return 0
+	}
+	return l.chunk[l.pos+n]
+}
+
+// addComment 记录一行注释，同一行有多个注释时拼接起来
+func (l *protoLexer) addComment(line int, str string, alone bool) {
+	str = strings.TrimSpace(str)
+	if oldComment, ok := l.commentMap[line]; ok {
+		if str != "" {
+			oldComment.str = strings.TrimSpace(oldComment.str + " " + str)
+		}
+		return
+	}
+
+	l.commentMap[line] = &protoComment{
+		str:   str,
+		alone: alone,
+	}
+}
+
+// skipWhiteAndComment 跳过空白以及注释
+func (l *protoLexer) skipWhiteAndComment() {
+	for l.pos < len(l.chunk) {
+		ch := l.chunk[l.pos]
+		if unicode.IsSpace(ch) {
+			l.next()
+			continue
+		}
+
+		if ch == '/' && l.peek(1) == '/' {
+			line, alone := l.line, !l.lineToken
+			beginPos := l.pos + 2
+			for l.pos < len(l.chunk) && l.chunk[l.pos] != '\n' {
+				l.next()
+			}
+			l.addComment(line, strings.TrimLeft(string(l.chunk[beginPos:l.pos]), "/"), alone)
+			continue
+		}
+
+		if ch == '/' && l.peek(1) == '*' {
+			alone := !l.lineToken
+			l.next()
+			l.next()
+			beginPos, line := l.pos, l.line
+			for l.pos < len(l.chunk) && !(l.chunk[l.pos] == '*' && l.peek(1) == '/') {
+				if l.chunk[l.pos] == '\n' {
+					l.addComment(line, strings.TrimLeft(strings.TrimSpace(string(l.chunk[beginPos:l.pos])), "*"), alone)
+					beginPos, line, alone = l.pos+1, l.line+1, true
+				}
+				l.next()
+			}
+			l.addComment(line, strings.TrimLeft(strings.TrimSpace(string(l.chunk[beginPos:l.pos])), "*"), alone)
+			if l.pos < len(l.chunk) {
+				l.next()
+				l.next()
+			}
+			continue
+		}
+
+		return
+	}
+}
+
+// isIdentChar 判断是否为标识符的字符
+func isIdentChar(ch rune, first bool) bool {
+	if ch == '_' || unicode.IsLetter(ch) {
+		return true
+	}
+
+	return !first && unicode.IsDigit(ch)
+}
+
+// nextToken 切出下一个词
+func (l *protoLexer) nextToken() (token protoToken) {
+	l.skipWhiteAndComment()
+	token.line = l.line
+	token.col = l.col
+	if l.pos >= len(l.chunk) {
+		token.kind = tkEOF
+		return token
+	}
+
+	l.lineToken = true
+	beginPos := l.pos
+	ch := l.chunk[l.pos]
+	switch {
+	case isIdentChar(ch, true):
+		for l.pos < len(l.chunk) && isIdentChar(l.chunk[l.pos], false) {
+			l.next()
+		}
+		token.kind = tkIdent
+		token.str = string(l.chunk[beginPos:l.pos])
+	case unicode.IsDigit(ch) || ((ch == '-' || ch == '+') && unicode.IsDigit(l.peek(1))):
+		l.next()
+		for l.pos < len(l.chunk) && (isIdentChar(l.chunk[l.pos], false) || l.chunk[l.pos] == '.') {
+			l.next()
+		}
+		token.kind = tkNumber
+		token.str = string(l.chunk[beginPos:l.pos])
+	case ch == '"' || ch == '\'':
+		l.next()
+		for l.pos < len(l.chunk) && l.chunk[l.pos] != ch && l.chunk[l.pos] != '\n' {
+			if l.chunk[l.pos] == '\\' && l.pos+1 < len(l.chunk) {
+				l.next()
+			}
+			l.next()
+		}
+		token.kind = tkString
+		token.str = string(l.chunk[beginPos+1 : l.pos])
+		if l.pos < len(l.chunk) && l.chunk[l.pos] == ch {
+			l.next()
+		}
+	default:
+		l.next()
+		token.kind = tkSymbol
+		token.str = string(ch)
+	}
+
+	return token
+}
@@ -0,0 +1,460 @@
+package protoparser
+
+import (
+	"fmt"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"strings"
+)
+
+// protobuf .proto文件的语法分析，支持proto2与proto3
+// 只提取message与enum的定义，service、extend、option等内容跳过
+
+// ProtoField message的一个字段
+type ProtoField struct {
+	Name     string         // 字段的名称
+	NameLoc  lexer.Location // 字段名称的位置
+	TypeName string         // 字段的类型，map时为value的类型，例如int32、.pkg.Msg
+	KeyType  string         // map字段的key类型，不是map时为空
+	Repeated bool           // 是否为repeated字段
+	Comment  string         // 字段前面或是同一行后面的注释
+}
+
+// ProtoMessage 定义的一个message
+type ProtoMessage struct {
+	Name      string         // message的名称，嵌套的message包含外层的名称，例如Outer.Inner
+	NameLoc   lexer.Location // message名称的位置
+	Comment   string         // message前面的注释
+	FieldList []*ProtoField  // 所有的字段，包括oneof中的字段
+}
+
+// ProtoEnumValue enum的一个取值
+type ProtoEnumValue struct {
+	Name    string         // 取值的名称
+	NameLoc lexer.Location // 名称的位置
+	Number  string         // 对应的数值
+	Comment string         // 注释
+}
+
+// ProtoEnum 定义的一个enum
+type ProtoEnum struct {
+	Name      string            // enum的名称，嵌套在message中的包含外层的名称
+	NameLoc   lexer.Location    // enum名称的位置
+	Comment   string            // enum前面的注释
+	ValueList []*ProtoEnumValue // 所有的取值
+}
+
+// ProtoError 语法错误
+type ProtoError struct {
+	ErrStr string
+	Loc    lexer.Location
+}
+
+// ProtoFile 一个.proto文件解析的结果
+type ProtoFile struct {
+	Package     string          // package的名称
+	ImportList  []string        // import的文件
+	MessageList []*ProtoMessage // 所有的message，包括嵌套的
+	EnumList    []*ProtoEnum    // 所有的enum，包括嵌套的
+	ErrList     []ProtoError    // 语法错误，出错的语句会跳过
+}
+
+// protoParser 语法分析器
+type protoParser struct {
+	tokenVec   []protoToken
+	index      int
+	commentMap map[int]*protoComment
+	result     *ProtoFile
+}
+
+// ParseProto 解析.proto文件的内容
+func ParseProto(content string) *ProtoFile {
+	l := createProtoLexer(content)
+	p := &protoParser{
+		result: &ProtoFile{},
+	}
+
+	for {
+		token := l.nextToken()
+		p.tokenVec = append(p.tokenVec, token)
+		if token.kind == tkEOF {
+			break
+		}
+	}
+	p.commentMap = l.commentMap
+
+	p.parseTopLevel()
+	return p.result
+}
+
+// now 当前的词
+func (p *protoParser) now() protoToken {
+	return p.tokenVec[p.index]
+}
+
+// lookAhead 当前之后的第n个词
+func (p *protoParser) lookAhead(n int) protoToken {
+	if p.index+n >= len(p.tokenVec) {
+		return p.tokenVec[len(p.tokenVec)-1]
+	}
+	return p.tokenVec[p.index+n]
+}
+
+// nextToken 获取当前的词，并向前移动
+func (p *protoParser) nextToken() protoToken {
+	token := p.tokenVec[p.index]
+	if token.kind != tkEOF {
+		p.index++
+	}
+	return token
+}
+
+// isSymbol 当前的词是否为指定的符号
+func (p *protoParser) isSymbol(str string) bool {
+	token := p.now()
+	return token.kind == tkSymbol && token.str == str
+}
+
+// isIdent 当前的词是否为指定的标识符
+func (p *protoParser) isIdent(str string) bool {
+	token := p.now()
+	return token.kind == tkIdent && token.str == str
+}
+
+// pushError 插入一个语法错误
+func (p *protoParser) pushError(token protoToken, errStr string) {
+	p.result.ErrList = append(p.result.ErrList, ProtoError{
+		ErrStr: errStr,
+		Loc:    getTokenLoc(token),
+	})
+}
+
+// getTokenLoc 获取词的位置
+func getTokenLoc(token protoToken) lexer.Location {
+	return lexer.Location{
+		StartLine:   token.line,
+		StartColumn: token.col,
+		EndLine:     token.line,
+		EndColumn:   token.col + len([]rune(token.str)),
+	}
+}
+
+// getLeadingComment 获取某一行前面紧挨着的注释
+func (p *protoParser) getLeadingComment(line int) string {
+	beginLine := line
+	for {
+		comment, ok := p.commentMap[beginLine-1]
+		if !ok || !comment.alone {
+			break
+		}
+		beginLine--
+	}
+
+	strVec := []string{}
+	for i := beginLine; i < line; i++ {
+		strVec = append(strVec, p.commentMap[i].str)
+	}
+	return strings.TrimSpace(strings.Join(strVec, "\n"))
+}
+
+// getComment 获取定义的注释，优先为前面的注释，其次为同一行后面的注释
+func (p *protoParser) getComment(beginLine, endLine int) string {
+	if strComment := p.getLeadingComment(beginLine); strComment != "" {
+		return strComment
+	}
+
+	if comment, ok := p.commentMap[endLine]; ok && !comment.alone {
+		return comment.str
+	}
+	return ""
+}
+
+// skipStatement 跳过一条语句，语句以;结束，包含{}的跳过整个块
+func (p *protoParser) skipStatement() {
+	for {
+		token := p.now()
+		if token.kind == tkEOF {
+			return
+		}
+
+		if p.isSymbol("}") {
+			return
+		}
+
+		p.nextToken()
+		if token.kind == tkSymbol && token.str == ";" {
+			return
+		}
+
+		if token.kind == tkSymbol && token.str == "{" {
+			p.skipBlockBody()
+			return
+		}
+	}
+}
+
+// skipBlockBody 跳过{}块中的内容，当前位置为{之后
+func (p *protoParser) skipBlockBody() {
+	depth := 1
+	for depth > 0 {
+		token := p.nextToken()
+		if token.kind == tkEOF {
+			return
+		}
+
+		if token.kind == tkSymbol && token.str == "{" {
+			depth++
+		} else if token.kind == tkSymbol && token.str == "}" {
+			depth--
+		}
+	}
+}
+
+// parseFullIdent 解析完整的名称，例如 .pkg.Msg
+func (p *protoParser) parseFullIdent() (strName string, ok bool) {
+	if p.isSymbol(".") {
+		p.nextToken()
+		strName = "."
+	}
+
+	for {
+		token := p.now()
+		if token.kind != tkIdent {
+			return strName, false
+		}
+		p.nextToken()
+		strName += token.str
+
+		if !p.isSymbol(".") {
+			return strName, true
+		}
+		p.nextToken()
+		strName += "."
+	}
+}
+
+// expectSymbol 判断当前为指定的符号，并向前移动
+func (p *protoParser) expectSymbol(str string) bool {
+	if !p.isSymbol(str) {
+		p.pushError(p.now(), fmt.Sprintf("expect '%s', near '%s'", str, p.now().str))
+		return false
+	}
+
+	p.nextToken()
+	return true
+}
+
+// parseTopLevel 解析文件最外层的定义
+func (p *protoParser) parseTopLevel() {
+	for p.now().kind != tkEOF {
+		token := p.now()
+		switch {
+		case p.isSymbol(";"):
+			p.nextToken()
+		case p.isIdent("package"):
+			p.nextToken()
+			if strName, ok := p.parseFullIdent(); ok {
+				p.result.Package = strName
+			}
+			p.skipStatement()
+		case p.isIdent("import"):
+			p.nextToken()
+			if p.isIdent("public") || p.isIdent("weak") {
+				p.nextToken()
+			}
+			if p.now().kind == tkString {
+				p.result.ImportList = append(p.result.ImportList, p.now().str)
+			}
+			p.skipStatement()
+		case p.isIdent("message"):
+			p.parseMessage("")
+		case p.isIdent("enum"):
+			p.parseEnum("")
+		case p.isSymbol("}"):
+			p.pushError(token, "unexpected '}'")
+			p.nextToken()
+		default:
+			// syntax、option、service、extend 等直接跳过
+			p.skipStatement()
+		}
+	}
+}
+
+// parseMessage 解析message的定义，当前位置为message关键字
+func (p *protoParser) parseMessage(strScope string) {
+	beginToken := p.nextToken()
+	nameToken := p.now()
+	if nameToken.kind != tkIdent {
+		p.pushError(nameToken, "expect message name")
+		p.skipStatement()
+		return
+	}
+	p.nextToken()
+
+	message := &ProtoMessage{
+		Name:    strScope + nameToken.str,
+		NameLoc: getTokenLoc(nameToken),
+		Comment: p.getComment(beginToken.line, nameToken.line),
+	}
+	p.result.MessageList = append(p.result.MessageList, message)
+
+	if !p.expectSymbol("{") {
+		p.skipStatement()
+		return
+	}
+
+	p.parseMessageBody(message)
+}
+
+// parseMessageBody 解析message中的内容，直到}结束
+func (p *protoParser) parseMessageBody(message *ProtoMessage) {
+	for {
+		token := p.now()
+		switch {
+		case token.kind == tkEOF:
+			p.pushError(token, "expect '}' to close message "+message.Name)
+			return
+		case p.isSymbol("}"):
+			p.nextToken()
+			return
+		case p.isSymbol(";"):
+			p.nextToken()
+		case p.isIdent("message") && p.lookAhead(1).kind == tkIdent:
+			p.parseMessage(message.Name + ".")
+		case p.isIdent("enum") && p.lookAhead(1).kind == tkIdent:
+			p.parseEnum(message.Name + ".")
+		case p.isIdent("oneof") && p.lookAhead(1).kind == tkIdent && p.lookAhead(2).str == "{":
+			p.nextToken()
+			p.nextToken()
+			p.nextToken()
+			p.parseMessageBody(message)
+		case p.isIdent("option") || p.isIdent("reserved") || p.isIdent("extensions") || p.isIdent("extend"):
+			p.skipStatement()
+		default:
+			p.parseField(message)
+		}
+	}
+}
+
+// parseField 解析message中的一个字段
+// [repeated|optional|required] type name = number [options];
+// map<key, value> name = number [options];
+func (p *protoParser) parseField(message *ProtoMessage) {
+	beginToken := p.now()
+	field := &ProtoField{}
+	if p.isIdent("repeated") || p.isIdent("optional") || p.isIdent("required") {
+		field.Repeated = p.isIdent("repeated")
+		p.nextToken()
+	}
+
+	if p.isIdent("map") && p.lookAhead(1).str == "<" {
+		p.nextToken()
+		p.nextToken()
+		keyType, ok1 := p.parseFullIdent()
+		ok2 := ok1 && p.expectSymbol(",")
+		valueType, ok3 := p.parseFullIdent()
+		if !ok2 || !ok3 || !p.expectSymbol(">") {
+			p.skipStatement()
+			return
+		}
+		field.KeyType = keyType
+		field.TypeName = valueType
+	} else {
+		typeName, ok := p.parseFullIdent()
+		if !ok {
+			p.pushError(p.now(), "expect field type, near '"+p.now().str+"'")
+			p.skipStatement()
+			return
+		}
+		field.TypeName = typeName
+	}
+
+	nameToken := p.now()
+	if nameToken.kind != tkIdent {
+		p.pushError(nameToken, "expect field name, near '"+nameToken.str+"'")
+		p.skipStatement()
+		return
+	}
+	p.nextToken()
+	field.Name = nameToken.str
+	field.NameLoc = getTokenLoc(nameToken)
+
+	if field.TypeName == "group" {
+		// proto2 的group，字段名称为group的名称
+		p.skipStatement()
+		return
+	}
+
+	// 跳过字段的编号以及选项，直到;结束
+	endToken := p.now()
+	for !p.isSymbol(";") && !p.isSymbol("}") && p.now().kind != tkEOF {
+		endToken = p.nextToken()
+	}
+	if p.isSymbol(";") {
+		endToken = p.nextToken()
+	} else {
+		p.pushError(p.now(), "expect ';' after field "+field.Name)
+	}
+
+	field.Comment = p.getComment(beginToken.line, endToken.line)
+	message.FieldList = append(message.FieldList, field)
+}
+
+// parseEnum 解析enum的定义，当前位置为enum关键字
+func (p *protoParser) parseEnum(strScope string) {
+	beginToken := p.nextToken()
+	nameToken := p.now()
+	if nameToken.kind != tkIdent {
+		p.pushError(nameToken, "expect enum name")
+		p.skipStatement()
+		return
+	}
+	p.nextToken()
+
+	enum := &ProtoEnum{
+		Name:    strScope + nameToken.str,
+		NameLoc: getTokenLoc(nameToken),
+		Comment: p.getComment(beginToken.line, nameToken.line),
+	}
+	p.result.EnumList = append(p.result.EnumList, enum)
+
+	if !p.expectSymbol("{") {
+		p.skipStatement()
+		return
+	}
+
+	for {
+		token := p.now()
+		switch {
+		case token.kind == tkEOF:
+			p.pushError(token, "expect '}' to close enum "+enum.Name)
+			return
+		case p.isSymbol("}"):
+			p.nextToken()
+			return
+		case p.isSymbol(";"):
+			p.nextToken()
+		case p.isIdent("option") || p.isIdent("reserved"):
+			p.skipStatement()
+		case token.kind == tkIdent && p.lookAhead(1).str == "=":
+			p.nextToken()
+			p.nextToken()
+			enumValue := &ProtoEnumValue{
+				Name:    token.str,
+				NameLoc: getTokenLoc(token),
+				Number:  p.now().str,
+			}
+			endLine := p.now().line
+			for !p.isSymbol(";") && !p.isSymbol("}") && p.now().kind != tkEOF {
+				endLine = p.nextToken().line
+			}
+			if p.isSymbol(";") {
+				endLine = p.nextToken().line
+			}
+			enumValue.Comment = p.getComment(token.line, endLine)
+			enum.ValueList = append(enum.ValueList, enumValue)
+		default:
+			p.pushError(token, "unexpected '"+token.str+"' in enum "+enum.Name)
+			p.skipStatement()
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package protoparser
+
+import (
+	"testing"
+)
+
+func TestParseProto(t *testing.T) {
+	content := `syntax = "proto3";
+package game.login;
+
+import "common.proto";
+
+// 登录请求
+message LoginReq {
+    string account = 1; // 账号
+    // 登录的平台
+    Platform platform = 2;
+    repeated .game.common.Item items = 3 [packed = true];
+    map<string, int64> attrs = 4;
+    oneof extra {
+        int32 code = 5;
+    }
+
+    message Device {
+        string os = 1;
+    }
+
+    enum Platform {
+        PC = 0;
+        MOBILE = 1; /* 手机 */
+    }
+    reserved 10 to 20;
+}
+
+service Login {
+    rpc Login (LoginReq) returns (LoginRsp) { option deprecated = true; }
+}
+`
+	protoFile := ParseProto(content)
+	if len(protoFile.ErrList) != 0 {
+		t.Fatalf("parse proto err=%s, line=%d", protoFile.ErrList[0].ErrStr, protoFile.ErrList[0].Loc.StartLine)
+	}
+
+	if protoFile.Package != "game.login" || len(protoFile.ImportList) != 1 {
+		t.Fatalf("parse proto package=%s, import=%v", protoFile.Package, protoFile.ImportList)
+	}
+
+	if len(protoFile.MessageList) != 2 || len(protoFile.EnumList) != 1 {
+		t.Fatalf("parse proto message num=%d, enum num=%d", len(protoFile.MessageList), len(protoFile.EnumList))
+	}
+
+	message := protoFile.MessageList[0]
+	if message.Name != "LoginReq" || message.Comment != "登录请求" || message.NameLoc.StartLine != 7 ||
+		message.NameLoc.StartColumn != 8 {
+		t.Fatalf("parse proto message=%s, comment=%s, loc=%v", message.Name, message.Comment, message.NameLoc)
+	}
+
+	if protoFile.MessageList[1].Name != "LoginReq.Device" || protoFile.EnumList[0].Name != "LoginReq.Platform" {
+		t.Fatalf("parse proto nested message=%s, enum=%s", protoFile.MessageList[1].Name, protoFile.EnumList[0].Name)
+	}
+
+	fieldList := []ProtoField{
+		{Name: "account", TypeName: "string", Comment: "账号"},
+		{Name: "platform", TypeName: "Platform", Comment: "登录的平台"},
+		{Name: "items", TypeName: ".game.common.Item", Repeated: true},
+		{Name: "attrs", TypeName: "int64", KeyType: "string"},
+		{Name: "code", TypeName: "int32"},
+	}
+	if len(message.FieldList) != len(fieldList) {
+		t.Fatalf("parse proto field num=%d, expect=%d", len(message.FieldList), len(fieldList))
+	}
+
+	for index, oneField := range message.FieldList {
+		expectField := fieldList[index]
+		if oneField.Name != expectField.Name || oneField.TypeName != expectField.TypeName ||
+			oneField.KeyType != expectField.KeyType || oneField.Repeated != expectField.Repeated ||
+			oneField.Comment != expectField.Comment {
+			t.Fatalf("parse proto field=%v, expect=%v", *oneField, expectField)
+		}
+	}
+
+	enumValue := protoFile.EnumList[0].ValueList[1]
+	if enumValue.Name != "MOBILE" || enumValue.Number != "1" || enumValue.Comment != "手机" {
+		t.Fatalf("parse proto enum value=%v", *enumValue)
+	}
+}
+
+func TestParseProtoError(t *testing.T) {
+	content := `message A {
+    int32 = 1;
+    string name = 2;
+}
+`
+	protoFile := ParseProto(content)
+	if len(protoFile.ErrList) != 1 || protoFile.ErrList[0].Loc.StartLine != 2 {
+		t.Fatalf("parse proto err num=%d", len(protoFile.ErrList))
+	}
+
+	if len(protoFile.MessageList) != 1 || len(protoFile.MessageList[0].FieldList) != 1 {
+		t.Fatalf("parse proto skip error field failed")
+	}
+}
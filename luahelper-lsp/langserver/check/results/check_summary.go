@@ -0,0 +1,85 @@
+package results
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"sort"
+)
+
+// CheckSummary 第二、三阶段分析结果中，可以序列化保存到磁盘缓存的部分
+// 启动时作为上一次的分析结果进行增量分析，内容没有变化的文件不需要遍历AST，沿用之前的告警
+type CheckSummary struct {
+	FileHashMap  map[string]string          // 分析时各个文件内容的hash值，没有摘要的文件不在其中
+	DependentMap map[string][]string        // 分析时直接依赖各个文件的其他文件，文件变化之前的依赖关系
+	ProjectMap   map[string]*ProjectSummary // 第二阶段各个工程的结果，key值为工程的入口文件
+	Third        *ThirdSummary              // 第三阶段散落文件的结果，为nil表示没有进行第三阶段的分析
+}
+
+// ProjectSummary 第二阶段单个工程的结果
+type ProjectSummary struct {
+	EnterFileVec []string                       // 按执行顺序依次进入分析的文件
+	AllFiles     []string                       // 该工程包含的所有的lua文件
+	FileErrorMap map[string][]common.CheckError // 第二阶段所有的分析错误信息
+}
+
+// ThirdSummary 第三阶段散落文件的结果
+type ThirdSummary struct {
+	AllFile      []string                       // 所有散落的lua文件
+	FileErrorMap map[string][]common.CheckError // 第三阶段所有的分析错误信息
+}
+
+// sortedFiles 文件集合转换成排好序的列表
+func sortedFiles(fileMap map[string]struct{}) []string {
+	fileVec := make([]string, 0, len(fileMap))
+	for strFile := range fileMap {
+		fileVec = append(fileVec, strFile)
+	}
+	sort.Strings(fileVec)
+	return fileVec
+}
+
+// CreateProjectSummary 由第二阶段分析完的工程，生成摘要
+func CreateProjectSummary(second *SingleProjectResult) *ProjectSummary {
+	return &ProjectSummary{
+		EnterFileVec: second.EnterFileVec,
+		AllFiles:     sortedFiles(second.AllFiles),
+		FileErrorMap: second.FileErrorMap,
+	}
+}
+
+// CreateSecondProject 由摘要还原出第二阶段分析完的工程，只用于增量分析
+func (p *ProjectSummary) CreateSecondProject(entryFile string) *SingleProjectResult {
+	second := CreateAnalysisSecondProject(entryFile)
+	second.EnterFileVec = p.EnterFileVec
+	for _, strFile := range p.AllFiles {
+		second.AllFiles[strFile] = struct{}{}
+	}
+	if p.FileErrorMap != nil {
+		second.FileErrorMap = p.FileErrorMap
+	}
+	return second
+}
+
+// CreateThirdSummary 由第三阶段分析完的结果，生成摘要
+func CreateThirdSummary(third *AnalysisThird) *ThirdSummary {
+	fileMap := make(map[string]struct{}, len(third.AllFile))
+	for strFile := range third.AllFile {
+		fileMap[strFile] = struct{}{}
+	}
+
+	return &ThirdSummary{
+		AllFile:      sortedFiles(fileMap),
+		FileErrorMap: third.FileErrorMap,
+	}
+}
+
+// CreateAnalysisThird 由摘要还原出第三阶段分析完的结果，只用于增量分析
+func (t *ThirdSummary) CreateAnalysisThird() *AnalysisThird {
+	third := CreateAnalysisThirdAllStruct()
+	for _, strFile := range t.AllFile {
+		third.AllFile[strFile] = true
+	}
+	if t.FileErrorMap != nil {
+		third.FileErrorMap = t.FileErrorMap
+	}
+	return third
+}
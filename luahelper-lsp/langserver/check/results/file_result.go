@@ -224,9 +224,10 @@ func (f *FileResult) CheckReferFile(referInfo *common.ReferInfo, allFilesMap map
 	strFile := referInfo.ReferStr
 	strFile = pathpre.GetRemovePreStr(strFile)
 	curFile := f.Name
+	fileConfig := common.GConfig.GetFileConfig(curFile)
 
 	// 如果该文件是需要被忽略，直接返回
-	if common.GConfig.IsIngoreNeedReadFile(strFile) {
+	if fileConfig.IsIngoreNeedReadFile(strFile) {
 		referInfo.Valid = false
 		return
 	}
@@ -234,7 +235,7 @@ func (f *FileResult) CheckReferFile(referInfo *common.ReferInfo, allFilesMap map
 	dirManager := common.GConfig.GetDirManager()
 
 	// 判断引入方式，是否要包含后缀的方式
-	suffixFlag := common.JudgeReferSuffixFlag(referInfo.ReferType, referInfo.ReferTypeStr)
+	suffixFlag := common.JudgeReferSuffixFlag(curFile, referInfo.ReferType, referInfo.ReferTypeStr)
 	// 1) 是带后缀的
 	if suffixFlag {
 		// 1.1) 如果是包含了后缀，查看路径下面是否直接有, 直接存在返回true
@@ -246,7 +247,7 @@ func (f *FileResult) CheckReferFile(referInfo *common.ReferInfo, allFilesMap map
 		}
 
 		// 1.2) 非全路径匹配
-		if !common.GConfig.ReferMatchPathFlag {
+		if !fileConfig.ReferMatchPathFlag {
 			// 如果配置为非全路径匹配，尝试模糊匹配路径
 			bestFilePath := common.GetBestMatchReferFile(curFile, strFile, allFilesMap)
 			if bestFilePath != "" {
@@ -267,7 +268,7 @@ func (f *FileResult) CheckReferFile(referInfo *common.ReferInfo, allFilesMap map
 	}
 
 	// 判断是否忽略require 该模块变量
-	if referInfo.ReferType == common.ReferTypeRequire && common.GConfig.IsIgnoreRequireModuleError(strFile) {
+	if referInfo.ReferType == common.ReferTypeRequire && fileConfig.IsIgnoreRequireModuleError(strFile) {
 		referInfo.Valid = false
 		return
 	}
@@ -275,7 +276,7 @@ func (f *FileResult) CheckReferFile(referInfo *common.ReferInfo, allFilesMap map
 	// 下面处理，引入不包含后缀的
 	// require可能包含. 替换为/
 	strNewFile := strings.Replace(strFile, ".", "/", -1)
-	if common.GConfig.ReferMatchPathFlag {
+	if fileConfig.ReferMatchPathFlag {
 		// a) 优先尝试找so
 		// 如果不匹配，但是下面存在.so的，优先匹配到so的
 		soFile := strNewFile + ".so"
@@ -402,7 +403,14 @@ func (f *FileResult) ReanalyseReferInfo(needReferFileMap map[string]struct{}, al
 
 	log.Debug("strFile=%s has change refer", f.Name)
 
-	// 2) 如果该文件有变动的引用关系，引用关系重新梳理，清除掉之前的引用关系错误
+	// 2) 如果该文件有变动的引用关系，引用关系重新梳理
+	f.RecheckAllReferInfo(allFilesMap)
+}
+
+// RecheckAllReferInfo 清除掉之前的引用关系错误，重新扫描所有的引用关系
+// allFilesMap map[string]bool 为所有加载的文件列表
+func (f *FileResult) RecheckAllReferInfo(allFilesMap map[string]struct{}) {
+	// 1) 清除掉之前的引用关系错误
 	var newErrVec []common.CheckError
 	for _, oneError := range f.CheckErrVec {
 		if oneError.ErrType == common.CheckErrorNoFile {
@@ -413,7 +421,7 @@ func (f *FileResult) ReanalyseReferInfo(needReferFileMap map[string]struct{}, al
 	}
 	f.CheckErrVec = newErrVec
 
-	// 3) 然后重新扫描所有的引用关系
+	// 2) 然后重新扫描所有的引用关系
 	for _, oneRefer := range f.ReferVec {
 		oneRefer.Valid = true
 		f.CheckReferFile(oneRefer, allFilesMap)
@@ -461,7 +469,7 @@ func (f *FileResult) FindAllSymbol() (symbolVec []common.FileSymbolStruct) {
 
 	// 2) 查找这个文件的所有全局变量
 	for strVar, oneVar := range f.GlobalMaps {
-		if common.GConfig.IsStrProtocol(strVar) {
+		if common.GConfig.GetFileConfig(f.Name).IsStrProtocol(strVar) {
 			continue
 		}
 
@@ -683,7 +691,7 @@ func (f *FileResult) GetFuncInfoReferGlobalName(funcInfo *common.FuncInfo) strin
 // FindGMapsScopes 找到当前全局变量 和 全局协议变量下的所有Scope
 func (f *FileResult) FindGMapsScopes() (scopes []*common.ScopeInfo) {
 	for strName, varInfo := range f.GlobalMaps {
-		if common.GConfig.IsStrProtocol(strName) {
+		if common.GConfig.GetFileConfig(f.Name).IsStrProtocol(strName) {
 			continue
 		}
 
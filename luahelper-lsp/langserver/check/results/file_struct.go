@@ -1,6 +1,9 @@
 package results
 
-import "luahelper-lsp/langserver/check/common"
+import (
+	"luahelper-lsp/langserver/check/common"
+	"sync"
+)
 
 // FileHandleResult 表示文件处理的结果
 type FileHandleResult int
@@ -22,6 +25,8 @@ type FileStruct struct {
 	AnnotateFile *common.AnnotateFile // 这个文件对应注解信息
 	IsCommonFile bool                 // 是否是常规工程下的文件
 	Contents     []byte               // 文件内容的切片，用于比较文件是否有变动
+	SummaryFlag  bool                 // 结果是否只是磁盘缓存的摘要，为true时没有AST，需要调用LoadSummary补全
+	summaryMutex sync.Mutex           // 补全摘要的互斥锁
 }
 
 // CreateFileStruct 创建一个新的FileStruct
@@ -38,4 +43,17 @@ func CreateFileStruct(strFile string) *FileStruct {
 // GetFileHandleErr 获取是否有错误
 func (f *FileStruct) GetFileHandleErr() FileHandleResult {
 	return f.HandleResult
-}
\ No newline at end of file
+}
+
+// LoadSummary 若结果只是磁盘缓存的摘要，调用loadFunc重新进行完整的分析，多协程安全
+func (f *FileStruct) LoadSummary(loadFunc func(f *FileStruct)) {
+	f.summaryMutex.Lock()
+	defer f.summaryMutex.Unlock()
+
+	if !f.SummaryFlag {
+		return
+	}
+
+	loadFunc(f)
+	f.SummaryFlag = false
+}
@@ -0,0 +1,224 @@
+package results
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/ast"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+)
+
+// FileSummary 单个文件第一阶段结果中，可以序列化保存到磁盘缓存的部分
+// 包含全局变量、引用信息、函数的签名、注释片段以及第一阶段的告警，不包含AST
+type FileSummary struct {
+	StrFile     string                     // 文件的名称
+	ContentHash string                     // 文件内容的hash值
+	Loc         lexer.Location             // 整个文件主函数的位置信息
+	ReferVec    []common.ReferInfo         // 所有的引用信息
+	Globals     []SummaryVar               // 所有的全局变量，同名的按定义的先后顺序存放
+	Protocols   []SummaryVar               // 所有的协议前缀符号
+	Nodefines   []SummaryVar               // 没有在本文件定义但是出现的全局变量
+	CheckErrVec []common.CheckError        // 第一阶段的告警
+	CommentMap  map[int]*lexer.CommentInfo // 所有的注释信息，注解片段由这里重新生成
+}
+
+// SummaryFunc 函数的签名信息
+type SummaryFunc struct {
+	ParamList     []string       // 函数所有的参数列表
+	Loc           lexer.Location // 位置信息
+	FuncLv        int            // func的层级
+	IsVararg      bool           // 是否含义可变参数
+	IsColon       bool           // 是否为: 这样的函数
+	RelateVarName string         // 冒号函数反向关联的变量名
+}
+
+// SummaryGlobal 全局变量的扩展信息
+type SummaryGlobal struct {
+	StrProPre string // 协议的前缀
+	FuncLv    int    // 函数的层级
+	ScopeLv   int    // 所在的scope层数
+	GFlag     bool   // 是否为_G 类型的变量
+}
+
+// SummaryVar 变量的摘要信息
+type SummaryVar struct {
+	Name       string         // 变量名
+	Loc        lexer.Location // 初始定义的位置信息
+	VarType    common.LuaType // 变量定义的类型
+	VarIndex   uint8          // 一行语句声明了多个变量时的index
+	ReferIndex int            // 引用其他文件时，在ReferVec中的下标，-1表示没有
+	Func       *SummaryFunc   // 为函数时，函数的签名信息
+	Global     *SummaryGlobal // 全局变量的扩展信息，nil表示不是全局变量
+	SubVars    []SummaryVar   // 所有的成员
+	IsExpEmpty bool           // 定义时是否为nil
+	IsMemFlag  bool           // 是否为其他的变量的成员变量
+}
+
+// CreateFileSummary 根据第一阶段的分析结果，生成可以序列化的摘要
+func CreateFileSummary(strFile string, contentHash string, fileResult *FileResult) *FileSummary {
+	summary := &FileSummary{
+		StrFile:     strFile,
+		ContentHash: contentHash,
+		Loc:         fileResult.MainFunc.Loc,
+		CheckErrVec: append([]common.CheckError{}, fileResult.CheckErrVec...),
+		CommentMap:  fileResult.CommentMap,
+	}
+
+	referIndexMap := map[*common.ReferInfo]int{}
+	for i, referInfo := range fileResult.ReferVec {
+		referIndexMap[referInfo] = i
+		summary.ReferVec = append(summary.ReferVec, *referInfo)
+	}
+
+	summary.Globals = createSummaryGlobals(fileResult.GlobalMaps, referIndexMap)
+	summary.Protocols = createSummaryGlobals(fileResult.ProtocolMaps, referIndexMap)
+	for strName, varInfo := range fileResult.NodefineMaps {
+		summary.Nodefines = append(summary.Nodefines, SummaryVar{
+			Name:       strName,
+			Loc:        varInfo.Loc,
+			VarType:    varInfo.VarType,
+			VarIndex:   varInfo.VarIndex,
+			ReferIndex: -1,
+		})
+	}
+	return summary
+}
+
+// createSummaryGlobals 同名的全局变量通过Prev串联，按定义的先后顺序展开
+func createSummaryGlobals(globalMaps map[string]*common.VarInfo, referIndexMap map[*common.ReferInfo]int) (vars []SummaryVar) {
+	for strName, varInfo := range globalMaps {
+		var varList []*common.VarInfo
+		for oneVar := varInfo; oneVar != nil; oneVar = oneVar.ExtraGlobal.Prev {
+			varList = append(varList, oneVar)
+		}
+
+		for i := len(varList) - 1; i >= 0; i-- {
+			vars = append(vars, createSummaryVar(strName, varList[i], referIndexMap, 0))
+		}
+	}
+
+	return vars
+}
+
+// 成员变量的最大层数，防止成员之间相互引用出现死循环
+const maxSummaryVarLevel = 10
+
+func createSummaryVar(strName string, varInfo *common.VarInfo, referIndexMap map[*common.ReferInfo]int,
+	level int) SummaryVar {
+	summaryVar := SummaryVar{
+		Name:       strName,
+		Loc:        varInfo.Loc,
+		VarType:    varInfo.VarType,
+		VarIndex:   varInfo.VarIndex,
+		ReferIndex: -1,
+		IsExpEmpty: varInfo.IsExpEmpty,
+		IsMemFlag:  varInfo.IsMemFlag,
+	}
+
+	if varInfo.ReferInfo != nil {
+		if index, ok := referIndexMap[varInfo.ReferInfo]; ok {
+			summaryVar.ReferIndex = index
+		}
+	}
+
+	if funcInfo := varInfo.ReferFunc; funcInfo != nil {
+		summaryVar.Func = &SummaryFunc{
+			ParamList: funcInfo.ParamList,
+			Loc:       funcInfo.Loc,
+			FuncLv:    funcInfo.FuncLv,
+			IsVararg:  funcInfo.IsVararg,
+			IsColon:   funcInfo.IsColon,
+		}
+		if funcInfo.RelateVar != nil {
+			summaryVar.Func.RelateVarName = funcInfo.RelateVar.StrName
+		}
+	}
+
+	if extraGlobal := varInfo.ExtraGlobal; extraGlobal != nil {
+		summaryVar.Global = &SummaryGlobal{
+			StrProPre: extraGlobal.StrProPre,
+			FuncLv:    extraGlobal.FuncLv,
+			ScopeLv:   extraGlobal.ScopeLv,
+			GFlag:     extraGlobal.GFlag,
+		}
+	}
+
+	if level < maxSummaryVarLevel {
+		for subName, subVar := range varInfo.SubMaps {
+			summaryVar.SubVars = append(summaryVar.SubVars, createSummaryVar(subName, subVar, referIndexMap, level+1))
+		}
+	}
+
+	return summaryVar
+}
+
+// CreateFileResult 由摘要还原出第一阶段的结果，没有AST，Block为空的代码块
+func (s *FileSummary) CreateFileResult() *FileResult {
+	fileResult := CreateFileResult(s.StrFile, &ast.Block{Loc: s.Loc}, CheckTermFirst, "")
+	fileResult.MainFunc.MainScope.Loc = s.Loc
+	fileResult.InertNewFunc(fileResult.MainFunc)
+	fileResult.CheckErrVec = append(fileResult.CheckErrVec, s.CheckErrVec...)
+	if s.CommentMap != nil {
+		fileResult.CommentMap = s.CommentMap
+	}
+
+	for i := range s.ReferVec {
+		referInfo := s.ReferVec[i]
+		fileResult.ReferVec = append(fileResult.ReferVec, &referInfo)
+	}
+
+	for _, globalVars := range [][]SummaryVar{s.Globals, s.Protocols} {
+		for i := range globalVars {
+			if globalVars[i].Global == nil {
+				continue
+			}
+
+			varInfo := globalVars[i].createVarInfo(s.StrFile, fileResult.ReferVec)
+			fileResult.InsertGlobalVar(globalVars[i].Name, varInfo)
+		}
+	}
+
+	for i := range s.Nodefines {
+		fileResult.NodefineMaps[s.Nodefines[i].Name] = s.Nodefines[i].createVarInfo(s.StrFile, nil)
+	}
+
+	return fileResult
+}
+
+// createVarInfo 由摘要还原出变量信息
+func (s *SummaryVar) createVarInfo(strFile string, referVec []*common.ReferInfo) *common.VarInfo {
+	varInfo := common.CreateVarInfo(s.VarType, nil, s.Loc, s.VarIndex)
+	varInfo.IsExpEmpty = s.IsExpEmpty
+	varInfo.IsMemFlag = s.IsMemFlag
+
+	if s.ReferIndex >= 0 && s.ReferIndex < len(referVec) {
+		varInfo.ReferInfo = referVec[s.ReferIndex]
+	}
+
+	if s.Func != nil {
+		funcInfo := common.CreateFuncInfo(nil, s.Func.FuncLv, s.Func.Loc, s.Func.IsVararg, nil)
+		funcInfo.ParamList = s.Func.ParamList
+		funcInfo.IsColon = s.Func.IsColon
+		if s.Func.RelateVarName != "" {
+			funcInfo.RelateVar = common.CreateFuncRelateVar(s.Func.RelateVarName, nil)
+		}
+		varInfo.ReferFunc = funcInfo
+	}
+
+	if s.Global != nil {
+		varInfo.ExtraGlobal = &common.ExtraGlobal{
+			FileName:  strFile,
+			StrProPre: s.Global.StrProPre,
+			FuncLv:    s.Global.FuncLv,
+			ScopeLv:   s.Global.ScopeLv,
+			GFlag:     s.Global.GFlag,
+		}
+	}
+
+	if len(s.SubVars) > 0 {
+		varInfo.SubMaps = map[string]*common.VarInfo{}
+		for i := range s.SubVars {
+			varInfo.SubMaps[s.SubVars[i].Name] = s.SubVars[i].createVarInfo(strFile, referVec)
+		}
+	}
+
+	return varInfo
+}
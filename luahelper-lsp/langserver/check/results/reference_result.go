@@ -139,7 +139,7 @@ func (r *ReferenceFileResult) FindProjectGlobal(selfConvert SelfConvertInterface
 
 	// 向工程的第一阶段全局_G符号表中查找
 	if r.thirdStruct != nil {
-		ok, oneVar := r.thirdStruct.FindThirdGlobalGInfo(false, strName, strProPre)
+		ok, oneVar := r.thirdStruct.FindThirdGlobalGInfo(r.StrFile, false, strName, strProPre)
 		if !ok {
 			return false
 		}
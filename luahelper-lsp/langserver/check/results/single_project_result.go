@@ -1,6 +1,9 @@
 package results
 
-import "luahelper-lsp/langserver/check/common"
+import (
+	"luahelper-lsp/langserver/check/common"
+	"sort"
+)
 
 // SingleProjectResult 第二阶段分析的工程结构
 type SingleProjectResult struct {
@@ -17,6 +20,16 @@ type SingleProjectResult struct {
 	// 当前已经执行的require加载的文件， 例如require("one") ，map中存放的是one.lua
 	FirstRequireFileMap  map[string]bool
 	SecondRequireFileMap map[string]bool
+
+	// 按执行顺序依次进入分析的文件，由第一阶段的引用信息得到，增量分析时用于判断执行顺序是否有变化
+	EnterFileVec []string
+
+	// 增量分析时，只有变化的文件、依赖它们的文件以及执行顺序变化之后进入的文件重新遍历AST
+	// 其他的文件由第一阶段的结果还原，沿用上一次分析的告警
+	incrementalFlag bool                           // 是否为增量分析
+	traverseFileMap map[string]struct{}            // 需要遍历AST的文件
+	replayFileMap   map[string]struct{}            // 由第一阶段的结果还原的文件，分析完成后保留
+	oldErrorMap     map[string][]common.CheckError // 上一次分析的告警
 }
 
 // CreateAnalysisSecondProject 创建第二阶段分析的结果指针
@@ -35,6 +48,73 @@ func CreateAnalysisSecondProject(entryFile string) *SingleProjectResult {
 	}
 }
 
+// PrepareTraverse 设置按执行顺序依次进入的文件，得到需要遍历AST的文件
+// oldResult不为nil时进行增量分析，只有changeFileMap中的文件，以及进入顺序与上一次分析不一致之后的文件需要遍历AST
+func (s *SingleProjectResult) PrepareTraverse(enterFileVec []string, oldResult *SingleProjectResult,
+	changeFileMap map[string]struct{}) {
+	s.EnterFileVec = enterFileVec
+	s.traverseFileMap = map[string]struct{}{}
+	s.replayFileMap = map[string]struct{}{}
+	s.incrementalFlag = oldResult != nil
+	if !s.incrementalFlag {
+		for _, strFile := range enterFileVec {
+			s.traverseFileMap[strFile] = struct{}{}
+		}
+		return
+	}
+
+	s.oldErrorMap = oldResult.FileErrorMap
+	sameOrderFlag := true
+	for i, strFile := range enterFileVec {
+		if i >= len(oldResult.EnterFileVec) || oldResult.EnterFileVec[i] != strFile {
+			sameOrderFlag = false
+		}
+
+		if _, ok := changeFileMap[strFile]; ok || !sameOrderFlag {
+			s.traverseFileMap[strFile] = struct{}{}
+		}
+	}
+}
+
+// GetTraverseFiles 获取需要遍历AST的文件
+func (s *SingleProjectResult) GetTraverseFiles() []string {
+	fileVec := make([]string, 0, len(s.traverseFileMap))
+	for strFile := range s.traverseFileMap {
+		fileVec = append(fileVec, strFile)
+	}
+	return fileVec
+}
+
+// NeedTraverseFile 执行流进入一个文件时调用，判断是否需要遍历该文件的AST，返回false时该文件由第一阶段的结果还原
+func (s *SingleProjectResult) NeedTraverseFile(strFile string) bool {
+	if !s.incrementalFlag {
+		return true
+	}
+
+	if _, ok := s.traverseFileMap[strFile]; ok {
+		return true
+	}
+
+	s.replayFileMap[strFile] = struct{}{}
+	return false
+}
+
+// IsReplayFile 判断文件是否由第一阶段的结果还原
+func (s *SingleProjectResult) IsReplayFile(strFile string) bool {
+	_, ok := s.replayFileMap[strFile]
+	return ok
+}
+
+// GetReplayFiles 获取增量分析中由第一阶段的结果还原的所有文件，按文件名排序
+func (s *SingleProjectResult) GetReplayFiles() []string {
+	fileVec := make([]string, 0, len(s.replayFileMap))
+	for strFile := range s.replayFileMap {
+		fileVec = append(fileVec, strFile)
+	}
+	sort.Strings(fileVec)
+	return fileVec
+}
+
 // checkTerm 1 第一阶段进行完毕，第一阶段所有的全局符号表中插入一个新的全局变量信息
 // checkTerm 2 表示第二阶段工程check中，每解析到一个_G的全局变量，放入到第二阶段全局map中
 func (s *SingleProjectResult) InsertGlobalGMaps(strName string, varInfo *common.VarInfo, checkTerm CheckTerm) {
@@ -109,16 +189,23 @@ func (s *SingleProjectResult) FindGlobalGInfo(strName string, checkTerm CheckTer
 
 // 第二阶段分析完了，把文件分析的错误转存在专用结构中，销毁不用的数据
 func (s *SingleProjectResult) FinishSecondProject() {
-	// 1) 转成所有的错误
+	// 1) 转成所有的错误，由第一阶段结果还原的文件，沿用上一次分析的告警
 	for strFile, fileResult := range s.AnalysisFileMap {
-		if len(fileResult.CheckErrVec) == 0 {
+		errVec := fileResult.CheckErrVec
+		if s.IsReplayFile(strFile) {
+			errVec = s.oldErrorMap[strFile]
+		}
+
+		if len(errVec) == 0 {
 			continue
 		}
 
-		s.FileErrorMap[strFile] = fileResult.CheckErrVec
+		s.FileErrorMap[strFile] = errVec
 	}
 
 	s.AnalysisFileMap = map[string]*FileResult{}
 	s.FirstRequireFileMap = map[string]bool{}
 	s.SecondRequireFileMap = map[string]bool{}
+	s.traverseFileMap = nil
+	s.oldErrorMap = nil
 }
@@ -87,6 +87,11 @@ func (third *AnalysisThird) JudgeShouldInsertGlobalInfo(strName string, varInfo
 			continue
 		}
 
+		// 多根工作区时，没有关联的工作区文件夹之间的定义相互隔离，不需要比较
+		if !common.GConfig.IsFolderVisible(varInfo.ExtraGlobal.FileName, oneVar.ExtraGlobal.FileName) {
+			continue
+		}
+
 		if oneVar.ExtraGlobal.FuncLv < varInfo.ExtraGlobal.FuncLv {
 			log.Debug("thirdStruct strName=%s, not insert, before file=%s, funcLv=%d,now file=%s, funcLv=%d",
 				strName, oneVar.ExtraGlobal.FileName, oneVar.ExtraGlobal.FuncLv, varInfo.ExtraGlobal.FileName, varInfo.ExtraGlobal.FuncLv)
@@ -112,7 +117,8 @@ func (third *AnalysisThird) JudgeShouldInsertGlobalInfo(strName string, varInfo
 
 // 向第三阶段公共结构中，保存的全局指针，获取信息
 // gFlag 表示是否只查找_G前缀变量的
-func (third *AnalysisThird) FindThirdGlobalGInfo(gFlag bool, strName string, strProPre string) (bool, *common.VarInfo) {
+func (third *AnalysisThird) FindThirdGlobalGInfo(strFile string, gFlag bool, strName string,
+	strProPre string) (bool, *common.VarInfo) {
 	globalGmaps := third.GlobalVarMaps
 	if strProPre != "" {
 		globalGmaps = third.ProtcolVarMaps
@@ -129,7 +135,12 @@ func (third *AnalysisThird) FindThirdGlobalGInfo(gFlag bool, strName string, str
 
 	for i := len(varInfoList.VarVec) - 1; i >= 0; i-- {
 		oneVar := varInfoList.VarVec[i]
-		if oneVar.ExtraGlobal.StrProPre == strProPre {
+		if oneVar.ExtraGlobal.StrProPre != strProPre {
+			continue
+		}
+
+		// 多根工作区时，其他没有关联的工作区文件夹中定义的全局变量不可见
+		if common.GConfig.IsFolderVisible(strFile, oneVar.ExtraGlobal.FileName) {
 			return true, oneVar
 		}
 	}
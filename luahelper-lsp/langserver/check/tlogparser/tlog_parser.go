@@ -0,0 +1,165 @@
+package tlogparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// tlog的xml描述文件的解析，只提取struct与entry的定义，格式如下
+// <metalib name="log" version="1">
+//     <struct name="PlayerLogin" desc="玩家登录">
+//         <entry name="GameSvrId" type="string" size="25" desc="登录的服务器"/>
+//     </struct>
+// </metalib>
+
+// TlogEntry struct的一个字段
+type TlogEntry struct {
+	Name    string         // 字段的名称
+	NameLoc lexer.Location // 字段名称在xml文件中的位置
+	Type    string         // 字段的类型，例如int、string、datetime
+	Desc    string         // 字段的描述
+}
+
+// TlogStruct 定义的一个struct
+type TlogStruct struct {
+	Name      string         // struct的名称
+	NameLoc   lexer.Location // struct名称在xml文件中的位置
+	Desc      string         // struct的描述
+	EntryList []*TlogEntry   // 所有的字段
+}
+
+// TlogMeta 一个tlog xml文件解析的结果
+type TlogMeta struct {
+	StructList []*TlogStruct
+}
+
+// FindEntry 查找struct中指定名称的字段，没有找到返回nil
+func (s *TlogStruct) FindEntry(strName string) *TlogEntry {
+	for _, entry := range s.EntryList {
+		if entry.Name == strName {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+// nameAttrRegexp 元素中name属性值开始的位置
+var nameAttrRegexp = regexp.MustCompile(`\bname\s*=\s*["']`)
+
+// offsetConverter 把xml内容的字节偏移转换为行号与列号，偏移只能递增
+type offsetConverter struct {
+	content []byte
+	offset  int
+	line    int
+	col     int
+}
+
+// getLineCol 获取偏移对应的行号与列号，行号从1开始，列号为字符的偏移，从0开始
+func (c *offsetConverter) getLineCol(offset int) (line, col int) {
+	for c.offset < offset && c.offset < len(c.content) {
+		ch, size := utf8.DecodeRune(c.content[c.offset:])
+		if ch == '\n' {
+			c.line++
+			c.col = 0
+		} else {
+			c.col++
+		}
+		c.offset += size
+	}
+
+	return c.line, c.col
+}
+
+// getAttr 获取元素的属性值，属性名忽略大小写
+func getAttr(element xml.StartElement, strName string) string {
+	for _, attr := range element.Attr {
+		if strings.EqualFold(attr.Name.Local, strName) {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+// getNameLoc 获取元素中name属性值的位置，beginOffset与endOffset为元素在xml内容中的范围
+func (c *offsetConverter) getNameLoc(beginOffset, endOffset int, strName string) (loc lexer.Location) {
+	if endOffset > len(c.content) {
+		endOffset = len(c.content)
+	}
+
+	valueOffset := beginOffset
+	if index := nameAttrRegexp.FindIndex(c.content[beginOffset:endOffset]); index != nil {
+		valueOffset = beginOffset + index[1]
+	}
+
+	loc.StartLine, loc.StartColumn = c.getLineCol(valueOffset)
+	loc.EndLine = loc.StartLine
+	loc.EndColumn = loc.StartColumn + utf8.RuneCountInString(strName)
+	return loc
+}
+
+// ParseTlogXML 解析tlog的xml描述文件，内容需要为utf8编码。出错时返回出错之前已经解析到的struct
+func ParseTlogXML(content []byte) (*TlogMeta, error) {
+	meta := &TlogMeta{}
+	converter := &offsetConverter{
+		content: content,
+		line:    1,
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	// 声明的编码为gbk等时，内容已经提前转换为了utf8
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	var curStruct *TlogStruct
+	for {
+		beginOffset := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return meta, nil
+		}
+		if err != nil {
+			return meta, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			strName := getAttr(element, "name")
+			if strName == "" {
+				continue
+			}
+
+			strDesc := getAttr(element, "desc")
+			if strDesc == "" {
+				strDesc = getAttr(element, "cname")
+			}
+
+			if strings.EqualFold(element.Name.Local, "struct") {
+				curStruct = &TlogStruct{
+					Name:    strName,
+					NameLoc: converter.getNameLoc(beginOffset, int(decoder.InputOffset()), strName),
+					Desc:    strDesc,
+				}
+				meta.StructList = append(meta.StructList, curStruct)
+			} else if strings.EqualFold(element.Name.Local, "entry") && curStruct != nil {
+				curStruct.EntryList = append(curStruct.EntryList, &TlogEntry{
+					Name:    strName,
+					NameLoc: converter.getNameLoc(beginOffset, int(decoder.InputOffset()), strName),
+					Type:    getAttr(element, "type"),
+					Desc:    strDesc,
+				})
+			}
+		case xml.EndElement:
+			if strings.EqualFold(element.Name.Local, "struct") {
+				curStruct = nil
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package tlogparser
+
+import (
+	"testing"
+)
+
+func TestParseTlogXML(t *testing.T) {
+	content := `<?xml version="1.0" encoding="GBK" standalone="yes" ?>
+<metalib name="log" tagsetversion="1" version="1">
+    <macro name="MAX_NAME_LEN" value="64"/>
+    <!-- 玩家登录 -->
+    <struct name="PlayerLogin" version="1" desc="玩家登录">
+        <entry name="GameSvrId" type="string" size="25" desc="登录的服务器"/>
+        <entry  type="datetime" name="dtEventTime" cname="事件时间"/>
+    </struct>
+    <struct name="PlayerLogout" version="1">
+        <entry name="OnlineTime" type="int"/>
+    </struct>
+</metalib>
+`
+	meta, err := ParseTlogXML([]byte(content))
+	if err != nil {
+		t.Fatalf("parse err=%s", err.Error())
+	}
+
+	if len(meta.StructList) != 2 {
+		t.Fatalf("struct num=%d", len(meta.StructList))
+	}
+
+	loginStruct := meta.StructList[0]
+	if loginStruct.Name != "PlayerLogin" || loginStruct.Desc != "玩家登录" || len(loginStruct.EntryList) != 2 {
+		t.Fatalf("struct=%v", loginStruct)
+	}
+	if loginStruct.NameLoc.StartLine != 5 || loginStruct.NameLoc.StartColumn != 18 ||
+		loginStruct.NameLoc.EndColumn != 29 {
+		t.Fatalf("struct loc=%v", loginStruct.NameLoc)
+	}
+
+	entry := loginStruct.FindEntry("dtEventTime")
+	if entry == nil || entry.Type != "datetime" || entry.Desc != "事件时间" {
+		t.Fatalf("entry=%v", entry)
+	}
+	if entry.NameLoc.StartLine != 7 || entry.NameLoc.StartColumn != 38 {
+		t.Fatalf("entry loc=%v", entry.NameLoc)
+	}
+
+	if loginStruct.FindEntry("OnlineTime") != nil {
+		t.Fatalf("find entry of other struct")
+	}
+
+	if _, err := ParseTlogXML([]byte(`<metalib><struct name="A"><entry name="a"></struct>`)); err == nil {
+		t.Fatalf("parse error xml without err")
+	}
+}
@@ -0,0 +1,66 @@
+package codingconv
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// DefaultFileEncoding 没有配置时，非utf-8的文件默认按gbk解码
+const DefaultFileEncoding = "gbk"
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// GetEncoding 获取编码名称对应的编码，名称不区分大小写，例如gbk、gb18030、big5、shift_jis
+func GetEncoding(strEncoding string) (encoding.Encoding, bool) {
+	enc, err := htmlindex.Get(strings.TrimSpace(strEncoding))
+	if err != nil {
+		return nil, false
+	}
+
+	return enc, true
+}
+
+// DecodeFileContent 把磁盘上读取的Lua文件内容转换为utf-8，语法分析与返回给客户端的位置都基于转换后的内容
+// 依次按BOM、是否为合法的utf-8判断文件的编码，都不满足时按配置的编码strEncoding解码，配置为utf-8时保留原始的字节
+// 带BOM的文件转换后去掉BOM，与编辑器中显示的内容一致
+func DecodeFileContent(data []byte, strEncoding string) []byte {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return data[len(utf8BOM):]
+	case bytes.HasPrefix(data, utf16LEBOM), bytes.HasPrefix(data, utf16BEBOM):
+		decoder := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder()
+		if ret, err := decoder.Bytes(data); err == nil {
+			return ret
+		}
+		return data
+	}
+
+	if utf8.Valid(data) {
+		return data
+	}
+
+	// 没有配置或是不支持的编码，按默认的gbk解码
+	enc, ok := GetEncoding(strEncoding)
+	if !ok {
+		enc, _ = GetEncoding(DefaultFileEncoding)
+	}
+	if enc == unicode.UTF8 {
+		return data
+	}
+
+	ret, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return data
+	}
+
+	return ret
+}
@@ -0,0 +1,37 @@
+package codingconv
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestDecodeFileContent(t *testing.T) {
+	strLua := "local s = \"中文\"\n"
+	gbkData, _ := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(strLua))
+	sjisData, _ := japanese.ShiftJIS.NewEncoder().Bytes([]byte("local s = \"日本語\"\n"))
+	utf16Data, _ := unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewEncoder().Bytes([]byte(strLua))
+
+	type decodeCase struct {
+		data        []byte
+		strEncoding string
+		expect      string
+	}
+	caseVec := []decodeCase{
+		{[]byte(strLua), "big5", strLua},
+		{append([]byte{0xEF, 0xBB, 0xBF}, strLua...), "", strLua},
+		{utf16Data, "", strLua},
+		{gbkData, "", strLua},
+		{gbkData, "unknown", strLua},
+		{gbkData, "utf-8", string(gbkData)},
+		{sjisData, "Shift_JIS", "local s = \"日本語\"\n"},
+	}
+
+	for i, oneCase := range caseVec {
+		if ret := string(DecodeFileContent(oneCase.data, oneCase.strEncoding)); ret != oneCase.expect {
+			t.Fatalf("case %d decode failed, encoding=%s, ret=%q", i, oneCase.strEncoding, ret)
+		}
+	}
+}
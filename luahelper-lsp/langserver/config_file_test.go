@@ -0,0 +1,232 @@
+package langserver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"luahelper-lsp/langserver/check/common"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// findConfigError 查找配置文件中包含指定内容的诊断信息
+func findConfigError(errMap map[string][]common.CheckError, strFile string, strContent string) *common.CheckError {
+	for _, oneErr := range errMap[strFile] {
+		if strings.Contains(oneErr.ErrStr, strContent) {
+			return &oneErr
+		}
+	}
+
+	return nil
+}
+
+func TestConfigFile(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strConfigsPath, _ := filepath.Abs(paths + "../testdata/configs")
+	strRootPath := strConfigsPath + "/project"
+	createFoldersLspTest(strRootPath, nil)
+
+	mainFile := strRootPath + "/main.lua"
+	subFile := strRootPath + "/sub/sub.lua"
+
+	// 继承了base.json的配置，类型错误的ShowWarnFlag使用默认值
+	if !common.GConfig.IsIgnoreNameVar("baseModule") {
+		t.Fatalf("baseModule should be ignored by the extended config")
+	}
+	if !common.GConfig.IsIgnoreErrorFile(mainFile, common.CheckErrorLocalNoUse) ||
+		common.GConfig.IsIgnoreErrorFile(mainFile, common.CheckErrorNoDefine) {
+		t.Fatalf("main.lua should only ignore the error types of the extended config")
+	}
+
+	// 子目录的配置覆盖IgnoreModules，其他的配置沿用上层目录的
+	subConfig := common.GConfig.GetFileConfig(subFile)
+	if subConfig == common.GConfig || common.GConfig.GetFileConfig(mainFile) != common.GConfig {
+		t.Fatalf("sub.lua should use the nested config")
+	}
+	if !subConfig.IsIgnoreNameVar("subModule") || subConfig.IsIgnoreNameVar("baseModule") {
+		t.Fatalf("the nested config should override IgnoreModules")
+	}
+	if !common.GConfig.IsIgnoreErrorFile(subFile, common.CheckErrorLocalNoUse) {
+		t.Fatalf("the nested config should inherit IgnoreErrorTypes")
+	}
+	if !common.GConfig.IsFolderVisible(subFile, mainFile) {
+		t.Fatalf("the nested config should not isolate sub.lua")
+	}
+
+	errMap := common.GConfig.GetConfigErrors()
+	projectConfig := strRootPath + "/luahelper.json"
+	checkVec := []struct {
+		strFile    string
+		strContent string
+	}{
+		{projectConfig, "did you mean \"IgnoreFileOrFloder\""},
+		{projectConfig, "ShowWarnFlag: wrong type, expected integer, got string"},
+		{projectConfig, "invalid regular expression \"port(\""},
+		{projectConfig, "extends \"../shared/missing.json\": file not found"},
+		{strConfigsPath + "/shared/cycle.json", "circular reference"},
+		{strRootPath + "/sub/luahelper.json", "\"BaseDir\" only takes effect in the root luahelper.json"},
+		{projectConfig, "DiagnosticSeverity: invalid severity \"fatal\""},
+		{projectConfig, "DiagnosticSeverity: invalid warning type \"x\""},
+	}
+	for _, oneCheck := range checkVec {
+		if findConfigError(errMap, oneCheck.strFile, oneCheck.strContent) == nil {
+			t.Fatalf("file:%s should have the error: %s, errors=%v", oneCheck.strFile, oneCheck.strContent, errMap)
+		}
+	}
+
+	oneErr := findConfigError(errMap, projectConfig, "IgnoreFileOrFloder")
+	if oneErr.Loc.StartLine != 4 || oneErr.Loc.StartColumn != 1 || oneErr.Loc.EndColumn != 21 {
+		t.Fatalf("unknown key error location is wrong: %v", oneErr.Loc)
+	}
+	if len(errMap[strConfigsPath+"/shared/base.json"]) != 0 {
+		t.Fatalf("base.json should not have errors")
+	}
+}
+
+func TestDiagnosticSeverity(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/configs/project")
+	lspServer := createFoldersLspTest(strRootPath, nil)
+	rangeConverter := lspServer.getFileCache().CreateRangeConverter()
+
+	// 配置了工程入口文件时，告警关联到分析时所在的入口文件
+	common.GConfig.ProjectFiles = []string{"main.lua"}
+
+	// 配置中类型2为hint，非法的级别fatal使用默认的warning，类型4为off不推送；子目录的配置沿用上层目录的级别
+	type severityCase struct {
+		strFile  string
+		errType  common.CheckErrorType
+		severity lsp.DiagnosticSeverity
+		ok       bool
+	}
+	caseVec := []severityCase{
+		{strRootPath + "/main.lua", common.CheckErrorNoDefine, lsp.SeverityHint, true},
+		{strRootPath + "/main.lua", common.CheckErrorCycleDefine, lsp.SeverityWarning, true},
+		{strRootPath + "/main.lua", common.CheckErrorSyntax, lsp.SeverityError, true},
+		{strRootPath + "/main.lua", common.CheckErrorLocalNoUse, 0, false},
+		{strRootPath + "/sub/sub.lua", common.CheckErrorNoDefine, lsp.SeverityHint, true},
+		{strRootPath + "/sub/sub.lua", common.CheckErrorNoUseAssign, lsp.SeverityWarning, true},
+	}
+	for _, oneCase := range caseVec {
+		checkErr := common.CheckError{
+			ErrType:   oneCase.errType,
+			ErrStr:    "test error",
+			EntryFile: strRootPath + "/main.lua",
+		}
+		diagnostic, ok := changeErrToDiagnostic(rangeConverter, oneCase.strFile, &checkErr)
+		if ok != oneCase.ok || diagnostic.Severity != oneCase.severity {
+			t.Fatalf("case %v severity is wrong: %d, ok=%v", oneCase, diagnostic.Severity, ok)
+		}
+		if !ok {
+			continue
+		}
+
+		if diagnostic.Code != int(oneCase.errType) || diagnostic.Message != "test error" ||
+			!strings.HasSuffix(diagnostic.CodeDescription.Href, fmt.Sprintf("#warn-type-%d", oneCase.errType)) {
+			t.Fatalf("case %v code is wrong: %v", oneCase, diagnostic)
+		}
+
+		hasTag := len(diagnostic.Tags) == 1 && diagnostic.Tags[0] == lsp.Unnecessary
+		if hasTag != (oneCase.errType == common.CheckErrorNoUseAssign) {
+			t.Fatalf("case %v tags are wrong: %v", oneCase, diagnostic.Tags)
+		}
+
+		if len(diagnostic.RelatedInformation) != 1 ||
+			!strings.Contains(diagnostic.RelatedInformation[0].Message, "process entry file") {
+			t.Fatalf("case %v entry file should be in relatedInformation: %v", oneCase, diagnostic.RelatedInformation)
+		}
+	}
+
+	// 配置了说明文档的地址，链接到配置的文档
+	common.GConfig.SetDiagnosticDocURL("https://example.com/docs/config.md")
+	defer common.GConfig.SetDiagnosticDocURL("")
+	checkErr := common.CheckError{
+		ErrType: common.CheckErrorNoDefine,
+		ErrStr:  "test error",
+	}
+	diagnostic, _ := changeErrToDiagnostic(rangeConverter, strRootPath+"/main.lua", &checkErr)
+	if diagnostic.CodeDescription.Href != "https://example.com/docs/config.md#warn-type-2" {
+		t.Fatalf("configured doc url is wrong: %s", diagnostic.CodeDescription.Href)
+	}
+}
+
+func TestCheckFlagList(t *testing.T) {
+	// 客户端的告警开关，每种错误类型都有对应的开关
+	initOptions := getDefaultIntialOptions()
+	if len(getCheckFlagList(initOptions)) != common.CheckErrorLast+1 {
+		t.Fatalf("init check flag list len error, len=%d", len(getCheckFlagList(initOptions)))
+	}
+	if len(getWarnCheckList(&WarnParams{})) != common.CheckErrorLast+1 {
+		t.Fatalf("warn check flag list len error, len=%d", len(getWarnCheckList(&WarnParams{})))
+	}
+
+	common.GlobalConfigDefautInit()
+	common.GConfig.IntialGlobalVar()
+
+	// 没有配置文件时，可以单独关闭新增的告警类型
+	initOptions.CheckUnusedParam = false
+	common.GConfig.HandleChangeCheckList(getCheckFlagList(initOptions), nil, nil)
+	if !common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorUnusedParam) ||
+		common.GConfig.IsGlobalIgnoreErrType(common.CheckErrorDiscardedResult) {
+		t.Fatalf("CheckUnusedParam should only ignore unused param")
+	}
+	if common.GConfig.GetErrorSeverity(common.CheckErrorAccidentalGlobal) != common.SeverityOff {
+		t.Fatalf("accidental global should be off by default")
+	}
+
+	// 默认关闭的告警类型，打开开关后按warning显示
+	initOptions.CheckAccidentalGlobal = true
+	common.GConfig.HandleChangeCheckList(getCheckFlagList(initOptions), nil, nil)
+	if common.GConfig.GetErrorSeverity(common.CheckErrorAccidentalGlobal) != common.SeverityWarning {
+		t.Fatalf("accidental global should be warning after enabled")
+	}
+}
+
+func TestDiagnosticDocAnchor(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	// 默认的说明文档即为仓库中的docs/manual/config.md，每种错误类型都要有对应的锚点
+	if !strings.HasSuffix(common.DefaultDiagnosticDocURL, "/docs/manual/config.md") {
+		t.Fatalf("default doc url is wrong: %s", common.DefaultDiagnosticDocURL)
+	}
+
+	strDocFile, _ := filepath.Abs(paths + "../../docs/manual/config.md")
+	data, err := ioutil.ReadFile(strDocFile)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", strDocFile, err.Error())
+	}
+
+	for errType := common.CheckErrorSyntax; errType <= common.CheckErrorDiscardedResult; errType++ {
+		strAnchor := fmt.Sprintf("<a id=\"warn-type-%d\"></a>", errType)
+		if !strings.Contains(string(data), strAnchor) {
+			t.Fatalf("doc file:%s not find anchor warn-type-%d", strDocFile, errType)
+		}
+	}
+}
+
+func TestConfigSchema(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	schemaPath, _ := filepath.Abs(paths + "../../luahelper-vscode/schemas/luahelper.schema.json")
+	fileData, err := ioutil.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("read schema file err=%s", err.Error())
+	}
+
+	schemaData, err := common.GetJSONConfigSchema()
+	if err != nil {
+		t.Fatalf("get schema err=%s", err.Error())
+	}
+
+	if string(fileData) != string(schemaData)+"\n" {
+		t.Fatalf("%s is out of date, regenerate it with: luahelper-lsp -schema <path>", schemaPath)
+	}
+}
@@ -2,7 +2,6 @@ package langserver
 
 import (
 	"context"
-	"fmt"
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/log"
 	"luahelper-lsp/langserver/lspcommon"
@@ -15,8 +14,11 @@ func (l *LspServer) pushFileErrList(ctx context.Context, strFile string, fileErr
 	var diagnostics lsp.PublishDiagnosticsParams
 	diagnostics.URI = getFileDocumentURI(strFile)
 	diagnostics.Diagnostics = []lsp.Diagnostic{}
+	rangeConverter := l.getFileCache().CreateRangeConverter()
 	for _, oneErr := range fileErrVec {
-		diagnostics.Diagnostics = append(diagnostics.Diagnostics, changeErrToDiagnostic(&oneErr))
+		if diagnostic, ok := changeErrToDiagnostic(rangeConverter, strFile, &oneErr); ok {
+			diagnostics.Diagnostics = append(diagnostics.Diagnostics, diagnostic)
+		}
 	}
 
 	// 发送单个文件的诊断信息
@@ -44,6 +46,26 @@ func (l *LspServer) GetAllDiagnostics(ctx context.Context) {
 	}
 }
 
+// pushConfigDiagnostics 推送luahelper.json配置文件的诊断错误，之前有错误现在修复了的配置文件清除错误
+func (l *LspServer) pushConfigDiagnostics(ctx context.Context) {
+	configErrorMap := common.GConfig.GetConfigErrors()
+	for strFile := range l.configErrorMap {
+		if _, ok := configErrorMap[strFile]; !ok {
+			l.ClearOneFileDiagnostic(ctx, strFile)
+		}
+	}
+
+	for strFile, newErrList := range configErrorMap {
+		if oldErrList, ok := l.configErrorMap[strFile]; ok && lspcommon.IsSameErrList(oldErrList, newErrList) {
+			continue
+		}
+
+		l.pushFileErrList(ctx, strFile, newErrList)
+	}
+
+	l.configErrorMap = configErrorMap
+}
+
 // pushAllDiagnosticsAgain 再次全量获取诊断信息，增量推送诊断信息给客户端
 func (l *LspServer) pushAllDiagnosticsAgain(ctx context.Context) {
 	project := l.getAllProject()
@@ -86,6 +108,40 @@ func (l *LspServer) pushAllDiagnosticsAgain(ctx context.Context) {
 	}
 }
 
+// pushChangeDiagnostics 文件变化后，只获取受影响文件的诊断信息，有变化的才推送给客户端
+func (l *LspServer) pushChangeDiagnostics(ctx context.Context) {
+	project := l.getAllProject()
+	fileMap := project.GetDiagnosticFiles()
+	if fileMap == nil {
+		l.pushAllDiagnosticsAgain(ctx)
+		return
+	}
+
+	fileErrorMap := project.GetFilesErrorInfo(fileMap)
+	for strFile := range fileMap {
+		oldErrList, oldOk := l.fileErrorMap[strFile]
+		newErrList, newOk := fileErrorMap[strFile]
+		if !newOk {
+			if oldOk {
+				// 之前有该文件诊断错误，但是修复了; 清除这个文件的错误
+				delete(l.fileErrorMap, strFile)
+				l.ClearOneFileDiagnostic(ctx, strFile)
+			}
+			continue
+		}
+
+		if oldOk && lspcommon.IsSameErrList(oldErrList, newErrList) {
+			// 告警是一样的，不用推送
+			continue
+		}
+
+		l.fileErrorMap[strFile] = newErrList
+		l.pushFileErrList(ctx, strFile, newErrList)
+	}
+
+	log.Debug("pushChangeDiagnostics file num=%d", len(fileMap))
+}
+
 // ClearOneFileDiagnostic 清空某个文件的所有诊断错误
 func (l *LspServer) ClearOneFileDiagnostic(ctx context.Context, strFile string) {
 	var diagnostics lsp.PublishDiagnosticsParams
@@ -109,7 +165,10 @@ func (l *LspServer) pushFileChangeDiagnostic(ctx context.Context, strFile string
 	var diagnostics lsp.PublishDiagnosticsParams
 	diagnostics.URI = getFileDocumentURI(strFile)
 	diagnostics.Diagnostics = []lsp.Diagnostic{}
-	diagnostics.Diagnostics = append(diagnostics.Diagnostics, changeErrToDiagnostic(&oneErr))
+	rangeConverter := l.getFileCache().CreateRangeConverter()
+	if diagnostic, ok := changeErrToDiagnostic(rangeConverter, strFile, &oneErr); ok {
+		diagnostics.Diagnostics = append(diagnostics.Diagnostics, diagnostic)
+	}
 	// 发送单个文件的诊断信息
 	l.sendDiagnostics(ctx, diagnostics)
 }
@@ -126,11 +185,14 @@ func (l *LspServer) pushFileDiagnostic(ctx context.Context, strFile string, igno
 	var diagnostics lsp.PublishDiagnosticsParams
 	diagnostics.URI = getFileDocumentURI(strFile)
 	diagnostics.Diagnostics = []lsp.Diagnostic{}
+	rangeConverter := l.getFileCache().CreateRangeConverter()
 	for _, oneErr := range fileErrVec {
 		if oneErr.ErrType == common.CheckErrorSyntax && ignoreSyntax {
 			continue
 		}
-		diagnostics.Diagnostics = append(diagnostics.Diagnostics, changeErrToDiagnostic(&oneErr))
+		if diagnostic, ok := changeErrToDiagnostic(rangeConverter, strFile, &oneErr); ok {
+			diagnostics.Diagnostics = append(diagnostics.Diagnostics, diagnostic)
+		}
 	}
 
 	// 发送单个文件的诊断信息
@@ -199,44 +261,75 @@ func (l *LspServer) pushAllChangeFileDiagnosticErr(ctx context.Context) {
 	}
 }
 
-// changeErrToDiagnostic 该文件为所有分析文件的诊断管理
-func changeErrToDiagnostic(checkErr *common.CheckError) lsp.Diagnostic {
-	var diagnostic lsp.Diagnostic
-	if checkErr.ErrType == common.CheckErrorSyntax {
-		diagnostic.Severity = lsp.SeverityError
-	} else if checkErr.ErrType == common.CheckErrorAnnotate {
-		diagnostic.Severity = lsp.SeverityInformation
-	} else {
-		diagnostic.Severity = lsp.SeverityWarning
+// getDiagnosticSeverity 配置的诊断级别转换为lsp的级别，配置为off时返回false
+func getDiagnosticSeverity(strSeverity string) (lsp.DiagnosticSeverity, bool) {
+	switch strSeverity {
+	case common.SeverityError:
+		return lsp.SeverityError, true
+	case common.SeverityWarning:
+		return lsp.SeverityWarning, true
+	case common.SeverityInfo:
+		return lsp.SeverityInformation, true
+	case common.SeverityHint:
+		return lsp.SeverityHint, true
 	}
-	strPre := ""
-	if checkErr.ErrType == common.CheckErrorSyntax {
-		strPre = fmt.Sprintf("[Warn type:%d], ", checkErr.ErrType)
-	} else {
-		strPre = fmt.Sprintf("[Warn type:%d], ", checkErr.ErrType)
+
+	return 0, false
+}
+
+// changeErrToDiagnostic 该文件为所有分析文件的诊断管理
+// rangeConverter 按协商的位置编码转换错误的位置，strFile为错误所在的文件
+// 错误类型的诊断级别配置为off时，返回false，不推送这个诊断信息
+func changeErrToDiagnostic(rangeConverter *lspcommon.RangeConverter, strFile string,
+	checkErr *common.CheckError) (diagnostic lsp.Diagnostic, ok bool) {
+	diagnostic.Severity, ok = getDiagnosticSeverity(common.GConfig.GetFileConfig(strFile).GetErrorSeverity(checkErr.ErrType))
+	if !ok {
+		return
 	}
 
-	diagnostic.Message = strPre + checkErr.ErrStr
+	diagnostic.Range = rangeConverter.LocToRange(strFile, &checkErr.Loc)
+	diagnostic.Code = int(checkErr.ErrType)
+	diagnostic.CodeDescription = &lsp.CodeDescription{
+		Href: common.GConfig.GetDiagnosticDocHref(checkErr.ErrType),
+	}
+	diagnostic.Source = "LuaHelper"
+	diagnostic.Message = checkErr.ErrStr
+
+	// 未使用的变量、参数、label与不可达的代码，客户端显示为淡化的代码
+	switch checkErr.ErrType {
+	case common.CheckErrorLocalNoUse, common.CheckErrorNoUseAssign, common.CheckErrorUnreachable,
+		common.CheckErrorUnusedParam, common.CheckErrorUnusedLabel:
+		diagnostic.Tags = []lsp.DiagnosticTag{lsp.Unnecessary}
+	}
 
+	// 没有配置工程入口文件时，告警关联到分析时所在的入口文件
 	if checkErr.EntryFile != "" && !common.GConfig.IsHasProjectEntryFile() {
+		entryRelate := lsp.DiagnosticRelatedInformation{
+			Location: lsp.Location{
+				URI: getFileDocumentURI(checkErr.EntryFile),
+			},
+			Message: "process entry file: " + checkErr.EntryFile,
+		}
 		if checkErr.EntryFile == "common project" {
-			diagnostic.Message = strPre + checkErr.ErrStr + ". <" + checkErr.EntryFile + ">"
-		} else {
-			diagnostic.Message = strPre + checkErr.ErrStr + ". <process entry file: " + checkErr.EntryFile + ">"
+			entryRelate.Location = lsp.Location{
+				URI:   getFileDocumentURI(strFile),
+				Range: diagnostic.Range,
+			}
+			entryRelate.Message = checkErr.EntryFile
 		}
+		diagnostic.RelatedInformation = append(diagnostic.RelatedInformation, entryRelate)
 	}
 
 	for _, oneRelate := range checkErr.RelateVec {
 		oneRelateLsp := lsp.DiagnosticRelatedInformation{
 			Location: lsp.Location{
 				URI:   getFileDocumentURI(oneRelate.LuaFile),
-				Range: lspcommon.LocToRange(&oneRelate.Loc),
+				Range: rangeConverter.LocToRange(oneRelate.LuaFile, &oneRelate.Loc),
 			},
 			Message: oneRelate.ErrStr,
 		}
 		diagnostic.RelatedInformation = append(diagnostic.RelatedInformation, oneRelateLsp)
 	}
 
-	diagnostic.Range = lspcommon.LocToRange(&checkErr.Loc)
-	return diagnostic
+	return diagnostic, true
 }
@@ -0,0 +1,98 @@
+package langserver
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"luahelper-lsp/langserver/check"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/log"
+)
+
+// initDiskCachePath 初始化第一阶段结果磁盘缓存的路径，每个工程目录对应一个缓存文件
+// 放在系统的用户缓存目录下，不会在工程目录里面产生文件
+func (l *LspServer) initDiskCachePath(enableFlag bool, vscodeRoot string) {
+	l.diskCachePath = ""
+	if !enableFlag || vscodeRoot == "" {
+		return
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		log.Error("get user cache dir err=%s", err.Error())
+		return
+	}
+
+	sum := sha1.Sum([]byte(vscodeRoot))
+	l.diskCachePath = filepath.Join(cacheDir, "LuaHelper", hex.EncodeToString(sum[:])+".cache")
+	log.Debug("disk cache path=%s", l.diskCachePath)
+}
+
+// setDiskCacheConfig 设置影响分析结果的配置，配置有变化时磁盘缓存失效
+// 包含根目录以及各个工作区文件夹luahelper.json的内容，以及客户端传入的告警、忽略文件等配置
+func (l *LspServer) setDiskCacheConfig(configParams ...interface{}) {
+	if l.diskCachePath == "" {
+		return
+	}
+
+	hash := sha1.New()
+	dirManager := common.GConfig.GetDirManager()
+	jsonPath := filepath.Join(dirManager.GetVsRootDir(), "luahelper.json")
+	if data, err := ioutil.ReadFile(jsonPath); err == nil {
+		hash.Write(data)
+	}
+
+	for _, folderPath := range l.diskCacheFolders {
+		hash.Write([]byte(folderPath))
+		if data, err := ioutil.ReadFile(filepath.Join(folderPath, "luahelper.json")); err == nil {
+			hash.Write(data)
+		}
+	}
+
+	if data, err := json.Marshal(configParams); err == nil {
+		hash.Write(data)
+	}
+
+	l.diskCacheConfig = hex.EncodeToString(hash.Sum(nil))
+}
+
+// createDiskCache 创建第一阶段结果的磁盘缓存，没有开启时返回nil
+func (l *LspServer) createDiskCache() *check.DiskCache {
+	if l.diskCachePath == "" {
+		return nil
+	}
+
+	return check.CreateDiskCache(l.diskCachePath, clientVerStr, l.getDiskCacheConfig())
+}
+
+// getDiskCacheConfig 获取影响分析结果的配置的hash值
+// 继承的配置文件以及子目录下的配置文件，读取了配置后才知道，在这里一起计算
+func (l *LspServer) getDiskCacheConfig() string {
+	configFiles := common.GConfig.GetConfigFiles()
+	if len(configFiles) == 0 {
+		return l.diskCacheConfig
+	}
+
+	hash := sha1.New()
+	hash.Write([]byte(l.diskCacheConfig))
+	for _, strFile := range configFiles {
+		hash.Write([]byte(strFile))
+		if data, err := ioutil.ReadFile(strFile); err == nil {
+			hash.Write(data)
+		}
+	}
+
+	// 主工程以及各工作区文件夹配置的tlog xml描述文件会影响第一阶段的告警
+	for _, strPath := range common.GConfig.GetTlogXMLPaths() {
+		hash.Write([]byte(strPath))
+		if data, err := ioutil.ReadFile(strPath); err == nil {
+			hash.Write(data)
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
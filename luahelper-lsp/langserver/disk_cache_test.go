@@ -0,0 +1,215 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"luahelper-lsp/langserver/check/common"
+	lsp "luahelper-lsp/langserver/protocol"
+
+	"github.com/yinfei8/jrpc2"
+	"github.com/yinfei8/jrpc2/handler"
+)
+
+// createDiskCacheLspTest 开启磁盘缓存，初始化分析工程
+func createDiskCacheLspTest(strRootPath string) *LspServer {
+	common.GlobalConfigDefautInit()
+	common.GConfig.IntialGlobalVar()
+
+	lspServer := CreateLspServer()
+	lspServer.server = jrpc2.NewServer(handler.Map{}, &jrpc2.ServerOptions{
+		AllowPush:   false,
+		Concurrency: 1,
+	})
+
+	initOptions := getDefaultIntialOptions()
+	initOptions.DiskCache = true
+	initializeParams := InitializeParams{
+		InitializeParams: lsp.InitializeParams{
+			InnerInitializeParams: lsp.InnerInitializeParams{
+				RootPath: strRootPath,
+				RootURI:  lsp.DocumentURI("file://" + strRootPath),
+			},
+		},
+		InitializationOptions: initOptions,
+	}
+	lspServer.Initialize(context.Background(), initializeParams)
+	return lspServer
+}
+
+func TestDiskCache(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	// 测试中会修改文件，复制到临时目录，缓存文件按目录区分
+	srcPath, _ := filepath.Abs(paths + "../testdata/diskcache")
+	strRootPath := t.TempDir()
+	fileNames := []string{"luahelper.json", "main.lua", "moda.lua"}
+	for _, strName := range fileNames {
+		data, err := ioutil.ReadFile(filepath.Join(srcPath, strName))
+		if err != nil {
+			t.Fatalf("read file:%s err=%s", strName, err.Error())
+		}
+		if err := ioutil.WriteFile(filepath.Join(strRootPath, strName), data, 0644); err != nil {
+			t.Fatalf("write file:%s err=%s", strName, err.Error())
+		}
+	}
+
+	mainFile := strRootPath + "/main.lua"
+	modaFile := strRootPath + "/moda.lua"
+	jsonFile := strRootPath + "/luahelper.json"
+	context := context.Background()
+
+	// 启动分析后，返回各个文件是否由磁盘缓存的摘要还原，以及分析的告警
+	startServer := func() (summaryMap map[string]bool, errMap map[string][]common.CheckError) {
+		lspServer := createDiskCacheLspTest(strRootPath)
+		if lspServer.diskCachePath == "" {
+			t.Skip("user cache dir not exist")
+		}
+		t.Cleanup(func() {
+			os.Remove(lspServer.diskCachePath)
+		})
+
+		project := lspServer.getAllProject()
+		summaryMap = map[string]bool{
+			mainFile: project.IsSummaryFile(mainFile),
+			modaFile: project.IsSummaryFile(modaFile),
+		}
+		errMap = project.GetAllFileErrorInfo()
+
+		// 退出时同步保存磁盘缓存
+		lspServer.Shutdown(context)
+		return summaryMap, errMap
+	}
+
+	writeFile := func(strFile string, content string) {
+		if err := ioutil.WriteFile(strFile, []byte(content), 0644); err != nil {
+			t.Fatalf("write file:%s err=%s", strFile, err.Error())
+		}
+	}
+
+	// 屏蔽了需要关联分析的告警，没有入口文件时不遍历AST，只使用第一阶段的摘要
+	// 1) 第一次启动，没有磁盘缓存
+	summaryMap, coldErrMap := startServer()
+	if summaryMap[mainFile] || summaryMap[modaFile] {
+		t.Fatalf("cold start should not load summary, summaryMap=%v", summaryMap)
+	}
+	if len(coldErrMap[mainFile]) == 0 || len(coldErrMap[modaFile]) == 0 {
+		t.Fatalf("cold start check error, errMap=%v", coldErrMap)
+	}
+
+	// 2) 再次启动，所有文件都由磁盘缓存的摘要还原，告警与第一次启动一致
+	summaryMap, warmErrMap := startServer()
+	if !summaryMap[mainFile] || !summaryMap[modaFile] {
+		t.Fatalf("warm start should load summary, summaryMap=%v", summaryMap)
+	}
+	if !reflect.DeepEqual(coldErrMap, warmErrMap) {
+		t.Fatalf("warm start error not equal, cold=%v, warm=%v", coldErrMap, warmErrMap)
+	}
+
+	// 3) 文件内容变化，只有该文件的缓存失效
+	writeFile(modaFile, "local M = {}\n\nfunction M.run()\nend\n\nfunction moda_global()\nend\n\nreturn M\n")
+	summaryMap, _ = startServer()
+	if !summaryMap[mainFile] || summaryMap[modaFile] {
+		t.Fatalf("content change error, summaryMap=%v", summaryMap)
+	}
+
+	// 4) luahelper.json变化，所有的缓存失效
+	writeFile(jsonFile, "{\n    \"BaseDir\": \"./\",\n    \"IgnoreErrorTypes\": [2, 3, 10, 11, 12],\n"+
+		"    \"ProjectFiles\": []\n}\n")
+	summaryMap, _ = startServer()
+	if summaryMap[mainFile] || summaryMap[modaFile] {
+		t.Fatalf("luahelper.json change error, summaryMap=%v", summaryMap)
+	}
+
+	// 5) 插件版本变化，所有的缓存失效
+	oldVerStr := clientVerStr
+	clientVerStr = oldVerStr + ".test"
+	summaryMap, _ = startServer()
+	clientVerStr = oldVerStr
+	if summaryMap[mainFile] || summaryMap[modaFile] {
+		t.Fatalf("version change error, summaryMap=%v", summaryMap)
+	}
+}
+
+func TestDiskCacheProject(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	// 以main.lua为入口文件，没有屏蔽需要关联分析的告警，第二、三阶段都需要遍历AST
+	srcPath, _ := filepath.Abs(paths + "../testdata/diskcacheproject")
+	strRootPath := t.TempDir()
+	for _, strName := range []string{"luahelper.json", "main.lua", "a.lua", "loose.lua"} {
+		data, err := ioutil.ReadFile(filepath.Join(srcPath, strName))
+		if err != nil {
+			t.Fatalf("read file:%s err=%s", strName, err.Error())
+		}
+		if err := ioutil.WriteFile(filepath.Join(strRootPath, strName), data, 0644); err != nil {
+			t.Fatalf("write file:%s err=%s", strName, err.Error())
+		}
+	}
+
+	mainFile := strRootPath + "/main.lua"
+	aFile := strRootPath + "/a.lua"
+	looseFile := strRootPath + "/loose.lua"
+	fileVec := []string{mainFile, aFile, looseFile}
+	context := context.Background()
+
+	// 启动分析后，返回各个文件是否由磁盘缓存的摘要还原，以及分析的告警
+	startServer := func() (summaryMap map[string]bool, errMap map[string][]common.CheckError) {
+		lspServer := createDiskCacheLspTest(strRootPath)
+		if lspServer.diskCachePath == "" {
+			t.Skip("user cache dir not exist")
+		}
+		t.Cleanup(func() {
+			os.Remove(lspServer.diskCachePath)
+		})
+
+		project := lspServer.getAllProject()
+		summaryMap = map[string]bool{}
+		for _, strFile := range fileVec {
+			summaryMap[strFile] = project.IsSummaryFile(strFile)
+		}
+		errMap = project.GetAllFileErrorInfo()
+
+		lspServer.Shutdown(context)
+		return summaryMap, errMap
+	}
+
+	// 1) 第一次启动，没有磁盘缓存
+	_, coldErrMap := startServer()
+	if len(coldErrMap[mainFile]) == 0 || len(coldErrMap[looseFile]) == 0 {
+		t.Fatalf("cold start check error, errMap=%v", coldErrMap)
+	}
+
+	// 2) 再次启动，所有文件都没有变化，第二、三阶段沿用缓存的结果，都不需要遍历AST
+	summaryMap, warmErrMap := startServer()
+	for _, strFile := range fileVec {
+		if !summaryMap[strFile] {
+			t.Fatalf("warm start should load summary, summaryMap=%v", summaryMap)
+		}
+	}
+	if !reflect.DeepEqual(coldErrMap, warmErrMap) {
+		t.Fatalf("warm start error not equal, cold=%v, warm=%v", coldErrMap, warmErrMap)
+	}
+
+	// 3) a.lua删除了main.lua使用的全局函数，main.lua依赖a.lua需要重新分析，散落的loose.lua仍然沿用缓存
+	if err := ioutil.WriteFile(aFile, []byte("local M = {}\n\nfunction M.run()\nend\n\nreturn M\n"), 0644); err != nil {
+		t.Fatalf("write file:%s err=%s", aFile, err.Error())
+	}
+	summaryMap, changeErrMap := startServer()
+	if summaryMap[mainFile] || summaryMap[aFile] || !summaryMap[looseFile] {
+		t.Fatalf("content change error, summaryMap=%v", summaryMap)
+	}
+	if len(changeErrMap[mainFile]) != len(coldErrMap[mainFile])+1 {
+		t.Fatalf("main.lua should report a_global not define, errMap=%v", changeErrMap[mainFile])
+	}
+	if !reflect.DeepEqual(coldErrMap[looseFile], changeErrMap[looseFile]) {
+		t.Fatalf("loose.lua error not equal, cold=%v, change=%v", coldErrMap[looseFile], changeErrMap[looseFile])
+	}
+}
@@ -0,0 +1,158 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	"luahelper-lsp/langserver/check"
+	"luahelper-lsp/langserver/check/common"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/yinfei8/jrpc2"
+	"github.com/yinfei8/jrpc2/handler"
+)
+
+// createFoldersLspTest 创建多根工作区的测试
+func createFoldersLspTest(strRootPath string, folderVec []string) *LspServer {
+	common.GlobalConfigDefautInit()
+	common.GConfig.IntialGlobalVar()
+
+	lspServer := CreateLspServer()
+	lspServer.server = jrpc2.NewServer(handler.Map{}, &jrpc2.ServerOptions{
+		AllowPush:   false,
+		Concurrency: 1,
+	})
+
+	initializeParams := InitializeParams{
+		InitializeParams: lsp.InitializeParams{
+			InnerInitializeParams: lsp.InnerInitializeParams{
+				RootPath: strRootPath,
+				RootURI:  lsp.DocumentURI("file://" + strRootPath),
+			},
+		},
+	}
+	initializeParams.WorkspaceFolders = append(initializeParams.WorkspaceFolders, lsp.WorkspaceFolder{
+		URI:  "file://" + strRootPath,
+		Name: filepath.Base(strRootPath),
+	})
+	for _, strFolder := range folderVec {
+		initializeParams.WorkspaceFolders = append(initializeParams.WorkspaceFolders, lsp.WorkspaceFolder{
+			URI:  "file://" + strFolder,
+			Name: filepath.Base(strFolder),
+		})
+	}
+
+	lspServer.Initialize(context.Background(), initializeParams)
+	return lspServer
+}
+
+// findFileDefine 查找文件中变量的定义
+func findFileDefine(t *testing.T, lspServer *LspServer, fileName string, strName string) []check.DefineStruct {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context.Background(), openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for index, strLine := range lines {
+		if strings.HasPrefix(strLine, "--") {
+			continue
+		}
+
+		character := strings.Index(strLine, strName)
+		if character < 0 {
+			continue
+		}
+
+		position := lsp.Position{
+			Line:      uint32(index),
+			Character: uint32(character + 1),
+		}
+		fileRequest := lspServer.beginFileRequest(lsp.DocumentURI(fileName), position)
+		if !fileRequest.result {
+			t.Fatalf("beginFileRequest file:%s failed", fileName)
+		}
+
+		varStruct := getVarStruct(fileRequest.contents, fileRequest.offset, position.Line, position.Character)
+		return lspServer.getAllProject().FindVarDefineInfo(fileName, &varStruct)
+	}
+
+	t.Fatalf("not find %s in file:%s", strName, fileName)
+	return nil
+}
+
+func TestFolderConfig(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strFoldersPath, _ := filepath.Abs(paths + "../testdata/folders")
+	strRootPath := strFoldersPath + "/root"
+	strFolderA := strFoldersPath + "/folderA"
+	strFolderB := strFoldersPath + "/folderB"
+	lspServer := createFoldersLspTest(strRootPath, []string{strFolderA, strFolderB})
+
+	rootFile := strRootPath + "/root.lua"
+	folderAFile := strFolderA + "/folderA.lua"
+	folderBFile := strFolderB + "/folderB.lua"
+
+	// folderA 有自己的luahelper.json，root和folderB使用主工程的配置
+	folderAConfig := common.GConfig.GetFileConfig(folderAFile)
+	if folderAConfig == common.GConfig || !folderAConfig.IsIgnoreNameVar("ignoreFolderA") {
+		t.Fatalf("folderA.lua should use the config of folderA")
+	}
+	if common.GConfig.GetFileConfig(rootFile) != common.GConfig ||
+		common.GConfig.GetFileConfig(folderBFile) != common.GConfig {
+		t.Fatalf("root.lua and folderB.lua should use the main config")
+	}
+
+	// folderA 关联了folderB，主工程目录与folderA相互隔离
+	if !common.GConfig.IsFolderVisible(folderAFile, folderBFile) || !common.GConfig.IsFolderVisible(folderBFile, folderAFile) {
+		t.Fatalf("folderA and folderB should be linked")
+	}
+	if common.GConfig.IsFolderVisible(rootFile, folderAFile) {
+		t.Fatalf("root should not see folderA")
+	}
+
+	if defineVecs := findFileDefine(t, lspServer, rootFile, "gFolderAValue"); len(defineVecs) != 0 {
+		t.Fatalf("gFolderAValue should not be visible in root.lua")
+	}
+
+	defineVecs := findFileDefine(t, lspServer, folderAFile, "gFolderBValue")
+	if len(defineVecs) != 1 || defineVecs[0].StrFile != folderBFile {
+		t.Fatalf("gFolderBValue should be defined in folderB.lua")
+	}
+
+	// folderA 配置的loadA引入方式为require，按folderA.lua所使用的配置获取
+	project := lspServer.getAllProject()
+	fileStruct, _ := project.GetFirstFileStuct(folderAFile)
+	if fileStruct == nil || fileStruct.FileResult == nil {
+		t.Fatalf("get file:%s first result failed", folderAFile)
+	}
+	findFlag := false
+	for _, referInfo := range fileStruct.FileResult.ReferVec {
+		if referInfo.ReferTypeStr != "loadA" {
+			continue
+		}
+
+		findFlag = true
+		if subType := project.GetReferFrameType(referInfo); subType != common.RtypeRequire {
+			t.Fatalf("loadA in folderA.lua should be require, subType=%d", subType)
+		}
+	}
+	if !findFlag {
+		t.Fatalf("not find loadA refer in file:%s", folderAFile)
+	}
+}
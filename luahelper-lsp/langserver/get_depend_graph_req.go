@@ -0,0 +1,46 @@
+package langserver
+
+import (
+	"context"
+
+	"luahelper-lsp/langserver/check"
+	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/pathpre"
+)
+
+// 调试用的请求，导出文件之间的依赖关系图
+
+// GetDependGraphParams 获取依赖关系图的参数
+type GetDependGraphParams struct {
+	URI string `json:"uri,omitempty"` // 指定的文件，为空时导出所有的文件
+}
+
+// GetDependGraphReturn 依赖关系图的返回
+type GetDependGraphReturn struct {
+	Nodes []check.DependNode `json:"nodes"` // 所有文件的依赖关系
+}
+
+// GetDependGraphReq 导出文件之间的依赖关系图
+func (l *LspServer) GetDependGraphReq(ctx context.Context, vs GetDependGraphParams) (graphReturn GetDependGraphReturn, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	project := l.getAllProject()
+	if project == nil {
+		log.Error("CheckProject is nil")
+		return
+	}
+
+	strFile := ""
+	if vs.URI != "" {
+		strFile = pathpre.VscodeURIToString(vs.URI)
+	}
+
+	graphReturn.Nodes = project.GetDependGraph(strFile)
+	if graphReturn.Nodes == nil {
+		graphReturn.Nodes = []check.DependNode{}
+	}
+
+	log.Debug("GetDependGraphReq strFile=%s, node num=%d", strFile, len(graphReturn.Nodes))
+	return
+}
@@ -0,0 +1,247 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	"luahelper-lsp/langserver/check"
+	"luahelper-lsp/langserver/check/common"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestDependGraph(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath := paths + "../testdata/depend"
+	strRootPath, _ = filepath.Abs(strRootPath)
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	graphReturn, err := lspServer.GetDependGraphReq(context, GetDependGraphParams{})
+	if err != nil {
+		t.Fatalf("GetDependGraphReq err=%s", err.Error())
+	}
+
+	nodeMap := map[string]map[string]bool{}
+	for _, node := range graphReturn.Nodes {
+		dependMap := map[string]bool{}
+		for _, edge := range node.Depends {
+			dependMap[filepath.Base(edge.File)] = edge.Refer
+		}
+		nodeMap[filepath.Base(node.File)] = dependMap
+	}
+
+	// depend1.lua require了depend2.lua
+	if refer, ok := nodeMap["depend1.lua"]["depend2.lua"]; !ok || !refer {
+		t.Fatalf("depend1.lua should refer depend2.lua")
+	}
+
+	// depend3.lua 使用了depend2.lua 定义的全局变量
+	if refer, ok := nodeMap["depend3.lua"]["depend2.lua"]; !ok || refer {
+		t.Fatalf("depend3.lua should use global of depend2.lua")
+	}
+
+	// depend4.lua 没有任何依赖
+	if len(nodeMap["depend4.lua"]) != 0 {
+		t.Fatalf("depend4.lua should not depend other file")
+	}
+
+	affectMap := map[string]struct{}{}
+	for _, node := range graphReturn.Nodes {
+		if filepath.Base(node.File) != "depend2.lua" {
+			continue
+		}
+
+		for _, strFile := range node.Dependents {
+			affectMap[filepath.Base(strFile)] = struct{}{}
+		}
+	}
+
+	if len(affectMap) != 2 {
+		t.Fatalf("depend2.lua dependents num=%d, should be 2", len(affectMap))
+	}
+
+	// 没有被依赖的文件变化，只影响自身
+	project := lspServer.getAllProject()
+	project.HandleFileEventChanges([]check.FileEventStruct{
+		{
+			StrFile: strRootPath + "/depend4.lua",
+			Type:    check.FileEventChanged,
+		},
+	})
+	if len(project.GetDiagnosticFiles()) != 1 {
+		t.Fatalf("depend4.lua change affect num=%d, should be 1", len(project.GetDiagnosticFiles()))
+	}
+
+	// depend2.lua 变化，影响所有依赖它的文件
+	project.HandleFileEventChanges([]check.FileEventStruct{
+		{
+			StrFile: strRootPath + "/depend2.lua",
+			Type:    check.FileEventChanged,
+		},
+	})
+	if len(project.GetDiagnosticFiles()) != 3 {
+		t.Fatalf("depend2.lua change affect num=%d, should be 3", len(project.GetDiagnosticFiles()))
+	}
+}
+
+func TestIncrementalSecondProject(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	// 测试中会修改文件，复制到临时目录
+	srcPath, _ := filepath.Abs(paths + "../testdata/incremental")
+	strRootPath := t.TempDir()
+	for _, strName := range []string{"luahelper.json", "main.lua", "moda.lua", "modb.lua"} {
+		data, err := ioutil.ReadFile(filepath.Join(srcPath, strName))
+		if err != nil {
+			t.Fatalf("read file:%s err=%s", strName, err.Error())
+		}
+		if err := ioutil.WriteFile(filepath.Join(strRootPath, strName), data, 0644); err != nil {
+			t.Fatalf("write file:%s err=%s", strName, err.Error())
+		}
+	}
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	project := lspServer.getAllProject()
+
+	mainFile := strRootPath + "/main.lua"
+	modaFile := strRootPath + "/moda.lua"
+	modbFile := strRootPath + "/modb.lua"
+	getNoDefineNum := func(strFile string) (num int) {
+		for _, oneErr := range project.GetAllFileErrorInfo()[strFile] {
+			if oneErr.ErrType == common.CheckErrorNoDefine {
+				num++
+			}
+		}
+		return num
+	}
+
+	if getNoDefineNum(modbFile) != 1 || getNoDefineNum(modaFile) != 0 {
+		t.Fatalf("first check error, moda=%d, modb=%d", getNoDefineNum(modaFile), getNoDefineNum(modbFile))
+	}
+
+	// moda.lua 变化，只重新遍历moda.lua以及依赖它的main.lua，modb.lua由第一阶段的结果还原
+	err := ioutil.WriteFile(modaFile, []byte("function moda_func()\n    print(moda_undefined)\nend\n"), 0644)
+	if err != nil {
+		t.Fatalf("write file:%s err=%s", modaFile, err.Error())
+	}
+	project.HandleFileEventChanges([]check.FileEventStruct{
+		{
+			StrFile: modaFile,
+			Type:    check.FileEventChanged,
+		},
+	})
+
+	replayFiles := project.GetSecondReplayFiles(mainFile)
+	if len(replayFiles) != 1 || replayFiles[0] != modbFile {
+		t.Fatalf("replay files error, files=%v", replayFiles)
+	}
+
+	// 还原的文件沿用之前的告警，重新遍历的文件生成新的告警
+	if getNoDefineNum(modbFile) != 1 || getNoDefineNum(modaFile) != 1 {
+		t.Fatalf("incremental check error, moda=%d, modb=%d", getNoDefineNum(modaFile), getNoDefineNum(modbFile))
+	}
+
+	// 执行顺序变化后，之后进入的文件都重新遍历
+	err = ioutil.WriteFile(mainFile, []byte("require(\"modb\")\nrequire(\"moda\")\n\nmoda_func()\n"), 0644)
+	if err != nil {
+		t.Fatalf("write file:%s err=%s", mainFile, err.Error())
+	}
+	project.HandleFileEventChanges([]check.FileEventStruct{
+		{
+			StrFile: mainFile,
+			Type:    check.FileEventChanged,
+		},
+	})
+
+	if replayFiles := project.GetSecondReplayFiles(mainFile); len(replayFiles) != 0 {
+		t.Fatalf("replay files should be empty after order change, files=%v", replayFiles)
+	}
+	if getNoDefineNum(modbFile) != 1 || getNoDefineNum(modaFile) != 1 {
+		t.Fatalf("order change check error, moda=%d, modb=%d", getNoDefineNum(modaFile), getNoDefineNum(modbFile))
+	}
+}
+
+func TestDependGraphAnnotateType(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	// 测试中会修改文件，复制到临时目录
+	srcPath, _ := filepath.Abs(paths + "../testdata/dependtype")
+	strRootPath := t.TempDir()
+	for _, strName := range []string{"luahelper.json", "class.lua", "user.lua", "other.lua"} {
+		data, err := ioutil.ReadFile(filepath.Join(srcPath, strName))
+		if err != nil {
+			t.Fatalf("read file:%s err=%s", strName, err.Error())
+		}
+		if err := ioutil.WriteFile(filepath.Join(strRootPath, strName), data, 0644); err != nil {
+			t.Fatalf("write file:%s err=%s", strName, err.Error())
+		}
+	}
+
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	project := lspServer.getAllProject()
+
+	classFile := strRootPath + "/class.lua"
+	userFile := strRootPath + "/user.lua"
+	otherFile := strRootPath + "/other.lua"
+
+	// user.lua 注解中使用了class.lua定义的Player类型
+	nodeVec := project.GetDependGraph(userFile)
+	if len(nodeVec) != 1 || len(nodeVec[0].Depends) != 1 || nodeVec[0].Depends[0].File != classFile ||
+		len(nodeVec[0].Depends[0].Types) != 1 || nodeVec[0].Depends[0].Types[0] != "Player" {
+		t.Fatalf("user.lua should depend on class.lua by annotate type, nodeVec=%v", nodeVec)
+	}
+
+	getAnnotateErrNum := func(strFile string) (num int) {
+		for _, oneErr := range project.GetAllFileErrorInfo()[strFile] {
+			if oneErr.ErrType == common.CheckErrorAnnotate {
+				num++
+			}
+		}
+		return num
+	}
+	if getAnnotateErrNum(userFile) != 0 {
+		t.Fatalf("user.lua should not have annotate error")
+	}
+
+	changeClassFile := func(content string) {
+		if err := ioutil.WriteFile(classFile, []byte(content), 0644); err != nil {
+			t.Fatalf("write file:%s err=%s", classFile, err.Error())
+		}
+		project.HandleFileEventChanges([]check.FileEventStruct{
+			{
+				StrFile: classFile,
+				Type:    check.FileEventChanged,
+			},
+		})
+
+		// 使用了类型的user.lua诊断信息重新推送，无关的other.lua不受影响
+		diagnosticMap := project.GetDiagnosticFiles()
+		if _, ok := diagnosticMap[userFile]; !ok {
+			t.Fatalf("user.lua should be in diagnostic files, files=%v", diagnosticMap)
+		}
+		if _, ok := diagnosticMap[otherFile]; ok {
+			t.Fatalf("other.lua should not be in diagnostic files, files=%v", diagnosticMap)
+		}
+	}
+
+	// Player类型改名后，user.lua使用的类型没有定义
+	changeClassFile("---@class Hero\n---@field name string\nlocal Hero = {}\n\nreturn Hero\n")
+	if getAnnotateErrNum(userFile) != 1 {
+		t.Fatalf("user.lua should have annotate error after class renamed")
+	}
+
+	// 再改回来，告警消失
+	changeClassFile("---@class Player\n---@field name string\nlocal Player = {}\n\nreturn Player\n")
+	if getAnnotateErrNum(userFile) != 0 {
+		t.Fatalf("user.lua annotate error should be removed")
+	}
+}
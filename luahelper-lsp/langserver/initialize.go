@@ -5,8 +5,10 @@ import (
 	"luahelper-lsp/langserver/check"
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/lspcommon"
 	"luahelper-lsp/langserver/pathpre"
 	lsp "luahelper-lsp/langserver/protocol"
+	"luahelper-lsp/langserver/telemetry"
 	"strings"
 	"time"
 )
@@ -39,9 +41,27 @@ type InitializationOptions struct {
 	CheckErrorAndAlwaysFalse       bool     `json:"CheckErrorAndAlwaysFalse,omitempty"`
 	CheckNoUseAssign               bool     `json:"CheckNoUseAssign,omitempty"`
 	CheckAnnotateType              bool     `json:"CheckAnnotateType,omitempty"`
+	CheckConfig                    bool     `json:"CheckConfig,omitempty"`
+	CheckTlog                      bool     `json:"CheckTlog,omitempty"`
+	CheckShadowVar                 bool     `json:"CheckShadowVar,omitempty"`
+	CheckUnreachable               bool     `json:"CheckUnreachable,omitempty"`
+	CheckMissingReturn             bool     `json:"CheckMissingReturn,omitempty"`
+	CheckConstAssign               bool     `json:"CheckConstAssign,omitempty"`
+	CheckCloseVar                  bool     `json:"CheckCloseVar,omitempty"`
+	CheckFormatString              bool     `json:"CheckFormatString,omitempty"`
+	CheckAccidentalGlobal          bool     `json:"CheckAccidentalGlobal,omitempty"`
+	CheckUnusedParam               bool     `json:"CheckUnusedParam,omitempty"`
+	CheckUnusedLabel               bool     `json:"CheckUnusedLabel,omitempty"`
+	CheckDiscardedResult           bool     `json:"CheckDiscardedResult,omitempty"`
 	IgnoreFileOrDir                []string `json:"IgnoreFileOrDir,omitempty"`
 	IgnoreFileOrDirError           []string `json:"IgnoreFileOrDirError,omitempty"`
 	RequirePathSeparator           string   `json:"RequirePathSeparator,omitempty"`
+	DiskCache                      bool     `json:"DiskCache,omitempty"`
+	CallSnippet                    bool     `json:"CallSnippet,omitempty"`
+	DiagnosticDocURL               string   `json:"DiagnosticDocURL,omitempty"`
+
+	// 统计上报的配置，默认关闭
+	Telemetry *telemetry.Config `json:"telemetry,omitempty"`
 }
 
 // InitializeParams 初始化参数
@@ -61,6 +81,22 @@ func (l *LspServer) Initialize(ctx context.Context, vs InitializeParams) (lsp.In
 
 	workspaceFolderNum := len(vs.WorkspaceFolders)
 
+	// 协商位置的编码，返回的位置中character按这种编码计数
+	positionEncoding := lspcommon.NegotiatePositionEncoding(vs.Capabilities.General.PositionEncodings)
+	lspcommon.SetPositionEncoding(positionEncoding)
+
+	// 客户端支持拉取诊断时，诊断错误由客户端拉取，否则仍然推送给客户端
+	var diagnosticProvider *lsp.DiagnosticOptions
+	if vs.Capabilities.TextDocument.Diagnostic != nil {
+		l.pullDiagnosticFlag = true
+		l.diagnosticRefreshFlag = vs.Capabilities.Workspace.Diagnostics.RefreshSupport
+		diagnosticProvider = &lsp.DiagnosticOptions{
+			Identifier:            "luahelper",
+			InterFileDependencies: true,
+			WorkspaceDiagnostics:  true,
+		}
+	}
+
 	initOptions := vs.InitializationOptions
 	if initOptions == nil {
 		initOptions = getDefaultIntialOptions()
@@ -77,6 +113,13 @@ func (l *LspServer) Initialize(ctx context.Context, vs InitializeParams) (lsp.In
 	// 按顺序插入
 	checkFlagList := getCheckFlagList(initOptions)
 
+	// 第一阶段结果的磁盘缓存，配置有变化时缓存失效
+	l.initDiskCachePath(initOptions.DiskCache, vscodeRoot)
+	for _, oneFloder := range vs.WorkspaceFolders {
+		l.diskCacheFolders = append(l.diskCacheFolders, pathpre.VscodeURIToString(oneFloder.URI))
+	}
+	l.setDiskCacheConfig(checkFlagList, initOptions.IgnoreFileOrDir, initOptions.IgnoreFileOrDirError, associalList)
+
 	initErr := l.initialCheckProject(ctx, checkFlagList, initOptions.Client, workspaceFolderNum, vs.WorkspaceFolders,
 		initOptions.LocalRun, initOptions.IgnoreFileOrDir, initOptions.IgnoreFileOrDirError)
 	if initErr != nil {
@@ -85,12 +128,23 @@ func (l *LspServer) Initialize(ctx context.Context, vs InitializeParams) (lsp.In
 	}
 	log.Debug("initial luahelper ok")
 
+	// 工程分析完成后，再根据配置开启统计上报
+	l.setupTelemetry(initOptions.Telemetry)
+
 	// 设置require其他lua文件的路径分割
 	common.GConfig.SetRequirePathSeparator(initOptions.RequirePathSeparator)
 
+	// 补全函数时插入参数的代码片段，需要客户端支持snippet
+	snippetSupport := vs.Capabilities.TextDocument.Completion.CompletionItem.SnippetSupport
+	common.GConfig.SetCallSnippetFlag(initOptions.CallSnippet && snippetSupport)
+
+	// 诊断信息链接到的错误类型说明文档
+	common.GConfig.SetDiagnosticDocURL(initOptions.DiagnosticDocURL)
+
 	return lsp.InitializeResult{
 		Capabilities: lsp.ServerCapabilities{
 			InnerServerCapabilities: lsp.InnerServerCapabilities{
+				PositionEncoding: positionEncoding,
 				TextDocumentSync: &lsp.TextDocumentSyncOptions{
 					OpenClose: true,
 					Change:    lsp.Incremental,
@@ -113,14 +167,19 @@ func (l *LspServer) Initialize(ctx context.Context, vs InitializeParams) (lsp.In
 				SignatureHelpProvider: lsp.SignatureHelpOptions{
 					TriggerCharacters: []string{"(", ","},
 				},
+				CodeActionProvider: lsp.CodeActionOptions{
+					CodeActionKinds: []lsp.CodeActionKind{codeActionGenerateComments, codeActionGenerateClass,
+						codeActionExtractLocal, codeActionExtractFunction, lsp.QuickFix},
+				},
 				CodeLensProvider: lsp.CodeLensOptions{
-					ResolveProvider: false,
+					ResolveProvider: true,
 				},
 				DocumentLinkProvider: lsp.DocumentLinkOptions{
 					ResolveProvider: false,
 				},
 				RenameProvider:            true,
 				DocumentHighlightProvider: true,
+				DiagnosticProvider:        diagnosticProvider,
 				Workspace: lsp.WorkspaceGn{
 					WorkspaceFolders: lsp.WorkspaceFoldersGn{
 						Supported:           true,
@@ -137,6 +196,9 @@ func (l *LspServer) Initialized(ctx context.Context, initialParam InitializedPar
 	log.Debug("Initialized")
 	// 获取所有的诊断错误
 	l.GetAllDiagnostics(ctx)
+
+	// 推送配置文件的诊断错误
+	l.pushConfigDiagnostics(ctx)
 	return nil
 }
 
@@ -175,6 +237,11 @@ func (l *LspServer) initialCheckProject(ctx context.Context, checkFlagList []boo
 			continue
 		}
 		dirManager.PushOneSubDir(folderPath)
+
+		// 工作区文件夹可以有自己的luahelper.json配置，读取失败时使用主工程的配置
+		if readErr := common.GConfig.AddFolderConfig(folderPath, "luahelper.json"); readErr != nil {
+			log.Error("read folder config err: %s", readErr.Error())
+		}
 	}
 
 	mainDir := dirManager.GetMainDir()
@@ -188,27 +255,38 @@ func (l *LspServer) initialCheckProject(ctx context.Context, checkFlagList []boo
 	clientExpPathList := dirManager.GetPathFileList(dirManager.GetClientExtLuaPath())
 	checkList = append(checkList, clientExpPathList...)
 
+	// 子目录下的luahelper.json覆盖上层目录的配置
+	loadNestedConfigs(checkList)
+
 	// 补全所有的入口文件
 	var entryFileList []string
 	for _, luaFile := range common.GConfig.ProjectFiles {
 		luaFile = pathpre.GetRemovePreStr(luaFile)
 		entryFileList = append(entryFileList, dirManager.GetCompletePath(mainDir, luaFile))
 	}
+	entryFileList = append(entryFileList, common.GConfig.GetFolderProjectFiles()...)
 	allProject := check.CreateAllProject(checkList, entryFileList, clientExpPathList)
+	allProject.SetDiskCache(l.createDiskCache())
 	allProject.HandleCheck()
+	allProject.SaveDiskCache(true)
 
 	// 工程路径变量设置到Glsp侧
 	l.project = allProject
 	costMsTime := time.Since(timeBegin).Milliseconds()
 
-	// 设置向中心服需要统计的信息
+	// 设置统计上报的信息
 	l.SetOnlineReportParam(clientType, allProject.GetAllFileNumber(), (int)(costMsTime), workspaceFolderNum)
-
-	// 启动一个协程来向中心服务器上报信息
-	go l.UDPReportOnline()
+	l.setReportCheckTime()
 	return nil
 }
 
+// loadNestedConfigs 读取分析的文件所在子目录下的luahelper.json，读取失败时使用上层目录的配置
+func loadNestedConfigs(fileList []string) {
+	for _, readErr := range common.GConfig.LoadNestedConfigs(fileList, "luahelper.json") {
+		log.Error("read nested config err: %s", readErr.Error())
+	}
+}
+
 // 初始化时，获取其他后缀关联到的lua 类型  (associalList string[])
 func getInitAssociationList(associationInitData interface{}) (associalList []string) {
 	assMap, flag := associationInitData.(map[string]interface{})
@@ -259,6 +337,19 @@ func getDefaultIntialOptions() (initOptions *InitializationOptions) {
 		CheckErrorAndAlwaysFalse:       false,
 		CheckNoUseAssign:               false,
 		CheckAnnotateType:              false,
+		CheckConfig:                    true,
+		CheckTlog:                      true,
+		CheckShadowVar:                 true,
+		CheckUnreachable:               true,
+		CheckMissingReturn:             true,
+		CheckConstAssign:               true,
+		CheckCloseVar:                  true,
+		CheckFormatString:              true,
+		CheckAccidentalGlobal:          false,
+		CheckUnusedParam:               true,
+		CheckUnusedLabel:               true,
+		CheckDiscardedResult:           true,
+		CallSnippet:                    true,
 	}
 
 	return initOptions
@@ -287,6 +378,18 @@ func getCheckFlagList(initOptions *InitializationOptions) (checkFlagList []bool)
 		initOptions.CheckErrorAndAlwaysFalse,
 		initOptions.CheckNoUseAssign,
 		initOptions.CheckAnnotateType,
+		initOptions.CheckConfig,
+		initOptions.CheckTlog,
+		initOptions.CheckShadowVar,
+		initOptions.CheckUnreachable,
+		initOptions.CheckMissingReturn,
+		initOptions.CheckConstAssign,
+		initOptions.CheckCloseVar,
+		initOptions.CheckFormatString,
+		initOptions.CheckAccidentalGlobal,
+		initOptions.CheckUnusedParam,
+		initOptions.CheckUnusedLabel,
+		initOptions.CheckDiscardedResult,
 	}
 
 	return checkFlagList
@@ -44,6 +44,13 @@ func (l *LspServer) RunLocalDiagnostices(localpath string) {
 		return
 	}
 
+	// 配置文件的错误一起输出
+	for file, info := range common.GConfig.GetConfigErrors() {
+		for _, errinfo := range info {
+			fmt.Printf("%v`%v`%v\n", file, errinfo.Loc.StartLine, errinfo.ErrStr)
+		}
+	}
+
 	fileErrorMap := project.GetAllFileErrorInfo()
 
 	// 保存全局的错误诊断信息
@@ -1,10 +1,13 @@
 package langserver
 
 import (
+	"context"
 	"luahelper-lsp/langserver/check"
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/log"
 	"luahelper-lsp/langserver/lspcommon"
+	lsp "luahelper-lsp/langserver/protocol"
+	"luahelper-lsp/langserver/telemetry"
 	"sync"
 	"time"
 
@@ -41,11 +44,42 @@ type LspServer struct {
 	// 所有文件的诊断错误信息, 动态的，文件实时修改了，但是没有保存的错误
 	fileChangeErrorMap map[string]common.CheckError
 
+	// luahelper.json配置文件的诊断错误信息
+	configErrorMap map[string][]common.CheckError
+
 	// 请求互斥锁
 	requestMutex sync.Mutex
 
-	// 向中心服务器，需要上报统计的信息
-	onlineReport OnlineReport
+	// 客户端支持拉取诊断时为true，诊断错误不再推送，保存下来等客户端拉取
+	pullDiagnosticFlag bool
+
+	// 客户端是否支持workspace/diagnostic/refresh请求
+	diagnosticRefreshFlag bool
+
+	// 拉取模式下每个文件当前的诊断信息，key为文件名
+	pullDiagnosticMap map[string]lsp.WorkspaceFullDocumentDiagnosticReport
+	pullMutex         sync.Mutex
+
+	// 是否正在等待客户端响应刷新请求，以及等待期间诊断信息是否又有变化
+	refreshingFlag   bool
+	refreshAgainFlag bool
+	refreshMutex     sync.Mutex
+
+	// 可以被$/cancelRequest取消的请求，key为请求id的json格式
+	cancelMap   map[string]context.CancelFunc
+	cancelMutex sync.Mutex
+
+	// 统计上报的对象，默认关闭
+	reporter *telemetry.Reporter
+
+	// 第一阶段结果磁盘缓存的路径，为空表示没有开启
+	diskCachePath string
+
+	// 影响分析结果的配置的hash值，有变化时磁盘缓存失效
+	diskCacheConfig string
+
+	// 多根工作区的所有文件夹，文件夹下的luahelper.json也会影响分析结果
+	diskCacheFolders []string
 
 	// 最后一次获取文档着色功能的时间
 	colorTime int64
@@ -67,16 +101,13 @@ func CreateLspServer() *LspServer {
 		project:            nil,
 		fileErrorMap:       map[string][]common.CheckError{},
 		fileChangeErrorMap: map[string]common.CheckError{},
+		configErrorMap:     map[string][]common.CheckError{},
+		pullDiagnosticMap:  map[string]lsp.WorkspaceFullDocumentDiagnosticReport{},
+		cancelMap:          map[string]context.CancelFunc{},
 		fileCache:          lspcommon.CreateFileMapCache(),
-		onlineReport: OnlineReport{
-			ClientType:  "vsc",
-			FileNumber:  0,
-			OsType:      "vsc",
-			ClientVer:   clientVerStr,
-			FirstReport: 1,
-		},
-		colorTime:      0,
-		changeConfFlag: false,
+		reporter:           createReporter(),
+		colorTime:          0,
+		changeConfFlag:     false,
 	}
 
 	return lspServer
@@ -86,7 +117,7 @@ func CreateLspServer() *LspServer {
 func CreateServer() *jrpc2.Server {
 	lspServer := CreateLspServer()
 
-	lspServer.server = jrpc2.NewServer(handler.Map{
+	lspServer.server = jrpc2.NewServer(wrapTimeHandler(handler.Map{
 		"initialize":                          handler.New(lspServer.Initialize),
 		"initialized":                         handler.New(lspServer.Initialized),
 		"textDocument/didChange":              handler.New(lspServer.TextDocumentDidChange),
@@ -102,19 +133,24 @@ func CreateServer() *jrpc2.Server {
 		"textDocument/signatureHelp":          handler.New(lspServer.TextDocumentSignatureHelp),
 		"textDocument/documentColor":          handler.New(lspServer.TextDocumentColor),
 		"textDocument/codeLens":               handler.New(lspServer.TextDocumentCodeLens),
+		"codeLens/resolve":                    handler.New(lspServer.CodeLensResolve),
 		"textDocument/documentLink":           handler.New(lspServer.TextDocumentdocumentLink),
 		"textDocument/completion":             handler.New(lspServer.TextDocumentComplete),
+		"textDocument/codeAction":             handler.New(lspServer.TextDocumentCodeAction),
+		"textDocument/diagnostic":             handler.New(lspServer.TextDocumentDiagnostic),
 		"completionItem/resolve":              handler.New(lspServer.TextDocumentCompleteResolve),
 		"workspace/didChangeConfiguration":    handler.New(lspServer.ChangeConfiguration),
 		"workspace/didChangeWorkspaceFolders": handler.New(lspServer.WorkspaceChangeWorkspaceFolders),
 		"workspace/didChangeWatchedFiles":     handler.New(lspServer.WorkspaceChangeWatchedFiles),
 		"workspace/symbol":                    handler.New(lspServer.WorkspaceSymbolRequest),
+		"workspace/diagnostic":                handler.New(lspServer.WorkspaceDiagnostic),
 		"luahelper/getVarColor":               handler.New(lspServer.TextDocumentGetVarColor),
 		"luahelper/getOnlineReq":              handler.New(lspServer.GetOnlineReq),
+		"luahelper/getDependGraph":            handler.New(lspServer.GetDependGraphReq),
 		"$/cancelRequest":                     handler.New(lspServer.CancelRequest),
 		"shutdown":                            handler.New(lspServer.Shutdown),
 		"exit":                                handler.New(lspServer.Exit),
-	}, &jrpc2.ServerOptions{
+	}, lspServer.reporter), &jrpc2.ServerOptions{
 		AllowPush:   true,
 		Concurrency: 4,
 		Logger:      log.LspLog,
@@ -56,78 +56,6 @@ func (fileMapCache *FileMapCache) GetFileContent(strFile string) (contents []byt
 	return contents, found
 }
 
-//	Previously used bytes converted to rune.
-//	Now use the high bit to determine how many bits the character occupies.
-func offsetForStartAndEnd(contents []byte, startPos lsp.Position, endPos lsp.Position) (startOffset,
-	endOffset int, err error) {
-	line  := uint32(0)
-	col := uint32(0)
-	offset := 0
-	startFlag := false
-	endFlag := false
-
-	getCharBytes := func(b byte) int {
-		num := 0
-		for b&(1<<uint32(7-num)) != 0 {
-			num++
-		}
-		return num
-	}
-
-	for index := 0; index < len(contents); index++ {
-		if !startFlag {
-			if line == startPos.Line && col == startPos.Character {
-				startOffset = offset
-				startFlag = true
-			}
-		}
-
-		if !startFlag {
-			if (line == startPos.Line && col > startPos.Character) || line > startPos.Line {
-				return 0, 0, fmt.Errorf("character %d (zero-based) is beyond line %d boundary (zero-based)", startPos.Character, startPos.Line)
-			}
-		}
-
-		if startFlag && !endFlag {
-			if line == endPos.Line && col == endPos.Character {
-				endOffset = offset
-				endFlag = true
-
-				// 两个都找到了
-				return startOffset, endOffset, nil
-			}
-
-			if (line == endPos.Line && col > endPos.Character) || line > endPos.Line {
-				return 0, 0, fmt.Errorf("character %d (zero-based) is beyond line %d boundary (zero-based)", endPos.Character, endPos.Line)
-			}
-		}
-		curChar := contents[index]
-		if curChar > 127 {
-			curCharBytes := getCharBytes(curChar)
-			index += curCharBytes - 1
-			offset += curCharBytes - 1
-		}
-		offset++
-		if curChar == '\n' {
-			line++
-			col = 0
-		} else {
-			col++
-		}
-	}
-	if startFlag && line == endPos.Line && col == endPos.Character {
-		return startOffset, offset, nil
-	}
-	if !startFlag && line == startPos.Line && col == startPos.Character &&
-		line == endPos.Line && col == endPos.Character {
-		return offset, offset, nil
-	}
-	if line == 0 {
-		return 0, 0, fmt.Errorf("character %d (zero-based) is beyond first line boundary", startPos.Character)
-	}
-	return 0, 0, fmt.Errorf("file only has %d lines", line+1)
-}
-
 // ApplyContentChanges updates `contents` based on `changes`
 func (fileMapCache *FileMapCache) ApplyContentChanges(strFile string, contents []byte, changes []lsp.TextDocumentContentChangeEvent) ([]byte, error) {
 	for _, change := range changes {
@@ -136,10 +64,16 @@ func (fileMapCache *FileMapCache) ApplyContentChanges(strFile string, contents [
 			continue
 		}
 
-		start, end, err := offsetForStartAndEnd(contents, change.Range.Start, change.Range.End)
+		// 按协商的位置编码转换为字节偏移
+		converter := CreatePosConverter(contents)
+		start, err := converter.PositionToOffset(change.Range.Start)
 		if err != nil {
 			return nil, fmt.Errorf("invalid position %q on %q: %s", change.Range.Start, strFile, err.Error())
 		}
+		end, err := converter.PositionToOffset(change.Range.End)
+		if err != nil {
+			return nil, fmt.Errorf("invalid position %q on %q: %s", change.Range.End, strFile, err.Error())
+		}
 
 		if start < 0 || end > len(contents) || end < start {
 			return nil, fmt.Errorf(" for out of range position %q on %q", change.Range, strFile)
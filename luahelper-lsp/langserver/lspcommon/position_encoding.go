@@ -0,0 +1,234 @@
+package lspcommon
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	lsp "luahelper-lsp/langserver/protocol"
+)
+
+// positionEncoding 与客户端协商的位置编码，lsp.Position中的character按这种编码计数
+// 语法分析得到的lexer.Location中的列按unicode字符计数，两者之间的转换都通过PosConverter
+var positionEncoding = lsp.PositionEncodingUTF16
+
+// NegotiatePositionEncoding 按客户端的优先顺序选择一种支持的位置编码，客户端没有声明时为协议默认的utf-16
+func NegotiatePositionEncoding(clientEncodings []lsp.PositionEncodingKind) lsp.PositionEncodingKind {
+	for _, encoding := range clientEncodings {
+		switch encoding {
+		case lsp.PositionEncodingUTF8, lsp.PositionEncodingUTF16, lsp.PositionEncodingUTF32:
+			return encoding
+		}
+	}
+
+	return lsp.PositionEncodingUTF16
+}
+
+// SetPositionEncoding 设置与客户端协商的位置编码
+func SetPositionEncoding(encoding lsp.PositionEncodingKind) {
+	positionEncoding = encoding
+}
+
+// GetPositionEncoding 获取与客户端协商的位置编码
+func GetPositionEncoding() lsp.PositionEncodingKind {
+	return positionEncoding
+}
+
+// runeWidth 一个字符在位置编码下占的长度，size为字符的utf8字节数，非法的utf8字节按一个字符计算
+func runeWidth(r rune, size int) int {
+	switch positionEncoding {
+	case lsp.PositionEncodingUTF8:
+		return size
+	case lsp.PositionEncodingUTF16:
+		if r > 0xFFFF {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+// PosConverter 一个文件中lexer.Location与lsp.Position之间的转换
+type PosConverter struct {
+	contents   []byte
+	lineStarts []int // 每一行开头的字节偏移，第一次用到时计算
+}
+
+// CreatePosConverter 创建文件的位置转换，contents为文件的内容
+func CreatePosConverter(contents []byte) *PosConverter {
+	return &PosConverter{
+		contents: contents,
+	}
+}
+
+// getLine 获取某一行的内容以及开头的字节偏移，不包括换行符，line从0开始
+func (c *PosConverter) getLine(line int) (strLine string, offset int, ok bool) {
+	if c.lineStarts == nil {
+		c.lineStarts = []int{0}
+		for i, ch := range c.contents {
+			if ch == '\n' {
+				c.lineStarts = append(c.lineStarts, i+1)
+			}
+		}
+	}
+
+	if line < 0 || line >= len(c.lineStarts) {
+		return "", 0, false
+	}
+
+	offset = c.lineStarts[line]
+	endOffset := len(c.contents)
+	if line+1 < len(c.lineStarts) {
+		endOffset = c.lineStarts[line+1] - 1
+	}
+	if endOffset > offset && c.contents[endOffset-1] == '\r' {
+		endOffset--
+	}
+
+	return string(c.contents[offset:endOffset]), offset, true
+}
+
+// ColToCharacter 按字符计数的列转换为位置编码下的character，line从0开始
+func (c *PosConverter) ColToCharacter(line, col int) uint32 {
+	if positionEncoding == lsp.PositionEncodingUTF32 || col <= 0 {
+		return uint32(col)
+	}
+
+	strLine, _, ok := c.getLine(line)
+	if !ok {
+		return uint32(col)
+	}
+
+	character := 0
+	for i := 0; i < col; i++ {
+		if len(strLine) == 0 {
+			// 超过了行尾，剩下的按一个字符计算
+			character += col - i
+			break
+		}
+
+		r, size := utf8.DecodeRuneInString(strLine)
+		character += runeWidth(r, size)
+		strLine = strLine[size:]
+	}
+
+	return uint32(character)
+}
+
+// CharacterToCol 位置编码下的character转换为按字符计数的列，line从0开始
+// character在一个字符的中间时（例如utf-16的代理对），返回这个字符开头的列；超过行尾时返回行尾
+func (c *PosConverter) CharacterToCol(line int, character uint32) int {
+	if positionEncoding == lsp.PositionEncodingUTF32 {
+		return int(character)
+	}
+
+	strLine, _, ok := c.getLine(line)
+	if !ok {
+		return int(character)
+	}
+
+	col, units := 0, 0
+	for len(strLine) > 0 {
+		r, size := utf8.DecodeRuneInString(strLine)
+		units += runeWidth(r, size)
+		if units > int(character) {
+			break
+		}
+
+		col++
+		strLine = strLine[size:]
+	}
+
+	return col
+}
+
+// LocToRange lexer.Location转换为lsp.Range
+func (c *PosConverter) LocToRange(loc *lexer.Location) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{
+			Line:      uint32(loc.StartLine) - 1,
+			Character: c.ColToCharacter(loc.StartLine-1, loc.StartColumn),
+		},
+		End: lsp.Position{
+			Line:      uint32(loc.EndLine) - 1,
+			Character: c.ColToCharacter(loc.EndLine-1, loc.EndColumn),
+		},
+	}
+}
+
+// RangeToLoc 客户端传入的lsp.Range转换为lexer.Location
+func (c *PosConverter) RangeToLoc(ra lsp.Range) lexer.Location {
+	return lexer.Location{
+		StartLine:   int(ra.Start.Line) + 1,
+		StartColumn: c.CharacterToCol(int(ra.Start.Line), ra.Start.Character),
+		EndLine:     int(ra.End.Line) + 1,
+		EndColumn:   c.CharacterToCol(int(ra.End.Line), ra.End.Character),
+	}
+}
+
+// ToRunePosition 客户端传入的位置转换为列按字符计数的位置
+func (c *PosConverter) ToRunePosition(pos lsp.Position) lsp.Position {
+	return lsp.Position{
+		Line:      pos.Line,
+		Character: uint32(c.CharacterToCol(int(pos.Line), pos.Character)),
+	}
+}
+
+// PositionToOffset 客户端传入的位置转换为文件内容中的字节偏移
+func (c *PosConverter) PositionToOffset(pos lsp.Position) (int, error) {
+	strLine, offset, ok := c.getLine(int(pos.Line))
+	if !ok {
+		return 0, fmt.Errorf("line %d (zero-based) is beyond file boundary, file only has %d lines", pos.Line,
+			len(c.lineStarts))
+	}
+
+	col := c.CharacterToCol(int(pos.Line), pos.Character)
+	for i := 0; i < col && len(strLine) > 0; i++ {
+		_, size := utf8.DecodeRuneInString(strLine)
+		offset += size
+		strLine = strLine[size:]
+	}
+
+	return offset, nil
+}
+
+// RangeConverter 多个文件的位置转换，文件打开时使用缓存中的内容，否则读取磁盘上的文件，每个文件只读取一次
+type RangeConverter struct {
+	fileMapCache *FileMapCache
+	converterMap map[string]*PosConverter
+}
+
+// CreateRangeConverter 创建多个文件的位置转换，用于一次请求中返回多个文件的位置
+func (fileMapCache *FileMapCache) CreateRangeConverter() *RangeConverter {
+	return &RangeConverter{
+		fileMapCache: fileMapCache,
+		converterMap: map[string]*PosConverter{},
+	}
+}
+
+// GetConverter 获取某一个文件的位置转换
+func (r *RangeConverter) GetConverter(strFile string) *PosConverter {
+	if converter, ok := r.converterMap[strFile]; ok {
+		return converter
+	}
+
+	// utf-32时列与字符数一致，不需要文件的内容
+	var contents []byte
+	if positionEncoding != lsp.PositionEncodingUTF32 {
+		if fileCache, ok := r.fileMapCache.m[strFile]; ok {
+			contents = fileCache.content
+		} else if data, err := common.GConfig.ReadLuaFile(strFile); err == nil {
+			contents = data
+		}
+	}
+
+	converter := CreatePosConverter(contents)
+	r.converterMap[strFile] = converter
+	return converter
+}
+
+// LocToRange 某一个文件中的lexer.Location转换为lsp.Range
+func (r *RangeConverter) LocToRange(strFile string, loc *lexer.Location) lsp.Range {
+	return r.GetConverter(strFile).LocToRange(loc)
+}
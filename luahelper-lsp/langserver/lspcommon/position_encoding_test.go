@@ -0,0 +1,104 @@
+package lspcommon
+
+import (
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	lsp "luahelper-lsp/langserver/protocol"
+	"testing"
+)
+
+func TestNegotiatePositionEncoding(t *testing.T) {
+	type encodingCase struct {
+		clientEncodings []lsp.PositionEncodingKind
+		expect          lsp.PositionEncodingKind
+	}
+	caseVec := []encodingCase{
+		{nil, lsp.PositionEncodingUTF16},
+		{[]lsp.PositionEncodingKind{"utf-7"}, lsp.PositionEncodingUTF16},
+		{[]lsp.PositionEncodingKind{lsp.PositionEncodingUTF8, lsp.PositionEncodingUTF16}, lsp.PositionEncodingUTF8},
+		{[]lsp.PositionEncodingKind{"utf-7", lsp.PositionEncodingUTF32}, lsp.PositionEncodingUTF32},
+	}
+
+	for _, oneCase := range caseVec {
+		if encoding := NegotiatePositionEncoding(oneCase.clientEncodings); encoding != oneCase.expect {
+			t.Fatalf("negotiate %v failed, encoding=%s", oneCase.clientEncodings, encoding)
+		}
+	}
+}
+
+func TestPosConverter(t *testing.T) {
+	defer SetPositionEncoding(GetPositionEncoding())
+
+	// 第二行中，"中文"在utf-8下各占3个字节，😀在utf-16下占两个单元，在utf-8下占4个字节
+	contents := []byte("local a = 1\r\nlocal s = \"中文😀\" .. b\nprint(s)")
+
+	// b的位置，按字符计数为第19列
+	loc := lexer.Location{StartLine: 2, StartColumn: 19, EndLine: 2, EndColumn: 20}
+	type convertCase struct {
+		encoding  lsp.PositionEncodingKind
+		character uint32
+		offset    int
+	}
+	caseVec := []convertCase{
+		{lsp.PositionEncodingUTF8, 26, 39},
+		{lsp.PositionEncodingUTF16, 20, 39},
+		{lsp.PositionEncodingUTF32, 19, 39},
+	}
+
+	for _, oneCase := range caseVec {
+		SetPositionEncoding(oneCase.encoding)
+		converter := CreatePosConverter(contents)
+
+		ra := converter.LocToRange(&loc)
+		if ra.Start.Line != 1 || ra.Start.Character != oneCase.character || ra.End.Character != oneCase.character+1 {
+			t.Fatalf("%s LocToRange failed, range=%v", oneCase.encoding, ra)
+		}
+
+		if newLoc := converter.RangeToLoc(ra); newLoc != loc {
+			t.Fatalf("%s RangeToLoc failed, loc=%v", oneCase.encoding, newLoc)
+		}
+
+		offset, err := converter.PositionToOffset(ra.Start)
+		if err != nil || offset != oneCase.offset || contents[offset] != 'b' {
+			t.Fatalf("%s PositionToOffset failed, offset=%d, err=%v", oneCase.encoding, offset, err)
+		}
+	}
+
+	// utf-16下位置在代理对的中间时，取这个字符的开头；超过行尾时取行尾
+	SetPositionEncoding(lsp.PositionEncodingUTF16)
+	converter := CreatePosConverter(contents)
+	if col := converter.CharacterToCol(1, 14); col != 13 {
+		t.Fatalf("surrogate pair CharacterToCol failed, col=%d", col)
+	}
+	if col := converter.CharacterToCol(0, 100); col != 11 {
+		t.Fatalf("line end CharacterToCol failed, col=%d", col)
+	}
+	if _, err := converter.PositionToOffset(lsp.Position{Line: 5}); err == nil {
+		t.Fatalf("PositionToOffset beyond file should fail")
+	}
+}
+
+func TestApplyContentChangesUTF16(t *testing.T) {
+	defer SetPositionEncoding(GetPositionEncoding())
+	SetPositionEncoding(lsp.PositionEncodingUTF16)
+
+	fileMapCache := CreateFileMapCache()
+	strFile := "test.lua"
+	oldContents := []byte("local s = \"😀\" .. a\n")
+	fileMapCache.SetFileContent(strFile, oldContents)
+
+	// 把a替换为b，utf-16下a的character为18
+	changes := []lsp.TextDocumentContentChangeEvent{
+		{
+			Range: &lsp.Range{
+				Start: lsp.Position{Line: 0, Character: 18},
+				End:   lsp.Position{Line: 0, Character: 19},
+			},
+			Text: "b",
+		},
+	}
+
+	contents, err := fileMapCache.ApplyContentChanges(strFile, oldContents, changes)
+	if err != nil || string(contents) != "local s = \"😀\" .. b\n" {
+		t.Fatalf("ApplyContentChanges failed, contents=%s, err=%v", string(contents), err)
+	}
+}
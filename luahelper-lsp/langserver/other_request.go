@@ -2,6 +2,7 @@ package langserver
 
 import (
 	"context"
+	"encoding/json"
 
 	"luahelper-lsp/langserver/check"
 	"luahelper-lsp/langserver/check/common"
@@ -12,34 +13,23 @@ import (
 	lsp "luahelper-lsp/langserver/protocol"
 )
 
-// CancelRequest 取消一个请求
+// CancelRequest 取消一个请求，只有登记过的请求可以取消
 func (l *LspServer) CancelRequest(ctx context.Context, vs lsp.CancelParams) error {
 	log.Debug("CancelRequest, id=%v", vs.ID)
-	return nil
-	// var strId string
-	// switch id := vs.ID.(type) {
-	// case float64:
-	// 	strId = fmt.Sprintf("%.f", id)
-	// 	break
-	// case string:
-	// 	strId = id
-	// 	break
-	// default:
-	// 	return nil
-	// }
-
-	// l.server.CancelRequest(strId)
-	// return nil
-}
 
-// TextDocumentCodeLens 请求
-func (l *LspServer) TextDocumentCodeLens(ctx context.Context, vs lsp.CodeLensParams) (edit []lsp.CodeLens, err error) {
-	return
-}
+	// 请求id可以是数字或是字符串，转换为json格式与登记的id比较
+	data, err := json.Marshal(vs.ID)
+	if err != nil {
+		return nil
+	}
 
-// TextDocumentdocumentLink 请求
-func (l *LspServer) TextDocumentdocumentLink(ctx context.Context, vs lsp.DocumentLinkParams) (edit []lsp.DocumentLink, err error) {
-	return
+	l.cancelMutex.Lock()
+	cancel, ok := l.cancelMap[string(data)]
+	l.cancelMutex.Unlock()
+	if ok {
+		cancel()
+	}
+	return nil
 }
 
 // SourceParams 请求的原参数
@@ -88,6 +78,18 @@ type WarnParams struct {
 	CheckErrorAndAlwaysFalse       bool `json:"CheckErrorAndAlwaysFalse,omitempty"`
 	CheckNoUseAssign               bool `json:"CheckNoUseAssign,omitempty"`
 	CheckAnnotateType              bool `json:"CheckAnnotateType,omitempty"`
+	CheckConfig                    bool `json:"CheckConfig,omitempty"`
+	CheckTlog                      bool `json:"CheckTlog,omitempty"`
+	CheckShadowVar                 bool `json:"CheckShadowVar,omitempty"`
+	CheckUnreachable               bool `json:"CheckUnreachable,omitempty"`
+	CheckMissingReturn             bool `json:"CheckMissingReturn,omitempty"`
+	CheckConstAssign               bool `json:"CheckConstAssign,omitempty"`
+	CheckCloseVar                  bool `json:"CheckCloseVar,omitempty"`
+	CheckFormatString              bool `json:"CheckFormatString,omitempty"`
+	CheckAccidentalGlobal          bool `json:"CheckAccidentalGlobal,omitempty"`
+	CheckUnusedParam               bool `json:"CheckUnusedParam,omitempty"`
+	CheckUnusedLabel               bool `json:"CheckUnusedLabel,omitempty"`
+	CheckDiscardedResult           bool `json:"CheckDiscardedResult,omitempty"`
 }
 
 // LuahelperParams 整体的设置
@@ -169,6 +171,8 @@ func (l *LspServer) ChangeConfiguration(ctx context.Context, vs ChangeConfigurat
 
 	// 按顺序插入
 	checkFlagList := getWarnCheckList(&vs.Settings.Luahelper.WarnParam)
+	l.setDiskCacheConfig(checkFlagList, vs.Settings.Luahelper.Project.IgnoreFileOrDir,
+		vs.Settings.Luahelper.Project.IgnoreFileOrDirError, associalList)
 
 	common.GConfig.HandleChangeCheckList(checkFlagList, vs.Settings.Luahelper.Project.IgnoreFileOrDir,
 		vs.Settings.Luahelper.Project.IgnoreFileOrDirError)
@@ -205,20 +209,29 @@ func (l *LspServer) handleChange(ctx context.Context) error {
 	clientExpPathList := dirManager.GetPathFileList(dirManager.GetClientExtLuaPath())
 	checkList = append(checkList, clientExpPathList...)
 
+	// 子目录下的luahelper.json覆盖上层目录的配置
+	loadNestedConfigs(checkList)
+
 	// 补全所有的入口文件
 	var entryFileList []string
 	for _, luaFile := range common.GConfig.ProjectFiles {
 		luaFile = pathpre.GetRemovePreStr(luaFile)
 		entryFileList = append(entryFileList, dirManager.GetCompletePath(mainDir, luaFile))
 	}
+	entryFileList = append(entryFileList, common.GConfig.GetFolderProjectFiles()...)
 	allProject := check.CreateAllProject(checkList, entryFileList, clientExpPathList)
+	allProject.SetDiskCache(l.createDiskCache())
 	allProject.HandleCheck()
+	allProject.SaveDiskCache(true)
 
 	// 工程路径变量设置到Glsp侧
 	l.project = allProject
+	l.SetLuaFileNumber(allProject.GetAllFileNumber())
+	l.setReportCheckTime()
 
 	// 再一次获取所有诊断信息
 	l.pushAllDiagnosticsAgain(ctx)
+	l.pushConfigDiagnostics(ctx)
 
 	return nil
 }
@@ -231,12 +244,18 @@ type InitializedParams struct {
 // Shutdown lsp 关闭
 func (l *LspServer) Shutdown(ctx context.Context) error {
 	log.Debug("Shutdown")
+
+	// 退出前保存第一阶段结果的磁盘缓存
+	if l.project != nil {
+		l.project.SaveDiskCache(false)
+	}
 	return nil
 }
 
 // Exit 退出了
 func (l *LspServer) Exit(ctx context.Context) error {
 	log.Debug("Exit")
+	l.reporter.Stop()
 	return nil
 }
 
@@ -262,6 +281,18 @@ func getWarnCheckList(warnParam *WarnParams) (checkFlagList []bool) {
 		warnParam.CheckErrorAndAlwaysFalse,
 		warnParam.CheckNoUseAssign,
 		warnParam.CheckAnnotateType,
+		warnParam.CheckConfig,
+		warnParam.CheckTlog,
+		warnParam.CheckShadowVar,
+		warnParam.CheckUnreachable,
+		warnParam.CheckMissingReturn,
+		warnParam.CheckConstAssign,
+		warnParam.CheckCloseVar,
+		warnParam.CheckFormatString,
+		warnParam.CheckAccidentalGlobal,
+		warnParam.CheckUnusedParam,
+		warnParam.CheckUnusedLabel,
+		warnParam.CheckDiscardedResult,
 	}
 
 	return checkFlagList
@@ -464,6 +464,14 @@ type CodeActionContext struct {
  */
 type CodeActionKind string
 
+/**
+ * A type indicating how positions are encoded,
+ * specifically what column offsets mean.
+ *
+ * @since 3.17.0
+ */
+type PositionEncodingKind string
+
 /**
  * Provider options for a [CodeActionRequest](#CodeActionRequest).
  */
@@ -1335,6 +1343,66 @@ type DiagnosticSeverity int
  * @since 3.15.0
  */
 type DiagnosticTag int
+/**
+ * Client capabilities specific to diagnostic pull requests.
+ *
+ * @since 3.17.0
+ */
+type DiagnosticClientCapabilities struct {
+	/**
+	 * Whether implementation supports dynamic registration. If this is set to `true`
+	 * the client supports the new `(TextDocumentRegistrationOptions & StaticRegistrationOptions)`
+	 * return value for the corresponding server capability as well.
+	 */
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+	/**
+	 * Whether the clients supports related documents for document diagnostic pulls.
+	 */
+	RelatedDocumentSupport bool `json:"relatedDocumentSupport,omitempty"`
+}
+
+/**
+ * Diagnostic options.
+ *
+ * @since 3.17.0
+ */
+type DiagnosticOptions struct {
+	/**
+	 * An optional identifier under which the diagnostics are
+	 * managed by the client.
+	 */
+	Identifier string `json:"identifier,omitempty"`
+	/**
+	 * Whether the language has inter file dependencies meaning that
+	 * editing code in one file can result in a different diagnostic
+	 * set in another file. Inter file dependencies are common for
+	 * most programming languages and typically uncommon for linters.
+	 */
+	InterFileDependencies bool `json:"interFileDependencies"`
+	/**
+	 * The server provides support for workspace diagnostics as well.
+	 */
+	WorkspaceDiagnostics bool `json:"workspaceDiagnostics"`
+	WorkDoneProgressOptions
+}
+
+/**
+ * Workspace client capabilities specific to diagnostic pull requests.
+ *
+ * @since 3.17.0
+ */
+type DiagnosticWorkspaceClientCapabilities struct {
+	/**
+	 * Whether the client implementation supports a refresh request sent from
+	 * the server to the client.
+	 *
+	 * Note that this event is global and will force the client to refresh all
+	 * pulled diagnostics currently shown. It should be used with absolute care and
+	 * is useful for situation where a server for example detects a project wide
+	 * change that requires such a calculation.
+	 */
+	RefreshSupport bool `json:"refreshSupport,omitempty"`
+}
 
 type DidChangeConfigurationClientCapabilities struct {
 	/**
@@ -1483,6 +1551,34 @@ type DocumentColorRegistrationOptions struct {
 	StaticRegistrationOptions
 	DocumentColorOptions
 }
+/**
+ * Parameters of the document diagnostic request.
+ *
+ * @since 3.17.0
+ */
+type DocumentDiagnosticParams struct {
+	/**
+	 * The text document.
+	 */
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	/**
+	 * The additional identifier  provided during registration.
+	 */
+	Identifier string `json:"identifier,omitempty"`
+	/**
+	 * The result id of a previous response if provided.
+	 */
+	PreviousResultID string `json:"previousResultId,omitempty"`
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
+/**
+ * The document diagnostic report kinds.
+ *
+ * @since 3.17.0
+ */
+type DocumentDiagnosticReportKind string
 
 /**
  * A document filter denotes a document by different properties like
@@ -2251,6 +2347,27 @@ type FormattingOptions struct {
 	 */
 	TrimFinalNewlines bool `json:"trimFinalNewlines,omitempty"`
 }
+/**
+ * A diagnostic report with a full set of problems.
+ *
+ * @since 3.17.0
+ */
+type FullDocumentDiagnosticReport struct {
+	/**
+	 * A full document diagnostic report.
+	 */
+	Kind DocumentDiagnosticReportKind `json:"kind"`
+	/**
+	 * An optional result id. If provided it will
+	 * be sent on the next diagnostic request for the
+	 * same document.
+	 */
+	ResultID string `json:"resultId,omitempty"`
+	/**
+	 * The actual items.
+	 */
+	Items []Diagnostic `json:"items"`
+}
 
 /**
  * General client capabilities.
@@ -2290,6 +2407,22 @@ type GeneralClientCapabilities struct {
 	 * @since 3.16.0
 	 */
 	Markdown MarkdownClientCapabilities `json:"markdown,omitempty"`
+	/**
+	 * The position encodings supported by the client. Client and server
+	 * have to agree on the same position encoding to ensure that offsets
+	 * (e.g. character position in a line) are interpreted the same on both
+	 * side.
+	 *
+	 * To keep the protocol backwards compatible the following applies: if
+	 * the value 'utf-16' is missing from the array of position encodings
+	 * servers can assume that the client supports UTF-16. UTF-16 is
+	 * therefore a mandatory encoding.
+	 *
+	 * If omitted it defaults to ['utf-16'].
+	 *
+	 * @since 3.17.0
+	 */
+	PositionEncodings []PositionEncodingKind `json:"positionEncodings,omitempty"`
 }
 
 /**
@@ -2504,6 +2637,18 @@ type InnerInitializeParams struct {
  * server.
  */
 type InnerServerCapabilities struct {
+	/**
+	 * The position encoding the server picked from the encodings offered
+	 * by the client via the client capability `general.positionEncodings`.
+	 *
+	 * If the client didn't provide any position encodings the only valid
+	 * value that a server can return is 'utf-16'.
+	 *
+	 * If omitted it defaults to 'utf-16'.
+	 *
+	 * @since 3.17.0
+	 */
+	PositionEncoding PositionEncodingKind `json:"positionEncoding,omitempty"`
 	/**
 	 * Defines how text documents are synced. Is either a detailed structure defining each notification or
 	 * for backwards compatibility the TextDocumentSyncKind number.
@@ -2629,6 +2774,12 @@ type InnerServerCapabilities struct {
 	 * @since 3.16.0
 	 */
 	MonikerProvider interface{}/* bool | MonikerOptions | MonikerRegistrationOptions*/ `json:"monikerProvider,omitempty"`
+	/**
+	 * The server has support for pull model diagnostics.
+	 *
+	 * @since 3.17.0
+	 */
+	DiagnosticProvider *DiagnosticOptions `json:"diagnosticProvider,omitempty"`
 	/**
 	 * Experimental server capabilities.
 	 */
@@ -3006,6 +3157,22 @@ type PrepareRenameParams struct {
 	TextDocumentPositionParams
 	WorkDoneProgressParams
 }
+/**
+ * A previous result id in a workspace pull request.
+ *
+ * @since 3.17.0
+ */
+type PreviousResultID struct {
+	/**
+	 * The URI for which the client knowns a
+	 * result id.
+	 */
+	URI DocumentURI `json:"uri"`
+	/**
+	 * The value of the previous result id.
+	 */
+	Value string `json:"value"`
+}
 
 type PrepareSupportDefaultBehavior = interface{}
 
@@ -4064,6 +4231,12 @@ type TextDocumentClientCapabilities struct {
 	 * @since 3.16.0
 	 */
 	Moniker MonikerClientCapabilities `json:"moniker,omitempty"`
+	/**
+	 * Capabilities specific to the diagnostic pull model.
+	 *
+	 * @since 3.17.0
+	 */
+	Diagnostic *DiagnosticClientCapabilities `json:"diagnostic,omitempty"`
 }
 
 /**
@@ -4298,6 +4471,26 @@ type Uinteger float64
  * @since 3.16.0
  */
 type UniquenessLevel string
+/**
+ * A diagnostic report indicating that the last returned
+ * report is still accurate.
+ *
+ * @since 3.17.0
+ */
+type UnchangedDocumentDiagnosticReport struct {
+	/**
+	 * A document diagnostic report indicating
+	 * no changes to the last result. A server can
+	 * only return `unchanged` if result ids are
+	 * provided.
+	 */
+	Kind DocumentDiagnosticReportKind `json:"kind"`
+	/**
+	 * A result id which will be sent on the next
+	 * diagnostic request for the same document.
+	 */
+	ResultID string `json:"resultId"`
+}
 
 /**
  * General parameters to unregister a request or notification.
@@ -4529,6 +4722,85 @@ type WorkspaceClientCapabilities struct {
 	 * Since 3.16.0
 	 */
 	FileOperations FileOperationClientCapabilities `json:"fileOperations,omitempty"`
+	/**
+	 * Capabilities specific to the diagnostic requests scoped to the
+	 * workspace.
+	 *
+	 * @since 3.17.0.
+	 */
+	Diagnostics DiagnosticWorkspaceClientCapabilities `json:"diagnostics,omitempty"`
+}
+/**
+ * Parameters of the workspace diagnostic request.
+ *
+ * @since 3.17.0
+ */
+type WorkspaceDiagnosticParams struct {
+	/**
+	 * The additional identifier provided during registration.
+	 */
+	Identifier string `json:"identifier,omitempty"`
+	/**
+	 * The currently known diagnostic reports with their
+	 * previous result ids.
+	 */
+	PreviousResultIds []PreviousResultID `json:"previousResultIds"`
+	WorkDoneProgressParams
+	PartialResultParams
+}
+
+/**
+ * A workspace diagnostic report.
+ *
+ * @since 3.17.0
+ */
+type WorkspaceDiagnosticReport struct {
+	Items []interface{} /*WorkspaceFullDocumentDiagnosticReport | WorkspaceUnchangedDocumentDiagnosticReport*/ `json:"items"`
+}
+
+/**
+ * A partial result for a workspace diagnostic report.
+ *
+ * @since 3.17.0
+ */
+type WorkspaceDiagnosticReportPartialResult struct {
+	Items []interface{} /*WorkspaceFullDocumentDiagnosticReport | WorkspaceUnchangedDocumentDiagnosticReport*/ `json:"items"`
+}
+
+/**
+ * A full document diagnostic report for a workspace diagnostic result.
+ *
+ * @since 3.17.0
+ */
+type WorkspaceFullDocumentDiagnosticReport struct {
+	FullDocumentDiagnosticReport
+	/**
+	 * The URI for which diagnostic information is reported.
+	 */
+	URI DocumentURI `json:"uri"`
+	/**
+	 * The version number for which the diagnostics are reported.
+	 * If the document is not marked as open `null` can be provided.
+	 */
+	Version *int32 `json:"version"`
+}
+
+/**
+ * An unchanged document diagnostic report for a workspace diagnostic result.
+ *
+ * @since 3.17.0
+ */
+type WorkspaceUnchangedDocumentDiagnosticReport struct {
+	UnchangedDocumentDiagnosticReport
+	/**
+	 * The URI for which diagnostic information is reported.
+	 */
+	URI DocumentURI `json:"uri"`
+	/**
+	 * The version number for which the diagnostics are reported.
+	 * If the document is not marked as open `null` can be provided.
+	 */
+	Version *int32 `json:"version"`
 }
 
 /**
@@ -4715,6 +4987,46 @@ type WorkspaceSymbolParams struct {
 	PartialResultParams
 }
 
+const (
+	/**
+	 * Character offsets count UTF-8 code units (e.g bytes).
+	 */
+
+	PositionEncodingUTF8 PositionEncodingKind = "utf-8"
+	/**
+	 * Character offsets count UTF-16 code units.
+	 *
+	 * This is the default and must always be supported
+	 * by servers
+	 */
+
+	PositionEncodingUTF16 PositionEncodingKind = "utf-16"
+	/**
+	 * Character offsets count UTF-32 code units.
+	 *
+	 * Implementation note: these are the same as Unicode code points,
+	 * so this `PositionEncodingKind` may also be used for an
+	 * encoding-agnostic representation of character offsets.
+	 */
+
+	PositionEncodingUTF32 PositionEncodingKind = "utf-32"
+)
+
+const (
+	/**
+	 * A diagnostic report with a full
+	 * set of problems.
+	 */
+
+	DiagnosticFull DocumentDiagnosticReportKind = "full"
+	/**
+	 * A report indicating that the last
+	 * returned report is still accurate.
+	 */
+
+	DiagnosticUnchanged DocumentDiagnosticReportKind = "unchanged"
+)
+
 const (
 	/**
 	 * Empty kind.
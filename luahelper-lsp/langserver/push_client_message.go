@@ -22,6 +22,12 @@ import (
 
 // sendDiagnostics 给客户端推送错误诊断消息
 func (l *LspServer)sendDiagnostics(ctx context.Context, diagnostics lsp.PublishDiagnosticsParams) {
+	// 客户端支持拉取诊断时，保存下来等客户端拉取
+	if l.pullDiagnosticFlag {
+		l.savePullDiagnostics(diagnostics)
+		return
+	}
+
 	err := l.server.Notify(ctx, "textDocument/publishDiagnostics", diagnostics)
 	if err != nil {
 		log.Debug("PushShowMessage error=%v", err)
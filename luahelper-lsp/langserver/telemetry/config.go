@@ -0,0 +1,31 @@
+package telemetry
+
+// Config 统计上报的配置，由客户端初始化参数 InitializationOptions 传入，默认关闭
+type Config struct {
+	// 是否开启统计上报，默认为false
+	Enable bool `json:"enable,omitempty"`
+
+	// 上报的地址，支持三种格式:
+	// udp://host:port        以udp的方式发送
+	// http://host/path       以http post的方式发送，也支持https
+	// file:///path/to/file   追加写入本地文件，每行一个json
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// 本地的json日志路径，不为空时每次上报的数据也追加写入这个文件，方便调试查看上报了什么内容
+	LogPath string `json:"logPath,omitempty"`
+
+	// 上报的间隔时间，单位为秒，小于等于0时采用默认值
+	Interval int `json:"interval,omitempty"`
+}
+
+// defaultInterval 默认的上报间隔，单位为秒
+const defaultInterval int = 120
+
+// getInterval 获取上报的间隔时间，单位为秒
+func (c *Config) getInterval() int {
+	if c.Interval <= 0 {
+		return defaultInterval
+	}
+
+	return c.Interval
+}
@@ -0,0 +1,63 @@
+package telemetry
+
+// ClientInfo 客户端的基本信息，字段名与之前中心服务器的上报格式保持一致
+type ClientInfo struct {
+	ClientType         string `json:"clientType"`         // 客户端类型
+	FileNumber         int    `json:"fileNumber"`         // 工程lua文件的数量
+	OsType             string `json:"osType"`             // 客户端操作系统类型
+	ClientVer          string `json:"clientVer"`          // 客户端的版本号，例如0.2.1
+	FirstReport        int    `json:"firstReport"`        // 客户端是否首次打开插件上报， 1为是
+	CostMsTime         int    `json:"costMsTime"`         // 初次加载的耗时时间毫秒
+	WorkspaceFolderNum int    `json:"workspaceFolderNum"` // 用户多文件夹数
+}
+
+// CheckTime 工程整体分析各阶段的耗时，单位为毫秒
+type CheckTime struct {
+	AllMs    int64 `json:"allMs"`    // 总的耗时
+	FirstMs  int64 `json:"firstMs"`  // 第一阶段耗时
+	SecondMs int64 `json:"secondMs"` // 第二阶段耗时
+	ThirdMs  int64 `json:"thirdMs"`  // 第三阶段耗时
+}
+
+// RequestStat 单个lsp请求在一个上报周期内的耗时统计，单位为毫秒
+type RequestStat struct {
+	Count   int     `json:"count"`   // 请求的次数
+	TotalMs float64 `json:"totalMs"` // 总的耗时
+	MaxMs   float64 `json:"maxMs"`   // 最大的耗时
+	AvgMs   float64 `json:"avgMs"`   // 平均的耗时
+}
+
+// CacheStat 缓存的命中统计
+type CacheStat struct {
+	Hit     uint64  `json:"hit"`     // 命中的次数
+	Miss    uint64  `json:"miss"`    // 未命中的次数
+	HitRate float64 `json:"hitRate"` // 命中率，0-1之间
+}
+
+// Report 一次上报的完整数据
+type Report struct {
+	ClientInfo
+	ReportTime int64                  `json:"reportTime"`         // 上报的时间戳，单位为秒
+	CheckTime  CheckTime              `json:"checkTime"`          // 最近一次工程整体分析的耗时
+	Requests   map[string]RequestStat `json:"requests,omitempty"` // 本上报周期内，各请求的耗时统计
+	Caches     map[string]CacheStat   `json:"caches,omitempty"`   // 各缓存的命中统计
+}
+
+// ReportReturn 对端的回包
+type ReportReturn struct {
+	Num int `json:"Num"` // 所有在线的人数
+}
+
+// CreateCacheStat 根据命中与未命中的次数，创建缓存统计
+func CreateCacheStat(hit, miss uint64) CacheStat {
+	stat := CacheStat{
+		Hit:  hit,
+		Miss: miss,
+	}
+
+	if hit+miss > 0 {
+		stat.HitRate = float64(hit) / float64(hit+miss)
+	}
+
+	return stat
+}
@@ -0,0 +1,329 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"luahelper-lsp/langserver/log"
+)
+
+// sinkGroup 一次Setup创建的所有发送端，每次上报时增加引用计数
+// Setup替换发送端后，等待正在进行的上报结束，再关闭旧的发送端
+type sinkGroup struct {
+	sinks []Sink
+	refs  sync.WaitGroup
+}
+
+// close 等待所有引用的上报结束后，关闭所有的发送端
+func (g *sinkGroup) close() {
+	g.refs.Wait()
+	for _, sink := range g.sinks {
+		sink.Close()
+	}
+}
+
+// Reporter 统计上报的管理对象，默认为关闭状态，需要调用Setup传入开启的配置
+type Reporter struct {
+	mu sync.Mutex
+
+	// 多次Setup之间互斥
+	setupMu sync.Mutex
+
+	// 是否开启
+	enable bool
+
+	// 上报的间隔时间，单位为秒
+	interval int
+
+	// 所有的发送端，包括上报地址和本地的json日志，没有开启时为nil
+	sinks *sinkGroup
+
+	// 客户端的基本信息
+	clientInfo ClientInfo
+
+	// 最近一次工程整体分析的耗时
+	checkTime CheckTime
+
+	// 本上报周期内，各请求的耗时统计，key为请求的方法名
+	requests map[string]*RequestStat
+
+	// 各缓存的命中统计，key为缓存的名称
+	caches map[string]CacheStat
+
+	// 每次上报前调用，用于刷新文件数量、缓存命中等需要实时获取的数据
+	collector func()
+
+	// 对端返回的在线人数
+	onlineNum int
+
+	// 停止上报协程的通知
+	stopChan chan struct{}
+}
+
+// CreateReporter 创建统计上报对象，默认不开启
+func CreateReporter(clientInfo ClientInfo) *Reporter {
+	clientInfo.FirstReport = 1
+	return &Reporter{
+		enable:     false,
+		interval:   defaultInterval,
+		clientInfo: clientInfo,
+		requests:   map[string]*RequestStat{},
+		caches:     map[string]CacheStat{},
+	}
+}
+
+// Setup 根据配置开启统计上报，conf为nil或是没有开启时，关闭统计上报
+func (r *Reporter) Setup(conf *Config) error {
+	r.setupMu.Lock()
+	defer r.setupMu.Unlock()
+
+	r.Stop()
+
+	r.mu.Lock()
+	oldSinks := r.sinks
+	r.sinks = nil
+	r.enable = false
+	r.mu.Unlock()
+
+	// 在锁外等待正在进行的上报结束，避免关闭后还在写入
+	if oldSinks != nil {
+		oldSinks.close()
+	}
+
+	if conf == nil || !conf.Enable {
+		return nil
+	}
+
+	newSinks := &sinkGroup{}
+	if conf.Endpoint != "" {
+		sink, err := CreateSink(conf.Endpoint)
+		if err != nil {
+			return err
+		}
+		newSinks.sinks = append(newSinks.sinks, sink)
+	}
+
+	if conf.LogPath != "" {
+		sink, err := CreateFileSink(conf.LogPath)
+		if err != nil {
+			newSinks.close()
+			return err
+		}
+		newSinks.sinks = append(newSinks.sinks, sink)
+	}
+
+	// 没有任何的发送端，无需开启
+	if len(newSinks.sinks) == 0 {
+		log.Debug("telemetry enable, but endpoint and logPath are empty")
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sinks = newSinks
+	r.interval = conf.getInterval()
+	r.enable = true
+	return nil
+}
+
+// IsEnable 是否开启了统计上报
+func (r *Reporter) IsEnable() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enable
+}
+
+// SetCollector 设置每次上报前调用的数据收集函数
+func (r *Reporter) SetCollector(collector func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collector = collector
+}
+
+// SetClientInfo 设置客户端的基本信息
+func (r *Reporter) SetClientInfo(clientType string, fileNumber int, costMsTime int, workspaceFolderNum int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clientInfo.ClientType = clientType
+	r.clientInfo.FileNumber = fileNumber
+	r.clientInfo.CostMsTime = costMsTime
+	r.clientInfo.WorkspaceFolderNum = workspaceFolderNum
+}
+
+// SetFileNumber 更新工程lua文件的数量
+func (r *Reporter) SetFileNumber(fileNumber int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clientInfo.FileNumber = fileNumber
+}
+
+// SetCheckTime 设置最近一次工程整体分析的耗时
+func (r *Reporter) SetCheckTime(checkTime CheckTime) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkTime = checkTime
+}
+
+// SetCacheStat 设置缓存的命中统计
+func (r *Reporter) SetCacheStat(name string, hit, miss uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.caches[name] = CreateCacheStat(hit, miss)
+}
+
+// RecordRequest 记录一次lsp请求的耗时，没有开启时直接忽略
+func (r *Reporter) RecordRequest(method string, cost time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.enable {
+		return
+	}
+
+	costMs := float64(cost) / float64(time.Millisecond)
+	stat, ok := r.requests[method]
+	if !ok {
+		stat = &RequestStat{}
+		r.requests[method] = stat
+	}
+
+	stat.Count++
+	stat.TotalMs += costMs
+	if costMs > stat.MaxMs {
+		stat.MaxMs = costMs
+	}
+}
+
+// GetOnlineNum 获取对端返回的在线人数，没有开启或是对端没有返回时为0
+func (r *Reporter) GetOnlineNum() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.onlineNum
+}
+
+// Start 启动上报的协程，没有开启时不做任何处理
+func (r *Reporter) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.enable || r.stopChan != nil {
+		return
+	}
+
+	stopChan := make(chan struct{})
+	r.stopChan = stopChan
+	interval := time.Duration(r.interval) * time.Second
+	go r.loop(stopChan, interval)
+}
+
+// Stop 停止上报的协程
+func (r *Reporter) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopChan != nil {
+		close(r.stopChan)
+		r.stopChan = nil
+	}
+}
+
+// loop 定时上报
+func (r *Reporter) loop(stopChan chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.Report()
+
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Report 立即上报一次数据
+func (r *Reporter) Report() {
+	r.mu.Lock()
+	collector := r.collector
+	r.mu.Unlock()
+
+	if collector != nil {
+		collector()
+	}
+
+	r.mu.Lock()
+	if !r.enable {
+		r.mu.Unlock()
+		return
+	}
+
+	data, err := json.Marshal(r.snapshot())
+
+	// 增加引用，Setup需要等待本次上报结束后才关闭这些发送端
+	sinks := r.sinks
+	sinks.refs.Add(1)
+	defer sinks.refs.Done()
+
+	// 请求的耗时按上报周期统计，上报后清空
+	r.requests = map[string]*RequestStat{}
+	r.clientInfo.FirstReport = 0
+	r.mu.Unlock()
+
+	if err != nil {
+		log.Error("telemetry marshal err=%s", err.Error())
+		return
+	}
+
+	for _, sink := range sinks.sinks {
+		reply, err := sink.Send(data)
+		if err != nil {
+			log.Error("telemetry send err=%s", err.Error())
+			continue
+		}
+
+		r.handleReply(reply)
+	}
+}
+
+// snapshot 生成当前的上报数据，调用方需要加锁
+func (r *Reporter) snapshot() *Report {
+	report := &Report{
+		ClientInfo: r.clientInfo,
+		ReportTime: time.Now().Unix(),
+		CheckTime:  r.checkTime,
+		Requests:   map[string]RequestStat{},
+		Caches:     map[string]CacheStat{},
+	}
+
+	for method, stat := range r.requests {
+		oneStat := *stat
+		if oneStat.Count > 0 {
+			oneStat.AvgMs = oneStat.TotalMs / float64(oneStat.Count)
+		}
+		report.Requests[method] = oneStat
+	}
+
+	for name, stat := range r.caches {
+		report.Caches[name] = stat
+	}
+
+	return report
+}
+
+// handleReply 处理对端的回包，获取在线人数
+func (r *Reporter) handleReply(reply []byte) {
+	if len(reply) == 0 {
+		return
+	}
+
+	var reportReturn ReportReturn
+	if err := json.Unmarshal(reply, &reportReturn); err != nil {
+		log.Debug("telemetry handleReply error:%s", err.Error())
+		return
+	}
+
+	log.Debug("telemetry handleReply ok, num=%d", reportReturn.Num)
+	r.mu.Lock()
+	r.onlineNum = reportReturn.Num
+	r.mu.Unlock()
+}
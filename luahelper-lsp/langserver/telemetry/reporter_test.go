@@ -0,0 +1,202 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// readFileReports 读取本地json日志中的所有上报数据
+func readFileReports(t *testing.T, logPath string) []Report {
+	data, err := ioutil.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("read log file:%s err=%s", logPath, err.Error())
+	}
+
+	reportVec := []Report{}
+	for _, strLine := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var oneReport Report
+		if err := json.Unmarshal([]byte(strLine), &oneReport); err != nil {
+			t.Fatalf("unmarshal report:%s err=%s", strLine, err.Error())
+		}
+		reportVec = append(reportVec, oneReport)
+	}
+	return reportVec
+}
+
+func TestReporterDisableDefault(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "telemetry.log")
+	reporter := CreateReporter(ClientInfo{ClientType: "vsc"})
+	if reporter.IsEnable() {
+		t.Fatalf("reporter should be disabled by default")
+	}
+
+	// 没有开启时，配置的日志路径也不写入
+	if err := reporter.Setup(&Config{Enable: false, LogPath: logPath}); err != nil {
+		t.Fatalf("setup err=%s", err.Error())
+	}
+	reporter.RecordRequest("textDocument/hover", time.Millisecond)
+	reporter.Start()
+	reporter.Report()
+	reporter.Stop()
+
+	if reporter.IsEnable() {
+		t.Fatalf("reporter should be disabled when enable is false")
+	}
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Fatalf("log file should not be created when disabled")
+	}
+	if len(reporter.requests) != 0 {
+		t.Fatalf("requests should not be recorded when disabled, requests=%v", reporter.requests)
+	}
+}
+
+func TestReporterFileSink(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "log", "telemetry.log")
+	reporter := CreateReporter(ClientInfo{ClientType: "vsc"})
+	if err := reporter.Setup(&Config{Enable: true, LogPath: logPath}); err != nil {
+		t.Fatalf("setup err=%s", err.Error())
+	}
+	defer reporter.Setup(nil)
+
+	reporter.RecordRequest("textDocument/hover", 2*time.Millisecond)
+	reporter.RecordRequest("textDocument/hover", 4*time.Millisecond)
+	reporter.SetCacheStat("fileLRU", 3, 1)
+	reporter.Report()
+
+	// 请求的耗时按上报周期统计，第二次上报时已经清空
+	reporter.Report()
+
+	reportVec := readFileReports(t, logPath)
+	if len(reportVec) != 2 {
+		t.Fatalf("report num error, reports=%v", reportVec)
+	}
+
+	firstReport := reportVec[0]
+	hoverStat := firstReport.Requests["textDocument/hover"]
+	if firstReport.ClientType != "vsc" || firstReport.FirstReport != 1 || hoverStat.Count != 2 ||
+		hoverStat.MaxMs != 4 || hoverStat.AvgMs != 3 || firstReport.Caches["fileLRU"].HitRate != 0.75 {
+		t.Fatalf("first report error, report=%v", firstReport)
+	}
+
+	secondReport := reportVec[1]
+	if secondReport.FirstReport != 0 || len(secondReport.Requests) != 0 || secondReport.Caches["fileLRU"].Hit != 3 {
+		t.Fatalf("second report error, report=%v", secondReport)
+	}
+}
+
+func TestReporterHTTPSink(t *testing.T) {
+	var bodyVec [][]byte
+	var bodyMutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		bodyMutex.Lock()
+		bodyVec = append(bodyVec, body)
+		bodyMutex.Unlock()
+		w.Write([]byte(`{"Num":5}`))
+	}))
+	defer server.Close()
+
+	reporter := CreateReporter(ClientInfo{ClientType: "vsc"})
+	if err := reporter.Setup(&Config{Enable: true, Endpoint: server.URL + "/report"}); err != nil {
+		t.Fatalf("setup err=%s", err.Error())
+	}
+	defer reporter.Setup(nil)
+
+	reporter.RecordRequest("textDocument/definition", time.Millisecond)
+	reporter.Report()
+
+	bodyMutex.Lock()
+	defer bodyMutex.Unlock()
+	if len(bodyVec) != 1 {
+		t.Fatalf("http report num error, num=%d", len(bodyVec))
+	}
+
+	var oneReport Report
+	if err := json.Unmarshal(bodyVec[0], &oneReport); err != nil {
+		t.Fatalf("unmarshal report err=%s", err.Error())
+	}
+	if oneReport.Requests["textDocument/definition"].Count != 1 {
+		t.Fatalf("http report error, report=%v", oneReport)
+	}
+	if reporter.GetOnlineNum() != 5 {
+		t.Fatalf("online num error, num=%d", reporter.GetOnlineNum())
+	}
+}
+
+// blockSink 发送时阻塞，直到收到继续的通知，用于模拟上报过程中重新Setup
+type blockSink struct {
+	sendBegin chan struct{}
+	sendGoOn  chan struct{}
+
+	mu          sync.Mutex
+	closed      bool
+	sendOnClose bool
+}
+
+func (s *blockSink) Send(data []byte) ([]byte, error) {
+	close(s.sendBegin)
+	<-s.sendGoOn
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		s.sendOnClose = true
+	}
+	return nil, nil
+}
+
+func (s *blockSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func TestReporterSetupWaitReport(t *testing.T) {
+	sink := &blockSink{
+		sendBegin: make(chan struct{}),
+		sendGoOn:  make(chan struct{}),
+	}
+	reporter := CreateReporter(ClientInfo{})
+	reporter.sinks = &sinkGroup{sinks: []Sink{sink}}
+	reporter.enable = true
+
+	reportDone := make(chan struct{})
+	go func() {
+		reporter.Report()
+		close(reportDone)
+	}()
+	<-sink.sendBegin
+
+	// 上报过程中关闭统计，需要等待上报结束后再关闭发送端
+	setupDone := make(chan struct{})
+	go func() {
+		reporter.Setup(nil)
+		close(setupDone)
+	}()
+
+	select {
+	case <-setupDone:
+		t.Fatalf("setup should wait for the report in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(sink.sendGoOn)
+	<-reportDone
+	<-setupDone
+
+	if !sink.closed || sink.sendOnClose {
+		t.Fatalf("sink should be closed after the report, closed=%v, sendOnClose=%v", sink.closed, sink.sendOnClose)
+	}
+	if reporter.IsEnable() {
+		t.Fatalf("reporter should be disabled after setup nil")
+	}
+}
@@ -0,0 +1,183 @@
+package telemetry
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink 上报数据的发送端，不同的上报地址对应不同的实现
+type Sink interface {
+	// Send 发送一次上报的数据，reply为对端的回包，没有回包时为nil
+	Send(data []byte) (reply []byte, err error)
+
+	// Close 关闭发送端
+	Close() error
+}
+
+// 等待对端回包的超时时间
+const replyTimeout = 5 * time.Second
+
+// CreateSink 根据上报地址创建对应的发送端
+func CreateSink(endpoint string) (Sink, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "udp":
+		if u.Host == "" {
+			return nil, errors.New("telemetry udp endpoint not set host: " + endpoint)
+		}
+		return createUDPSink(u.Host)
+	case "http", "https":
+		return createHTTPSink(endpoint), nil
+	case "file":
+		path := u.Path
+		if u.Host != "" {
+			// 兼容 file://relative/path 的写法
+			path = u.Host + u.Path
+		}
+		// windows下 file:///c:/xxx 解析出来为 /c:/xxx，去掉前面的/
+		if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+			path = path[1:]
+		}
+		return CreateFileSink(path)
+	}
+
+	return nil, errors.New("telemetry endpoint scheme not support: " + endpoint)
+}
+
+// udpSink 以udp的方式发送，发送后等待对端的回包
+type udpSink struct {
+	conn net.Conn
+	mu   sync.Mutex
+}
+
+func createUDPSink(addr string) (*udpSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &udpSink{
+		conn: conn,
+	}, nil
+}
+
+// Send 发送数据，并尝试读取一次回包
+func (s *udpSink) Send(data []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.conn.Write(data); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 2048)
+	s.conn.SetReadDeadline(time.Now().Add(replyTimeout))
+	n, err := s.conn.Read(buf)
+	if err != nil || n == 0 {
+		// 没有回包不算错误，udp本身就不可靠
+		return nil, nil
+	}
+
+	return buf[0:n], nil
+}
+
+// Close 关闭连接
+func (s *udpSink) Close() error {
+	return s.conn.Close()
+}
+
+// httpSink 以http post的方式发送json数据
+type httpSink struct {
+	endpoint string
+	client   *http.Client
+}
+
+func createHTTPSink(endpoint string) *httpSink {
+	return &httpSink{
+		endpoint: endpoint,
+		client: &http.Client{
+			Timeout: replyTimeout,
+		},
+	}
+}
+
+// Send post数据，返回回包的body
+func (s *httpSink) Send(data []byte) ([]byte, error) {
+	resp, err := s.client.Post(s.endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.New("telemetry http status: " + resp.Status)
+	}
+
+	return body, nil
+}
+
+// Close http无需关闭
+func (s *httpSink) Close() error {
+	return nil
+}
+
+// fileSink 追加写入本地文件，每行为一个json
+type fileSink struct {
+	file *os.File
+	mu   sync.Mutex
+}
+
+// CreateFileSink 创建写入本地文件的发送端，也用于本地的json调试日志
+func CreateFileSink(path string) (Sink, error) {
+	if path == "" {
+		return nil, errors.New("telemetry file path is empty")
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		os.MkdirAll(dir, 0755)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileSink{
+		file: file,
+	}, nil
+}
+
+// Send 写入一行数据
+func (s *fileSink) Send(data []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line := make([]byte, 0, len(data)+1)
+	line = append(line, data...)
+	line = append(line, '\n')
+	_, err := s.file.Write(line)
+	return nil, err
+}
+
+// Close 关闭文件
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
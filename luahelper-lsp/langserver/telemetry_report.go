@@ -0,0 +1,110 @@
+package langserver
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/telemetry"
+
+	"github.com/yinfei8/jrpc2"
+	"github.com/yinfei8/jrpc2/handler"
+)
+
+// createReporter 创建统计上报的对象，默认关闭，初始化时根据客户端的配置开启
+func createReporter() *telemetry.Reporter {
+	return telemetry.CreateReporter(telemetry.ClientInfo{
+		ClientType: "vsc",
+		FileNumber: 0,
+		OsType:     runtime.GOOS,
+		ClientVer:  clientVerStr,
+	})
+}
+
+// setupTelemetry 根据客户端的配置开启统计上报，默认关闭
+func (l *LspServer) setupTelemetry(conf *telemetry.Config) {
+	if err := l.reporter.Setup(conf); err != nil {
+		log.Error("setup telemetry err=%s", err.Error())
+		return
+	}
+
+	if !l.reporter.IsEnable() {
+		log.Debug("telemetry is disable")
+		return
+	}
+
+	l.reporter.SetCollector(l.collectReportData)
+	l.reporter.Start()
+}
+
+// collectReportData 每次上报前，收集需要实时获取的数据
+func (l *LspServer) collectReportData() {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	project := l.getAllProject()
+	if project == nil {
+		return
+	}
+
+	l.reporter.SetFileNumber(project.GetAllFileNumber())
+
+	hitNum, missNum := project.GetFileCacheStat()
+	l.reporter.SetCacheStat("fileLRU", hitNum, missNum)
+}
+
+// SetOnlineReportParam 连接初始化时，同步客户端的类型，以及这个工程的所包含的lua文件数量
+func (l *LspServer) SetOnlineReportParam(clientType string, fileNumber int, costMsTime int, workspaceFolderNum int) {
+	l.reporter.SetClientInfo(clientType, fileNumber, costMsTime, workspaceFolderNum)
+}
+
+// SetLuaFileNumber 更新工程lua文件的数量
+func (l *LspServer) SetLuaFileNumber(fileNumber int) {
+	l.reporter.SetFileNumber(fileNumber)
+}
+
+// setReportCheckTime 同步最近一次工程整体分析的耗时
+func (l *LspServer) setReportCheckTime() {
+	project := l.getAllProject()
+	if project == nil {
+		return
+	}
+
+	checkTime := project.GetCheckTimeInfo()
+	l.reporter.SetCheckTime(telemetry.CheckTime{
+		AllMs:    checkTime.All,
+		FirstMs:  checkTime.First,
+		SecondMs: checkTime.Second,
+		ThirdMs:  checkTime.Third,
+	})
+}
+
+// timeHandler 统计每个请求的耗时
+type timeHandler struct {
+	method   string
+	handler  jrpc2.Handler
+	reporter *telemetry.Reporter
+}
+
+// Handle 调用实际的处理函数，并记录耗时
+func (t timeHandler) Handle(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+	timeBegin := time.Now()
+	result, err := t.handler.Handle(ctx, req)
+	t.reporter.RecordRequest(t.method, time.Since(timeBegin))
+	return result, err
+}
+
+// wrapTimeHandler 对所有的请求处理函数封装一层耗时统计
+func wrapTimeHandler(handlerMap handler.Map, reporter *telemetry.Reporter) handler.Map {
+	wrapMap := handler.Map{}
+	for method, oneHandler := range handlerMap {
+		wrapMap[method] = timeHandler{
+			method:   method,
+			handler:  oneHandler,
+			reporter: reporter,
+		}
+	}
+
+	return wrapMap
+}
@@ -0,0 +1,362 @@
+package langserver
+
+import (
+	"context"
+	"fmt"
+	"luahelper-lsp/langserver/check"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/check/compiler/lexer"
+	lsp "luahelper-lsp/langserver/protocol"
+	"strings"
+)
+
+// codeActionGenerateComments 为文件中所有没有注解的函数生成注解
+const codeActionGenerateComments lsp.CodeActionKind = "source.generateComments"
+
+// codeActionGenerateClass 为光标所在行定义的table，由其成员生成class注解
+const codeActionGenerateClass lsp.CodeActionKind = "refactor.rewrite.generateClass"
+
+// codeActionExtractLocal 选中的表达式提取为局部变量
+const codeActionExtractLocal lsp.CodeActionKind = "refactor.extract.local"
+
+// codeActionExtractFunction 选中的语句提取为局部函数
+const codeActionExtractFunction lsp.CodeActionKind = "refactor.extract.function"
+
+// TextDocumentCodeAction 代码操作请求
+func (l *LspServer) TextDocumentCodeAction(ctx context.Context, vs lsp.CodeActionParams) (actions []lsp.CodeAction, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	comResult := l.beginFileRequest(vs.TextDocument.URI, vs.Range.Start)
+	if !comResult.result {
+		return
+	}
+
+	if isCodeActionKindWanted(vs.Context.Only, codeActionGenerateComments) {
+		if action, ok := l.getGenerateCommentsAction(vs.TextDocument.URI, comResult); ok {
+			actions = append(actions, action)
+		}
+	}
+
+	if isCodeActionKindWanted(vs.Context.Only, codeActionGenerateClass) {
+		if action, ok := l.getGenerateClassAction(vs.TextDocument.URI, comResult); ok {
+			actions = append(actions, action)
+		}
+	}
+
+	// 有选中的内容时，才提示提取
+	if vs.Range.Start != vs.Range.End {
+		selLoc := comResult.converter.RangeToLoc(vs.Range)
+
+		if isCodeActionKindWanted(vs.Context.Only, codeActionExtractLocal) {
+			if action, ok := l.getExtractLocalAction(vs.TextDocument.URI, comResult, selLoc); ok {
+				actions = append(actions, action)
+			}
+		}
+
+		if isCodeActionKindWanted(vs.Context.Only, codeActionExtractFunction) {
+			if action, ok := l.getExtractFunctionAction(vs.TextDocument.URI, comResult, selLoc); ok {
+				actions = append(actions, action)
+			}
+		}
+	}
+
+	if isCodeActionKindWanted(vs.Context.Only, lsp.QuickFix) {
+		for _, diagnostic := range vs.Context.Diagnostics {
+			if action, ok := l.getAddLocalAction(vs.TextDocument.URI, comResult, diagnostic); ok {
+				actions = append(actions, action)
+			}
+		}
+	}
+
+	return
+}
+
+// isCodeActionKindWanted 判断客户端是否请求了这种类型的代码操作，only为空表示请求所有的
+func isCodeActionKindWanted(onlyVec []lsp.CodeActionKind, kind lsp.CodeActionKind) bool {
+	if len(onlyVec) == 0 {
+		return true
+	}
+
+	for _, onlyKind := range onlyVec {
+		if onlyKind == kind || strings.HasPrefix(string(kind), string(onlyKind)+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getLineIndent 获取某一行开头的缩进
+func getLineIndent(contents []byte, line int) string {
+	lines := strings.Split(string(contents), "\n")
+	if line < 0 || line >= len(lines) {
+		return ""
+	}
+
+	strLine := lines[line]
+	return strLine[:len(strLine)-len(strings.TrimLeft(strLine, " \t"))]
+}
+
+// getFuncCommentText 生成插入的注解文本
+func getFuncCommentText(commentInfo check.FuncCommentInfo, strIndent string) (strText string) {
+	for _, oneParam := range commentInfo.ParamVec {
+		strText = strText + strIndent + "---@param " + oneParam.Name + " " + oneParam.TypeStr + "\n"
+	}
+
+	for _, strReturn := range commentInfo.ReturnVec {
+		strText = strText + strIndent + "---@return " + strReturn + "\n"
+	}
+
+	return strText
+}
+
+// getGenerateCommentsAction 生成文件中所有没有注解的函数的注解
+func (l *LspServer) getGenerateCommentsAction(uri lsp.DocumentURI, comResult commFileRequest) (action lsp.CodeAction, ok bool) {
+	project := l.getAllProject()
+	commentVec := project.GetFileFuncComments(comResult.strFile)
+	if len(commentVec) == 0 {
+		return
+	}
+
+	textEdits := []lsp.TextEdit{}
+	for _, oneComment := range commentVec {
+		line := oneComment.Loc.StartLine - 1
+		pos := lsp.Position{
+			Line:      uint32(line),
+			Character: 0,
+		}
+
+		textEdits = append(textEdits, lsp.TextEdit{
+			Range: lsp.Range{
+				Start: pos,
+				End:   pos,
+			},
+			NewText: getFuncCommentText(oneComment.Comment, getLineIndent(comResult.contents, line)),
+		})
+	}
+
+	action = lsp.CodeAction{
+		Title: "Generate annotations for all functions",
+		Kind:  codeActionGenerateComments,
+		Edit: lsp.WorkspaceEdit{
+			Changes: map[string][]lsp.TextEdit{
+				string(uri): textEdits,
+			},
+		},
+	}
+	return action, true
+}
+
+// getClassAnnotateText 生成插入的class注解文本
+func getClassAnnotateText(classInfo check.ClassAnnotateInfo, strIndent string) string {
+	strText := strIndent + "---@class " + classInfo.ClassName
+	if classInfo.ParentName != "" {
+		strText = strText + " : " + classInfo.ParentName
+	}
+	strText = strText + "\n"
+
+	for _, oneField := range classInfo.FieldVec {
+		strText = strText + strIndent + "---@field " + oneField.Name + " " + oneField.TypeStr + "\n"
+	}
+
+	return strText
+}
+
+// getGenerateClassAction 光标在没有注解的table定义上时，由table的成员生成class注解
+func (l *LspServer) getGenerateClassAction(uri lsp.DocumentURI, comResult commFileRequest) (action lsp.CodeAction, ok bool) {
+	project := l.getAllProject()
+	classInfo, ok := project.GetClassAnnotate(comResult.strFile, int(comResult.pos.Line)+1)
+	if !ok {
+		return
+	}
+
+	line := classInfo.Loc.StartLine - 1
+	pos := lsp.Position{
+		Line:      uint32(line),
+		Character: 0,
+	}
+
+	action = lsp.CodeAction{
+		Title: "Generate class annotation",
+		Kind:  codeActionGenerateClass,
+		Edit: lsp.WorkspaceEdit{
+			Changes: map[string][]lsp.TextEdit{
+				string(uri): {
+					{
+						Range: lsp.Range{
+							Start: pos,
+							End:   pos,
+						},
+						NewText: getClassAnnotateText(classInfo, getLineIndent(comResult.contents, line)),
+					},
+				},
+			},
+		},
+	}
+	return action, true
+}
+
+// getExtractLocalAction 选中的表达式提取为局部变量，定义插入到包含表达式的语句前面
+func (l *LspServer) getExtractLocalAction(uri lsp.DocumentURI, comResult commFileRequest,
+	selLoc lexer.Location) (action lsp.CodeAction, ok bool) {
+	project := l.getAllProject()
+	extractInfo, ok := project.GetExtractLocal(comResult.strFile, comResult.contents, selLoc)
+	if !ok {
+		return
+	}
+
+	insertLine := extractInfo.InsertLoc.StartLine - 1
+	insertPos := lsp.Position{
+		Line:      uint32(insertLine),
+		Character: comResult.converter.ColToCharacter(insertLine, extractInfo.InsertLoc.StartColumn),
+	}
+	strIndent := getLineIndent(comResult.contents, insertLine)
+
+	action = lsp.CodeAction{
+		Title: "Extract to local variable",
+		Kind:  codeActionExtractLocal,
+		Edit: lsp.WorkspaceEdit{
+			Changes: map[string][]lsp.TextEdit{
+				string(uri): {
+					{
+						Range: lsp.Range{
+							Start: insertPos,
+							End:   insertPos,
+						},
+						NewText: "local " + extractInfo.Name + " = " + extractInfo.Text + "\n" + strIndent,
+					},
+					{
+						Range:   comResult.converter.LocToRange(&extractInfo.Loc),
+						NewText: extractInfo.Name,
+					},
+				},
+			},
+		},
+	}
+	return action, true
+}
+
+// getExtractFuncText 生成提取的局部函数的定义以及调用的文本
+func getExtractFuncText(extractInfo check.ExtractFuncInfo, strIndent string) string {
+	strUnit := "    "
+	if strings.Contains(strIndent, "\t") {
+		strUnit = "\t"
+	}
+
+	paramVec := extractInfo.ParamVec
+	if extractInfo.IsVararg {
+		paramVec = append(paramVec[:len(paramVec):len(paramVec)], "...")
+	}
+	strParams := strings.Join(paramVec, ", ")
+
+	// 1) 函数的定义，选中的语句缩进一层作为函数体
+	strText := "local function " + extractInfo.Name + "(" + strParams + ")\n"
+	for i, strLine := range strings.Split(extractInfo.Text, "\n") {
+		if i == 0 {
+			strLine = strIndent + strLine
+		}
+		if strings.TrimSpace(strLine) == "" {
+			strText = strText + "\n"
+			continue
+		}
+		strText = strText + strUnit + strLine + "\n"
+	}
+	if len(extractInfo.ReturnVec) > 0 {
+		strText = strText + strIndent + strUnit + "return " + strings.Join(extractInfo.ReturnVec, ", ") + "\n"
+	}
+	strText = strText + strIndent + "end\n" + strIndent
+
+	// 2) 函数的调用，返回值中选中的语句里面定义的局部变量，需要重新定义
+	strCall := extractInfo.Name + "(" + strParams + ")"
+	if len(extractInfo.ReturnVec) == 0 {
+		return strText + strCall
+	}
+
+	strReturns := strings.Join(extractInfo.ReturnVec, ", ")
+	if len(extractInfo.LocalVec) == len(extractInfo.ReturnVec) {
+		return strText + "local " + strReturns + " = " + strCall
+	}
+	if len(extractInfo.LocalVec) > 0 {
+		strText = strText + "local " + strings.Join(extractInfo.LocalVec, ", ") + "\n" + strIndent
+	}
+	return strText + strReturns + " = " + strCall
+}
+
+// getExtractFunctionAction 选中的语句提取为局部函数，包含跳出选中范围的break、goto、return时不能提取
+func (l *LspServer) getExtractFunctionAction(uri lsp.DocumentURI, comResult commFileRequest,
+	selLoc lexer.Location) (action lsp.CodeAction, ok bool) {
+	project := l.getAllProject()
+	extractInfo, ok := project.GetExtractFunc(comResult.strFile, comResult.contents, selLoc)
+	if !ok {
+		return
+	}
+
+	action = lsp.CodeAction{
+		Title: "Extract to local function",
+		Kind:  codeActionExtractFunction,
+	}
+	if extractInfo.ErrStr != "" {
+		action.Disabled = &struct {
+			Reason string `json:"reason"`
+		}{
+			Reason: extractInfo.ErrStr,
+		}
+		return action, true
+	}
+
+	strIndent := getLineIndent(comResult.contents, extractInfo.Loc.StartLine-1)
+	action.Edit = lsp.WorkspaceEdit{
+		Changes: map[string][]lsp.TextEdit{
+			string(uri): {
+				{
+					Range:   comResult.converter.LocToRange(&extractInfo.Loc),
+					NewText: getExtractFuncText(extractInfo, strIndent),
+				},
+			},
+		},
+	}
+	return action, true
+}
+
+// getAddLocalAction 函数内意外定义的全局变量的快速修复，在第一次赋值的语句前面添加local
+func (l *LspServer) getAddLocalAction(uri lsp.DocumentURI, comResult commFileRequest,
+	diagnostic lsp.Diagnostic) (action lsp.CodeAction, ok bool) {
+	// 客户端传回的code经过json解析后为数字，统一按字符串比较
+	if fmt.Sprint(diagnostic.Code) != fmt.Sprint(int(common.CheckErrorAccidentalGlobal)) {
+		return
+	}
+
+	project := l.getAllProject()
+	insertLoc, ok := project.GetAddLocalLoc(comResult.strFile, comResult.converter.RangeToLoc(diagnostic.Range))
+	if !ok {
+		return
+	}
+
+	insertLine := insertLoc.StartLine - 1
+	insertPos := lsp.Position{
+		Line:      uint32(insertLine),
+		Character: comResult.converter.ColToCharacter(insertLine, insertLoc.StartColumn),
+	}
+
+	action = lsp.CodeAction{
+		Title:       "Add local",
+		Kind:        lsp.QuickFix,
+		Diagnostics: []lsp.Diagnostic{diagnostic},
+		IsPreferred: true,
+		Edit: lsp.WorkspaceEdit{
+			Changes: map[string][]lsp.TextEdit{
+				string(uri): {
+					{
+						Range: lsp.Range{
+							Start: insertPos,
+							End:   insertPos,
+						},
+						NewText: "local ",
+					},
+				},
+			},
+		},
+	}
+	return action, true
+}
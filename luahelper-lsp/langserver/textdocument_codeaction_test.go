@@ -0,0 +1,259 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGenerateComments(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/funccomment")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "test.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	actionParams := lsp.CodeActionParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI(fileName),
+		},
+	}
+	actions, err := lspServer.TextDocumentCodeAction(context, actionParams)
+	if err != nil || len(actions) != 1 {
+		t.Fatalf("code action error, actions=%v", actions)
+	}
+
+	textEdits := actions[0].Edit.Changes[fileName]
+	resultMap := map[uint32]string{}
+	for _, oneEdit := range textEdits {
+		resultMap[oneEdit.Range.Start.Line] = oneEdit.NewText
+	}
+
+	expectMap := map[uint32]string{
+		6:  "---@param a string|table\n---@param b function\n---@return any\n",
+		17: "---@param x string\n---@param y number\n---@param z table\n---@return boolean\n",
+		28: "---@param p string\n---@param q string\n---@param r table\n---@param s number\n" +
+			"---@return string|number\n---@return boolean|nil\n",
+		38: "---@param v any\n---@return any\n",
+		39: "    ---@param v table\n    ---@return any\n",
+	}
+	for line, strExpect := range expectMap {
+		if resultMap[line] != strExpect {
+			t.Fatalf("line %d comment error, expect:\n%s\nget:\n%s", line, strExpect, resultMap[line])
+		}
+	}
+	if len(resultMap) != len(expectMap) {
+		t.Fatalf("annotated functions should be skipped, edits=%v", resultMap)
+	}
+
+	// 函数前输入---时，补全生成的注释
+	project := lspServer.getAllProject()
+	completeVecs := project.FuncCommentComplete(fileName, 5)
+	if len(completeVecs) != 1 || !strings.Contains(completeVecs[0].InsetText, "---@param a ${2:string|table}") ||
+		!strings.Contains(completeVecs[0].InsetText, "---@return ${4:any}") {
+		t.Fatalf("func comment complete error, complete=%v", completeVecs)
+	}
+}
+
+func TestGenerateClassAnnotate(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/classannotate")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "test.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	getClassText := func(line uint32) string {
+		actionParams := lsp.CodeActionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Range: lsp.Range{
+				Start: lsp.Position{Line: line},
+				End:   lsp.Position{Line: line},
+			},
+			Context: lsp.CodeActionContext{
+				Only: []lsp.CodeActionKind{"refactor.rewrite"},
+			},
+		}
+		actions, err := lspServer.TextDocumentCodeAction(context, actionParams)
+		if err != nil || len(actions) > 1 {
+			t.Fatalf("code action error, actions=%v", actions)
+		}
+		if len(actions) == 0 {
+			return ""
+		}
+
+		textEdits := actions[0].Edit.Changes[fileName]
+		if len(textEdits) != 1 || textEdits[0].Range.Start.Line != line {
+			t.Fatalf("class annotate edit error, edits=%v", textEdits)
+		}
+		return textEdits[0].NewText
+	}
+
+	expectMap := map[uint32]string{
+		2: "---@class M : Base\n---@field count number\n---@field name string\n---@field list table\n" +
+			"---@field add fun(self:M, num:number):boolean\n---@field create fun(name:any):any\n",
+		16: "---@class Player : M\n---@field level number\n---@field enabled boolean\n",
+		3:  "",
+		23: "",
+	}
+	for line, strExpect := range expectMap {
+		if strText := getClassText(line); strText != strExpect {
+			t.Fatalf("line %d class annotate error, expect:\n%s\nget:\n%s", line, strExpect, strText)
+		}
+	}
+}
+
+func TestExtractRefactor(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/extract")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "test.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	getExtractAction := func(kind lsp.CodeActionKind, startLine, startCh, endLine, endCh uint32) *lsp.CodeAction {
+		actionParams := lsp.CodeActionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Range: lsp.Range{
+				Start: lsp.Position{Line: startLine, Character: startCh},
+				End:   lsp.Position{Line: endLine, Character: endCh},
+			},
+			Context: lsp.CodeActionContext{
+				Only: []lsp.CodeActionKind{kind},
+			},
+		}
+		actions, err := lspServer.TextDocumentCodeAction(context, actionParams)
+		if err != nil || len(actions) > 1 {
+			t.Fatalf("code action error, actions=%v", actions)
+		}
+		if len(actions) == 0 {
+			return nil
+		}
+		return &actions[0]
+	}
+
+	// 1) 表达式提取为局部变量
+	action := getExtractAction(codeActionExtractLocal, 4, 24, 4, 29)
+	if action == nil {
+		t.Fatalf("extract local failed")
+	}
+	textEdits := action.Edit.Changes[fileName]
+	if len(textEdits) != 2 || textEdits[0].NewText != "local newLocal = v * 2\n        " ||
+		textEdits[0].Range.Start.Line != 4 || textEdits[0].Range.Start.Character != 8 ||
+		textEdits[1].NewText != "newLocal" || textEdits[1].Range.Start.Character != 24 {
+		t.Fatalf("extract local edits error, edits=%v", textEdits)
+	}
+
+	// return中的表达式，定义插入到return的前面
+	action = getExtractAction(codeActionExtractLocal, 8, 11, 8, 24)
+	if action == nil || action.Edit.Changes[fileName][0].Range.Start.Character != 4 {
+		t.Fatalf("extract return local failed, action=%v", action)
+	}
+
+	// 赋值语句的左边，while的条件不能提取
+	if getExtractAction(codeActionExtractLocal, 4, 8, 4, 13) != nil ||
+		getExtractAction(codeActionExtractLocal, 20, 10, 20, 19) != nil {
+		t.Fatalf("extract invalid local")
+	}
+
+	// 2) 语句提取为局部函数，外部的局部变量作为参数，后面用到的作为返回值
+	action = getExtractAction(codeActionExtractFunction, 2, 0, 7, 0)
+	if action == nil || action.Disabled != nil {
+		t.Fatalf("extract function failed, action=%v", action)
+	}
+	strExpect := "local function newFunction(list, total)\n" +
+		"        local count = 0\n" +
+		"        for _, v in ipairs(list) do\n" +
+		"            total = total + v * 2\n" +
+		"            count = count + 1\n" +
+		"        end\n" +
+		"        return total, count\n" +
+		"    end\n" +
+		"    local count\n" +
+		"    total, count = newFunction(list, total)"
+	textEdits = action.Edit.Changes[fileName]
+	if len(textEdits) != 1 || textEdits[0].NewText != strExpect || textEdits[0].Range.Start.Line != 2 ||
+		textEdits[0].Range.End.Line != 6 {
+		t.Fatalf("extract function edits error, expect:\n%s\nget:\n%s", strExpect, textEdits[0].NewText)
+	}
+
+	// 用到了...，透传给新函数
+	action = getExtractAction(codeActionExtractFunction, 7, 4, 7, 28)
+	if action == nil || !strings.Contains(action.Edit.Changes[fileName][0].NewText, "newFunction(total, count, ...)") {
+		t.Fatalf("extract vararg function failed, action=%v", action)
+	}
+
+	// 3) 包含跳出选中范围的return、break时不能提取
+	for _, oneCase := range [][4]uint32{{13, 0, 16, 0}, {16, 0, 19, 0}, {8, 0, 9, 0}} {
+		action = getExtractAction(codeActionExtractFunction, oneCase[0], oneCase[1], oneCase[2], oneCase[3])
+		if action == nil || action.Disabled == nil {
+			t.Fatalf("extract function should be disabled, case=%v, action=%v", oneCase, action)
+		}
+	}
+
+	// 选中了完整的循环，break不会跳出选中范围
+	action = getExtractAction(codeActionExtractFunction, 20, 0, 23, 0)
+	if action == nil || action.Disabled != nil {
+		t.Fatalf("extract loop function failed, action=%v", action)
+	}
+}
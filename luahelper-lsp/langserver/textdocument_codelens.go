@@ -0,0 +1,150 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"luahelper-lsp/langserver/check"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/pathpre"
+	lsp "luahelper-lsp/langserver/protocol"
+)
+
+// commandShowReferences 客户端注册的显示引用列表的命令，参数为lsp格式的uri、位置以及位置列表
+// editor.action.showReferences需要vscode的Uri、Position、Location类型，由客户端转换后再调用
+const commandShowReferences = "LuaHelper.showReferences"
+
+// codeLensData 需要延迟计算的codeLens，在codeLens/resolve 请求中保留的数据
+type codeLensData struct {
+	Kind     check.CodeLensKind `json:"kind"`
+	URI      lsp.DocumentURI    `json:"uri"`
+	Position lsp.Position       `json:"position"`
+	Name     string             `json:"name"`
+}
+
+// TextDocumentCodeLens 文件中最外层函数以及注解class上面显示的codeLens，引用数量与子类数量延迟计算
+func (l *LspServer) TextDocumentCodeLens(ctx context.Context, vs lsp.CodeLensParams) (codeLensVec []lsp.CodeLens, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	strFile := pathpre.VscodeURIToString(string(vs.TextDocument.URI))
+	project := l.getAllProject()
+	if !project.IsNeedHandle(strFile) {
+		log.Debug("not need to handle strFile=%s", strFile)
+		return
+	}
+
+	rangeConverter := l.getFileCache().CreateRangeConverter()
+	for _, oneCodeLens := range project.GetCodeLens(strFile) {
+		ra := rangeConverter.LocToRange(strFile, &oneCodeLens.Loc)
+		codeLens := lsp.CodeLens{
+			Range: ra,
+		}
+
+		if oneCodeLens.Kind == check.CodeLensOverride {
+			codeLens.Command = getShowReferencesCommand(oneCodeLens.Title, vs.TextDocument.URI, ra.Start,
+				defineVecConvert(rangeConverter, oneCodeLens.DefineVec))
+		} else {
+			codeLens.Data = codeLensData{
+				Kind:     oneCodeLens.Kind,
+				URI:      vs.TextDocument.URI,
+				Position: ra.Start,
+				Name:     oneCodeLens.Name,
+			}
+		}
+
+		codeLensVec = append(codeLensVec, codeLens)
+	}
+
+	return
+}
+
+// CodeLensResolve 计算codeLens的引用数量或是子类数量
+func (l *LspServer) CodeLensResolve(ctx context.Context, vs lsp.CodeLens) (codeLens lsp.CodeLens, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	codeLens = vs
+	var data codeLensData
+	bytes, err := json.Marshal(vs.Data)
+	if err != nil {
+		return codeLens, nil
+	}
+	if err = json.Unmarshal(bytes, &data); err != nil {
+		log.Error("CodeLensResolve data err=%s", err.Error())
+		return codeLens, nil
+	}
+
+	switch data.Kind {
+	case check.CodeLensReferences:
+		locList := l.getCodeLensReferences(data)
+		codeLens.Command = getShowReferencesCommand(getCountTitle(len(locList), "reference", "references"), data.URI,
+			data.Position, locList)
+	case check.CodeLensSubClasses:
+		locList := defineVecConvert(l.getFileCache().CreateRangeConverter(), l.getAllProject().FindSubClasses(data.Name))
+		codeLens.Command = getShowReferencesCommand(getCountTitle(len(locList), "subclass", "subclasses"), data.URI,
+			data.Position, locList)
+	}
+
+	return codeLens, nil
+}
+
+// getCodeLensReferences 查找函数的所有引用，不包括函数定义本身
+func (l *LspServer) getCodeLensReferences(data codeLensData) (locList []lsp.Location) {
+	comResult := l.beginFileRequest(data.URI, data.Position)
+	if !comResult.result || comResult.offset >= len(comResult.contents) {
+		return
+	}
+
+	varStruct := getVarStruct(comResult.contents, comResult.offset, comResult.pos.Line, comResult.pos.Character)
+	if !varStruct.ValidFlag {
+		return
+	}
+
+	project := l.getAllProject()
+	posLine := int(data.Position.Line) + 1
+	posCh := int(comResult.pos.Character)
+	rangeConverter := l.getFileCache().CreateRangeConverter()
+	for _, referVarInfo := range project.FindReferences(comResult.strFile, &varStruct, common.CRSReference) {
+		if referVarInfo.StrFile == comResult.strFile && referVarInfo.Loc.IsInLocStruct(posLine, posCh) {
+			continue
+		}
+
+		locList = append(locList, lsp.Location{
+			URI:   getFileDocumentURI(referVarInfo.StrFile),
+			Range: rangeConverter.LocToRange(referVarInfo.StrFile, &referVarInfo.Loc),
+		})
+	}
+
+	return locList
+}
+
+// getCountTitle 获取codeLens显示的数量，例如 1 reference、2 references
+func getCountTitle(count int, strSingular, strPlural string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, strSingular)
+	}
+
+	return fmt.Sprintf("%d %s", count, strPlural)
+}
+
+// getShowReferencesCommand 点击codeLens时，客户端显示位置列表的命令
+func getShowReferencesCommand(strTitle string, uri lsp.DocumentURI, pos lsp.Position,
+	locList []lsp.Location) (command lsp.Command) {
+	command.Title = strTitle
+	if len(locList) == 0 {
+		return
+	}
+
+	for _, arg := range []interface{}{uri, pos, locList} {
+		bytes, err := json.Marshal(arg)
+		if err != nil {
+			return lsp.Command{Title: strTitle}
+		}
+		command.Arguments = append(command.Arguments, bytes)
+	}
+
+	command.Command = commandShowReferences
+	return command
+}
@@ -0,0 +1,81 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCodeLens(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/codelens")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileList := []string{"dog.lua", "animal.lua"}
+	titleList := [][]string{
+		{"0 subclasses", "1 reference", "overrides Animal:speak", "1 reference", "overrides Animal:move",
+			"2 references"},
+		{"1 subclass", "0 references"},
+	}
+
+	for index, oneFile := range fileList {
+		fileName := strRootPath + "/" + oneFile
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			t.Fatalf("read file:%s err=%s", fileName, err.Error())
+		}
+
+		openParams := lsp.DidOpenTextDocumentParams{
+			TextDocument: lsp.TextDocumentItem{
+				URI:  lsp.DocumentURI(fileName),
+				Text: string(data),
+			},
+		}
+		err = lspServer.TextDocumentDidOpen(context, openParams)
+		if err != nil {
+			t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+		}
+
+		codeLensParams := lsp.CodeLensParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+		}
+		codeLensVec, err := lspServer.TextDocumentCodeLens(context, codeLensParams)
+		if err != nil {
+			t.Fatalf("CodeLens file:%s err=%s", fileName, err.Error())
+		}
+
+		if len(codeLensVec) != len(titleList[index]) {
+			t.Fatalf("CodeLens file:%s len=%d, expect=%d", fileName, len(codeLensVec), len(titleList[index]))
+		}
+
+		for i, oneCodeLens := range codeLensVec {
+			if oneCodeLens.Command.Title == "" {
+				// 引用数量与子类数量需要resolve
+				oneCodeLens, err = lspServer.CodeLensResolve(context, oneCodeLens)
+				if err != nil {
+					t.Fatalf("CodeLensResolve file:%s err=%s", fileName, err.Error())
+				}
+			}
+
+			if oneCodeLens.Command.Title != titleList[index][i] {
+				t.Fatalf("CodeLens file:%s index=%d title=%s, expect=%s", fileName, i, oneCodeLens.Command.Title,
+					titleList[index][i])
+			}
+
+			// 有位置列表时，由客户端注册的命令转换参数后显示
+			if oneCodeLens.Command.Command != "" && (oneCodeLens.Command.Command != commandShowReferences ||
+				len(oneCodeLens.Command.Arguments) != 3) {
+				t.Fatalf("CodeLens file:%s index=%d command error, command=%v", fileName, i, oneCodeLens.Command)
+			}
+		}
+	}
+}
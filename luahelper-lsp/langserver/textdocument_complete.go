@@ -20,6 +20,9 @@ type CompletionItemTmp struct {
 	//Documentation string             `json:"documentation,omitempty"`
 	Data interface{} `json:"data,omitempty"`
 	//SortText      string             `json:"sortText,omitempty"`
+	AdditionalTextEdits []lsp.TextEdit       `json:"additionalTextEdits,omitempty"`
+	InsertText          string               `json:"insertText,omitempty"`
+	InsertTextFormat    lsp.InsertTextFormat `json:"insertTextFormat,omitempty"`
 }
 
 type CompletionListTmp struct {
@@ -75,6 +78,25 @@ func (l *LspServer) TextDocumentComplete(ctx context.Context, vs lsp.CompletionP
 		return compeleteFileList, nil
 	}
 
+	// 4.1) 判断是否为打tlog日志的函数调用中，第一个参数struct名称的补全
+	if l.judgeCompleteTlogStruct(strFile, comResult.contents, comResult.offset) {
+		return CompletionListTmp{
+			IsIncomplete: false,
+			Items:        l.convertToCompletionItems(),
+		}, nil
+	}
+
+	// 5) 还没有输入字符时，判断光标是否在table构造中key的位置，补全期望的class的field
+	if isTableKeyBeginPos(comResult.contents, comResult.offset) {
+		project.TableKeyComplete(strFile, (int)(comResult.pos.Line), (int)(comResult.pos.Character))
+		if items := l.convertToCompletionItems(); len(items) > 0 {
+			return CompletionListTmp{
+				IsIncomplete: false,
+				Items:        items,
+			}, nil
+		}
+	}
+
 	// 5.1) 获取这个代码补全的前缀字符串
 	preCompeleteStr := getCompeletePreStr(comResult.contents, comResult.offset)
 	if preCompeleteStr == "" {
@@ -234,7 +256,7 @@ func (l *LspServer) judgeCompeleteFile(strFile string, contents []byte, offset i
 	// 1) 定义为匹配的引入其他文件的字符串
 	referNameStr := ""
 	matchReferStr := ""
-	referFileTypes := common.GConfig.GetAllReferFileTypes()
+	referFileTypes := common.GConfig.GetFileConfig(strFile).GetAllReferFileTypes()
 	for _, strOne := range referFileTypes {
 		regexpStr := strOne + ` *?(\()? *?[\"|\'][0-9a-zA-Z_/|.]*`
 		regRefer := regexp.MustCompile(regexpStr)
@@ -255,7 +277,7 @@ func (l *LspServer) judgeCompeleteFile(strFile string, contents []byte, offset i
 	if matchReferStr == "" {
 		return
 	}
-	referType := common.StrToReferType(referNameStr)
+	referType := common.StrToReferType(strFile, referNameStr)
 	if referType == common.ReferNotValid {
 		return
 	}
@@ -276,6 +298,35 @@ func (l *LspServer) judgeCompeleteFile(strFile string, contents []byte, offset i
 	return
 }
 
+// tlogStructRegexp 打tlog日志的函数调用中，正在输入的第一个参数字符串，例如 tlog("Player 或是 log:tlog('
+var tlogStructRegexp = regexp.MustCompile(`([0-9a-zA-Z_]+)\s*\(\s*["'][0-9a-zA-Z_]*$`)
+
+// judgeCompleteTlogStruct 判断是否为打tlog日志的struct名称补全，是的时候补全tlog xml中所有的struct名称
+func (l *LspServer) judgeCompleteTlogStruct(strFile string, contents []byte, offset int) bool {
+	strLine := getPreLineStr(offset, contents)
+	if strLine == "" {
+		return false
+	}
+
+	matchVec := tlogStructRegexp.FindStringSubmatch(strLine)
+	if len(matchVec) < 2 || !common.GConfig.GetFileConfig(strFile).IsTlogFunc(matchVec[1]) {
+		return false
+	}
+
+	l.getAllProject().TlogStructComplete(strFile)
+	return true
+}
+
+// isTableKeyBeginPos 光标前面为空白、{ 或是分隔符时，可能为table构造中开始输入key的位置
+func isTableKeyBeginPos(contents []byte, offset int) bool {
+	if offset <= 0 || offset > len(contents) {
+		return false
+	}
+
+	ch := contents[offset-1]
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '{' || ch == ',' || ch == ';'
+}
+
 // 字符串进行拆分
 func getComplelteStruct(str string, line, character int) (validFlag bool, completeVar common.CompleteVarStruct) {
 	lastEmptyFlag := false
@@ -531,6 +582,32 @@ func (l *LspServer) convertToCompletionItems() (items []CompletionItemTmp) {
 			item.Kind = lsp.InterfaceCompletion
 		} else if oneComplete.Kind == common.IKAnnotateAlias {
 			item.Kind = lsp.InterfaceCompletion
+		} else if oneComplete.Kind == common.IKModule {
+			item.Kind = lsp.ModuleCompletion
+		}
+
+		// 自动引入其他文件的补全，选中后同时插入引入语句
+		if oneComplete.ReferStr != "" {
+			referPos := lsp.Position{
+				Line: uint32(oneComplete.ReferLine),
+			}
+			item.AdditionalTextEdits = []lsp.TextEdit{
+				{
+					Range: lsp.Range{
+						Start: referPos,
+						End:   referPos,
+					},
+					NewText: oneComplete.ReferStr,
+				},
+			}
+		}
+
+		// 函数补全插入带参数占位符的代码片段
+		if oneComplete.SnippetFlag {
+			item.InsertText = oneComplete.InsetText
+			item.InsertTextFormat = lsp.SnippetTextFormat
+		} else if oneComplete.InsetText != "" {
+			item.InsertText = oneComplete.InsetText
 		}
 
 		item.Data = float64(i)
@@ -3,8 +3,10 @@ package langserver
 import (
 	"context"
 	"io/ioutil"
+	"luahelper-lsp/langserver/check/common"
 	lsp "luahelper-lsp/langserver/protocol"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"testing"
 )
@@ -870,3 +872,262 @@ func TestComplete3(t *testing.T) {
 		}
 	}
 }
+
+func TestRequireComplete(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/requirecomplete")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "main.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	completionParams := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: lsp.Position{
+				Line:      7,
+				Character: 16,
+			},
+		},
+		Context: lsp.CompletionContext{
+			TriggerKind: lsp.CompletionTriggerKind(1),
+		},
+	}
+
+	completionReturn, err := lspServer.TextDocumentComplete(context, completionParams)
+	if err != nil {
+		t.Fatalf("complete file:%s err=%s", fileName, err.Error())
+	}
+
+	completionListTmp, _ := completionReturn.(CompletionListTmp)
+	itemMap := map[string]CompletionItemTmp{}
+	for _, item := range completionListTmp.Items {
+		itemMap[item.Label] = item
+	}
+
+	// skill.lua 返回了模块，补全时插入require语句到已有的require后面
+	skillItem, ok := itemMap["skill"]
+	if !ok || len(skillItem.AdditionalTextEdits) != 1 {
+		t.Fatalf("skill should be completed with require, items=%v", completionListTmp.Items)
+	}
+	textEdit := skillItem.AdditionalTextEdits[0]
+	if textEdit.NewText != "local skill = require(\"battle.skill\")\n" || textEdit.Range.Start.Line != 2 {
+		t.Fatalf("require text edit error, edit=%v", textEdit)
+	}
+
+	// 模块的成员补全为模块名.成员，同样插入require语句
+	castItem, ok := itemMap["skill.cast"]
+	if !ok || castItem.InsertText != "skill.cast" || len(castItem.AdditionalTextEdits) != 1 ||
+		castItem.AdditionalTextEdits[0].NewText != textEdit.NewText || castItem.Kind != lsp.FunctionCompletion {
+		t.Fatalf("skill.cast should be completed with require, item=%v", castItem)
+	}
+
+	// 没有返回模块的文件，顶层定义的全局函数和全局表，补全时只引入文件
+	for _, strName := range []string{"skill_global", "SkillConfig"} {
+		globalItem, ok := itemMap[strName]
+		if !ok || len(globalItem.AdditionalTextEdits) != 1 ||
+			globalItem.AdditionalTextEdits[0].NewText != "require(\"battle.skill_util\")\n" {
+			t.Fatalf("%s should be completed with require, item=%v", strName, globalItem)
+		}
+	}
+
+	// 已经引入的，以及没有返回模块的文件不提示模块
+	if buffItem, ok := itemMap["buff"]; ok && len(buffItem.AdditionalTextEdits) != 0 {
+		t.Fatalf("buff is already required")
+	}
+	if _, ok := itemMap["buff.add"]; ok {
+		t.Fatalf("buff is already required")
+	}
+	if _, ok := itemMap["skill_util"]; ok {
+		t.Fatalf("skill_util does not return a module")
+	}
+}
+
+func TestCallSnippetComplete(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/callsnippet")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	// 测试时客户端没有传入snippet的能力，直接开启
+	common.GConfig.SetCallSnippetFlag(true)
+	defer common.GConfig.SetCallSnippetFlag(false)
+
+	fileName := strRootPath + "/" + "test.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	type snippetCase struct {
+		line      uint32
+		character uint32
+		label     string
+		snippets  []string
+	}
+	caseVec := []snippetCase{
+		// 函数的每个---@overload 单独作为一个补全项
+		{17, 3, "add", []string{"add(${1:a}, ${2:b})", "add(${1:a})"}},
+		// 冒号调用冒号函数，去掉隐含的self
+		{18, 3, "run", []string{"run(${1:speed})"}},
+		// 只有可变参数，光标停留在括号内
+		{19, 3, "log", []string{"log($1)"}},
+		// 点调用冒号函数，需要传入self
+		{20, 3, "run", []string{"run(${1:self}, ${2:speed})"}},
+	}
+
+	for _, oneCase := range caseVec {
+		completionParams := lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{
+					URI: lsp.DocumentURI(fileName),
+				},
+				Position: lsp.Position{
+					Line:      oneCase.line,
+					Character: oneCase.character,
+				},
+			},
+			Context: lsp.CompletionContext{
+				TriggerKind: lsp.CompletionTriggerKind(1),
+			},
+		}
+
+		completionReturn, err := lspServer.TextDocumentComplete(context, completionParams)
+		if err != nil {
+			t.Fatalf("complete file:%s err=%s", fileName, err.Error())
+		}
+
+		completionListTmp, _ := completionReturn.(CompletionListTmp)
+		snippetVec := []string{}
+		for _, item := range completionListTmp.Items {
+			if item.Label != oneCase.label {
+				continue
+			}
+
+			if item.InsertTextFormat != lsp.SnippetTextFormat {
+				t.Fatalf("item %s is not snippet, line=%d", item.Label, oneCase.line)
+			}
+			snippetVec = append(snippetVec, item.InsertText)
+		}
+
+		if !reflect.DeepEqual(snippetVec, oneCase.snippets) {
+			t.Fatalf("line=%d snippet error, get=%v, want=%v", oneCase.line, snippetVec, oneCase.snippets)
+		}
+	}
+}
+
+func TestTableKeyComplete(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/tablekey")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "test.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	type tableKeyCase struct {
+		line       uint32
+		character  uint32
+		existVec   []string
+		noExistVec []string
+	}
+	caseVec := []tableKeyCase{
+		// ---@type 注解的table，已经存在的key不提示
+		{20, 27, []string{"debug", "db"}, []string{"port"}},
+		// 嵌套的table，为外层class中field的类型
+		{22, 35, []string{"host"}, []string{"debug"}},
+		// ---@param 注解的实参，没有输入字符时提示所有的field，包括父类的
+		{23, 14, []string{"name", "port", "db", "debug"}, []string{}},
+	}
+
+	for _, oneCase := range caseVec {
+		completionParams := lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{
+					URI: lsp.DocumentURI(fileName),
+				},
+				Position: lsp.Position{
+					Line:      oneCase.line,
+					Character: oneCase.character,
+				},
+			},
+			Context: lsp.CompletionContext{
+				TriggerKind: lsp.CompletionTriggerKind(1),
+			},
+		}
+
+		completionReturn, err := lspServer.TextDocumentComplete(context, completionParams)
+		if err != nil {
+			t.Fatalf("complete file:%s err=%s", fileName, err.Error())
+		}
+
+		completionListTmp, _ := completionReturn.(CompletionListTmp)
+		itemMap := map[string]CompletionItemTmp{}
+		for _, item := range completionListTmp.Items {
+			if item.InsertText != "" {
+				itemMap[item.Label] = item
+			}
+		}
+
+		for _, strKey := range oneCase.existVec {
+			item, ok := itemMap[strKey]
+			if !ok || item.InsertText != strKey+" = " {
+				t.Fatalf("line=%d key %s should be completed, items=%v", oneCase.line, strKey, completionListTmp.Items)
+			}
+		}
+
+		for _, strKey := range oneCase.noExistVec {
+			if _, ok := itemMap[strKey]; ok {
+				t.Fatalf("line=%d key %s should not be completed", oneCase.line, strKey)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package langserver
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+func TestControlFlow(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/controlflow")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	project := lspServer.getAllProject()
+
+	fileName := strRootPath + "/" + "main.lua"
+	flowErrVec := []common.CheckError{}
+	for _, oneErr := range project.GetAllFileErrorInfo()[fileName] {
+		if oneErr.ErrType == common.CheckErrorUnreachable || oneErr.ErrType == common.CheckErrorMissingReturn {
+			flowErrVec = append(flowErrVec, oneErr)
+		}
+	}
+	sort.Slice(flowErrVec, func(i, j int) bool {
+		return flowErrVec[i].Loc.StartLine < flowErrVec[j].Loc.StartLine
+	})
+
+	type flowCase struct {
+		errType   common.CheckErrorType
+		startLine int
+		endLine   int
+	}
+	caseVec := []flowCase{
+		{common.CheckErrorUnreachable, 7, 7},
+		{common.CheckErrorUnreachable, 12, 13},
+		{common.CheckErrorMissingReturn, 20, 20},
+		{common.CheckErrorUnreachable, 26, 26},
+		{common.CheckErrorUnreachable, 33, 33},
+		{common.CheckErrorUnreachable, 38, 38},
+		{common.CheckErrorUnreachable, 50, 50},
+	}
+	if len(flowErrVec) != len(caseVec) {
+		t.Fatalf("control flow err num error, errs=%v", flowErrVec)
+	}
+
+	for i, oneCase := range caseVec {
+		oneErr := flowErrVec[i]
+		if oneErr.ErrType != oneCase.errType || oneErr.Loc.StartLine != oneCase.startLine ||
+			oneErr.Loc.EndLine != oneCase.endLine {
+			t.Fatalf("control flow err error, expect=%v, err=%v", oneCase, oneErr)
+		}
+	}
+
+	// 有返回值的return语句作为关联信息
+	missingErr := flowErrVec[2]
+	if len(missingErr.RelateVec) != 1 || missingErr.RelateVec[0].Loc.StartLine != 18 {
+		t.Fatalf("missing return relate error, relate=%v", missingErr.RelateVec)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"luahelper-lsp/langserver/lspcommon"
 	lsp "luahelper-lsp/langserver/protocol"
 	"strings"
+	"unicode/utf8"
 )
 
 // TextDocumentDefine 文件中查找变量的的定义
@@ -26,9 +27,17 @@ func (l *LspServer) TextDocumentDefine(ctx context.Context, vs lsp.TextDocumentP
 
 	strFile := fileRequest.strFile
 	project := l.getAllProject()
+	rangeConverter := l.getFileCache().CreateRangeConverter()
+
+	// 0) 判断是否为打tlog日志的struct名称或是字段，跳转到tlog xml中的定义
+	tlogDefineVecs := project.FindTlogDefine(strFile, (int)(fileRequest.pos.Line), (int)(fileRequest.pos.Character))
+	if len(tlogDefineVecs) > 0 {
+		locList = defineVecConvert(rangeConverter, tlogDefineVecs)
+		return locList, nil
+	}
 
 	// 1）判断查找的定义是否为打开一个文件
-	fileList := getOpenFileStr(fileRequest.contents, fileRequest.offset, (int)(fileRequest.pos.Character))
+	fileList := getOpenFileStr(strFile, fileRequest.contents, fileRequest.offset, (int)(fileRequest.pos.Character))
 	var openDefineVecs []check.DefineStruct
 	for _, strItem := range fileList {
 		openDefineVecs = project.FindOpenFileDefine(strFile, strItem)
@@ -36,14 +45,14 @@ func (l *LspServer) TextDocumentDefine(ctx context.Context, vs lsp.TextDocumentP
 			break
 		}
 	}
-	locList = defineVecConvert(openDefineVecs)
+	locList = defineVecConvert(rangeConverter, openDefineVecs)
 	return locList, nil
 
 	// 2) 判断是否为---@ 注解引入的查找里面的类型定义
 	defineAnnotateVecs, flag := l.handleAnnotateTypeDefine(strFile, fileRequest.contents, fileRequest.offset,
 		(int)(fileRequest.pos.Line), (int)(fileRequest.pos.Character))
 	if flag {
-		locList = defineVecConvert(defineAnnotateVecs)
+		locList = defineVecConvert(rangeConverter, defineAnnotateVecs)
 		return locList, nil
 	}
 
@@ -56,7 +65,7 @@ func (l *LspServer) TextDocumentDefine(ctx context.Context, vs lsp.TextDocumentP
 
 	defineVecs := project.FindVarDefineInfo(strFile, &varStruct)
 	if len(defineVecs) > 0 {
-		locList = defineVecConvert(defineVecs)
+		locList = defineVecConvert(rangeConverter, defineVecs)
 		return locList, nil
 	}
 
@@ -83,19 +92,19 @@ func (l *LspServer) handleAnnotateTypeDefine(strFile string, contents []byte, of
 	}
 
 	flag = true
-	col := posCharacter - (beginIndex + 2)
+	col := posCharacter - (utf8.RuneCountInString(strLine[:beginIndex]) + 2)
 	annotateStr := strLine[beginIndex+2:]
 	project := l.getAllProject()
 	defineVecs = project.AnnotateTypeDefine(strFile, annotateStr, posLine, col)
 	return
 }
 
-// defineVecConvert 转换为返回的定义结构
-func defineVecConvert(defineVecs []check.DefineStruct) (locList []lsp.Location) {
+// defineVecConvert 转换为返回的定义结构，定义可能在多个文件中，位置通过rangeConverter转换
+func defineVecConvert(rangeConverter *lspcommon.RangeConverter, defineVecs []check.DefineStruct) (locList []lsp.Location) {
 	for _, defineVarInfo := range defineVecs {
 		locList = append(locList, lsp.Location{
 			URI:   getFileDocumentURI(defineVarInfo.StrFile),
-			Range: lspcommon.LocToRange(&defineVarInfo.Loc),
+			Range: rangeConverter.LocToRange(defineVarInfo.StrFile, &defineVarInfo.Loc),
 		})
 	}
 
@@ -0,0 +1,233 @@
+package langserver
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/pathpre"
+	lsp "luahelper-lsp/langserver/protocol"
+	"sort"
+	"strconv"
+
+	"github.com/yinfei8/jrpc2"
+	"github.com/yinfei8/jrpc2/code"
+)
+
+// requestCancelledCode lsp约定的请求被取消的错误码
+const requestCancelledCode code.Code = -32800
+
+// workspaceDiagnosticBatchNum 拉取所有文件的诊断时，每次部分结果包含的文件数量
+const workspaceDiagnosticBatchNum = 100
+
+// getDiagnosticResultID 根据诊断信息的内容计算resultId，内容不变时resultId不变
+func getDiagnosticResultID(items []lsp.Diagnostic) string {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return ""
+	}
+
+	h := fnv.New64a()
+	h.Write(data)
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// createPullDiagnosticReport 创建一个文件全量的诊断报告
+func createPullDiagnosticReport(uri lsp.DocumentURI, items []lsp.Diagnostic) lsp.WorkspaceFullDocumentDiagnosticReport {
+	if items == nil {
+		items = []lsp.Diagnostic{}
+	}
+
+	return lsp.WorkspaceFullDocumentDiagnosticReport{
+		FullDocumentDiagnosticReport: lsp.FullDocumentDiagnosticReport{
+			Kind:     lsp.DiagnosticFull,
+			ResultID: getDiagnosticResultID(items),
+			Items:    items,
+		},
+		URI: uri,
+	}
+}
+
+// savePullDiagnostics 客户端拉取诊断时，保存文件最新的诊断信息，有变化时通知客户端重新拉取
+func (l *LspServer) savePullDiagnostics(diagnostics lsp.PublishDiagnosticsParams) {
+	strFile := pathpre.VscodeURIToString(string(diagnostics.URI))
+	report := createPullDiagnosticReport(diagnostics.URI, diagnostics.Diagnostics)
+
+	l.pullMutex.Lock()
+	oldReport, ok := l.pullDiagnosticMap[strFile]
+	if ok && oldReport.ResultID == report.ResultID {
+		l.pullMutex.Unlock()
+		return
+	}
+
+	if len(report.Items) == 0 {
+		// 没有错误的文件不再保存，客户端拉取时返回空的诊断
+		delete(l.pullDiagnosticMap, strFile)
+	} else {
+		l.pullDiagnosticMap[strFile] = report
+	}
+	l.pullMutex.Unlock()
+
+	if ok || len(report.Items) > 0 {
+		l.refreshDiagnostics()
+	}
+}
+
+// getPullDiagnostic 获取保存的文件诊断信息
+func (l *LspServer) getPullDiagnostic(strFile string) lsp.WorkspaceFullDocumentDiagnosticReport {
+	l.pullMutex.Lock()
+	defer l.pullMutex.Unlock()
+
+	if report, ok := l.pullDiagnosticMap[strFile]; ok {
+		return report
+	}
+
+	return createPullDiagnosticReport(getFileDocumentURI(strFile), nil)
+}
+
+// refreshDiagnostics 诊断信息有变化，请求客户端重新拉取诊断
+// 客户端还没有响应上一次的刷新时，等响应后再刷新一次，避免频繁的发送刷新请求
+func (l *LspServer) refreshDiagnostics() {
+	if !l.diagnosticRefreshFlag {
+		return
+	}
+
+	l.refreshMutex.Lock()
+	if l.refreshingFlag {
+		l.refreshAgainFlag = true
+		l.refreshMutex.Unlock()
+		return
+	}
+	l.refreshingFlag = true
+	l.refreshMutex.Unlock()
+
+	go func() {
+		for {
+			if _, err := l.server.Callback(context.Background(), "workspace/diagnostic/refresh", nil); err != nil {
+				log.Debug("refreshDiagnostics error=%v", err)
+			}
+
+			l.refreshMutex.Lock()
+			if !l.refreshAgainFlag {
+				l.refreshingFlag = false
+				l.refreshMutex.Unlock()
+				return
+			}
+			l.refreshAgainFlag = false
+			l.refreshMutex.Unlock()
+		}
+	}()
+}
+
+// TextDocumentDiagnostic 客户端拉取单个文件的诊断信息，诊断没有变化时返回unchanged
+func (l *LspServer) TextDocumentDiagnostic(ctx context.Context, vs lsp.DocumentDiagnosticParams) (interface{}, error) {
+	strFile := pathpre.VscodeURIToString(string(vs.TextDocument.URI))
+	report := l.getPullDiagnostic(strFile)
+	if vs.PreviousResultID != "" && vs.PreviousResultID == report.ResultID {
+		return lsp.UnchangedDocumentDiagnosticReport{
+			Kind:     lsp.DiagnosticUnchanged,
+			ResultID: report.ResultID,
+		}, nil
+	}
+
+	return report.FullDocumentDiagnosticReport, nil
+}
+
+// WorkspaceDiagnostic 客户端拉取所有文件的诊断信息
+// 客户端传入了partialResultToken时，通过$/progress分批返回结果；请求可以被$/cancelRequest取消
+func (l *LspServer) WorkspaceDiagnostic(ctx context.Context, vs lsp.WorkspaceDiagnosticParams) (
+	result lsp.WorkspaceDiagnosticReport, err error) {
+	ctx, finish := l.beginCancelableRequest(ctx)
+	defer finish()
+
+	result.Items = []interface{}{}
+	previousMap := map[string]string{}
+	for _, oneResult := range vs.PreviousResultIds {
+		previousMap[pathpre.VscodeURIToString(string(oneResult.URI))] = oneResult.Value
+	}
+
+	reportMap := map[string]lsp.WorkspaceFullDocumentDiagnosticReport{}
+	l.pullMutex.Lock()
+	for strFile, report := range l.pullDiagnosticMap {
+		reportMap[strFile] = report
+	}
+	l.pullMutex.Unlock()
+
+	// 客户端之前有诊断，现在已经没有错误的文件，返回空的诊断清除掉
+	for strFile := range previousMap {
+		if _, ok := reportMap[strFile]; !ok {
+			reportMap[strFile] = createPullDiagnosticReport(getFileDocumentURI(strFile), nil)
+		}
+	}
+
+	fileVec := make([]string, 0, len(reportMap))
+	for strFile := range reportMap {
+		fileVec = append(fileVec, strFile)
+	}
+	sort.Strings(fileVec)
+
+	items := []interface{}{}
+	for i, strFile := range fileVec {
+		if ctx.Err() != nil {
+			log.Debug("WorkspaceDiagnostic cancelled, file num=%d", i)
+			return result, jrpc2.Errorf(requestCancelledCode, "workspace diagnostic cancelled")
+		}
+
+		report := reportMap[strFile]
+		if previousMap[strFile] == report.ResultID {
+			items = append(items, lsp.WorkspaceUnchangedDocumentDiagnosticReport{
+				UnchangedDocumentDiagnosticReport: lsp.UnchangedDocumentDiagnosticReport{
+					Kind:     lsp.DiagnosticUnchanged,
+					ResultID: report.ResultID,
+				},
+				URI: report.URI,
+			})
+		} else {
+			items = append(items, report)
+		}
+
+		if vs.PartialResultToken == nil || (len(items) < workspaceDiagnosticBatchNum && i != len(fileVec)-1) {
+			continue
+		}
+
+		// 分批返回部分结果，最终的返回结果为空
+		if err := l.server.Notify(ctx, "$/progress", lsp.ProgressParams{
+			Token: vs.PartialResultToken,
+			Value: lsp.WorkspaceDiagnosticReportPartialResult{Items: items},
+		}); err != nil {
+			log.Debug("WorkspaceDiagnostic partial result error=%v", err)
+		}
+		items = []interface{}{}
+	}
+
+	if vs.PartialResultToken == nil {
+		result.Items = items
+	}
+	return result, nil
+}
+
+// beginCancelableRequest 登记可以被$/cancelRequest取消的请求，请求结束时调用返回的函数
+func (l *LspServer) beginCancelableRequest(ctx context.Context) (context.Context, func()) {
+	req := jrpc2.InboundRequest(ctx)
+	if req == nil || req.IsNotification() {
+		return ctx, func() {}
+	}
+
+	return l.registerCancelRequest(ctx, req.ID())
+}
+
+// registerCancelRequest 按请求的id登记取消函数，strID为请求id的json格式
+func (l *LspServer) registerCancelRequest(ctx context.Context, strID string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	l.cancelMutex.Lock()
+	l.cancelMap[strID] = cancel
+	l.cancelMutex.Unlock()
+
+	return ctx, func() {
+		l.cancelMutex.Lock()
+		delete(l.cancelMap, strID)
+		l.cancelMutex.Unlock()
+		cancel()
+	}
+}
@@ -0,0 +1,151 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPullDiagnostic(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/pulldiagnostic")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	lspServer.pullDiagnosticFlag = true
+	context := context.Background()
+
+	// 拉取模式下，诊断错误保存下来等客户端拉取
+	lspServer.Initialized(context, InitializedParams{})
+
+	mainFile := strRootPath + "/main.lua"
+	diagnosticParams := lsp.DocumentDiagnosticParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI(mainFile),
+		},
+	}
+	ret, err := lspServer.TextDocumentDiagnostic(context, diagnosticParams)
+	if err != nil {
+		t.Fatalf("diagnostic file:%s err=%s", mainFile, err.Error())
+	}
+	fullReport, ok := ret.(lsp.FullDocumentDiagnosticReport)
+	if !ok || len(fullReport.Items) == 0 || fullReport.ResultID == "" {
+		t.Fatalf("diagnostic file:%s should be full report, ret=%v", mainFile, ret)
+	}
+
+	// 诊断没有变化时，返回unchanged
+	diagnosticParams.PreviousResultID = fullReport.ResultID
+	ret, _ = lspServer.TextDocumentDiagnostic(context, diagnosticParams)
+	if unchangedReport, ok := ret.(lsp.UnchangedDocumentDiagnosticReport); !ok ||
+		unchangedReport.ResultID != fullReport.ResultID {
+		t.Fatalf("diagnostic file:%s should be unchanged, ret=%v", mainFile, ret)
+	}
+
+	// 实时修改修复语法错误后，诊断有变化
+	data, err := ioutil.ReadFile(mainFile)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", mainFile, err.Error())
+	}
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(mainFile),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", mainFile, err.Error())
+	}
+	changeParams := lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(mainFile),
+			},
+		},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{
+			{
+				Text: "local a = 1\n",
+			},
+		},
+	}
+	lspServer.TextDocumentDidChange(context, changeParams)
+	ret, _ = lspServer.TextDocumentDiagnostic(context, diagnosticParams)
+	if changeReport, ok := ret.(lsp.FullDocumentDiagnosticReport); !ok || len(changeReport.Items) != 0 ||
+		changeReport.ResultID == fullReport.ResultID {
+		t.Fatalf("diagnostic file:%s should be changed, ret=%v", mainFile, ret)
+	}
+
+	// 没有错误的文件返回空的诊断
+	cleanFile := strRootPath + "/clean.lua"
+	ret, _ = lspServer.TextDocumentDiagnostic(context, lsp.DocumentDiagnosticParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI(cleanFile),
+		},
+	})
+	if cleanReport, ok := ret.(lsp.FullDocumentDiagnosticReport); !ok || len(cleanReport.Items) != 0 {
+		t.Fatalf("diagnostic file:%s should be empty, ret=%v", cleanFile, ret)
+	}
+
+	// 拉取所有文件的诊断，otherFile没有变化，cleanFile之前有诊断，现在返回空的诊断
+	// mainFile修复了错误，客户端也没有它之前的诊断，不用返回
+	otherFile := strRootPath + "/other.lua"
+	otherReport := lspServer.getPullDiagnostic(otherFile)
+	workspaceParams := lsp.WorkspaceDiagnosticParams{
+		PreviousResultIds: []lsp.PreviousResultID{
+			{URI: lsp.DocumentURI(otherFile), Value: otherReport.ResultID},
+			{URI: lsp.DocumentURI(cleanFile), Value: "1"},
+		},
+	}
+	workspaceReport, err := lspServer.WorkspaceDiagnostic(context, workspaceParams)
+	if err != nil {
+		t.Fatalf("workspace diagnostic err=%s", err.Error())
+	}
+	if len(workspaceReport.Items) != 2 {
+		t.Fatalf("workspace diagnostic items num error, items=%v", workspaceReport.Items)
+	}
+	for _, oneItem := range workspaceReport.Items {
+		switch item := oneItem.(type) {
+		case lsp.WorkspaceUnchangedDocumentDiagnosticReport:
+			if item.URI != otherReport.URI {
+				t.Fatalf("workspace diagnostic unchanged file error, uri=%s", item.URI)
+			}
+		case lsp.WorkspaceFullDocumentDiagnosticReport:
+			if item.URI == otherReport.URI {
+				t.Fatalf("workspace diagnostic file:%s should be unchanged", otherFile)
+			}
+		}
+	}
+
+	// 传入partialResultToken时，结果通过$/progress返回
+	workspaceParams.PartialResultToken = "token"
+	workspaceReport, err = lspServer.WorkspaceDiagnostic(context, workspaceParams)
+	if err != nil || len(workspaceReport.Items) != 0 {
+		t.Fatalf("workspace diagnostic with partial result error, items=%v, err=%v", workspaceReport.Items, err)
+	}
+}
+
+func TestCancelRequest(t *testing.T) {
+	lspServer := CreateLspServer()
+	ctx, finish := lspServer.registerCancelRequest(context.Background(), "5")
+	defer finish()
+
+	// 没有登记的请求不受影响
+	lspServer.CancelRequest(context.Background(), lsp.CancelParams{ID: "5"})
+	if ctx.Err() != nil {
+		t.Fatalf("request \"5\" should not be cancelled")
+	}
+
+	lspServer.CancelRequest(context.Background(), lsp.CancelParams{ID: float64(5)})
+	if ctx.Err() == nil {
+		t.Fatalf("request 5 should be cancelled")
+	}
+
+	// 已经取消的请求，拉取所有文件的诊断返回错误
+	lspServer.pullDiagnosticMap["test.lua"] = createPullDiagnosticReport("test.lua", nil)
+	if _, err := lspServer.WorkspaceDiagnostic(ctx, lsp.WorkspaceDiagnosticParams{}); err == nil {
+		t.Fatalf("cancelled workspace diagnostic should return error")
+	}
+}
@@ -0,0 +1,48 @@
+package langserver
+
+import (
+	"context"
+	"luahelper-lsp/langserver/check/common"
+	"luahelper-lsp/langserver/log"
+	"luahelper-lsp/langserver/lspcommon"
+	"luahelper-lsp/langserver/pathpre"
+	lsp "luahelper-lsp/langserver/protocol"
+)
+
+// TextDocumentdocumentLink 文件中可以点击跳转的链接，包括require、dofile等引用的文件，注释中的url以及---@see 的路径
+func (l *LspServer) TextDocumentdocumentLink(ctx context.Context, vs lsp.DocumentLinkParams) (linkVec []lsp.DocumentLink, err error) {
+	l.requestMutex.Lock()
+	defer l.requestMutex.Unlock()
+
+	strFile := pathpre.VscodeURIToString(string(vs.TextDocument.URI))
+	project := l.getAllProject()
+	if !project.IsNeedHandle(strFile) {
+		log.Debug("not need to handle strFile=%s", strFile)
+		return
+	}
+
+	contents, found := l.getFileCache().GetFileContent(strFile)
+	if !found {
+		log.Error("DocumentLink file=%s not find contents", strFile)
+		return
+	}
+
+	dirManager := common.GConfig.GetDirManager()
+	converter := lspcommon.CreatePosConverter(contents)
+	for _, oneLink := range project.GetDocumentLinks(strFile, contents) {
+		documentLink := lsp.DocumentLink{
+			Range:   converter.LocToRange(&oneLink.Loc),
+			Target:  oneLink.URL,
+			Tooltip: oneLink.URL,
+		}
+
+		if oneLink.LuaFile != "" {
+			documentLink.Target = string(getFileDocumentURI(oneLink.LuaFile))
+			documentLink.Tooltip = dirManager.RemovePathDirPre(oneLink.LuaFile)
+		}
+
+		linkVec = append(linkVec, documentLink)
+	}
+
+	return
+}
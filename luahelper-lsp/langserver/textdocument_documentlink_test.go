@@ -0,0 +1,76 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDocumentLink(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/documentlink")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "main.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err = lspServer.TextDocumentDidOpen(context, openParams)
+	if err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	linkParams := lsp.DocumentLinkParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI(fileName),
+		},
+	}
+	linkVec, err := lspServer.TextDocumentdocumentLink(context, linkParams)
+	if err != nil {
+		t.Fatalf("DocumentLink file:%s err=%s", fileName, err.Error())
+	}
+
+	// 没有找到的文件require 'not_exist' 不生成链接
+	rangeList := []lsp.Range{
+		{Start: lsp.Position{Line: 0, Character: 9}, End: lsp.Position{Line: 0, Character: 45}},
+		{Start: lsp.Position{Line: 1, Character: 24}, End: lsp.Position{Line: 1, Character: 35}},
+		{Start: lsp.Position{Line: 4, Character: 8}, End: lsp.Position{Line: 4, Character: 23}},
+		{Start: lsp.Position{Line: 6, Character: 12}, End: lsp.Position{Line: 6, Character: 27}},
+	}
+	targetList := []string{
+		"https://github.com/Tencent/LuaHelper",
+		"util/helper.lua",
+		"util/helper.lua",
+		"util/helper.lua",
+	}
+
+	if len(linkVec) != len(rangeList) {
+		t.Fatalf("DocumentLink file:%s link len=%d, expect=%d", fileName, len(linkVec), len(rangeList))
+	}
+
+	for index, oneLink := range linkVec {
+		if oneLink.Range != rangeList[index] {
+			t.Fatalf("DocumentLink index=%d range=%v, expect=%v", index, oneLink.Range, rangeList[index])
+		}
+
+		if !strings.HasSuffix(oneLink.Target, targetList[index]) {
+			t.Fatalf("DocumentLink index=%d target=%s, expect=%s", index, oneLink.Target, targetList[index])
+		}
+	}
+}
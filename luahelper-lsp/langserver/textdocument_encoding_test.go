@@ -0,0 +1,100 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFileEncoding(t *testing.T) {
+	// big5.lua为big5编码，luahelper.json中配置了FileEncoding；bom.lua为带BOM的utf-8
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/encoding")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	type symbolCase struct {
+		fileName  string
+		name      string
+		line      uint32
+		character uint32
+	}
+
+	// 文件没有打开，位置基于磁盘上的文件转换为utf-8后的内容，character按utf-16计数
+	caseVec := []symbolCase{
+		{"big5.lua", "local s", 1, 6},
+		{"big5.lua", "local abc", 1, 21},
+		{"bom.lua", "local def", 0, 26},
+	}
+	for _, oneCase := range caseVec {
+		symbolParams := lsp.DocumentSymbolParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(strRootPath + "/" + oneCase.fileName),
+			},
+		}
+		symbolVec, err := lspServer.TextDocumentSymbol(context, symbolParams)
+		if err != nil {
+			t.Fatalf("symbol file:%s err=%s", oneCase.fileName, err.Error())
+		}
+
+		findFlag := false
+		for _, oneSymbol := range symbolVec {
+			if oneSymbol.Name != oneCase.name {
+				continue
+			}
+
+			findFlag = true
+			start := oneSymbol.Range.Start
+			if start.Line != oneCase.line || start.Character != oneCase.character {
+				t.Fatalf("symbol %s in %s range error, start=%v", oneCase.name, oneCase.fileName, start)
+			}
+		}
+
+		if !findFlag {
+			t.Fatalf("symbol %s not found in %s, symbols=%v", oneCase.name, oneCase.fileName, symbolVec)
+		}
+	}
+
+	// big5.lua中定义的全局变量，字符串的内容按big5解码
+	fileName := strRootPath + "/" + "main.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	hoverParams := lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI(fileName),
+		},
+		Position: lsp.Position{
+			Line:      0,
+			Character: 8,
+		},
+	}
+	hoverReturn, err := lspServer.TextDocumentHover(context, hoverParams)
+	if err != nil {
+		t.Fatalf("hover file:%s err=%s", fileName, err.Error())
+	}
+
+	hover, _ := hoverReturn.(MarkupHover)
+	if !strings.Contains(hover.Contents.Value, "\"繁體中文\"") {
+		t.Fatalf("hover gTitle error, contents=%s", hover.Contents.Value)
+	}
+}
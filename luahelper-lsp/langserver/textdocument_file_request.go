@@ -49,7 +49,7 @@ func (l *LspServer)TextDocumentDidOpen(ctx context.Context, vs lsp.DidOpenTextDo
 		// 处理所有的文件变化
 		if project.HandleFileEventChanges(fileEventVec) {
 			// 再一次获取所有诊断信息
-			l.pushAllDiagnosticsAgain(ctx)
+			l.pushChangeDiagnostics(ctx)
 		}
 	}
 
@@ -147,7 +147,7 @@ func (l *LspServer)WorkspaceChangeWatchedFiles(ctx context.Context, vs lsp.DidCh
 		}
 
 		// 再一次获取所有诊断信息
-		l.pushAllDiagnosticsAgain(ctx)
+		l.pushChangeDiagnostics(ctx)
 	}
 
 	// 更新下需要统计的信息
@@ -219,7 +219,7 @@ func (l *LspServer)TextDocumentDidSave(ctx context.Context, vs lsp.DidSaveTextDo
 	}
 
 	// 再一次获取所有诊断信息
-	l.pushAllDiagnosticsAgain(ctx)
+	l.pushChangeDiagnostics(ctx)
 
 	// 文件保存了，清除临时的错误显示, 并且重新推送这个文件的错误信息
 	l.SaveOneFilePushAgain(ctx, strFile)
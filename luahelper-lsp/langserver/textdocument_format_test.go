@@ -0,0 +1,58 @@
+package langserver
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+func TestFormatString(t *testing.T) {
+	// luahelper.json中配置了LOG_INFO与log:warn为格式化字符串的包装函数
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/formatstring")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	project := lspServer.getAllProject()
+
+	fileName := strRootPath + "/" + "main.lua"
+	formatErrVec := []common.CheckError{}
+	for _, oneErr := range project.GetAllFileErrorInfo()[fileName] {
+		if oneErr.ErrType == common.CheckErrorFormatString {
+			formatErrVec = append(formatErrVec, oneErr)
+		}
+	}
+	sort.Slice(formatErrVec, func(i, j int) bool {
+		return formatErrVec[i].Loc.StartLine < formatErrVec[j].Loc.StartLine
+	})
+
+	type formatCase struct {
+		line   int
+		errStr string
+	}
+	caseVec := []formatCase{
+		{3, "format string of 'string.format' needs 2 argument(s), but 1 given"},
+		{4, "format string of 'string.format' needs 1 argument(s), but 2 given"},
+		{5, "bad argument #2 to 'string.format' for '%d' (number expected, got string)"},
+		{8, "invalid conversion '%y' in format string of 'string.format'"},
+		{9, "format string of 'format' needs 2 argument(s), but 1 given"},
+		{10, "bad argument #2 to 'string.format' for '%d' (number has no integer representation)"},
+		{11, "bad argument #2 to 'string.format' for '%q' (value has no literal form)"},
+		{13, "format string of 'LOG_INFO' needs 2 argument(s), but 1 given"},
+		{16, "bad argument #5 to 'log:warn' for '%d' (number expected, got boolean)"},
+		{17, "invalid conversion '%123d' in format string of 'string.format'"},
+	}
+	if len(formatErrVec) != len(caseVec) {
+		t.Fatalf("format err num error, errs=%v", formatErrVec)
+	}
+
+	for i, oneCase := range caseVec {
+		oneErr := formatErrVec[i]
+		if oneErr.Loc.StartLine != oneCase.line || oneErr.ErrStr != oneCase.errStr {
+			t.Fatalf("format err error, expect=%v, err=%v", oneCase, oneErr)
+		}
+	}
+}
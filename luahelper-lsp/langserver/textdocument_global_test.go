@@ -0,0 +1,112 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	"luahelper-lsp/langserver/check/common"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+func TestAccidentalGlobal(t *testing.T) {
+	// luahelper.json中打开了该告警，并配置了IgnoreModules
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/accidentalglobal")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	project := lspServer.getAllProject()
+
+	fileName := strRootPath + "/" + "main.lua"
+	globalErrVec := []common.CheckError{}
+	for _, oneErr := range project.GetAllFileErrorInfo()[fileName] {
+		if oneErr.ErrType == common.CheckErrorAccidentalGlobal {
+			globalErrVec = append(globalErrVec, oneErr)
+		}
+	}
+	sort.Slice(globalErrVec, func(i, j int) bool {
+		return globalErrVec[i].Loc.StartLine < globalErrVec[j].Loc.StartLine
+	})
+
+	// fixLine为0表示不能添加local，例如多个变量的赋值
+	type globalCase struct {
+		line      int
+		name      string
+		fixLine   uint32
+		fixColumn uint32
+	}
+	caseVec := []globalCase{
+		{7, "hits", 6, 4},
+		{15, "helper", 14, 8},
+		{17, "cache", 0, 0},
+	}
+	if len(globalErrVec) != len(caseVec) {
+		t.Fatalf("accidental global err num error, errs=%v", globalErrVec)
+	}
+
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+	context := context.Background()
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	for i, oneCase := range caseVec {
+		oneErr := globalErrVec[i]
+		errStr := "global variable '" + oneCase.name + "' is assigned inside function, but not declared at top level"
+		if oneErr.Loc.StartLine != oneCase.line || oneErr.ErrStr != errStr {
+			t.Fatalf("accidental global err error, expect=%v, err=%v", oneCase, oneErr)
+		}
+
+		// 客户端传回的诊断信息，code经过json解析后为float64
+		diagnostic := lsp.Diagnostic{
+			Range: lsp.Range{
+				Start: lsp.Position{Line: uint32(oneErr.Loc.StartLine - 1), Character: uint32(oneErr.Loc.StartColumn)},
+				End:   lsp.Position{Line: uint32(oneErr.Loc.EndLine - 1), Character: uint32(oneErr.Loc.EndColumn)},
+			},
+			Code: float64(common.CheckErrorAccidentalGlobal),
+		}
+		actionParams := lsp.CodeActionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Range: diagnostic.Range,
+			Context: lsp.CodeActionContext{
+				Diagnostics: []lsp.Diagnostic{diagnostic},
+				Only:        []lsp.CodeActionKind{lsp.QuickFix},
+			},
+		}
+		actions, err := lspServer.TextDocumentCodeAction(context, actionParams)
+		if err != nil {
+			t.Fatalf("code action error, err=%s", err.Error())
+		}
+
+		if oneCase.fixLine == 0 {
+			if len(actions) != 0 {
+				t.Fatalf("add local should not be offered, case=%v, actions=%v", oneCase, actions)
+			}
+			continue
+		}
+
+		if len(actions) != 1 {
+			t.Fatalf("add local action error, case=%v, actions=%v", oneCase, actions)
+		}
+		textEdits := actions[0].Edit.Changes[fileName]
+		if len(textEdits) != 1 || textEdits[0].NewText != "local " ||
+			textEdits[0].Range.Start.Line != oneCase.fixLine || textEdits[0].Range.Start.Character != oneCase.fixColumn {
+			t.Fatalf("add local edit error, case=%v, edits=%v", oneCase, textEdits)
+		}
+	}
+}
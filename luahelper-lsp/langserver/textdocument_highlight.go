@@ -4,7 +4,6 @@ import (
 	"context"
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/log"
-	"luahelper-lsp/langserver/lspcommon"
 	lsp "luahelper-lsp/langserver/protocol"
 )
 
@@ -40,7 +39,7 @@ func (l *LspServer) TextDocumentHighlight(ctx context.Context, vs lsp.TextDocume
 	retVec = make([]lsp.DocumentHighlight, 0, len(referenVecs))
 	for _, referVarInfo := range referenVecs {
 		retVec = append(retVec, lsp.DocumentHighlight{
-			Range: lspcommon.LocToRange(&referVarInfo.Loc),
+			Range: comResult.converter.LocToRange(&referVarInfo.Loc),
 			Kind:  lsp.Write,
 		})
 	}
@@ -8,6 +8,7 @@ import (
 	"luahelper-lsp/langserver/log"
 	lsp "luahelper-lsp/langserver/protocol"
 	"strings"
+	"unicode/utf8"
 )
 
 type MarkupHover struct {
@@ -92,13 +93,21 @@ func (l *LspServer) getHoverStr(comResult commFileRequest) (lableStr, docStr, lu
 		return
 	}
 
-	// 3) 普通查找定义悬浮
+	// 3) 判断是否为table构造中的key，显示期望的class中对应field的注解
+	project := l.getAllProject()
+	lableStr, docStr, luaFileStr = project.GetTableKeyHover(comResult.strFile, (int)(comResult.pos.Line),
+		(int)(comResult.pos.Character))
+	if lableStr != "" {
+		docStr = codingconv.ConvertStrToUtf8(docStr)
+		return
+	}
+
+	// 4) 普通查找定义悬浮
 	varStruct := getVarStruct(comResult.contents, comResult.offset, comResult.pos.Line, comResult.pos.Character)
 	if !varStruct.ValidFlag {
 		log.Error("TextDocumentDefine not valid")
 		return
 	}
-	project := l.getAllProject()
 	lableStr, docStr, luaFileStr = project.GetLspHoverVarStr(comResult.strFile, &varStruct)
 	docStr = codingconv.ConvertStrToUtf8(docStr)
 	return
@@ -106,7 +115,7 @@ func (l *LspServer) getHoverStr(comResult commFileRequest) (lableStr, docStr, lu
 
 // 判断是否悬停提示打开一个文件
 func (l *LspServer) hoverOpenFile(comResult commFileRequest) (fileName string) {
-	fileList := getOpenFileStr(comResult.contents, comResult.offset, (int)(comResult.pos.Character))
+	fileList := getOpenFileStr(comResult.strFile, comResult.contents, comResult.offset, (int)(comResult.pos.Character))
 	if len(fileList) == 0 {
 		return
 	}
@@ -149,7 +158,7 @@ func (l *LspServer) handleAnnotateTypeHover(comResult commFileRequest) (strLabl,
 
 	// 2)
 	flag = true
-	col := (int)(comResult.pos.Character) - (beginIndex + 2)
+	col := (int)(comResult.pos.Character) - (utf8.RuneCountInString(strLine[:beginIndex]) + 2)
 	annotateStr := strLine[beginIndex+2:]
 	project := l.getAllProject()
 	strLabl, strHover, strFile = project.AnnotateTypeHover(comResult.strFile, annotateStr, strWord, (int)(comResult.pos.Line), col)
@@ -404,4 +404,64 @@ func TestHover4(t *testing.T) {
 			t.Fatalf("hover error, not find str=%s, index=%d", resultList[index], index)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestTableKeyHover(t *testing.T) {
+	// table构造中的key，显示期望的class中field的注解
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/tablekey")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "test.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	err = lspServer.TextDocumentDidOpen(context, openParams)
+	if err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	positionList := []lsp.Position{
+		{Line: 20, Character: 15},
+		{Line: 22, Character: 15},
+		{Line: 22, Character: 27},
+	}
+	resultList := [][]string{
+		{"ServerConfig.port : number", "listen port"},
+		{"BaseConfig.name : string", "server name"},
+		{"ServerConfig.db : DBConfig"},
+	}
+
+	for index, onePoisiton := range positionList {
+		hoverParams := lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: onePoisiton,
+		}
+		hoverReturn1, err1 := lspServer.TextDocumentHover(context, hoverParams)
+		if err1 != nil {
+			t.Fatalf("TextDocumentHover file:%s err=%s", fileName, err1.Error())
+		}
+
+		hoverMarkUpReturn1, _ := hoverReturn1.(MarkupHover)
+		for _, strResult := range resultList[index] {
+			if strings.Index(hoverMarkUpReturn1.Contents.Value, strResult) < 0 {
+				t.Fatalf("hover error, not find str=%s, index=%d, hover=%s", strResult, index,
+					hoverMarkUpReturn1.Contents.Value)
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	"luahelper-lsp/langserver/check/common"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLocalAttr(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/localattr")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	project := lspServer.getAllProject()
+
+	fileName := strRootPath + "/" + "main.lua"
+	attrErrVec := []common.CheckError{}
+	for _, oneErr := range project.GetAllFileErrorInfo()[fileName] {
+		if oneErr.ErrType == common.CheckErrorConstAssign || oneErr.ErrType == common.CheckErrorCloseVar {
+			attrErrVec = append(attrErrVec, oneErr)
+		}
+	}
+	sort.Slice(attrErrVec, func(i, j int) bool {
+		return attrErrVec[i].Loc.StartLine < attrErrVec[j].Loc.StartLine
+	})
+
+	type attrCase struct {
+		errType    common.CheckErrorType
+		line       int
+		errStr     string
+		relateLine int
+	}
+	caseVec := []attrCase{
+		{common.CheckErrorCloseVar, 3, "multiple to-be-closed variables in local list", 3},
+		{common.CheckErrorCloseVar, 4, "variable 's' got a non-closable value(string)", 0},
+		{common.CheckErrorCloseVar, 5, "variable 't' got a non-closable value(table without metatable)", 0},
+		{common.CheckErrorConstAssign, 8, "attempt to assign to const variable 'MAX'", 1},
+		{common.CheckErrorConstAssign, 11, "attempt to assign to const variable 'MAX'", 1},
+		{common.CheckErrorConstAssign, 12, "attempt to assign to const variable 'f'", 2},
+	}
+	if len(attrErrVec) != len(caseVec) {
+		t.Fatalf("local attr err num error, errs=%v", attrErrVec)
+	}
+
+	for i, oneCase := range caseVec {
+		oneErr := attrErrVec[i]
+		if oneErr.ErrType != oneCase.errType || oneErr.Loc.StartLine != oneCase.line || oneErr.ErrStr != oneCase.errStr {
+			t.Fatalf("local attr err error, expect=%v, err=%v", oneCase, oneErr)
+		}
+
+		if oneCase.relateLine != 0 && (len(oneErr.RelateVec) != 1 ||
+			oneErr.RelateVec[0].Loc.StartLine != oneCase.relateLine) {
+			t.Fatalf("local attr err relate error, expect=%v, relate=%v", oneCase, oneErr.RelateVec)
+		}
+	}
+
+	// hover显示变量的属性
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+	context := context.Background()
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	hoverParams := lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI(fileName),
+		},
+		Position: lsp.Position{
+			Line:      14,
+			Character: 15,
+		},
+	}
+	hoverReturn, err := lspServer.TextDocumentHover(context, hoverParams)
+	if err != nil {
+		t.Fatalf("TextDocumentHover file:%s err=%s", fileName, err.Error())
+	}
+	hoverMarkUp, _ := hoverReturn.(MarkupHover)
+	if !strings.Contains(hoverMarkUp.Contents.Value, "local MAX <const>") {
+		t.Fatalf("hover error, not find <const>, hover=%s", hoverMarkUp.Contents.Value)
+	}
+
+	// 只读的局部变量定义与引用都着色
+	colorResult := project.FindAllColorVar(fileName)
+	readonlyColor := colorResult[common.CTReadonlyVar]
+	if readonlyColor == nil {
+		t.Fatalf("readonly var color not found")
+	}
+	lineMap := map[int]bool{}
+	for _, oneLoc := range readonlyColor.LocVec {
+		lineMap[oneLoc.StartLine] = true
+	}
+	if !lineMap[1] || !lineMap[15] {
+		t.Fatalf("readonly var color error, locs=%v", readonlyColor.LocVec)
+	}
+}
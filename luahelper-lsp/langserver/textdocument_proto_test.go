@@ -0,0 +1,98 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestProtoMessage(t *testing.T) {
+	// 配置的.proto文件中的message，作为注解的class
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/proto")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "main.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	// 1) 协议函数的参数为同名的message，补全message的字段
+	completionParams := lsp.CompletionParams{
+		TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+			TextDocument: lsp.TextDocumentIdentifier{
+				URI: lsp.DocumentURI(fileName),
+			},
+			Position: lsp.Position{
+				Line:      8,
+				Character: 18,
+			},
+		},
+		Context: lsp.CompletionContext{
+			TriggerKind:      lsp.CompletionTriggerKind(2),
+			TriggerCharacter: ".",
+		},
+	}
+	completionReturn, err := lspServer.TextDocumentComplete(context, completionParams)
+	if err != nil {
+		t.Fatalf("complete file:%s err=%s", fileName, err.Error())
+	}
+
+	completionListTmp, _ := completionReturn.(CompletionListTmp)
+	labelMap := map[string]bool{}
+	for _, item := range completionListTmp.Items {
+		labelMap[item.Label] = true
+	}
+	for _, strLabel := range []string{"account", "roles", "main"} {
+		if !labelMap[strLabel] {
+			t.Fatalf("complete proto field %s failed, items=%v", strLabel, completionListTmp.Items)
+		}
+	}
+
+	// 2) hover显示字段的类型与注释
+	hoverParams := lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: lsp.DocumentURI(fileName),
+		},
+		Position: lsp.Position{
+			Line:      2,
+			Character: 15,
+		},
+	}
+	hoverReturn, err := lspServer.TextDocumentHover(context, hoverParams)
+	if err != nil {
+		t.Fatalf("hover file:%s err=%s", fileName, err.Error())
+	}
+	hoverMarkUp, _ := hoverReturn.(MarkupHover)
+	if !strings.Contains(hoverMarkUp.Contents.Value, "string") || !strings.Contains(hoverMarkUp.Contents.Value, "账号") {
+		t.Fatalf("hover proto field failed, hover=%s", hoverMarkUp.Contents.Value)
+	}
+
+	// 3) 跳转到.proto文件中字段的定义
+	fileRequest := lspServer.beginFileRequest(lsp.DocumentURI(fileName), lsp.Position{Line: 3, Character: 20})
+	varStruct := getVarStruct(fileRequest.contents, fileRequest.offset, fileRequest.pos.Line, fileRequest.pos.Character)
+	locList := defineVecConvert(lspServer.getFileCache().CreateRangeConverter(), lspServer.getAllProject().FindVarDefineInfo(fileRequest.strFile, &varStruct))
+	if len(locList) != 1 || !strings.HasSuffix(string(locList[0].URI), "proto/login.proto") ||
+		locList[0].Range.Start.Line != 6 || locList[0].Range.Start.Character != 11 {
+		t.Fatalf("define proto field failed, loc=%v", locList)
+	}
+}
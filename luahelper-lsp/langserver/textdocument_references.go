@@ -4,7 +4,6 @@ import (
 	"context"
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/log"
-	"luahelper-lsp/langserver/lspcommon"
 	protocol "luahelper-lsp/langserver/protocol"
 )
 
@@ -42,6 +41,7 @@ func (l *LspServer)TextDocumentReferences(ctx context.Context, vs protocol.Refer
 	referenVecs := project.FindReferences(comResult.strFile, &varStruct, common.CRSReference)
 	locList = make([]protocol.Location, 0, len(referenVecs))
 	referenceNum := common.GConfig.ReferenceMaxNum
+	rangeConverter := l.getFileCache().CreateRangeConverter()
 	for i, referVarInfo := range referenVecs {
 		if i >= referenceNum {
 			break
@@ -49,7 +49,7 @@ func (l *LspServer)TextDocumentReferences(ctx context.Context, vs protocol.Refer
 
 		locList = append(locList, protocol.Location{
 			URI:   getFileDocumentURI(referVarInfo.StrFile),
-			Range: lspcommon.LocToRange(&referVarInfo.Loc),
+			Range: rangeConverter.LocToRange(referVarInfo.StrFile, &referVarInfo.Loc),
 		})
 	}
 
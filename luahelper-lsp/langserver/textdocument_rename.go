@@ -4,7 +4,6 @@ import (
 	"context"
 	"luahelper-lsp/langserver/check/common"
 	"luahelper-lsp/langserver/log"
-	"luahelper-lsp/langserver/lspcommon"
 	lsp "luahelper-lsp/langserver/protocol"
 )
 
@@ -30,9 +29,10 @@ func (l *LspServer)TextDocumentRename(ctx context.Context, vs lsp.RenameParams)
 	// 去掉前缀后的名字
 	referenVecs := project.FindReferences(comResult.strFile, &varStruct, common.CRSRename)
 	edit.Changes = map[string][]lsp.TextEdit{}
+	rangeConverter := l.getFileCache().CreateRangeConverter()
 
 	for _, referVarInfo := range referenVecs {
-		retRange := lspcommon.LocToRange(&referVarInfo.Loc)
+		retRange := rangeConverter.LocToRange(referVarInfo.StrFile, &referVarInfo.Loc)
 		uriStr := string(getFileDocumentURI(referVarInfo.StrFile))
 
 		if _, ok := edit.Changes[uriStr]; !ok {
@@ -0,0 +1,61 @@
+package langserver
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestShadowVar(t *testing.T) {
+	// luahelper.json中配置了忽略ignored变量的遮蔽告警
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/shadow")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	project := lspServer.getAllProject()
+
+	fileName := strRootPath + "/" + "main.lua"
+	shadowErrVec := []common.CheckError{}
+	for _, oneErr := range project.GetAllFileErrorInfo()[fileName] {
+		if oneErr.ErrType == common.CheckErrorShadowVar {
+			shadowErrVec = append(shadowErrVec, oneErr)
+		}
+	}
+	sort.Slice(shadowErrVec, func(i, j int) bool {
+		return shadowErrVec[i].Loc.StartLine < shadowErrVec[j].Loc.StartLine
+	})
+
+	type shadowCase struct {
+		line       int
+		errStr     string
+		relateLine int
+	}
+	caseVec := []shadowCase{
+		{2, "param 'count' shadows upvalue 'count' defined at line 1", 1},
+		{4, "local 'i' shadows loop variable 'i' defined at line 3", 3},
+		{7, "local 'name' shadows param 'name' defined at line 2", 2},
+		{8, "local 'gConfig' shadows global 'gConfig' defined in " + strRootPath + "/global.lua", 1},
+		{13, "local 'count' shadows upvalue 'count' defined at line 2", 2},
+	}
+	if len(shadowErrVec) != len(caseVec) {
+		t.Fatalf("shadow err num error, errs=%v", shadowErrVec)
+	}
+
+	for i, oneCase := range caseVec {
+		oneErr := shadowErrVec[i]
+		if oneErr.Loc.StartLine != oneCase.line || oneErr.ErrStr != oneCase.errStr {
+			t.Fatalf("shadow err error, expect=%v, err=%v", oneCase, oneErr)
+		}
+
+		// 被遮蔽的定义作为关联信息
+		if len(oneErr.RelateVec) != 1 || oneErr.RelateVec[0].Loc.StartLine != oneCase.relateLine ||
+			!strings.HasPrefix(oneErr.RelateVec[0].ErrStr, "shadowed ") {
+			t.Fatalf("shadow err relate error, expect=%v, relate=%v", oneCase, oneErr.RelateVec)
+		}
+	}
+}
@@ -17,7 +17,7 @@ func (l *LspServer) TextDocumentSignatureHelp(ctx context.Context, vs lsp.TextDo
 		return
 	}
 
-	pos := vs.Position
+	pos := comResult.pos
 	varStruct := getVarStruct(comResult.contents, comResult.offset, pos.Line, pos.Character)
 	if !varStruct.ValidFlag {
 		return
@@ -22,17 +22,18 @@ func (l *LspServer)TextDocumentSymbol(ctx context.Context, vs lsp.DocumentSymbol
 	fileSymbolVec := project.FindFileAllSymbol(strFile)
 
 	// 将filesymbols 转换为 lsp document
-	itemsResult = transferSymbolVec(fileSymbolVec)
+	converter := l.getFileCache().CreateRangeConverter().GetConverter(strFile)
+	itemsResult = transferSymbolVec(converter, fileSymbolVec)
 	return
 }
 
 // transferSymbolVec 转换fileSybmols为DocumentSymbol
-func transferSymbolVec(fileSymbolVec []common.FileSymbolStruct) (items []lsp.DocumentSymbol) {
+func transferSymbolVec(converter *lspcommon.PosConverter, fileSymbolVec []common.FileSymbolStruct) (items []lsp.DocumentSymbol) {
 	vecLen := len(fileSymbolVec)
 	items = make([]lsp.DocumentSymbol, 0, vecLen)
 
 	for _, oneSymbol := range fileSymbolVec {
-		ra := lspcommon.LocToRange(&oneSymbol.Loc)
+		ra := converter.LocToRange(&oneSymbol.Loc)
 
 		fullName := oneSymbol.Name
 		if oneSymbol.ContainerName != "" {
@@ -51,7 +52,7 @@ func transferSymbolVec(fileSymbolVec []common.FileSymbolStruct) (items []lsp.Doc
 		}
 
 		if oneSymbol.Children != nil {
-			symbol.Children = transferSymbolVec(oneSymbol.Children)
+			symbol.Children = transferSymbolVec(converter, oneSymbol.Children)
 		}
 		if oneSymbol.Kind == common.IKAnnotateAlias {
 			symbol.Kind = lsp.Interface
@@ -0,0 +1,147 @@
+package langserver
+
+import (
+	"context"
+	"io/ioutil"
+	"luahelper-lsp/langserver/check/common"
+	lsp "luahelper-lsp/langserver/protocol"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestTlogXML(t *testing.T) {
+	// 配置的tlog xml中的struct，用于打tlog日志的函数调用
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/tlog")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	context := context.Background()
+
+	fileName := strRootPath + "/" + "main.lua"
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("read file:%s err=%s", fileName, err.Error())
+	}
+
+	openParams := lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{
+			URI:  lsp.DocumentURI(fileName),
+			Text: string(data),
+		},
+	}
+	if err := lspServer.TextDocumentDidOpen(context, openParams); err != nil {
+		t.Fatalf("didopen file:%s err=%s", fileName, err.Error())
+	}
+
+	getCompleteLabels := func(line, character uint32) []string {
+		completionParams := lsp.CompletionParams{
+			TextDocumentPositionParams: lsp.TextDocumentPositionParams{
+				TextDocument: lsp.TextDocumentIdentifier{
+					URI: lsp.DocumentURI(fileName),
+				},
+				Position: lsp.Position{
+					Line:      line,
+					Character: character,
+				},
+			},
+			Context: lsp.CompletionContext{
+				TriggerKind: lsp.CompletionTriggerKind(1),
+			},
+		}
+		completionReturn, err := lspServer.TextDocumentComplete(context, completionParams)
+		if err != nil {
+			t.Fatalf("complete file:%s err=%s", fileName, err.Error())
+		}
+
+		completionListTmp, _ := completionReturn.(CompletionListTmp)
+		labelVec := []string{}
+		for _, item := range completionListTmp.Items {
+			labelVec = append(labelVec, item.Label)
+		}
+		return labelVec
+	}
+
+	// 1) 第一个参数字符串中，补全所有的struct名称
+	if labels := strings.Join(getCompleteLabels(6, 6), ","); labels != "PlayerLogin,PlayerLogout" {
+		t.Fatalf("complete tlog struct failed, labels=%s", labels)
+	}
+
+	// 2) 第二个参数的table中，补全struct的字段
+	labelMap := map[string]bool{}
+	for _, strLabel := range getCompleteLabels(9, 22) {
+		labelMap[strLabel] = true
+	}
+	if len(labelMap) != 2 || !labelMap["GameSvrId"] || !labelMap["Level"] {
+		t.Fatalf("complete tlog entry failed, labels=%v", labelMap)
+	}
+
+	// 3) struct名称与字段跳转到xml中的定义
+	project := lspServer.getAllProject()
+	xmlFile := strRootPath + "/" + "tlog.xml"
+	type defineCase struct {
+		line      int
+		character int
+		defLine   uint32
+		defChar   uint32
+	}
+	for _, oneCase := range []defineCase{{6, 10, 2, 18}, {6, 25, 3, 21}, {7, 38, 7, 21}} {
+		locList := defineVecConvert(lspServer.getFileCache().CreateRangeConverter(), project.FindTlogDefine(fileName, oneCase.line, oneCase.character))
+		if len(locList) != 1 || !strings.HasSuffix(string(locList[0].URI), "tlog.xml") ||
+			locList[0].Range.Start.Line != oneCase.defLine || locList[0].Range.Start.Character != oneCase.defChar {
+			t.Fatalf("tlog define failed, case=%v, file=%s, locList=%v", oneCase, xmlFile, locList)
+		}
+	}
+
+	// 4) 未定义的struct或是字段告警
+	errStrVec := []string{}
+	for _, oneErr := range project.GetAllFileErrorInfo()[fileName] {
+		if oneErr.ErrType == common.CheckErrorTlog {
+			errStrVec = append(errStrVec, oneErr.ErrStr)
+		}
+	}
+	if strings.Join(errStrVec, ";") != "tlog struct PlayerLogout has no entry: Unknown;tlog struct not found: NoStruct" {
+		t.Fatalf("tlog check failed, errs=%v", errStrVec)
+	}
+}
+
+func TestTlogXMLFolders(t *testing.T) {
+	// 工作区文件夹的luahelper.json配置了自己的tlog xml，文件夹内的文件使用该xml检查
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strFoldersPath, _ := filepath.Abs(paths + "../testdata/tlogfolders")
+	strRootPath := strFoldersPath + "/root"
+	strFolderA := strFoldersPath + "/folderA"
+	lspServer := createFoldersLspTest(strRootPath, []string{strFolderA})
+	project := lspServer.getAllProject()
+
+	getTlogErrStr := func(fileName string) string {
+		errStrVec := []string{}
+		for _, oneErr := range project.GetAllFileErrorInfo()[fileName] {
+			if oneErr.ErrType == common.CheckErrorTlog {
+				errStrVec = append(errStrVec, oneErr.ErrStr)
+			}
+		}
+		return strings.Join(errStrVec, ";")
+	}
+
+	rootFile := strRootPath + "/root.lua"
+	if errStr := getTlogErrStr(rootFile); errStr != "tlog struct not found: GuildCreate" {
+		t.Fatalf("root tlog check failed, errs=%s", errStr)
+	}
+
+	folderAFile := strFolderA + "/folderA.lua"
+	if errStr := getTlogErrStr(folderAFile); errStr != "tlog struct not found: PlayerLogin" {
+		t.Fatalf("folderA tlog check failed, errs=%s", errStr)
+	}
+
+	// struct名称跳转到文件夹配置的xml中
+	locList := defineVecConvert(lspServer.getFileCache().CreateRangeConverter(), project.FindTlogDefine(folderAFile, 0, 8))
+	if len(locList) != 1 || !strings.HasSuffix(string(locList[0].URI), "log/guild.xml") || locList[0].Range.Start.Line != 2 {
+		t.Fatalf("folderA tlog define failed, locList=%v", locList)
+	}
+}
@@ -0,0 +1,56 @@
+package langserver
+
+import (
+	"luahelper-lsp/langserver/check/common"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+func TestUnusedParamLabelResult(t *testing.T) {
+	_, filename, _, _ := runtime.Caller(0)
+	paths, _ := filepath.Split(filename)
+
+	strRootPath, _ := filepath.Abs(paths + "../testdata/unused")
+	strRootURI := "file://" + strRootPath
+	lspServer := createLspTest(strRootPath, strRootURI)
+	project := lspServer.getAllProject()
+
+	fileName := strRootPath + "/" + "main.lua"
+	unusedErrVec := []common.CheckError{}
+	for _, oneErr := range project.GetAllFileErrorInfo()[fileName] {
+		switch oneErr.ErrType {
+		case common.CheckErrorUnusedParam, common.CheckErrorUnusedLabel, common.CheckErrorDiscardedResult:
+			unusedErrVec = append(unusedErrVec, oneErr)
+		}
+	}
+	sort.Slice(unusedErrVec, func(i, j int) bool {
+		return unusedErrVec[i].Loc.StartLine < unusedErrVec[j].Loc.StartLine
+	})
+
+	type unusedCase struct {
+		errType common.CheckErrorType
+		line    int
+		errStr  string
+	}
+	caseVec := []unusedCase{
+		{common.CheckErrorUnusedParam, 1, "unused parameter 'b'"},
+		{common.CheckErrorUnusedParam, 6, "unused parameter 'dt'"},
+		{common.CheckErrorUnusedLabel, 24, "label 'skip' defined and not used"},
+		{common.CheckErrorDiscardedResult, 25, "result of 'string.format' is discarded, the call has no side effect"},
+		{common.CheckErrorDiscardedResult, 26, "result of 'math.max' is discarded, the call has no side effect"},
+		{common.CheckErrorDiscardedResult, 28, "result of 'table.concat' is discarded, the call has no side effect"},
+		{common.CheckErrorDiscardedResult, 30, "result of 'string.upper' is discarded, the call has no side effect"},
+	}
+	if len(unusedErrVec) != len(caseVec) {
+		t.Fatalf("unused err num error, errs=%v", unusedErrVec)
+	}
+
+	for i, oneCase := range caseVec {
+		oneErr := unusedErrVec[i]
+		if oneErr.ErrType != oneCase.errType || oneErr.Loc.StartLine != oneCase.line || oneErr.ErrStr != oneCase.errStr {
+			t.Fatalf("unused err error, expect=%v, err=%v", oneCase, oneErr)
+		}
+	}
+}
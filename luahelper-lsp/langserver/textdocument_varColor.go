@@ -3,7 +3,6 @@ package langserver
 import (
 	"context"
 	"luahelper-lsp/langserver/log"
-	"luahelper-lsp/langserver/lspcommon"
 	"luahelper-lsp/langserver/pathpre"
 	lsp "luahelper-lsp/langserver/protocol"
 )
@@ -32,6 +31,7 @@ func (l *LspServer)TextDocumentGetVarColor(ctx context.Context, vs GetColorParam
 	}
 
 	colorResult := project.FindAllColorVar(strFile)
+	converter := l.getFileCache().CreateRangeConverter().GetConverter(strFile)
 	allLen := 0
 	for _, oneColor := range colorResult {
 		allLen = allLen + len(oneColor.LocVec)
@@ -45,7 +45,7 @@ func (l *LspServer)TextDocumentGetVarColor(ctx context.Context, vs GetColorParam
 		}
 
 		for _, oneLoc := range oneColor.LocVec {
-			oneRange := lspcommon.LocToRange(&oneLoc)
+			oneRange := converter.LocToRange(&oneLoc)
 			oneAnno.Ranges = append(oneAnno.Ranges, oneRange)
 		}
 		annolist = append(annolist, oneAnno)
@@ -67,6 +67,7 @@ func (l *LspServer)TextDocumentColor(ctx context.Context,colorParams lsp.Documen
 	}
 
 	colorResult := project.FindAllColorVar(strFile)
+	converter := l.getFileCache().CreateRangeConverter().GetConverter(strFile)
 	allLen := 0
 	for _, oneColor := range colorResult {
 		allLen = allLen + len(oneColor.LocVec)
@@ -76,7 +77,7 @@ func (l *LspServer)TextDocumentColor(ctx context.Context,colorParams lsp.Documen
 	for _, oneColor := range colorResult {
 		for _, oneLoc := range oneColor.LocVec {
 			oneAnno := lsp.ColorInformation{
-				Range: lspcommon.LocToRange(&oneLoc),
+				Range: converter.LocToRange(&oneLoc),
 				Color: lsp.Color {
 					Red:0.5,
 					Green:0.5,
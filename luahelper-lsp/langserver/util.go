@@ -17,7 +17,9 @@ type commFileRequest struct {
 	strFile  string       // 文件名
 	contents []byte       // 文件具体的内容
 	offset   int          // 光标的偏移
-	pos      lsp.Position // 请求的行与列
+	pos      lsp.Position // 请求的行与列，列已经转换为按字符计数
+
+	converter *lspcommon.PosConverter // 文件中位置的转换，按协商的位置编码
 }
 
 // beginFileRequest 通用的文件处理请求预处理
@@ -38,7 +40,8 @@ func (l *LspServer) beginFileRequest(url lsp.DocumentURI, pos lsp.Position) (fil
 		return
 	}
 
-	offset, err := lspcommon.OffsetForPosition(contents, (int)(pos.Line), (int)(pos.Character))
+	converter := lspcommon.CreatePosConverter(contents)
+	offset, err := converter.PositionToOffset(pos)
 	if err != nil {
 		log.Error("file position error=%s", err.Error())
 		return
@@ -48,7 +51,8 @@ func (l *LspServer) beginFileRequest(url lsp.DocumentURI, pos lsp.Position) (fil
 	fileRequest.strFile = strFile
 	fileRequest.contents = contents
 	fileRequest.offset = offset
-	fileRequest.pos = pos
+	fileRequest.pos = converter.ToRunePosition(pos)
+	fileRequest.converter = converter
 	return
 }
 
@@ -192,7 +196,8 @@ func getCompeleteLineStr(contents []byte, offset int) (lineStr string) {
 // getOpenFileStr 判断是否为打开的文件，返回文件名
 // 当为require时候，可能返回两个文件名， 因为require("one") 含义可能是包含one.lua 也有可能是one/init.lua
 // firstStr 为优先级高的文件名，secondStr为优先级次之的文件名
-func getOpenFileStr(contents []byte, offset int, character int) []string {
+// strFile 为当前的文件，多根工作区时不同的工作区文件夹可能配置了不同的引入方式
+func getOpenFileStr(strFile string, contents []byte, offset int, character int) []string {
 	// 获取当前行的所有内容
 	lineContents := getCompeleteLineStr(contents, offset)
 
@@ -219,7 +224,7 @@ func getOpenFileStr(contents []byte, offset int, character int) []string {
 	}
 
 	if len(importVec) == 0 {
-		referFiles := common.GConfig.GetFrameReferFiles()
+		referFiles := common.GConfig.GetFileConfig(strFile).GetFrameReferFiles()
 		for _, strOne := range referFiles {
 			regImport1 := regexp.MustCompile(strOne + ` *?(\()? *?[\"|\'][0-9a-zA-Z_/|.\-]+.lua+[\"|\'] *?(\))?`)
 			importVec = regImport1.FindAllString(lineContents, -1)
@@ -38,11 +38,20 @@ func (l *LspServer)addWorkspaceFolder(ctx context.Context, dirpath string) {
 	}
 	dirManager.PushOneSubDir(dirpath)
 
+	// 工作区文件夹可以有自己的luahelper.json配置，读取失败时使用主工程的配置
+	if readErr := common.GConfig.AddFolderConfig(dirpath, "luahelper.json"); readErr != nil {
+		log.Error("read folder config err: %s", readErr.Error())
+	}
+
 	allProject := l.getAllProject()
 
 	// 获取当前文件夹下所有lua文件
 	addFiles := dirManager.GetDirFileList(dirpath, false)
 
+	// 子目录下的luahelper.json覆盖上层目录的配置
+	loadNestedConfigs(addFiles)
+	l.pushConfigDiagnostics(ctx)
+
 	// 设置所有增加文件的事件
 	addFileEvent := make([]check.FileEventStruct, 0, len(addFiles))
 	for _, file := range addFiles {
@@ -77,6 +86,8 @@ func (l *LspServer)removeWorkspaceFolder(ctx context.Context, dirpath string) {
 	if !dirManager.RemoveOneSubDir(dirpath) {
 		log.Debug("remove error :%s", dirpath)	
 	}
+	common.GConfig.RemoveFolderConfig(dirpath)
+	l.pushConfigDiagnostics(ctx)
 
 	// 若移除的是subDirs 中的文件夹， 则直接进行所有文件删除处理
 	allProject := l.getAllProject()
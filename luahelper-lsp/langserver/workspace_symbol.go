@@ -3,7 +3,6 @@ package langserver
 import (
 	"context"
 	"luahelper-lsp/langserver/check/common"
-	"luahelper-lsp/langserver/lspcommon"
 	lsp "luahelper-lsp/langserver/protocol"
 )
 
@@ -14,11 +13,12 @@ func (l *LspServer)WorkspaceSymbolRequest(ctx context.Context, vs lsp.WorkspaceS
 
 	vecLen := len(fileSymbolVec)
 	items = make([]lsp.SymbolInformation, 0, vecLen)
+	rangeConverter := l.getFileCache().CreateRangeConverter()
 
 	for _, oneSymbol := range fileSymbolVec {
 		loc := lsp.Location{
 			URI:   getFileDocumentURI(oneSymbol.FileName),
-			Range: lspcommon.LocToRange(&oneSymbol.Loc),
+			Range: rangeConverter.LocToRange(oneSymbol.FileName, &oneSymbol.Loc),
 		}
 
 		item := lsp.SymbolInformation{
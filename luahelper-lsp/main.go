@@ -2,7 +2,9 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	_ "net/http/pprof"
@@ -24,8 +26,15 @@ func main() {
 	modeFlag := flag.Int("mode", 0, "mode type, 0 is run cmd, 1 is local rpc, 2 is socket rpc")
 	logFlag := flag.Int("logflag", 0, "0 is not open log, 1 is open log")
 	localpath := flag.String("localpath", "", "local project path")
+	schemaPath := flag.String("schema", "", "write the json schema of luahelper.json to the path and exit")
 	flag.Parse()
 
+	// 生成luahelper.json的json schema
+	if *schemaPath != "" {
+		writeConfigSchema(*schemaPath)
+		return
+	}
+
 	// 是否开启日志
 	enableLog := false
 	if *logFlag == 1 {
@@ -53,6 +62,19 @@ func main() {
 	}
 }
 
+// writeConfigSchema 生成luahelper.json的json schema，写到指定的文件
+func writeConfigSchema(schemaPath string) {
+	data, err := common.GetJSONConfigSchema()
+	if err == nil {
+		err = ioutil.WriteFile(schemaPath, append(data, '\n'), 0644)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write schema err: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
 //cmd 的方式运行rpc
 func cmdRPC() {
 	log.Debug("local stat running ....")
@@ -0,0 +1,27 @@
+local total = 0
+counter = 0
+
+local function inc()
+    counter = counter + 1
+    total = total + 1
+    hits = (hits or 0) + 1
+    _G.version = 2
+    skynet = {}
+end
+
+local function reset()
+    hits = 0
+    if total > 10 then
+        function helper() end
+    end
+    cache, counter = {}, 0
+    print(version, helper, cache)
+end
+
+function later()
+    late = 1
+end
+
+late = 0
+inc()
+reset()
@@ -0,0 +1,21 @@
+local M = {}
+
+---@overload fun(a:number):number
+---@param a number
+---@param b number
+---@return number
+function M.add(a, b)
+    return a + b
+end
+
+function M:run(speed, ...)
+    return speed
+end
+
+function M.log(...)
+end
+
+M.add(1, 2)
+M:run(1)
+M.log(1)
+M.run(M, 1)
@@ -0,0 +1,25 @@
+local Base = {}
+
+local M = setmetatable({}, {__index = Base})
+M.count = 1
+M.name = "module"
+M.list = {}
+
+function M:add(num)
+    self.count = self.count + num
+    return self.count > 10
+end
+
+function M.create(name)
+    return name
+end
+
+Player = {
+    level = 1,
+}
+setmetatable(Player, {__index = M})
+Player.enabled = true
+
+---@class Annotated
+local Annotated = {}
+Annotated.a = 1
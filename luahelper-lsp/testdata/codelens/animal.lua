@@ -0,0 +1,8 @@
+---@class Animal
+---@field speak fun(self:Animal):string
+local Animal = {}
+
+function Animal:move()
+end
+
+return Animal
@@ -0,0 +1,20 @@
+local Animal = require("animal")
+
+---@class Dog : Animal
+local Dog = {}
+
+function Dog:speak()
+    return "wang"
+end
+
+function Dog:move()
+    self:speak()
+end
+
+local function newDog()
+    return Dog
+end
+
+newDog():speak()
+newDog()
+Dog:move()
@@ -0,0 +1,2 @@
+gMainValue = 1
+print(baseModule)
@@ -0,0 +1 @@
+print(gMainValue, subModule)
@@ -0,0 +1,61 @@
+local function afterReturn(a)
+    if a then
+        return 1
+    else
+        return 2
+    end
+    print("dead")
+end
+
+local function afterError(a)
+    error("fail")
+    print(a)
+    print("dead")
+end
+
+local function missingReturn(a)
+    if a > 0 then
+        return a
+    end
+end
+
+local function loopBreak(list)
+    for _, v in ipairs(list) do
+        if v then
+            break
+            print(v)
+        end
+    end
+
+    while true do
+        os.exit(1)
+    end
+    return 0
+end
+
+local function gotoSkip(a)
+    goto done
+    print(a)
+    ::done::
+    if a then
+        return a
+    end
+    return nil
+end
+
+local function infiniteLoop()
+    while true do
+        coroutine.yield()
+    end
+    return 1
+end
+
+local function repeatLoop(a)
+    repeat
+        if a then
+            return a
+        end
+    until false
+end
+
+print(afterReturn, afterError, missingReturn, loopBreak, gotoSkip, infiniteLoop, repeatLoop)
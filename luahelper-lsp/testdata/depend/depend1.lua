@@ -0,0 +1,3 @@
+local depend2 = require("depend2")
+
+depend2.func1()
@@ -0,0 +1,7 @@
+local depend2 = {}
+
+function depend2.func1()
+    gDependValue = 1
+end
+
+return depend2
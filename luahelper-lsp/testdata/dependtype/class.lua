@@ -0,0 +1,5 @@
+---@class Player
+---@field name string
+local Player = {}
+
+return Player
@@ -0,0 +1,4 @@
+---@param player Player
+function show_player(player)
+    print(player.name)
+end
@@ -0,0 +1,6 @@
+local moda = require("moda")
+local unused = 2
+
+moda.run()
+moda_global()
+print(main_undefined)
@@ -0,0 +1,11 @@
+---@class ModA
+local M = {}
+
+function M.run()
+    local unused = 1
+end
+
+function moda_global()
+end
+
+return M
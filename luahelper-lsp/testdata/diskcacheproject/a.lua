@@ -0,0 +1,9 @@
+local M = {}
+
+function M.run()
+end
+
+function a_global()
+end
+
+return M
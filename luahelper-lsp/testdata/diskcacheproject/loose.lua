@@ -0,0 +1,3 @@
+local loose_unused = 1
+
+print(loose_undefined)
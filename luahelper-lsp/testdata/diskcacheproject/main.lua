@@ -0,0 +1,5 @@
+local a = require("a")
+local unused = 2
+
+a.run()
+a_global()
@@ -0,0 +1,9 @@
+-- 使用说明见 https://github.com/Tencent/LuaHelper
+local helper = require("util.helper")
+local other = require 'not_exist'
+
+---@see util/helper.lua
+local function test()
+    dofile("util/helper.lua")
+    return helper.add(1, 2)
+end
@@ -0,0 +1,7 @@
+local helper = {}
+
+function helper.add(a, b)
+    return a + b
+end
+
+return helper
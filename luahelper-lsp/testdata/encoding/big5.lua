@@ -0,0 +1,4 @@
+-- c餤媺
+local s = "c" local abc = 1
+gTitle = "c餤"
+return abc
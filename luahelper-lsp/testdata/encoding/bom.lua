@@ -0,0 +1,2 @@
+﻿local ming = "中文😀" local def = 2
+return def
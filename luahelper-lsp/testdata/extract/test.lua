@@ -0,0 +1,24 @@
+local function compute(list, base, ...)
+    local total = base
+    local count = 0
+    for _, v in ipairs(list) do
+        total = total + v * 2
+        count = count + 1
+    end
+    print(total, count, ...)
+    return total / count
+end
+
+local function search(list, value)
+    for i, v in ipairs(list) do
+        if v == value then
+            return i
+        end
+        if v > value then
+            break
+        end
+    end
+    while #list > 0 do
+        table.remove(list)
+    end
+end
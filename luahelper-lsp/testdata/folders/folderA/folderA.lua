@@ -0,0 +1,8 @@
+gFolderAValue = 1
+
+-- folderA 关联了folderB，可以看到folderB中定义的全局变量
+print(gFolderBValue)
+
+-- folderA 配置的loadA为类似require的引入方式
+local moduleA = loadA("moduleA")
+print(moduleA.valueA)
@@ -0,0 +1,5 @@
+local M = {}
+
+M.valueA = 1
+
+return M
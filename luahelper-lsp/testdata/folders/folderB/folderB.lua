@@ -0,0 +1 @@
+gFolderBValue = 2
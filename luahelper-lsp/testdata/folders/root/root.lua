@@ -0,0 +1,2 @@
+-- 主工程目录，看不到folderA中定义的全局变量
+print(gFolderAValue)
@@ -0,0 +1,17 @@
+local n = 3
+local name = "box"
+print(string.format("%d items for %s", n))
+print(string.format("%d items", n, name))
+print(string.format("%d items", "many"))
+print(string.format("%d items", "10"))
+print(string.format("%5.2f%%", 1.5))
+print(string.format("%y", n))
+print(("%s and %s"):format(name))
+print(string.format("%d", 1.5))
+print(string.format("%q", {}))
+print(string.format("%s %s", name, table.unpack({})))
+LOG_INFO("player %s level %d", name)
+local log = {}
+function log:warn(level, fmt, ...) print(level, fmt, ...) end
+log:warn(1, "%s lost %d", name, true)
+print(string.format("%123d", n))
@@ -0,0 +1,52 @@
+---@param name string
+---@param count number
+function annotated_func(name, count)
+    return name, count
+end
+
+local function check_type(a, b)
+    if type(a) == "string" then
+        return a
+    elseif type(a) == "table" then
+        return a.name
+    end
+
+    b()
+    return nil
+end
+
+function use_annotated(x, y, z)
+    annotated_func(x, y)
+    for k, v in pairs(z) do
+        print(k, v)
+    end
+
+    return true
+end
+
+local M = {}
+
+function M:usage(p, q, r, s)
+    local len = p:sub(1, 2)
+    if q == "ok" then
+        return q .. len, r.field ~= nil
+    end
+
+    local num = s + 1
+    return num
+end
+
+local shadow = function(v)
+    local function inner(v)
+        return v.x
+    end
+    return inner(v)
+end
+
+--- already has a comment
+---@param a number
+local function done(a)
+    return a
+end
+
+return M
@@ -0,0 +1,4 @@
+require("moda")
+require("modb")
+
+moda_func()
@@ -0,0 +1,2 @@
+function moda_func()
+end
@@ -0,0 +1 @@
+print(modb_undefined)
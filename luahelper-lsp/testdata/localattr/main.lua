@@ -0,0 +1,16 @@
+local MAX <const> = 10
+local f <close> = nil
+local a <close>, b <close> = nil, nil
+local s <close> = "text"
+local t <close> = {}
+local obj <close> = setmetatable({}, {__close = function() end})
+
+MAX = 20
+
+local function update()
+    MAX = 30
+    f = nil
+end
+
+local count = MAX + 1
+print(count, a, b, s, t, obj, update)
@@ -0,0 +1,12 @@
+---@param msg LoginReq
+local function handle(msg)
+    print(msg.account)
+    print(msg.main.name)
+end
+
+function c2s.LoginReq(req)
+    print(req.account)
+    local a = req.roles
+end
+
+handle({})
@@ -0,0 +1 @@
+local a = = 1
@@ -0,0 +1 @@
+local b = (2
@@ -0,0 +1,6 @@
+local buff = {}
+
+function buff.add()
+end
+
+return buff
@@ -0,0 +1,6 @@
+local skill = {}
+
+function skill.cast()
+end
+
+return skill
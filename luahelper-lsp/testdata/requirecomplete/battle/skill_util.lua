@@ -0,0 +1,7 @@
+function skill_global()
+end
+
+SkillConfig = {}
+
+function SkillConfig.get()
+end
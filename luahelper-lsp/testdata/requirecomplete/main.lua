@@ -0,0 +1,11 @@
+-- main module
+local buff = require("battle.buff")
+
+local M = {}
+
+function M.run()
+    buff.add()
+    local a = sk
+end
+
+return M
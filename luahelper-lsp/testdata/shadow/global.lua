@@ -0,0 +1 @@
+gConfig = {}
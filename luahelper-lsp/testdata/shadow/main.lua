@@ -0,0 +1,23 @@
+local count = 1
+local function handler(count, name)
+    for i = 1, 10 do
+        local i = i * 2
+        print(i)
+    end
+    local name = "x"
+    local gConfig = {}
+    local _ = 1
+    local _ = 2
+    local ignored = 1
+    return function()
+        local count = 2
+        local ignored = 2
+        local print = print
+        for _, v in pairs(gConfig) do
+            print(v)
+        end
+        return count + ignored + name + #gConfig
+    end
+end
+local count = 3
+print(count, handler, ignored)
@@ -0,0 +1,25 @@
+---@class BaseConfig
+---@field name string @server name
+local BaseConfig = {}
+
+---@class DBConfig
+---@field host string
+---@field port number
+local DBConfig = {}
+
+---@class ServerConfig : BaseConfig
+---@field port number @listen port
+---@field db DBConfig
+---@field debug boolean
+local ServerConfig = {}
+
+---@param cfg ServerConfig
+local function startServer(cfg)
+end
+
+---@type ServerConfig
+local cfg = { port = 80, de }
+
+startServer({ name = "a", db = { ho } })
+startServer({ })
+print(cfg)
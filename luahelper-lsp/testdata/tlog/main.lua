@@ -0,0 +1,11 @@
+local logger = {}
+
+function logger:TlogWrite(name, data)
+    print(name, data)
+end
+
+tlog("PlayerLogin", { GameSvrId = "1", Level = 10 })
+logger:TlogWrite("PlayerLogout", { OnlineTime = 10, Unknown = 1 })
+tlog("NoStruct", {})
+tlog("PlayerLogin", {  })
+print(logger)
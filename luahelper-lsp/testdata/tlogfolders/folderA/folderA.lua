@@ -0,0 +1,2 @@
+tlog("GuildCreate", { GuildId = 1 })
+tlog("PlayerLogin", { Level = 10 })
@@ -0,0 +1,2 @@
+tlog("PlayerLogin", { Level = 10 })
+tlog("GuildCreate", { GuildId = 1 })
@@ -0,0 +1,36 @@
+local function add(a, b, _c)
+    return a
+end
+
+local obj = {}
+function obj:update(dt, _)
+    return self
+end
+
+local function outer(x)
+    return function()
+        return x
+    end
+end
+
+for i = 1, 3 do
+    if i == 2 then
+        goto continue
+    end
+    print(i)
+    ::continue::
+end
+
+::skip::
+string.format("%d", 1)
+math.max(1, 2)
+math.randomseed(1)
+table.concat({"a"}, ",")
+table.insert(obj, 1);
+("abc"):upper()
+local s = string.rep("a", 2)
+print(add(1, 2), outer(1), s)
+do
+    local string = {format = print}
+    string.format("x")
+end
@@ -7,6 +7,7 @@ import * as notifications from "./notifications";
 let D_Global_Var: vscode.TextEditorDecorationType;
 let D_Global_Func: vscode.TextEditorDecorationType;
 let D_Annotate_Type: vscode.TextEditorDecorationType;
+let D_Readonly_Var: vscode.TextEditorDecorationType;
 
 function createDecoration(key: string, config: vscode.DecorationRenderOptions | undefined = undefined): vscode.TextEditorDecorationType {
     let color = vscode.workspace.getConfiguration("luahelper").get(key);
@@ -22,6 +23,7 @@ function updateDecorations() {
     D_Global_Var = createDecoration("colors.Global Field Color");
     D_Global_Func = createDecoration("colors.Global Fun Color");
     D_Annotate_Type = createDecoration("colors.Type(annotation) Color");
+    D_Readonly_Var = createDecoration("colors.Readonly Local Color");
 }
 
 export function onDidChangeConfiguration(client: LanguageClient) {
@@ -58,6 +60,7 @@ function requestAnnotatorsImpl(editor: vscode.TextEditor, client: LanguageClient
         let map: Map<AnnotatorType, vscode.Range[]> = new Map();
         map.set(AnnotatorType.GlobalVar, []);
         map.set(AnnotatorType.GlobalFunc, []);
+        map.set(AnnotatorType.ReadonlyVar, []);
 
         if (list !== undefined && list !== null) {
             list.forEach(data => {
@@ -93,5 +96,8 @@ function updateAnnotators(editor: vscode.TextEditor, type: AnnotatorType, ranges
         case AnnotatorType.AnnotateType:
             editor.setDecorations(D_Annotate_Type, ranges);
             break;
+        case AnnotatorType.ReadonlyVar:
+            editor.setDecorations(D_Readonly_Var, ranges);
+            break;
     }
 }
\ No newline at end of file
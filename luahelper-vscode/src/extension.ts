@@ -20,6 +20,8 @@ import { OnlinePeople } from './onlinePeople';
 import {
     LanguageClient,
     LanguageClientOptions,
+    Location,
+    Position,
     ServerOptions,
     StreamInfo,
 } from 'vscode-languageclient/node';
@@ -77,6 +79,8 @@ export function activate(context: vscode.ExtensionContext) {
     savedContext.subscriptions.push(vscode.commands.registerCommand("LuaHelper.openDebugFolder", openDebugFolder));
     // 设置格式化配置
     savedContext.subscriptions.push(vscode.commands.registerCommand("LuaHelper.setFormatConfig", setFormatConfig));
+    // codeLens点击后显示引用列表
+    savedContext.subscriptions.push(vscode.commands.registerCommand("LuaHelper.showReferences", showReferences));
 
     savedContext.subscriptions.push(vscode.languages.setLanguageConfiguration("lua", new LuaLanguageConfiguration()));
 
@@ -225,6 +229,17 @@ async function doStartServer() {
         }
     }
 
+    let telemetryConfig = vscode.workspace.getConfiguration("luahelper.telemetry", null);
+    let telemetryOptions = {
+        enable: telemetryConfig.get<boolean>("enable", false),
+        endpoint: telemetryConfig.get<string>("endpoint", ""),
+        logPath: telemetryConfig.get<string>("logPath", ""),
+    };
+
+    let diskCacheConfig = vscode.workspace.getConfiguration("luahelper.project", null).get<boolean>("diskCache", true);
+    let callSnippetConfig = vscode.workspace.getConfiguration("luahelper.completion", null).get<boolean>("callSnippet", true);
+    let diagnosticDocUrl = vscode.workspace.getConfiguration("luahelper.diagnostic", null).get<string>("docUrl", "");
+
     let ignoreFileOrDirArr: string[] | undefined = vscode.workspace.getConfiguration("luahelper.project", null).get("ignoreFileOrDir");
     let ignoreFileOrDirErrArr: string[] | undefined = vscode.workspace.getConfiguration("luahelper.project", null).get("ignoreFileOrDirError");
 
@@ -259,9 +274,25 @@ async function doStartServer() {
             CheckErrorAndAlwaysFalse: getWarnCheckFlag("CheckErrorAndAlwaysFalse"),
             CheckNoUseAssign: getWarnCheckFlag("CheckNoUseAssign"),
             CheckAnnotateType: getWarnCheckFlag("CheckAnnotateType"),
+            CheckConfig: getWarnCheckFlag("CheckConfig"),
+            CheckTlog: getWarnCheckFlag("CheckTlog"),
+            CheckShadowVar: getWarnCheckFlag("CheckShadowVar"),
+            CheckUnreachable: getWarnCheckFlag("CheckUnreachable"),
+            CheckMissingReturn: getWarnCheckFlag("CheckMissingReturn"),
+            CheckConstAssign: getWarnCheckFlag("CheckConstAssign"),
+            CheckCloseVar: getWarnCheckFlag("CheckCloseVar"),
+            CheckFormatString: getWarnCheckFlag("CheckFormatString"),
+            CheckAccidentalGlobal: getWarnCheckFlag("CheckAccidentalGlobal"),
+            CheckUnusedParam: getWarnCheckFlag("CheckUnusedParam"),
+            CheckUnusedLabel: getWarnCheckFlag("CheckUnusedLabel"),
+            CheckDiscardedResult: getWarnCheckFlag("CheckDiscardedResult"),
             IgnoreFileOrDir: ignoreFileOrDirArr,
             IgnoreFileOrDirError: ignoreFileOrDirErrArr,
             RequirePathSeparator: requirePathSeparator,
+            DiskCache: diskCacheConfig,
+            CallSnippet: callSnippetConfig,
+            DiagnosticDocURL: diagnosticDocUrl,
+            telemetry: telemetryOptions,
         },
         markdown: {
             isTrusted: true,
@@ -348,6 +379,17 @@ async function doStartServer() {
     });
 }
 
+// 服务器codeLens传过来的参数为lsp格式，转换为vscode的类型后再显示引用列表
+function showReferences(uri: string, position: Position, locations: Location[]) {
+    if (!client) {
+        return;
+    }
+
+    const converter = client.protocol2CodeConverter;
+    vscode.commands.executeCommand("editor.action.showReferences", converter.asUri(uri),
+        converter.asPosition(position), locations.map(location => converter.asLocation(location)));
+}
+
 function stopServer() {
     if (client) {
         client.stop();
@@ -8,6 +8,7 @@ export enum AnnotatorType {
     GlobalVar,
     GlobalFunc,
     AnnotateType,
+    ReadonlyVar,
 }
 
 export interface IAnnotator {